@@ -0,0 +1,410 @@
+package jsonreflect
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+const (
+	// arenaSlabSize is minimal count of items in arena slab
+	arenaSlabSize = 64
+
+	// maxInternedKeys is max count of object keys cached by arena
+	maxInternedKeys = 4096
+)
+
+// ParseInto parses source into passed document and reuses memory of values
+// created by previous ParseInto call with the same document.
+//
+// WARNING: all values of previous parse become invalid after this call.
+// Their memory is reused by new values, so retained values silently change
+// their contents. Values must not be retained or shared between goroutines
+// beyond the next ParseInto call with the same document.
+// Use Clone to keep a copy of a value.
+//
+// Programs built with "jsonreflect_debug" build tag don't reuse memory and
+// panic when a value of previous parse is accessed.
+//
+// Once document memory fits documents of similar shape, parsing performs no
+// heap allocations, except escaped strings, compact objects index,
+// booleans and nulls.
+// Use Reset to reuse parser for another source.
+//
+// If parser pool implements SlabPool, memory of document without values
+// is taken from the pool, see Document.Release.
+//
+// Source is not transcoded, unlike NewDocument.
+func (p *Parser) ParseInto(doc *Document) error {
+	if doc.arena == nil {
+		doc.slab, doc.slabs = newSlab(p.pool)
+		doc.arena = &doc.slab.arena
+	}
+	doc.arena.reset()
+
+	doc.Root, doc.src, doc.lines = nil, nil, nil
+	p.arena = doc.arena
+	defer func() {
+		p.arena = nil
+	}()
+
+	root, err := p.Parse()
+	if err != nil {
+		return err
+	}
+
+	doc.Root = root
+	doc.src = p.src
+	doc.Encoding = p.encoding
+	doc.BOM = p.start > p.skipped
+	doc.Skipped = p.skipped
+	return nil
+}
+
+// Release returns values memory of document to the pool it was taken from by ParseInto
+// and resets the document.
+//
+// WARNING: values of released document must not be used, as their memory is reused
+// by next ParseInto call which takes memory from the same pool.
+func (d *Document) Release() {
+	if d.slabs != nil {
+		d.slabs.PutSlab(d.slab)
+	}
+
+	d.Root, d.src, d.lines = nil, nil, nil
+	d.arena, d.slab, d.slabs = nil, nil, nil
+}
+
+// newSlab returns values memory from pool if pool implements SlabPool.
+//
+// Returns pool which slab should be returned to.
+func newSlab(pool Pool) (*Slab, SlabPool) {
+	sp, ok := pool.(SlabPool)
+	if !ok {
+		return &Slab{}, nil
+	}
+
+	if s := sp.GetSlab(); s != nil {
+		return s, sp
+	}
+	return &Slab{}, sp
+}
+
+// Reset resets parser state and replaces parser source, parser options are kept.
+func (p *Parser) Reset(src []byte) {
+	p.src, p.end = src, len(src)
+	p.offset, p.count, p.start = 0, 0, 0
+
+	enc, hasBOM := DetectEncoding(src)
+	p.encoding = enc
+	if enc == EncodingUTF8 && hasBOM {
+		p.start = len(bomUTF8)
+		p.offset = p.start
+	}
+
+	if p.leaves != nil {
+		p.leaves = &leafBudget{max: p.leaves.max}
+	}
+
+	p.trimSource()
+}
+
+// valueArena allocates parsed values in slabs which are reused between parse calls.
+type valueArena struct {
+	// gen is incremented on each reset and is used to detect stale values in debug builds
+	gen uint64
+
+	numbers numberArenaSlab
+	strings stringSlab
+	arrays  arraySlab
+	objects objectSlab
+	items   valueSlab
+	members memberSlab
+	maps    mapSlab
+
+	// keys are interned object keys
+	keys map[string]string
+
+	// scratchValues and scratchMembers are stacks of items of containers being parsed
+	scratchValues  []Value
+	scratchMembers []Member
+}
+
+func (a *valueArena) reset() {
+	a.gen++
+	a.numbers.reset()
+	a.strings.reset()
+	a.arrays.reset()
+	a.objects.reset()
+	a.items.reset()
+	a.members.reset()
+	a.maps.reset()
+
+	// drop references to previous values
+	a.scratchValues = a.scratchValues[:0]
+	a.scratchMembers = a.scratchMembers[:0]
+}
+
+func (a *valueArena) decodeNumber(pos Position, src []byte) (*Number, error) {
+	n := a.numbers.alloc()
+	if err := parseNumberInto(n, pos, bytesString(src), 64); err != nil {
+		// decode again to get an error which doesn't refer to source
+		_, err = numberValueFromString(pos, string(src), 64)
+		return nil, err
+	}
+
+	n.gen = a.generation()
+	return n, nil
+}
+
+func (a *valueArena) newString(pos Position, raw []byte) *String {
+	s := a.strings.alloc()
+	*s = String{baseValue: baseValue{Position: pos, gen: a.generation()}, rawValue: raw}
+	return s
+}
+
+// newArray creates array from scratch values starting at mark.
+func (a *valueArena) newArray(pos Position, mark int) *Array {
+	items := a.items.alloc(len(a.scratchValues) - mark)
+	copy(items, a.scratchValues[mark:])
+	a.scratchValues = a.scratchValues[:mark]
+
+	arr := a.arrays.alloc()
+	*arr = Array{baseValue: baseValue{Position: pos, gen: a.generation()}, Length: len(items), Items: items}
+	return arr
+}
+
+// newObject creates object from scratch members starting at mark.
+func (a *valueArena) newObject(start, end int, items map[string]Value, mark int, compact bool) *Object {
+	members := a.members.alloc(len(a.scratchMembers) - mark)
+	copy(members, a.scratchMembers[mark:])
+	a.scratchMembers = a.scratchMembers[:mark]
+
+	if compact {
+		o := newCompactObject(start, end, members)
+		o.gen = a.generation()
+		return o
+	}
+
+	o := a.objects.alloc()
+	*o = Object{baseValue: baseValue{Position: newPosition(start, end), gen: a.generation()}, Items: items, members: members}
+	return o
+}
+
+// key returns object key from raw string.
+//
+// Keys without escape sequences are interned.
+func (a *valueArena) key(raw []byte) (string, bool) {
+	if bytes.IndexByte(raw, '\\') != -1 || bytes.IndexByte(raw, '\n') != -1 || !utf8.Valid(raw) {
+		return "", false
+	}
+
+	str := raw[1 : len(raw)-1]
+	if key, ok := a.keys[string(str)]; ok {
+		return key, true
+	}
+
+	key := string(str)
+	if a.keys == nil {
+		a.keys = make(map[string]string)
+	}
+
+	if len(a.keys) < maxInternedKeys {
+		a.keys[key] = key
+	}
+	return key, true
+}
+
+// slabUsage tracks usage of arena slab.
+type slabUsage struct {
+	// used is count of used items in current slab
+	used int
+
+	// total is count of items allocated since reset
+	total int
+}
+
+// take reserves n items in current slab and returns index of the first one.
+func (u *slabUsage) take(n int) int {
+	u.used += n
+	u.total += n
+	return u.used - n
+}
+
+// grow returns size of a new slab which fits n items.
+func (u *slabUsage) grow(size, n int) int {
+	u.used = 0
+	size *= 2
+	if size < arenaSlabSize {
+		size = arenaSlabSize
+	}
+	if size < n {
+		size = n
+	}
+	return size
+}
+
+// resetSize returns size of slab after reset or -1 if current slab can be reused.
+//
+// If items didn't fit into a single slab, a slab of total size is allocated,
+// so next parse of similar document doesn't allocate.
+func (u *slabUsage) resetSize(size int) int {
+	total := u.total
+	*u = slabUsage{}
+	if debugArena || total > size {
+		return total
+	}
+	return -1
+}
+
+type numberArenaSlab struct {
+	slabUsage
+	items []Number
+}
+
+func (s *numberArenaSlab) alloc() *Number {
+	if s.used == len(s.items) {
+		s.items = make([]Number, s.grow(len(s.items), 1))
+	}
+	return &s.items[s.take(1)]
+}
+
+func (s *numberArenaSlab) reset() {
+	if size := s.resetSize(len(s.items)); size != -1 {
+		s.items = make([]Number, size)
+	}
+}
+
+type stringSlab struct {
+	slabUsage
+	items []String
+}
+
+func (s *stringSlab) alloc() *String {
+	if s.used == len(s.items) {
+		s.items = make([]String, s.grow(len(s.items), 1))
+	}
+	return &s.items[s.take(1)]
+}
+
+func (s *stringSlab) reset() {
+	if size := s.resetSize(len(s.items)); size != -1 {
+		s.items = make([]String, size)
+	}
+}
+
+type arraySlab struct {
+	slabUsage
+	items []Array
+}
+
+func (s *arraySlab) alloc() *Array {
+	if s.used == len(s.items) {
+		s.items = make([]Array, s.grow(len(s.items), 1))
+	}
+	return &s.items[s.take(1)]
+}
+
+func (s *arraySlab) reset() {
+	if size := s.resetSize(len(s.items)); size != -1 {
+		s.items = make([]Array, size)
+	}
+}
+
+type objectSlab struct {
+	slabUsage
+	items []Object
+}
+
+func (s *objectSlab) alloc() *Object {
+	if s.used == len(s.items) {
+		s.items = make([]Object, s.grow(len(s.items), 1))
+	}
+	return &s.items[s.take(1)]
+}
+
+func (s *objectSlab) reset() {
+	if size := s.resetSize(len(s.items)); size != -1 {
+		s.items = make([]Object, size)
+	}
+}
+
+// valueSlab allocates array items.
+type valueSlab struct {
+	slabUsage
+	items []Value
+}
+
+// alloc returns slice of n items, nil if n is zero.
+func (s *valueSlab) alloc(n int) []Value {
+	if n == 0 {
+		return nil
+	}
+
+	if s.used+n > len(s.items) {
+		s.items = make([]Value, s.grow(len(s.items), n))
+	}
+
+	// capacity is limited, so append to result doesn't overwrite neighbours
+	i := s.take(n)
+	return s.items[i : i+n : i+n]
+}
+
+func (s *valueSlab) reset() {
+	if size := s.resetSize(len(s.items)); size != -1 {
+		s.items = make([]Value, size)
+	}
+}
+
+// memberSlab allocates object members.
+type memberSlab struct {
+	slabUsage
+	items []Member
+}
+
+// alloc returns slice of n members, nil if n is zero.
+func (s *memberSlab) alloc(n int) []Member {
+	if n == 0 {
+		return nil
+	}
+
+	if s.used+n > len(s.items) {
+		s.items = make([]Member, s.grow(len(s.items), n))
+	}
+
+	i := s.take(n)
+	return s.items[i : i+n : i+n]
+}
+
+func (s *memberSlab) reset() {
+	if size := s.resetSize(len(s.items)); size != -1 {
+		s.items = make([]Member, size)
+	}
+}
+
+// mapSlab reuses object items maps.
+type mapSlab struct {
+	maps []map[string]Value
+	used int
+}
+
+// alloc returns empty map.
+func (s *mapSlab) alloc() map[string]Value {
+	if s.used == len(s.maps) {
+		s.maps = append(s.maps, make(map[string]Value))
+	}
+
+	m := s.maps[s.used]
+	for k := range m {
+		delete(m, k)
+	}
+
+	s.used++
+	return m
+}
+
+func (s *mapSlab) reset() {
+	if debugArena {
+		s.maps = nil
+	}
+	s.used = 0
+}
@@ -0,0 +1,24 @@
+//go:build jsonreflect_debug
+// +build jsonreflect_debug
+
+package jsonreflect
+
+// debugArena disables memory reuse by ParseInto and enables stale values detection
+const debugArena = true
+
+// valueGeneration is generation of value allocated by ParseInto.
+type valueGeneration struct {
+	arena *valueArena
+	gen   uint64
+}
+
+func (a *valueArena) generation() valueGeneration {
+	return valueGeneration{arena: a, gen: a.gen}
+}
+
+// check panics if value belongs to previous ParseInto call.
+func (g valueGeneration) check() {
+	if g.arena != nil && g.arena.gen != g.gen {
+		panic("jsonreflect: value of previous Parser.ParseInto call is used after document was reused")
+	}
+}
@@ -0,0 +1,33 @@
+//go:build jsonreflect_debug
+// +build jsonreflect_debug
+
+package jsonreflect
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParser_ParseInto_StaleValue(t *testing.T) {
+	doc := &Document{}
+	p := NewParser([]byte(`{"a": [1, "foo"]}`))
+	require.NoError(t, p.ParseInto(doc))
+	stale := doc.Root.(*Object)
+	item, _ := stale.Get("a")
+
+	p.Reset([]byte(`[true]`))
+	require.NoError(t, p.ParseInto(doc))
+	require.Equal(t, []interface{}{true}, doc.Root.Interface())
+
+	msg := "jsonreflect: value of previous Parser.ParseInto call is used after document was reused"
+	require.PanicsWithValue(t, msg, func() {
+		stale.Get("a")
+	})
+	require.PanicsWithValue(t, msg, func() {
+		_ = item.Interface()
+	})
+	require.PanicsWithValue(t, msg, func() {
+		_, _ = MarshalValue(item.(*Array).Items[1], nil)
+	})
+}
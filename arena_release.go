@@ -0,0 +1,19 @@
+//go:build !jsonreflect_debug
+// +build !jsonreflect_debug
+
+package jsonreflect
+
+// debugArena disables memory reuse by ParseInto and enables stale values detection
+const debugArena = false
+
+// valueGeneration is generation of value allocated by ParseInto.
+//
+// Generations are tracked only in debug builds.
+type valueGeneration struct{}
+
+func (a *valueArena) generation() valueGeneration {
+	return valueGeneration{}
+}
+
+// check panics if value belongs to previous ParseInto call.
+func (g valueGeneration) check() {}
@@ -0,0 +1,129 @@
+package jsonreflect
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParser_ParseInto(t *testing.T) {
+	sources := []string{
+		`{"a": 1, "b": [true, false, null, "foo"], "c": {"d": -1.5, "e": {}}, "f": []}`,
+		`{"a": "foo", "b": [{"x": 1}, {"x": 2}], "a": 3}`,
+		`[1, [2, [3, [4]]], {"k": "v"}, "bar"]`,
+		`"string"`,
+		`42`,
+		``,
+		"\xef\xbb\xbf" + `{"bom": true}`,
+	}
+
+	for mode, opts := range parserModes {
+		doc := &Document{}
+		for _, src := range sources {
+			want, err := NewParser([]byte(src), opts...).Parse()
+			require.NoError(t, err)
+
+			p := NewParser([]byte(src), opts...)
+			require.NoError(t, p.ParseInto(doc), mode)
+			if want == nil {
+				require.Nil(t, doc.Root)
+				continue
+			}
+
+			require.Equal(t, want.Interface(), doc.Root.Interface(), "%s: %s", mode, src)
+			if !debugArena {
+				// debug builds keep value generation
+				require.Equal(t, withoutRaw(want), withoutRaw(doc.Root), "%s: %s", mode, src)
+			}
+
+			got, err := MarshalValue(doc.Root, nil)
+			require.NoError(t, err)
+			expect, err := MarshalValue(want, nil)
+			require.NoError(t, err)
+			require.Equal(t, string(expect), string(got))
+		}
+	}
+}
+
+func TestParser_ParseInto_Error(t *testing.T) {
+	doc := &Document{}
+	require.NoError(t, NewParser([]byte(`[1, 2]`)).ParseInto(doc))
+	require.NotNil(t, doc.Root)
+
+	err := NewParser([]byte(`[1, {"a": 2`)).ParseInto(doc)
+	require.Error(t, err)
+	require.Nil(t, doc.Root)
+
+	require.NoError(t, NewParser([]byte(`{"a": [3]}`)).ParseInto(doc))
+	require.Equal(t, map[string]interface{}{"a": []interface{}{3}}, doc.Root.Interface())
+}
+
+func TestParser_ParseInto_Clone(t *testing.T) {
+	doc := &Document{}
+	p := NewParser([]byte(`{"a": [1, "foo", true], "b": null}`))
+	require.NoError(t, p.ParseInto(doc))
+	kept := Clone(doc.Root)
+
+	p.Reset([]byte(`{"c": [2, "bar", false], "d": 3}`))
+	require.NoError(t, p.ParseInto(doc))
+	require.Equal(t, map[string]interface{}{
+		"a": []interface{}{1, "foo", true},
+		"b": nil,
+	}, kept.Interface())
+}
+
+func TestParser_ParseInto_Allocs(t *testing.T) {
+	if debugArena {
+		t.Skip("memory is not reused in debug builds")
+	}
+
+	sources := [][]byte{
+		[]byte(`{"id": 1, "name": "foo", "tags": ["a", "b"], "active": true, "meta": {"score": 1.5, "parent": null}}`),
+		[]byte(`{"id": 2, "name": "bar", "tags": ["c"], "active": false, "meta": {"score": -3, "parent": null}}`),
+	}
+
+	// booleans and nulls are used by value and are allocated when stored in a container
+	const literalsCount = 2
+
+	for mode, opts := range parserModes {
+		doc := &Document{}
+		p := NewParser(nil, opts...)
+		i := 0
+		parse := func() {
+			p.Reset(sources[i%len(sources)])
+			i++
+			if err := p.ParseInto(doc); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// warm up document memory
+		parse()
+		parse()
+		require.Equal(t, float64(literalsCount), testing.AllocsPerRun(100, parse), mode)
+	}
+}
+
+func BenchmarkParser_ParseInto(b *testing.B) {
+	src := newNumericArrayFixture(1000)
+	b.Run("Parse", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := NewParser(src).Parse(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("ParseInto", func(b *testing.B) {
+		b.ReportAllocs()
+		doc := &Document{}
+		p := NewParser(src)
+		for i := 0; i < b.N; i++ {
+			p.Reset(src)
+			if err := p.ParseInto(doc); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -10,21 +10,41 @@ type Array struct {
 	Length int
 	// Items contains items list
 	Items []Value
+
+	// truncated is set when array has unparsed items, see MaxLeaves
+	truncated bool
 }
 
 func newArray(pos Position, items ...Value) *Array {
 	return &Array{
-		baseValue: baseValue{pos},
+		baseValue: baseValue{Position: pos},
 		Length:    len(items),
 		Items:     items,
 	}
 }
 
-func (arr Array) marshal(w io.Writer, mf *marshalFormatter) error {
-	if len(arr.Items) == 0 {
-		return mf.write(w, []byte{tokenArrayStart, tokenArrayClose})
+func (arr *Array) marshal(w io.Writer, mf *marshalFormatter) error {
+	if arr == nil {
+		return ErrNilValue
+	}
+
+	arr.gen.check()
+
+	if arr.truncated && (mf == nil || !mf.allowTruncated) {
+		return ErrTruncatedValue
 	}
 
+	if len(arr.Items) == 0 && !arr.truncated {
+		// empty value is written in-place, without indentation
+		_, err := w.Write([]byte{tokenArrayStart, tokenArrayClose})
+		return err
+	}
+
+	if err := mf.enter(arr); err != nil {
+		return err
+	}
+	defer mf.leave()
+
 	err := mf.writeOpenClause(w, tokenArrayStart)
 	if err != nil {
 		return err
@@ -32,12 +52,17 @@ func (arr Array) marshal(w io.Writer, mf *marshalFormatter) error {
 
 	childFmt := mf.childFormatter()
 	lastIndex := len(arr.Items) - 1
+	if arr.truncated {
+		// marker is the last item
+		lastIndex++
+	}
+
 	for i, v := range arr.Items {
 		if err = childFmt.writePrefix(w); err != nil {
 			return err
 		}
 
-		err = v.marshal(w, childFmt)
+		err = marshalValue(w, v, childFmt)
 		if err != nil {
 			return err
 		}
@@ -47,16 +72,48 @@ func (arr Array) marshal(w io.Writer, mf *marshalFormatter) error {
 			return err
 		}
 	}
+
+	if arr.truncated {
+		if err = childFmt.write(w, quoteString(truncatedMarker)); err != nil {
+			return err
+		}
+
+		if err = mf.writeElementDelimiter(w, true); err != nil {
+			return err
+		}
+	}
 	return mf.write(w, []byte{tokenArrayClose})
 }
 
 // Type implements jsonreflect.Value
-func (_ Array) Type() Type {
+func (arr *Array) Type() Type {
 	return TypeArray
 }
 
+// Ref implements jsonreflect.Value
+func (arr *Array) Ref() Position {
+	if arr == nil {
+		return Position{}
+	}
+
+	arr.gen.check()
+
+	return arr.Position
+}
+
+// String implements jsonreflect.Value
+func (arr *Array) String() (string, error) {
+	return "", ErrNotStringable
+}
+
 // Interface implements json.Value
-func (arr Array) Interface() interface{} {
+func (arr *Array) Interface() interface{} {
+	if arr == nil {
+		return nil
+	}
+
+	arr.gen.check()
+
 	out := make([]interface{}, 0, len(arr.Items))
 	for _, v := range arr.Items {
 		out = append(out, v.Interface())
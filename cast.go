@@ -1,6 +1,12 @@
 package jsonreflect
 
-import "fmt"
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
 
 func newInvalidValueError(gotType, wantType Type) error {
 	return fmt.Errorf("cannot convert jsonreflect.Value of type %s to %s", gotType.String(), wantType.String())
@@ -29,9 +35,12 @@ func ToObject(v Value) (*Object, error) {
 // Method only supports number and string values.
 func ToNumber(v Value, bitSize int) (*Number, error) {
 	switch t := TypeOf(v); t {
-	case TypeNumber:
-		return v.(*Number), nil
-	case TypeString:
+	case TypeNumber, TypeString:
+		if num, ok := v.(*Number); ok {
+			return num, nil
+		}
+
+		// custom number values are parsed from string representation
 		strval, err := v.String()
 		if err != nil {
 			return nil, err
@@ -73,3 +82,170 @@ func NewArray(items ...Value) *Array {
 		Items:  items,
 	}
 }
+
+// NewObject creates a new object from key-value pairs
+func NewObject(items map[string]Value) *Object {
+	if items == nil {
+		items = make(map[string]Value)
+	}
+	return &Object{Items: items}
+}
+
+// NewString creates a new string value
+func NewString(str string) *String {
+	return &String{rawValue: quoteString(str)}
+}
+
+// NewBoolean creates a new boolean value
+func NewBoolean(val bool) Boolean {
+	return Boolean{Value: val}
+}
+
+// NewNull creates a new null value
+func NewNull() Null {
+	return Null{}
+}
+
+// NewNumberInt creates a new integer number
+func NewNumberInt(val int64) *Number {
+	return &Number{mantissa: val, IsSigned: val < 0}
+}
+
+func newNumberUint(val uint64) (*Number, error) {
+	if val > math.MaxInt64 {
+		return nil, fmt.Errorf("unsupported number value %d: value out of range", val)
+	}
+	return NewNumberInt(int64(val)), nil
+}
+
+// NewNumberFloat creates a new floating point number.
+//
+// Number keeps exact value and is marshaled in shortest form which
+// represents the value, values from 1e21 are marshaled in exponent notation.
+// See Number.SetFormat to change format.
+//
+// Returns error if value is NaN or infinity.
+func NewNumberFloat(val float64) (*Number, error) {
+	if math.IsNaN(val) || math.IsInf(val, 0) {
+		return nil, fmt.Errorf("unsupported number value %v", val)
+	}
+
+	num, err := numberValueFromString(Position{}, formatFloat(val), 64)
+	if err != nil {
+		return nil, err
+	}
+
+	num.float = val
+	num.hasFloat = true
+	num.decimal = ""
+	return num, nil
+}
+
+// NewNumberDecimal creates a new number with value of coefficient * 10^-scale.
+//
+// Number keeps all digits of coefficient and is marshaled without rounding,
+// numbers with negative scale are marshaled in exponent notation.
+// See Number.Decimal to get the value back.
+//
+// Nil coefficient is treated as zero.
+// Returns *NumberRangeError if value is out of float64 range.
+func NewNumberDecimal(coefficient *big.Int, scale int32) (*Number, error) {
+	digits := "0"
+	if coefficient != nil {
+		digits = coefficient.String()
+	}
+
+	sign := ""
+	if digits[0] == '-' {
+		sign, digits = "-", digits[1:]
+	}
+
+	str := sign + digits
+	switch {
+	case scale > 0:
+		if pad := int(scale) - len(digits) + 1; pad > 0 {
+			digits = strings.Repeat("0", pad) + digits
+		}
+		dot := len(digits) - int(scale)
+		str = sign + digits[:dot] + "." + digits[dot:]
+	case scale < 0:
+		str += "e" + strconv.FormatInt(-int64(scale), 10)
+	}
+
+	num := &Number{}
+	if err := parseNumberInto(num, Position{}, str, 64); err != nil {
+		return nil, err
+	}
+	return num, nil
+}
+
+// InferScalar converts a bare string into a scalar value using the same rules as parser.
+//
+// Returns Number, Boolean or Null if the whole string matches the grammar of these
+// values, otherwise string is returned as String value as-is.
+//
+// Objects and arrays are out of scope and are returned as String values,
+// use ValueOf to parse them.
+func InferScalar(s string) Value {
+	src := []byte(s)
+	p := NewParser(src)
+	if tkn, pos, end := p.getStartTokenAtPos(0); end || tkn != tokenOther || pos != 0 {
+		return NewString(s)
+	}
+
+	v, err := p.decodeScalarValue(0, true)
+	if err != nil || v.Ref().End != len(src)-1 {
+		return NewString(s)
+	}
+	return v
+}
+
+// NewObjectFromMembers creates a new object from key-value pairs in specified order.
+//
+// Like in parser, first key occurrence defines key order and last one defines the value.
+func NewObjectFromMembers(members ...Member) *Object {
+	obj := &Object{
+		Items:   make(map[string]Value, len(members)),
+		members: make([]Member, 0, len(members)),
+	}
+
+	for _, m := range members {
+		if _, ok := obj.Items[m.Key]; !ok {
+			obj.members = append(obj.members, m)
+		}
+		obj.Items[m.Key] = m.Value
+	}
+	return obj
+}
+
+// SetPosition sets value position in source and returns the value.
+//
+// Booleans and nulls are passed by value, so their copy is returned.
+// Custom values are returned as-is.
+func SetPosition(v Value, pos Position) Value {
+	switch t := v.(type) {
+	case *String:
+		if t != nil {
+			t.Position = pos
+		}
+	case Boolean:
+		t.Position = pos
+		return t
+	case Null:
+		t.Position = pos
+		return t
+	case *Number:
+		if t != nil {
+			t.Position = pos
+		}
+	case *Array:
+		if t != nil {
+			t.Position = pos
+		}
+	case *Object:
+		if t != nil {
+			t.Position = pos
+		}
+	}
+	return v
+}
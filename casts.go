@@ -0,0 +1,125 @@
+package jsonreflect
+
+import (
+	"reflect"
+	"unicode/utf8"
+)
+
+// maxCastPreviewLen is max length of source value preview in cast report
+const maxCastPreviewLen = 32
+
+// CastReport is a list of value casts found by unmarshaler, see ReportCasts.
+type CastReport struct {
+	Casts []CastEntry
+}
+
+// CastEntry describes a cast of source value to a scalar destination type.
+type CastEntry struct {
+	// Path is path to source value in dotted form. Empty for root value.
+	Path string
+
+	// Position is source value position.
+	Position Position
+
+	// SourceType is source value type.
+	SourceType Type
+
+	// Preview is source value in JSON form, long values are truncated.
+	Preview string
+
+	// Type is destination type.
+	Type reflect.Type
+
+	// Applied is true if value was cast because of NoStrict option.
+	//
+	// In strict mode, cast is not applied and unmarshal fails with a type error.
+	Applied bool
+}
+
+// ReportCasts fills report with casts of source values made by unmarshaler.
+//
+// With NoStrict option, every performed cast is reported.
+// In strict mode, unmarshal fails as usual, but failure caused by a value which
+// could be cast without strict mode is reported as not applied cast.
+// Use it to find out where NoStrict option changes behavior.
+//
+// Report is reset on each unmarshal call.
+func ReportCasts(report *CastReport) UnmarshalOption {
+	return func(p *unmarshalParams) {
+		if report != nil {
+			*report = CastReport{}
+		}
+		p.castReport = report
+	}
+}
+
+// scalarUnmarshalFunc unmarshals source value into a scalar destination.
+type scalarUnmarshalFunc = func(src Value, dst reflect.Value, strict bool) error
+
+// castScalar unmarshals scalar value and reports casts made or required by strict mode.
+func (p unmarshalParams) castScalar(src Value, dst reflect.Value, fn scalarUnmarshalFunc) error {
+	err := fn(src, dst, p.strict)
+	if p.strict {
+		// dry run of non-strict unmarshal to find out if value could be cast
+		if err != nil && p.castReport != nil && fn(src, reflect.New(dst.Type()).Elem(), false) == nil {
+			p.recordCast(src, dst.Type(), false)
+		}
+		return err
+	}
+
+	if err != nil || !isScalarCast(src, dst.Type()) {
+		return err
+	}
+
+	p.recordCast(src, dst.Type(), true)
+	p.report(DiagnosticValueCast, dst.Type(), "", "%s value cast to %s", TypeOf(src), dst.Type())
+	return nil
+}
+
+// isScalarCast reports whether source value type doesn't match scalar destination type.
+func isScalarCast(src Value, dstType reflect.Type) bool {
+	want := TypeNumber
+	switch dstType.Kind() {
+	case reflect.String:
+		want = TypeString
+	case reflect.Bool:
+		want = TypeBoolean
+	}
+	return TypeOf(src) != want
+}
+
+func (p unmarshalParams) recordCast(src Value, dstType reflect.Type, applied bool) {
+	if p.castReport == nil {
+		return
+	}
+
+	p.castReport.Casts = append(p.castReport.Casts, CastEntry{
+		Path:       formatPath(p.path),
+		Position:   positionOf(src),
+		SourceType: TypeOf(src),
+		Preview:    castPreview(src),
+		Type:       dstType,
+		Applied:    applied,
+	})
+}
+
+func castPreview(src Value) string {
+	if src == nil {
+		return "null"
+	}
+
+	out, err := MarshalValue(src, nil)
+	if err != nil {
+		return ""
+	}
+
+	if len(out) <= maxCastPreviewLen {
+		return string(out)
+	}
+
+	end := maxCastPreviewLen
+	for end > 0 && !utf8.RuneStart(out[end]) {
+		end--
+	}
+	return string(out[:end]) + truncatedMarker
+}
@@ -0,0 +1,68 @@
+package jsonreflect
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReportCasts(t *testing.T) {
+	type item struct {
+		Count int    `json:"count"`
+		Label string `json:"label"`
+	}
+
+	type dst struct {
+		ID      string   `json:"id"`
+		Enabled bool     `json:"enabled"`
+		Ratio   *float64 `json:"ratio"`
+		Items   []item   `json:"items"`
+		Name    string   `json:"name"`
+	}
+
+	long := "0." + strings.Repeat("5", 40)
+	src := []byte(`{"id": 42, "enabled": "TRUE", "ratio": "` + long + `", "name": "foo",
+		"items": [{"count": 1, "label": null}, {"count": "2", "label": "x"}]}`)
+
+	report := CastReport{Casts: []CastEntry{{}}}
+	require.NoError(t, Unmarshal(src, new(dst), NoStrict, ReportCasts(&report)))
+
+	got := make([]CastEntry, 0, len(report.Casts))
+	for _, c := range report.Casts {
+		require.NotZero(t, c.Position, c.Path)
+		c.Position = Position{}
+		got = append(got, c)
+	}
+
+	require.Equal(t, []CastEntry{
+		{Path: "id", SourceType: TypeNumber, Preview: "42", Type: reflect.TypeOf(""), Applied: true},
+		{Path: "enabled", SourceType: TypeString, Preview: `"TRUE"`, Type: reflect.TypeOf(true), Applied: true},
+		{Path: "ratio", SourceType: TypeString, Preview: `"` + long[:31] + "…", Type: reflect.TypeOf(0.0), Applied: true},
+		{Path: "items[0].label", SourceType: TypeNull, Preview: "null", Type: reflect.TypeOf(""), Applied: true},
+		{Path: "items[1].count", SourceType: TypeString, Preview: `"2"`, Type: reflect.TypeOf(0), Applied: true},
+	}, got)
+
+	// strict mode fails as usual and reports cast which would be applied
+	err := Unmarshal(src, new(dst), ReportCasts(&report))
+	require.Error(t, err)
+	require.Len(t, report.Casts, 1)
+	require.Equal(t, "id", report.Casts[0].Path)
+	require.False(t, report.Casts[0].Applied)
+
+	// values which can't be cast are not reported
+	err = Unmarshal([]byte(`{"enabled": "maybe"}`), new(dst), ReportCasts(&report))
+	require.Error(t, err)
+	require.Empty(t, report.Casts)
+
+	// string with number out of destination range is not cast
+	err = Unmarshal([]byte(`{"items": [{"count": "`+strings.Repeat("9", 40)+`"}]}`), new(dst), NoStrict, ReportCasts(&report))
+	require.True(t, errors.Is(err, ErrNumberOutOfRange), err)
+	require.Contains(t, err.Error(), "value overflows int")
+	require.Empty(t, report.Casts)
+
+	require.NoError(t, Unmarshal([]byte(`{"id": "1", "enabled": true}`), new(dst), ReportCasts(&report)))
+	require.Empty(t, report.Casts)
+}
@@ -0,0 +1,159 @@
+package jsonreflect
+
+import (
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	. "github.com/x1unix/jsonreflect/internal/testutil"
+)
+
+func TestCompactObjects(t *testing.T) {
+	src := []byte(`{"b": 1, "a": {"y": true, "x": null}, "c": "foo", "b": 2, "d": [], "b": 3}`)
+	v, err := ValueOf(src, CompactObjects())
+	require.NoError(t, err)
+
+	obj := v.(*Object)
+	require.Nil(t, obj.Items)
+	require.Equal(t, 4, obj.Len())
+	require.Equal(t, []string{"a", "b", "c", "d"}, obj.Keys())
+	require.True(t, obj.HasKey("c"))
+	require.False(t, obj.HasKey("e"))
+
+	// first key occurrence defines position, last one defines value
+	b, ok := obj.Get("b")
+	require.True(t, ok)
+	require.Equal(t, 3, b.Interface())
+	members := obj.Members()
+	require.Equal(t, "b", members[0].Key)
+	require.Equal(t, newPosition(1, 3), members[0].KeyPos)
+
+	got, err := MarshalValue(v, nil)
+	require.NoError(t, err)
+	require.Equal(t, `{"b":3,"a":{"y":true,"x":null},"c":"foo","d":[]}`, string(got))
+
+	regular, err := ValueOf(src)
+	require.NoError(t, err)
+	require.Equal(t, regular.Interface(), v.Interface())
+	want, err := FingerprintValue(regular)
+	require.NoError(t, err)
+	sum, err := FingerprintValue(v)
+	require.NoError(t, err)
+	require.Equal(t, want, sum)
+
+	var dst struct {
+		A map[string]interface{} `json:"a"`
+		B int                    `json:"b"`
+		C string                 `json:"c"`
+	}
+	require.NoError(t, UnmarshalValue(v, &dst))
+	require.Equal(t, 3, dst.B)
+	require.Equal(t, "foo", dst.C)
+	require.Equal(t, map[string]interface{}{"x": nil, "y": true}, dst.A)
+
+	// object becomes regular after materialization
+	items := obj.MaterializeItems()
+	require.Len(t, items, 4)
+	items["e"] = NewNull()
+	require.True(t, obj.HasKey("e"))
+	require.Equal(t, "e", obj.Members()[4].Key)
+}
+
+func newWideObjectFixture(size int) []byte {
+	return RepeatFixture("{", ",", "}", size, `"key%[1]d": %[1]d`, nil)
+}
+
+func BenchmarkCompactObjects_Parse(b *testing.B) {
+	src := newWideObjectFixture(100000)
+	for name, opts := range map[string][]ParserOption{
+		"map":     nil,
+		"compact": {CompactObjects()},
+	} {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := NewParser(src, opts...).Parse(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkCompactObjects_Get(b *testing.B) {
+	src := newWideObjectFixture(100000)
+	for name, opts := range map[string][]ParserOption{
+		"map":     nil,
+		"compact": {CompactObjects()},
+	} {
+		b.Run(name, func(b *testing.B) {
+			v, err := NewParser(src, opts...).Parse()
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			obj := v.(*Object)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, ok := obj.Get("key" + strconv.Itoa(i%100000)); !ok {
+					b.Fatal("key not found")
+				}
+			}
+		})
+	}
+}
+
+func newNumericArrayFixture(size int) []byte {
+	return RepeatFixture("[", ",", "]", size, "%d.%d", func(i int) []interface{} {
+		return []interface{}{i - size/2, i % 100}
+	})
+}
+
+func TestCompactNumbers(t *testing.T) {
+	src := newNumericArrayFixture(1000)
+	want, err := ValueOf(src)
+	require.NoError(t, err)
+
+	got, err := ValueOf(src, CompactNumbers())
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+
+	// numbers share slab but are independent values
+	items := got.(*Array).Items
+	items[0].(*Number).SetFormat('e', 2)
+	require.Equal(t, want.(*Array).Items[1], items[1])
+
+	// numbers out of float64 range keep exact text
+	huge, err := ValueOf([]byte(`[1, 2, 1e999]`), CompactNumbers())
+	require.NoError(t, err)
+	require.True(t, math.IsInf(huge.(*Array).Items[2].(*Number).Float64(), 1))
+	huge.(*Array).Items[2].(*Number).SetFormat('e', 2)
+	out, err := MarshalValue(huge, nil)
+	require.NoError(t, err)
+	require.Equal(t, `[1,2,1e999]`, string(out))
+
+	p := NewParser([]byte(`1 -2.5 3`), CompactNumbers())
+	for _, want := range []interface{}{1, -2.5, 3} {
+		v, err := p.ParseNext()
+		require.NoError(t, err)
+		require.Equal(t, want, v.Interface())
+	}
+}
+
+func BenchmarkCompactNumbers(b *testing.B) {
+	src := newNumericArrayFixture(100000)
+	for name, opts := range map[string][]ParserOption{
+		"default": nil,
+		"compact": {CompactNumbers()},
+	} {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := NewParser(src, opts...).Parse(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
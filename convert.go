@@ -0,0 +1,93 @@
+package jsonreflect
+
+import "time"
+
+// dateLayout is a layout of full-date from RFC 3339
+const dateLayout = "2006-01-02"
+
+// ConvertOptions controls conversion of values to Go values, see InterfaceWith.
+//
+// Zero value produces the same result as Value.Interface.
+type ConvertOptions struct {
+	// Time converts strings in RFC 3339 date-time format, like "2024-01-02T15:04:05Z", to time.Time.
+	Time bool
+
+	// Dates converts strings in RFC 3339 full-date format, like "2024-01-02", to time.Time in UTC.
+	Dates bool
+}
+
+// InterfaceWith converts value to Go value like Value.Interface,
+// but applies additional conversions of strings.
+func InterfaceWith(v Value, conv ConvertOptions) interface{} {
+	switch t := v.(type) {
+	case *String:
+		if t == nil {
+			return nil
+		}
+		return conv.convertString(t)
+	case *Array:
+		if t == nil {
+			return nil
+		}
+
+		t.gen.check()
+		out := make([]interface{}, 0, len(t.Items))
+		for _, item := range t.Items {
+			out = append(out, InterfaceWith(item, conv))
+		}
+		return out
+	case *Object:
+		if t == nil {
+			return nil
+		}
+		return t.ToMapWith(conv)
+	case nil:
+		return nil
+	default:
+		return v.Interface()
+	}
+}
+
+// ToMapWith returns key-value pair of items like ToMap,
+// but applies additional conversions of strings, see InterfaceWith.
+func (o *Object) ToMapWith(conv ConvertOptions) map[string]interface{} {
+	if o == nil {
+		return nil
+	}
+
+	o.gen.check()
+	m := make(map[string]interface{}, o.Len())
+	if o.compact {
+		for _, member := range o.members {
+			m[member.Key] = InterfaceWith(member.Value, conv)
+		}
+		return m
+	}
+
+	for k, v := range o.Items {
+		m[k] = InterfaceWith(v, conv)
+	}
+	return m
+}
+
+func (conv ConvertOptions) convertString(s *String) interface{} {
+	v := s.Interface()
+	str, ok := v.(string)
+	if !ok || (!conv.Time && !conv.Dates) || len(str) < len(dateLayout) || str[0] < '0' || str[0] > '9' {
+		return v
+	}
+
+	if conv.Time && len(str) > len(dateLayout) {
+		if t, err := time.Parse(time.RFC3339Nano, str); err == nil {
+			return t
+		}
+		return v
+	}
+
+	if conv.Dates && len(str) == len(dateLayout) {
+		if t, err := time.Parse(dateLayout, str); err == nil {
+			return t
+		}
+	}
+	return v
+}
@@ -0,0 +1,108 @@
+package jsonreflect
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterfaceWith(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	tsNano := time.Date(2024, 1, 2, 15, 4, 5, 123000000, time.FixedZone("", 3*60*60))
+	date := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	cases := map[string]struct {
+		src  string
+		conv ConvertOptions
+		want interface{}
+	}{
+		"disabled": {
+			src:  `"2024-01-02T15:04:05Z"`,
+			want: "2024-01-02T15:04:05Z",
+		},
+		"time": {
+			src:  `"2024-01-02T15:04:05Z"`,
+			conv: ConvertOptions{Time: true},
+			want: ts,
+		},
+		"time with fraction and offset": {
+			src:  `"2024-01-02T15:04:05.123+03:00"`,
+			conv: ConvertOptions{Time: true},
+			want: tsNano,
+		},
+		"invalid month": {
+			src:  `"2024-13-01T00:00:00Z"`,
+			conv: ConvertOptions{Time: true, Dates: true},
+			want: "2024-13-01T00:00:00Z",
+		},
+		"no offset": {
+			src:  `"2024-01-02T15:04:05"`,
+			conv: ConvertOptions{Time: true},
+			want: "2024-01-02T15:04:05",
+		},
+		"space separator": {
+			src:  `"2024-01-02 15:04:05Z"`,
+			conv: ConvertOptions{Time: true},
+			want: "2024-01-02 15:04:05Z",
+		},
+		"date without dates option": {
+			src:  `"2024-01-02"`,
+			conv: ConvertOptions{Time: true},
+			want: "2024-01-02",
+		},
+		"date": {
+			src:  `"2024-01-02"`,
+			conv: ConvertOptions{Dates: true},
+			want: date,
+		},
+		"invalid date": {
+			src:  `"2024-02-30"`,
+			conv: ConvertOptions{Dates: true},
+			want: "2024-02-30",
+		},
+		"time without time option": {
+			src:  `"2024-01-02T15:04:05Z"`,
+			conv: ConvertOptions{Dates: true},
+			want: "2024-01-02T15:04:05Z",
+		},
+		"number": {
+			src:  `20240102`,
+			conv: ConvertOptions{Time: true, Dates: true},
+			want: 20240102,
+		},
+		"nested": {
+			src:  `{"a": ["2024-01-02T15:04:05Z", {"b": "2024-01-02"}], "c": "text"}`,
+			conv: ConvertOptions{Time: true, Dates: true},
+			want: map[string]interface{}{
+				"a": []interface{}{ts, map[string]interface{}{"b": date}},
+				"c": "text",
+			},
+		},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			v, err := NewParser([]byte(c.src)).Parse()
+			require.NoError(t, err)
+
+			got := InterfaceWith(v, c.conv)
+			if want, ok := c.want.(time.Time); ok {
+				require.IsType(t, time.Time{}, got)
+				require.True(t, want.Equal(got.(time.Time)), got)
+				return
+			}
+			require.Equal(t, c.want, got)
+			if c.conv == (ConvertOptions{}) {
+				require.Equal(t, v.Interface(), got)
+			}
+		})
+	}
+
+	v, err := NewParser([]byte(`{"at": "2024-01-02T15:04:05Z"}`)).Parse()
+	require.NoError(t, err)
+	obj := v.(*Object)
+	require.Equal(t, map[string]interface{}{"at": "2024-01-02T15:04:05Z"}, obj.ToMap())
+	require.Equal(t, map[string]interface{}{"at": ts}, obj.ToMapWith(ConvertOptions{Time: true}))
+	require.Nil(t, (*Object)(nil).ToMapWith(ConvertOptions{Time: true}))
+}
@@ -0,0 +1,102 @@
+package jsonreflect
+
+import "github.com/x1unix/jsonreflect/internal/pathutil"
+
+type pathSegment struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// cycleGuard tracks containers on the current traversal path.
+//
+// Only objects and arrays are tracked, as only containers can form a cycle.
+// Trees are usually shallow, so ancestors are kept in a stack
+// which is cheaper to maintain than a set.
+type cycleGuard struct {
+	ancestors []Value
+}
+
+// enter adds container to traversal path.
+//
+// Returns false if container is own ancestor.
+func (g *cycleGuard) enter(v Value) bool {
+	for _, a := range g.ancestors {
+		if a == v {
+			return false
+		}
+	}
+
+	g.ancestors = append(g.ancestors, v)
+	return true
+}
+
+// leave removes last container from traversal path.
+func (g *cycleGuard) leave() {
+	g.ancestors[len(g.ancestors)-1] = nil
+	g.ancestors = g.ancestors[:len(g.ancestors)-1]
+}
+
+// newError returns error with path to passed value which closes the cycle.
+//
+// Path is resolved only on error, so traversal doesn't track keys and indexes.
+func (g *cycleGuard) newError(v Value) *CycleError {
+	path := make([]pathSegment, 0, len(g.ancestors))
+	for i, parent := range g.ancestors {
+		child := v
+		if i+1 < len(g.ancestors) {
+			child = g.ancestors[i+1]
+		}
+
+		path = append(path, childSegment(parent, child))
+	}
+	return &CycleError{Path: formatPath(path)}
+}
+
+// childSegment returns path segment of container child.
+func childSegment(parent, child Value) pathSegment {
+	switch t := parent.(type) {
+	case *Array:
+		for i, item := range t.Items {
+			if isSameContainer(item, child) {
+				return pathSegment{index: i, isIndex: true}
+			}
+		}
+	case *Object:
+		for _, m := range t.Members() {
+			if isSameContainer(m.Value, child) {
+				return pathSegment{key: m.Key}
+			}
+		}
+	}
+	return pathSegment{}
+}
+
+// isSameContainer reports whether values point to the same object or array.
+//
+// Values of other types are not compared, as custom values may be not comparable.
+func isSameContainer(a, b Value) bool {
+	switch x := a.(type) {
+	case *Array:
+		y, ok := b.(*Array)
+		return ok && x == y
+	case *Object:
+		y, ok := b.(*Object)
+		return ok && x == y
+	default:
+		return false
+	}
+}
+
+// formatPath returns path segments in dotted form.
+func formatPath(segments []pathSegment) string {
+	path := ""
+	for _, s := range segments {
+		if s.isIndex {
+			path = pathutil.AppendIndex(path, s.index)
+			continue
+		}
+		path = pathutil.Append(path, s.key)
+	}
+	return path
+}
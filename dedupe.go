@@ -0,0 +1,211 @@
+package jsonreflect
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/fnv"
+	"io"
+	"reflect"
+)
+
+// Deduplicate replaces structurally identical objects and arrays in value tree
+// with a single shared instance and returns the root value.
+//
+// Containers are shared only if their serialized form is the same, including
+// key order and number notation. Shared containers keep position of the first occurrence.
+//
+// Tree is modified in place. As shared instance is referenced from several places,
+// any modification of it is visible in all of them, so deduplicated tree should be
+// treated as immutable. Use Clone to get a copy without shared values before modification.
+// Compact objects are not materialized, see CompactObjects.
+//
+// Truncated containers, custom values and cyclic references are not deduplicated.
+func Deduplicate(root Value) Value {
+	d := &deduplicator{
+		known: make(map[uint64][]Value),
+		guard: &cycleGuard{},
+	}
+
+	v, _, _ := d.visit(root)
+	return v
+}
+
+// Clone returns a deep copy of value tree.
+//
+// Values shared between several places of tree, like after Deduplicate,
+// are copied separately. Custom values are not copied.
+// References to own ancestor in cyclic trees are kept as-is.
+func Clone(v Value) Value {
+	if v == nil {
+		return nil
+	}
+
+	r := &rebaser{}
+	return r.rebase(v)
+}
+
+type deduplicator struct {
+	// known contains containers grouped by fingerprint
+	known map[uint64][]Value
+	guard *cycleGuard
+}
+
+// visit deduplicates children of container and returns value which should replace it.
+//
+// Returns value fingerprint and flag which reports whether value can be shared.
+func (d *deduplicator) visit(v Value) (Value, uint64, bool) {
+	switch t := v.(type) {
+	case *Array:
+		if t == nil || t.truncated || !d.guard.enter(t) {
+			return v, 0, false
+		}
+		defer d.guard.leave()
+
+		h := fnv.New64a()
+		writeFingerprintHeader(h, hashTagArray, len(t.Items))
+		shareable := true
+		for i, item := range t.Items {
+			item, sum, ok := d.visit(item)
+			t.Items[i] = item
+			shareable = shareable && ok
+			writeFingerprint(h, sum)
+		}
+
+		if !shareable {
+			return t, 0, false
+		}
+		return d.intern(t, h.Sum64()), h.Sum64(), true
+	case *Object:
+		if t == nil || t.truncated || !d.guard.enter(t) {
+			return v, 0, false
+		}
+		defer d.guard.leave()
+
+		// compact objects are updated in place and stay compact
+		members := t.members
+		if !t.compact {
+			members = t.Members()
+		}
+
+		h := fnv.New64a()
+		writeFingerprintHeader(h, hashTagObject, len(members))
+		shareable := true
+		for i, m := range members {
+			item, sum, ok := d.visit(m.Value)
+			if item != m.Value {
+				if t.compact {
+					t.members[i].Value = item
+				} else {
+					t.Items[m.Key] = item
+				}
+			}
+			shareable = shareable && ok
+
+			writeFingerprintHeader(h, hashTagString, len(m.Key))
+			_, _ = h.Write(stringBytes(m.Key))
+			writeFingerprint(h, sum)
+		}
+
+		// release replaced values referenced by source order list
+		for i, m := range t.members {
+			if item, ok := t.Items[m.Key]; ok {
+				t.members[i].Value = item
+			}
+		}
+
+		if !shareable {
+			return t, 0, false
+		}
+		return d.intern(t, h.Sum64()), h.Sum64(), true
+	case Null, Boolean:
+		sum, _ := FingerprintValue(v)
+		return v, sum, true
+	case *Number, *String:
+		if reflect.ValueOf(v).IsNil() {
+			return v, 0, false
+		}
+
+		// strings with invalid escapes are not shared
+		sum, err := FingerprintValue(v)
+		return v, sum, err == nil
+	default:
+		return v, 0, false
+	}
+}
+
+// intern returns previously visited container with the same serialized form
+// or registers passed one.
+func (d *deduplicator) intern(v Value, sum uint64) Value {
+	for _, candidate := range d.known[sum] {
+		if isSameValue(candidate, v) {
+			return candidate
+		}
+	}
+
+	d.known[sum] = append(d.known[sum], v)
+	return v
+}
+
+func writeFingerprintHeader(h io.Writer, tag byte, size int) {
+	var buf [binary.MaxVarintLen64 + 1]byte
+	buf[0] = tag
+	n := binary.PutUvarint(buf[1:], uint64(size))
+	_, _ = h.Write(buf[:n+1])
+}
+
+func writeFingerprint(h io.Writer, sum uint64) {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], sum)
+	_, _ = h.Write(buf[:])
+}
+
+// isSameValue reports whether values have the same serialized form.
+//
+// Children of containers are expected to be already deduplicated.
+func isSameValue(a, b Value) bool {
+	if a == b {
+		return true
+	}
+
+	switch x := a.(type) {
+	case Null:
+		_, ok := b.(Null)
+		return ok
+	case Boolean:
+		y, ok := b.(Boolean)
+		return ok && x.Value == y.Value
+	case *Number:
+		y, ok := b.(*Number)
+		return ok && x.asString() == y.asString()
+	case *String:
+		y, ok := b.(*String)
+		return ok && bytes.Equal(x.rawValue, y.rawValue)
+	case *Array:
+		y, ok := b.(*Array)
+		if !ok || len(x.Items) != len(y.Items) {
+			return false
+		}
+
+		for i := range x.Items {
+			if !isSameValue(x.Items[i], y.Items[i]) {
+				return false
+			}
+		}
+		return true
+	case *Object:
+		y, ok := b.(*Object)
+		if !ok || x.Len() != y.Len() {
+			return false
+		}
+
+		xm, ym := x.Members(), y.Members()
+		for i := range xm {
+			if xm[i].Key != ym[i].Key || !isSameValue(xm[i].Value, ym[i].Value) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
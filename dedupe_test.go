@@ -0,0 +1,108 @@
+package jsonreflect
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	. "github.com/x1unix/jsonreflect/internal/testutil"
+)
+
+func TestDeduplicate(t *testing.T) {
+	src := `[
+		{"id": 1, "settings": {"theme": "dark", "sizes": [1, 2]}},
+		{"id": 2, "settings": {"theme": "dark", "sizes": [1, 2]}},
+		{"id": 3, "settings": {"sizes": [1, 2], "theme": "dark"}},
+		{"id": 4, "settings": {"theme": "dark", "sizes": [1.0, 2]}},
+		{"id": 1, "settings": {"theme": "dark", "sizes": [1, 2]}}
+	]`
+
+	v, err := ValueOf([]byte(src))
+	require.NoError(t, err)
+	want, err := MarshalValue(v, nil)
+	require.NoError(t, err)
+
+	got := Deduplicate(v)
+	require.Same(t, v, got)
+
+	out, err := MarshalValue(got, nil)
+	require.NoError(t, err)
+	require.Equal(t, string(want), string(out))
+
+	items := got.(*Array).Items
+	settings := func(i int) Value {
+		return items[i].(*Object).Items["settings"]
+	}
+
+	require.Same(t, settings(0), settings(1))
+	require.Same(t, items[0], items[4])
+
+	// different key order
+	require.NotSame(t, settings(0), settings(2))
+	require.Same(t, settings(0).(*Object).Items["sizes"], settings(2).(*Object).Items["sizes"])
+
+	// different number notation
+	require.NotSame(t, settings(0), settings(3))
+
+	// clone undoes sharing
+	clone := Clone(got).(*Array)
+	require.NotSame(t, clone.Items[0], clone.Items[4])
+	clone.Items[0].(*Object).Items["settings"].(*Object).Items["theme"] = NewString("light")
+	require.Equal(t, "dark", settings(0).(*Object).Items["theme"].Interface())
+	require.Equal(t, "dark", clone.Items[1].(*Object).Items["settings"].(*Object).Items["theme"].Interface())
+}
+
+func TestDeduplicate_Compact(t *testing.T) {
+	src := RepeatFixture("[", ",", "]", 100,
+		`{"id": %d, "settings": {"theme": "dark", "notify": true, "limits": [10, 20, 30], "locale": "en"}}`, nil)
+
+	// allocations made by Deduplicate itself
+	dedupeAllocs := func(opts ...ParserOption) float64 {
+		parse := testing.AllocsPerRun(10, func() {
+			_, _ = ValueOf(src, opts...)
+		})
+		total := testing.AllocsPerRun(10, func() {
+			v, _ := ValueOf(src, opts...)
+			Deduplicate(v)
+		})
+		return total - parse
+	}
+
+	v, err := ValueOf(src, CompactObjects())
+	require.NoError(t, err)
+	want, err := MarshalValue(v, nil)
+	require.NoError(t, err)
+
+	got := Deduplicate(v)
+	out, err := MarshalValue(got, nil)
+	require.NoError(t, err)
+	require.Equal(t, string(want), string(out))
+
+	shared := make(map[Value]struct{})
+	for _, item := range got.(*Array).Items {
+		obj := item.(*Object)
+		require.True(t, obj.compact)
+		require.Nil(t, obj.Items)
+
+		settings, ok := obj.Get("settings")
+		require.True(t, ok)
+		require.True(t, settings.(*Object).compact)
+		shared[settings] = struct{}{}
+	}
+	require.Len(t, shared, 1)
+
+	// compact objects don't allocate items map
+	require.LessOrEqual(t, dedupeAllocs(CompactObjects()), dedupeAllocs())
+}
+
+func BenchmarkDeduplicate(b *testing.B) {
+	src := RepeatFixture("[", ",", "]", 1000, `{"id": %d, "settings": {"theme": "dark", "limits": [10, 20, 30]}}`, nil)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		v, err := ValueOf(src)
+		if err != nil {
+			b.Fatal(err)
+		}
+		Deduplicate(v)
+	}
+}
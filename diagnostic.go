@@ -0,0 +1,160 @@
+package jsonreflect
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+)
+
+// DiagnosticKind is kind of unmarshal diagnostic
+type DiagnosticKind uint8
+
+const (
+	// DiagnosticPrivateFieldSkipped is reported when private struct field
+	// was skipped, but could be set using DangerouslySetPrivateFields.
+	DiagnosticPrivateFieldSkipped DiagnosticKind = iota + 1
+
+	// DiagnosticOrphanCollectorUnused is reported when struct has orphan collector,
+	// but object has no orphan keys.
+	DiagnosticOrphanCollectorUnused
+
+	// DiagnosticValueCast is reported when value was cast to destination type
+	// because of NoStrict option.
+	DiagnosticValueCast
+
+	// DiagnosticNameGuessed is reported when struct field was bound to
+	// a source key using field name guessing.
+	DiagnosticNameGuessed
+
+	// DiagnosticSchemaKeywordIgnored is reported when schema keyword
+	// is not supported by ValidateSchema or has invalid value.
+	DiagnosticSchemaKeywordIgnored
+)
+
+// String returns diagnostic kind name
+func (k DiagnosticKind) String() string {
+	switch k {
+	case DiagnosticPrivateFieldSkipped:
+		return "private field skipped"
+	case DiagnosticOrphanCollectorUnused:
+		return "orphan collector unused"
+	case DiagnosticValueCast:
+		return "value cast"
+	case DiagnosticNameGuessed:
+		return "name guessed"
+	case DiagnosticSchemaKeywordIgnored:
+		return "schema keyword ignored"
+	default:
+		return "unknown"
+	}
+}
+
+// Diagnostic is non-fatal finding reported by unmarshaler.
+//
+// See WithDiagnostics.
+type Diagnostic struct {
+	Kind DiagnosticKind
+
+	// Path is path to source value in dotted form. Empty for root value.
+	Path string
+
+	// Type is destination struct type or destination value type for value cast.
+	Type reflect.Type
+
+	// Field is destination struct field name, if any.
+	Field string
+
+	// Message is human-readable description.
+	Message string
+}
+
+// String returns diagnostic in human-readable form
+func (d Diagnostic) String() string {
+	if d.Path == "" {
+		return fmt.Sprintf("%s: %s", d.Kind, d.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", d.Kind, d.Path, d.Message)
+}
+
+// WithDiagnostics sets a callback which receives non-fatal findings of unmarshaler.
+//
+// Diagnostics don't change unmarshal behavior and are useful to find
+// out why an option has no effect or which implicit conversions were made.
+func WithDiagnostics(fn func(Diagnostic)) UnmarshalOption {
+	return func(p *unmarshalParams) {
+		p.onDiagnostic = fn
+	}
+}
+
+// TraceFieldResolution writes a line per struct field with source keys
+// tried to populate it, e.g.:
+//
+//	Config.MaxRetries: tried "max_retries"(tag) -> miss, source keys: [maxRetries retries]
+//	Config.Name: tried "Name"(name) -> miss, "name"(camel case) -> hit
+//
+// Useful to find out why a struct field is not populated.
+// Write errors are ignored.
+func TraceFieldResolution(w io.Writer) UnmarshalOption {
+	return func(p *unmarshalParams) {
+		p.traceFields = w
+	}
+}
+
+// fieldTrace collects source keys tried by findSourceKey.
+type fieldTrace struct {
+	buf []byte
+}
+
+// try reports whether object has key and records attempt.
+//
+// Trace is nil if tracing is disabled.
+func (t *fieldTrace) try(obj *Object, key, source string) bool {
+	ok := obj.HasKey(key)
+	if t == nil {
+		return ok
+	}
+
+	if len(t.buf) > 0 {
+		t.buf = append(t.buf, ", "...)
+	}
+
+	t.buf = strconv.AppendQuote(t.buf, key)
+	t.buf = append(t.buf, '(')
+	t.buf = append(t.buf, source...)
+	t.buf = append(t.buf, ") -> "...)
+	if ok {
+		t.buf = append(t.buf, "hit"...)
+	} else {
+		t.buf = append(t.buf, "miss"...)
+	}
+	return ok
+}
+
+func (t *fieldTrace) write(w io.Writer, structType reflect.Type, field string, obj *Object, found bool) {
+	typeName := structType.Name()
+	if typeName == "" {
+		typeName = structType.String()
+	}
+
+	if found {
+		_, _ = fmt.Fprintf(w, "%s.%s: tried %s\n", typeName, field, t.buf)
+		return
+	}
+
+	_, _ = fmt.Fprintf(w, "%s.%s: tried %s, source keys: %v\n", typeName, field, t.buf, obj.Keys())
+}
+
+func (p unmarshalParams) report(kind DiagnosticKind, t reflect.Type, field, msg string, args ...interface{}) {
+	if p.onDiagnostic == nil {
+		return
+	}
+
+	p.onDiagnostic(Diagnostic{
+		Kind:    kind,
+		Path:    formatPath(p.path),
+		Type:    t,
+		Field:   field,
+		Message: fmt.Sprintf(msg, args...),
+	})
+}
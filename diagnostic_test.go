@@ -0,0 +1,104 @@
+package jsonreflect
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithDiagnostics(t *testing.T) {
+	type item struct {
+		Count int    `json:"count"`
+		Label string `json:"label"`
+	}
+
+	type dst struct {
+		UserName string
+		Items    []item            `json:"items"`
+		Extra    map[string]string `json:"..."`
+		hidden   string
+	}
+
+	cases := map[string]struct {
+		src  string
+		opts []UnmarshalOption
+		want []string
+	}{
+		"strict": {
+			src: `{"userName": "foo", "items": [{"count": 1}]}`,
+			want: []string{
+				`name guessed: field UserName bound to key "userName"`,
+				`orphan collector unused: orphan collector Extra didn't consume any key`,
+			},
+		},
+		"no strict": {
+			src:  `{"UserName": "foo", "items": [{"count": "1", "label": true}], "other": "x"}`,
+			opts: []UnmarshalOption{NoStrict},
+			want: []string{
+				`value cast: items[0].count: string value cast to int`,
+				`value cast: items[0].label: boolean value cast to string`,
+			},
+		},
+		"private fields": {
+			src:  `{"UserName": "foo", "hidden": "baz", "other": "x"}`,
+			opts: []UnmarshalOption{DangerouslySetPrivateFields},
+			want: []string{
+				`private field skipped: private field hidden has no json tag required by DangerouslySetPrivateFields option`,
+			},
+		},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			var got []string
+			opts := append(c.opts, WithDiagnostics(func(d Diagnostic) {
+				got = append(got, d.String())
+			}))
+
+			require.NoError(t, Unmarshal([]byte(c.src), new(dst), opts...))
+			require.Equal(t, c.want, got)
+		})
+	}
+}
+
+func TestTraceFieldResolution(t *testing.T) {
+	type Config struct {
+		MaxRetries int `json:"max_retries"`
+		Name       string
+		Timeout    int
+		Skipped    string                 `json:"-"`
+		Rest       map[string]interface{} `json:"..."`
+	}
+
+	cases := map[string]struct {
+		opts []UnmarshalOption
+		want []string
+	}{
+		"default": {
+			want: []string{
+				`Config.MaxRetries: tried "max_retries"(tag) -> miss, source keys: [Timeout maxRetries name]`,
+				`Config.Name: tried "Name"(name) -> miss, "name"(camel case) -> hit`,
+				`Config.Timeout: tried "Timeout"(name) -> hit`,
+			},
+		},
+		"no name guessing": {
+			opts: []UnmarshalOption{DisableNameGuessing},
+			want: []string{
+				`Config.MaxRetries: tried "max_retries"(tag) -> miss, source keys: [Timeout maxRetries name]`,
+				`Config.Name: tried "Name"(name) -> miss, source keys: [Timeout maxRetries name]`,
+				`Config.Timeout: tried "Timeout"(name) -> hit`,
+			},
+		},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			sb := &strings.Builder{}
+			opts := append(c.opts, TraceFieldResolution(sb))
+			src := `{"maxRetries": 3, "name": "foo", "Timeout": 10}`
+			require.NoError(t, Unmarshal([]byte(src), new(Config), opts...))
+			require.Equal(t, strings.Join(c.want, "\n")+"\n", sb.String())
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package jsonreflect
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+const defaultFileMode = 0644
+
+// Document is parsed JSON document
+type Document struct {
+	// Root is document root value.
+	//
+	// Nil for empty document.
+	Root Value
+
+	// MarshalOptions are options used to write a document.
+	MarshalOptions *MarshalOptions
+
+	// Encoding is document encoding.
+	//
+	// Document is written back in the same encoding as it was read.
+	Encoding Encoding
+
+	// BOM reports whether document is written with byte order mark.
+	BOM bool
+
+	// Skipped is count of leading source bytes skipped by ScanToFirstValue option.
+	//
+	// Value positions include skipped bytes.
+	Skipped int
+
+	// src is UTF-8 document source which positions refer to
+	src []byte
+
+	// lines is source line index, built on first use
+	lines *LineIndex
+
+	// arena is values memory reused by Parser.ParseInto
+	arena *valueArena
+
+	// slab holds arena, slabs is pool which slab is returned to by Release
+	slab  *Slab
+	slabs SlabPool
+}
+
+// NewDocument parses JSON-encoded data and returns a document.
+//
+// UTF-16 and UTF-32 documents are transcoded to UTF-8 before parsing.
+// Value and error positions of such documents are byte offsets in transcoded
+// UTF-8 source, not in the original input. Use LineIndex to get line and column
+// of a position, they match the original document.
+func NewDocument(src []byte, opts ...ParserOption) (*Document, error) {
+	_, hasBOM := DetectEncoding(src)
+	data, enc, err := ToUTF8(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := NewParser(data, opts...)
+	root, err := p.Parse()
+	if err != nil {
+		return nil, err
+	}
+	return &Document{Root: root, Encoding: enc, BOM: hasBOM, Skipped: p.skipped, src: data}, nil
+}
+
+// LoadDocument reads and parses JSON document from a file.
+func LoadDocument(path string, opts ...ParserOption) (*Document, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := NewDocument(data, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %q: %w", path, err)
+	}
+	return doc, nil
+}
+
+// LineIndex returns line index of document source.
+//
+// Index is built on first call. Index is empty if document wasn't parsed from source.
+func (d *Document) LineIndex() *LineIndex {
+	if d.lines == nil {
+		d.lines = NewLineIndex(d.src)
+	}
+	return d.lines
+}
+
+// FormatError returns error message prefixed with line and column of error position
+// in document source.
+func (d *Document) FormatError(err error) string {
+	return d.LineIndex().FormatError(err)
+}
+
+// Bytes returns JSON encoding of a document in document encoding.
+//
+// Empty document is encoded as empty output.
+func (d *Document) Bytes() ([]byte, error) {
+	if d == nil || d.Root == nil {
+		return nil, nil
+	}
+
+	data, err := MarshalValue(d.Root, d.MarshalOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.Encoding == EncodingUTF8 && !d.BOM {
+		return data, nil
+	}
+	return FromUTF8(data, d.Encoding, d.BOM), nil
+}
+
+// WriteTo implements io.WriterTo
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	data, err := d.Bytes()
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+// Save writes document to a file.
+//
+// Write is atomic: document is written to a temporary file
+// which replaces destination file on success.
+// Permissions of existing file are preserved.
+func (d *Document) Save(path string) error {
+	data, err := d.Bytes()
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(path, data)
+}
+
+func writeFileAtomic(path string, data []byte) (err error) {
+	mode := os.FileMode(defaultFileMode)
+	if stat, err := os.Stat(path); err == nil {
+		mode = stat.Mode().Perm()
+	}
+
+	dir, name := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+
+	f, err := ioutil.TempFile(dir, "."+name+".tmp*")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = f.Close()
+			_ = os.Remove(f.Name())
+		}
+	}()
+
+	if _, err = io.Copy(f, bytes.NewReader(data)); err != nil {
+		return err
+	}
+
+	if err = f.Chmod(mode); err != nil {
+		return err
+	}
+
+	if err = f.Sync(); err != nil {
+		return err
+	}
+
+	if err = f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
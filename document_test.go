@@ -0,0 +1,109 @@
+package jsonreflect
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocument_WriteTo(t *testing.T) {
+	doc, err := NewDocument([]byte(`{"foo": [1, true]}`))
+	require.NoError(t, err)
+
+	buff := &bytes.Buffer{}
+	n, err := doc.WriteTo(buff)
+	require.NoError(t, err)
+	require.Equal(t, `{"foo":[1,true]}`, buff.String())
+	require.Equal(t, int64(buff.Len()), n)
+
+	doc.MarshalOptions = &MarshalOptions{Indent: " ", TrailingNewline: true}
+	buff.Reset()
+	_, err = doc.WriteTo(buff)
+	require.NoError(t, err)
+	require.Equal(t, "{\n \"foo\": [\n  1,\n  true\n ]\n}\n", buff.String())
+
+	empty, err := NewDocument([]byte(" "))
+	require.NoError(t, err)
+	n, err = empty.WriteTo(buff)
+	require.NoError(t, err)
+	require.Zero(t, n)
+}
+
+func TestDocument_Save(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jsonreflect")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"foo": 1}`), 0600))
+
+	doc, err := LoadDocument(path)
+	require.NoError(t, err)
+
+	doc.Root.(*Object).Items["foo"] = NewString("bar")
+	require.NoError(t, doc.Save(path))
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, `{"foo":"bar"}`, string(data))
+
+	stat, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), stat.Mode().Perm())
+
+	// temporary files should be removed
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	// failed marshal should keep original file
+	doc.Root.(*Object).Items["foo"] = (*String)(nil)
+	require.Error(t, doc.Save(path))
+	data, err = ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, `{"foo":"bar"}`, string(data))
+
+	_, err = LoadDocument(filepath.Join(dir, "missing.json"))
+	require.Error(t, err)
+}
+
+func TestDocument_Encoding(t *testing.T) {
+	src := FromUTF8([]byte(`{"foo": "бар"}`), EncodingUTF16LE, true)
+	_, err := NewParser(src).Parse()
+	require.EqualError(t, err,
+		"unsupported document encoding UTF-16LE, document should be transcoded to UTF-8 (in range 0:0)")
+
+	doc, err := NewDocument(src)
+	require.NoError(t, err)
+	require.Equal(t, EncodingUTF16LE, doc.Encoding)
+	require.True(t, doc.BOM)
+
+	str, err := doc.Root.(*Object).Items["foo"].String()
+	require.NoError(t, err)
+	require.Equal(t, "бар", str)
+
+	// positions refer to UTF-8 source, line index maps them to columns of original document
+	pos := doc.Root.(*Object).Items["foo"].Ref()
+	require.Equal(t, newPosition(8, 15), pos)
+	line, col := doc.LineIndex().LineCol(pos.End)
+	require.Equal(t, []int{1, 13}, []int{line, col})
+
+	out, err := doc.Bytes()
+	require.NoError(t, err)
+	require.Equal(t, FromUTF8([]byte(`{"foo":"бар"}`), EncodingUTF16LE, true), out)
+
+	doc.Encoding = EncodingUTF8
+	doc.BOM = false
+	out, err = doc.Bytes()
+	require.NoError(t, err)
+	require.Equal(t, `{"foo":"бар"}`, string(out))
+
+	// UTF-8 BOM is skipped by parser
+	v, err := NewParser([]byte("\xEF\xBB\xBF [1]")).Parse()
+	require.NoError(t, err)
+	require.Equal(t, Position{Start: 4, End: 6}, v.Ref())
+}
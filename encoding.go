@@ -0,0 +1,194 @@
+package jsonreflect
+
+import (
+	"encoding/binary"
+	"fmt"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encoding is JSON document text encoding
+type Encoding uint8
+
+const (
+	// EncodingUTF8 is UTF-8 encoding
+	EncodingUTF8 Encoding = iota
+
+	// EncodingUTF16LE is UTF-16 little-endian encoding
+	EncodingUTF16LE
+
+	// EncodingUTF16BE is UTF-16 big-endian encoding
+	EncodingUTF16BE
+
+	// EncodingUTF32LE is UTF-32 little-endian encoding
+	EncodingUTF32LE
+
+	// EncodingUTF32BE is UTF-32 big-endian encoding
+	EncodingUTF32BE
+)
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+	bomUTF32LE = []byte{0xFF, 0xFE, 0x00, 0x00}
+	bomUTF32BE = []byte{0x00, 0x00, 0xFE, 0xFF}
+)
+
+// String returns encoding name
+func (e Encoding) String() string {
+	switch e {
+	case EncodingUTF8:
+		return "UTF-8"
+	case EncodingUTF16LE:
+		return "UTF-16LE"
+	case EncodingUTF16BE:
+		return "UTF-16BE"
+	case EncodingUTF32LE:
+		return "UTF-32LE"
+	case EncodingUTF32BE:
+		return "UTF-32BE"
+	default:
+		return "unknown"
+	}
+}
+
+func (e Encoding) bom() []byte {
+	switch e {
+	case EncodingUTF16LE:
+		return bomUTF16LE
+	case EncodingUTF16BE:
+		return bomUTF16BE
+	case EncodingUTF32LE:
+		return bomUTF32LE
+	case EncodingUTF32BE:
+		return bomUTF32BE
+	default:
+		return bomUTF8
+	}
+}
+
+// DetectEncoding detects document encoding by byte order mark (BOM).
+//
+// If document has no BOM, encoding is detected by pattern of null bytes
+// in first 4 bytes, as described in RFC 4627 section 3.
+//
+// Returns encoding and flag which reports whether document starts with BOM.
+func DetectEncoding(src []byte) (Encoding, bool) {
+	switch {
+	case hasPrefix(src, bomUTF32LE):
+		return EncodingUTF32LE, true
+	case hasPrefix(src, bomUTF32BE):
+		return EncodingUTF32BE, true
+	case hasPrefix(src, bomUTF8):
+		return EncodingUTF8, true
+	case hasPrefix(src, bomUTF16LE):
+		return EncodingUTF16LE, true
+	case hasPrefix(src, bomUTF16BE):
+		return EncodingUTF16BE, true
+	}
+
+	if len(src) < 2 {
+		return EncodingUTF8, false
+	}
+
+	if len(src) >= 4 {
+		switch {
+		case src[0] == 0 && src[1] == 0 && src[2] == 0 && src[3] != 0:
+			return EncodingUTF32BE, false
+		case src[0] != 0 && src[1] == 0 && src[2] == 0 && src[3] == 0:
+			return EncodingUTF32LE, false
+		}
+	}
+
+	switch {
+	case src[0] == 0 && src[1] != 0:
+		return EncodingUTF16BE, false
+	case src[0] != 0 && src[1] == 0:
+		return EncodingUTF16LE, false
+	}
+
+	return EncodingUTF8, false
+}
+
+func hasPrefix(src, prefix []byte) bool {
+	return len(src) >= len(prefix) && string(src[:len(prefix)]) == string(prefix)
+}
+
+// ToUTF8 detects document encoding and transcodes document to UTF-8.
+//
+// Byte order mark is removed from the result.
+// Positions of values parsed from the result refer to the result, not to the passed source.
+func ToUTF8(src []byte) ([]byte, Encoding, error) {
+	enc, hasBOM := DetectEncoding(src)
+	if hasBOM {
+		src = src[len(enc.bom()):]
+	}
+
+	switch enc {
+	case EncodingUTF16LE, EncodingUTF16BE:
+		if len(src)%2 != 0 {
+			return nil, enc, fmt.Errorf("invalid %s document: odd length", enc)
+		}
+
+		order := byteOrderOf(enc)
+		units := make([]uint16, 0, len(src)/2)
+		for i := 0; i < len(src); i += 2 {
+			units = append(units, order.Uint16(src[i:]))
+		}
+		return []byte(string(utf16.Decode(units))), enc, nil
+	case EncodingUTF32LE, EncodingUTF32BE:
+		if len(src)%4 != 0 {
+			return nil, enc, fmt.Errorf("invalid %s document: length is not multiple of 4", enc)
+		}
+
+		order := byteOrderOf(enc)
+		out := make([]byte, 0, len(src)/4)
+		for i := 0; i < len(src); i += 4 {
+			r := rune(order.Uint32(src[i:]))
+			if !utf8.ValidRune(r) {
+				return nil, enc, fmt.Errorf("invalid %s document: invalid code point at offset %d", enc, i)
+			}
+			out = append(out, string(r)...)
+		}
+		return out, enc, nil
+	default:
+		return src, enc, nil
+	}
+}
+
+// FromUTF8 transcodes UTF-8 document to specified encoding.
+func FromUTF8(src []byte, enc Encoding, withBOM bool) []byte {
+	var out []byte
+	if withBOM {
+		out = append(out, enc.bom()...)
+	}
+
+	switch enc {
+	case EncodingUTF16LE, EncodingUTF16BE:
+		order := byteOrderOf(enc)
+		buf := make([]byte, 2)
+		for _, unit := range utf16.Encode([]rune(string(src))) {
+			order.PutUint16(buf, unit)
+			out = append(out, buf...)
+		}
+		return out
+	case EncodingUTF32LE, EncodingUTF32BE:
+		order := byteOrderOf(enc)
+		buf := make([]byte, 4)
+		for _, r := range string(src) {
+			order.PutUint32(buf, uint32(r))
+			out = append(out, buf...)
+		}
+		return out
+	default:
+		return append(out, src...)
+	}
+}
+
+func byteOrderOf(enc Encoding) binary.ByteOrder {
+	if enc == EncodingUTF16BE || enc == EncodingUTF32BE {
+		return binary.BigEndian
+	}
+	return binary.LittleEndian
+}
@@ -0,0 +1,55 @@
+package jsonreflect
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectEncoding(t *testing.T) {
+	cases := map[string]struct {
+		src     []byte
+		want    Encoding
+		wantBOM bool
+	}{
+		"empty":          {src: nil, want: EncodingUTF8},
+		"utf8":           {src: []byte(`{}`), want: EncodingUTF8},
+		"utf8 single":    {src: []byte(`1`), want: EncodingUTF8},
+		"utf8 bom":       {src: []byte("\xEF\xBB\xBF{}"), want: EncodingUTF8, wantBOM: true},
+		"utf16le bom":    {src: []byte("\xFF\xFE{\x00}\x00"), want: EncodingUTF16LE, wantBOM: true},
+		"utf16be bom":    {src: []byte("\xFE\xFF\x00{\x00}"), want: EncodingUTF16BE, wantBOM: true},
+		"utf32le bom":    {src: []byte("\xFF\xFE\x00\x00{\x00\x00\x00"), want: EncodingUTF32LE, wantBOM: true},
+		"utf32be bom":    {src: []byte("\x00\x00\xFE\xFF\x00\x00\x00{"), want: EncodingUTF32BE, wantBOM: true},
+		"utf16le":        {src: []byte("{\x00}\x00"), want: EncodingUTF16LE},
+		"utf16be":        {src: []byte("\x00{\x00}"), want: EncodingUTF16BE},
+		"utf16le single": {src: []byte("1\x00"), want: EncodingUTF16LE},
+		"utf32le":        {src: []byte("1\x00\x00\x00"), want: EncodingUTF32LE},
+		"utf32be":        {src: []byte("\x00\x00\x001"), want: EncodingUTF32BE},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			got, hasBOM := DetectEncoding(c.src)
+			require.Equal(t, c.want, got, "want %s, got %s", c.want, got)
+			require.Equal(t, c.wantBOM, hasBOM)
+		})
+	}
+}
+
+func TestToUTF8(t *testing.T) {
+	src := `{"foo":["bar","юникод","😀"]}`
+	for _, enc := range []Encoding{
+		EncodingUTF8, EncodingUTF16LE, EncodingUTF16BE, EncodingUTF32LE, EncodingUTF32BE,
+	} {
+		for _, withBOM := range []bool{true, false} {
+			encoded := FromUTF8([]byte(src), enc, withBOM)
+			got, gotEnc, err := ToUTF8(encoded)
+			require.NoError(t, err, enc)
+			require.Equal(t, enc, gotEnc)
+			require.Equal(t, src, string(got), enc)
+		}
+	}
+
+	_, _, err := ToUTF8([]byte("\xFF\xFE{\x00}"))
+	require.EqualError(t, err, "invalid UTF-16LE document: odd length")
+}
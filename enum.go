@@ -0,0 +1,82 @@
+package jsonreflect
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const (
+	tagNameEnum = "enum"
+
+	enumValueSeparator = "|"
+)
+
+// CaseInsensitiveEnums enables case-insensitive matching of enum values.
+//
+// Matched value is replaced with allowed value as it's declared,
+// e.g. "Active" becomes "active". See UnmarshalEnum.
+var CaseInsensitiveEnums UnmarshalOption = func(fn *unmarshalParams) {
+	fn.caseInsensitiveEnums = true
+}
+
+// UnmarshalEnum decodes string value and checks that it's one of allowed values.
+//
+// Returns matched allowed value or *EnumError. Module targets Go 1.14,
+// which has no generics, so function takes and returns plain strings
+// instead of a string-based enum type. Result can be converted to such type:
+//
+//	state, err := jsonreflect.UnmarshalEnum(v, []string{"active", "archived"})
+//	return State(state), err
+//
+// Struct fields are checked using `enum` tag with allowed values separated by "|":
+//
+//	State string `json:"state" enum:"active|archived|deleted"`
+func UnmarshalEnum(v Value, allowed []string, opts ...UnmarshalOption) (string, error) {
+	p := newUnmarshalParams(opts)
+
+	var str string
+	if err := unmarshalString(v, reflect.ValueOf(&str).Elem(), p.strict); err != nil {
+		return "", err
+	}
+
+	return matchEnum(v, str, allowed, p)
+}
+
+func matchEnum(src Value, str string, allowed []string, p unmarshalParams) (string, error) {
+	for _, val := range allowed {
+		if str == val || (p.caseInsensitiveEnums && strings.EqualFold(str, val)) {
+			return val, nil
+		}
+	}
+
+	return "", &EnumError{Value: str, Allowed: allowed, Position: src.Ref()}
+}
+
+// checkEnumField checks that struct field value is one of values listed in `enum` tag.
+func checkEnumField(src Value, f reflect.StructField, dst reflect.Value, p unmarshalParams) error {
+	tag, ok := f.Tag.Lookup(tagNameEnum)
+	if !ok {
+		return nil
+	}
+
+	if dst.Kind() == reflect.Ptr {
+		if dst.IsNil() {
+			// null value
+			return nil
+		}
+		dst = dst.Elem()
+	}
+
+	if dst.Kind() != reflect.String {
+		return fmt.Errorf("%q tag is supported only by string fields (got %s)", tagNameEnum, dst.Type())
+	}
+
+	val, err := matchEnum(src, dst.String(), strings.Split(tag, enumValueSeparator), p)
+	if err != nil {
+		return err
+	}
+
+	dst.SetString(val)
+	return nil
+}
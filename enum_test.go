@@ -0,0 +1,118 @@
+package jsonreflect
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	. "github.com/x1unix/jsonreflect/internal/testutil"
+)
+
+type testState string
+
+func TestUnmarshalEnum(t *testing.T) {
+	allowed := []string{"active", "archived"}
+	cases := map[string]struct {
+		src  string
+		opts []UnmarshalOption
+		want string
+		err  ExpectedError
+	}{
+		"allowed value": {
+			src:  `"archived"`,
+			want: "archived",
+		},
+		"unknown value": {
+			src: `"deleted"`,
+			err: `value "deleted" is not one of allowed values: "active", "archived" (in range 0:8)`,
+		},
+		"case mismatch": {
+			src: `"Active"`,
+			err: `value "Active" is not one of allowed values: "active", "archived" (in range 0:7)`,
+		},
+		"case insensitive": {
+			src:  `"Active"`,
+			opts: []UnmarshalOption{CaseInsensitiveEnums},
+			want: "active",
+		},
+		"not a string": {
+			src: `1`,
+			err: `cannot unmarshal number value to string`,
+		},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			v, err := ValueOf([]byte(c.src))
+			require.NoError(t, err)
+
+			got, err := UnmarshalEnum(v, allowed, c.opts...)
+			if !c.err.AssertError(t, err) {
+				return
+			}
+			require.Equal(t, c.want, got)
+		})
+	}
+}
+
+func TestUnmarshal_EnumTag(t *testing.T) {
+	type dst struct {
+		State  testState  `json:"state" enum:"active|archived|deleted"`
+		Prev   *testState `json:"prev" enum:"active|archived|deleted"`
+		Amount int        `json:"amount" enum:"1|2"`
+	}
+
+	cases := map[string]struct {
+		src  string
+		opts []UnmarshalOption
+		want dst
+		err  ExpectedError
+	}{
+		"allowed values": {
+			src:  `{"state": "active", "prev": null}`,
+			want: dst{State: "active"},
+		},
+		"unknown value": {
+			src: `{"state": "unknown"}`,
+			err: `can't unmarshal field "state" to jsonreflect.dst.jsonreflect.testState: value "unknown" is not one of allowed values: "active", "archived", "deleted" (in range 10:18)`,
+		},
+		"unknown pointer value": {
+			src: `{"prev": "Deleted"}`,
+			err: `can't unmarshal field "prev" to jsonreflect.dst.*jsonreflect.testState: value "Deleted" is not one of allowed values: "active", "archived", "deleted" (in range 9:17)`,
+		},
+		"case insensitive": {
+			src:  `{"state": "ACTIVE", "prev": "Deleted"}`,
+			opts: []UnmarshalOption{CaseInsensitiveEnums},
+			want: dst{State: "active", Prev: func() *testState { s := testState("deleted"); return &s }()},
+		},
+		"non-string field": {
+			src: `{"amount": 1}`,
+			err: `can't unmarshal field "amount" to jsonreflect.dst.int: "enum" tag is supported only by string fields (got int)`,
+		},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			got := dst{}
+			err := Unmarshal([]byte(c.src), &got, c.opts...)
+			if !c.err.AssertError(t, err) {
+				return
+			}
+			require.Equal(t, c.want, got)
+		})
+	}
+}
+
+func TestEnumError(t *testing.T) {
+	err := Unmarshal([]byte(`{"state": "unknown"}`), &struct {
+		State string `json:"state" enum:"active|deleted"`
+	}{})
+
+	enumErr := new(EnumError)
+	require.True(t, errors.As(err, &enumErr))
+	require.Equal(t, &EnumError{
+		Position: newPosition(10, 18),
+		Value:    "unknown",
+		Allowed:  []string{"active", "deleted"},
+	}, enumErr)
+}
@@ -0,0 +1,554 @@
+package jsonreflect
+
+import (
+	"bytes"
+	"strings"
+)
+
+// EqualOptions is Equal and EqualBytes options
+type EqualOptions struct {
+	// OrderedKeys makes object comparison sensitive to keys order.
+	OrderedKeys bool
+}
+
+// Equal reports whether values are equal as JSON values.
+//
+// Strings are compared by decoded value, numbers are compared by value,
+// so 1 and 1.0 are equal. Object keys order is ignored unless OrderedKeys option is set.
+// Nil values and nil pointers of value types are equal to null, like in HashValueInto.
+func Equal(a, b Value, opts EqualOptions) bool {
+	if xn, yn := isNullValue(a), isNullValue(b); xn || yn {
+		return xn && yn
+	}
+
+	switch x := a.(type) {
+	case *Number:
+		y, ok := b.(*Number)
+		if !ok {
+			return false
+		}
+
+		return numbersEqual(x, y)
+	case Boolean:
+		y, ok := b.(Boolean)
+		return ok && x.Value == y.Value
+	case *String:
+		y, ok := b.(*String)
+		return ok && decodedString(x) == decodedString(y)
+	case *Array:
+		y, ok := b.(*Array)
+		if !ok || len(x.Items) != len(y.Items) {
+			return false
+		}
+
+		for i := range x.Items {
+			if !Equal(x.Items[i], y.Items[i], opts) {
+				return false
+			}
+		}
+		return true
+	case *Object:
+		y, ok := b.(*Object)
+		if !ok || x.Len() != y.Len() {
+			return false
+		}
+
+		if opts.OrderedKeys {
+			xm, ym := x.Members(), y.Members()
+			for i := range xm {
+				if xm[i].Key != ym[i].Key || !Equal(xm[i].Value, ym[i].Value, opts) {
+					return false
+				}
+			}
+			return true
+		}
+
+		for _, key := range x.Keys() {
+			xv, _ := x.Get(key)
+			yv, ok := y.Get(key)
+			if !ok || !Equal(xv, yv, opts) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// isNullValue reports whether value is null, nil or nil pointer of value type.
+func isNullValue(v Value) bool {
+	switch t := v.(type) {
+	case *Number:
+		return t == nil
+	case *String:
+		return t == nil
+	case *Array:
+		return t == nil
+	case *Object:
+		return t == nil
+	default:
+		return TypeOf(v) == TypeNull
+	}
+}
+
+// Difference is location of the first difference between two JSON documents.
+type Difference struct {
+	// A is byte offset in the first document
+	A int
+
+	// B is byte offset in the second document
+	B int
+}
+
+// numbersEqual reports whether numbers have the same value.
+//
+// Numbers which don't fit into float64 are compared by decimal digits.
+func numbersEqual(x, y *Number) bool {
+	if x.decimal == "" && y.decimal == "" {
+		if !x.IsFloat && !y.IsFloat {
+			return x.Int64() == y.Int64()
+		}
+		return x.Float64() == y.Float64()
+	}
+
+	xc, xs, err := x.Decimal()
+	if err != nil {
+		return false
+	}
+
+	yc, ys, err := y.Decimal()
+	if err != nil {
+		return false
+	}
+
+	xd, xe := normalizeDecimal(xc.String(), xs)
+	yd, ye := normalizeDecimal(yc.String(), ys)
+	return xd == yd && xe == ye
+}
+
+// normalizeDecimal removes trailing zeros of coefficient digits and adjusts scale.
+func normalizeDecimal(digits string, scale int32) (string, int64) {
+	trimmed := strings.TrimRight(digits, "0")
+	if trimmed == "" || trimmed == "-" {
+		return "0", 0
+	}
+	return trimmed, int64(scale) - int64(len(digits)-len(trimmed))
+}
+
+// EqualBytes reports whether two JSON documents are equal, see Equal.
+//
+// Documents are compared without building value trees, see FindDifference.
+func EqualBytes(a, b []byte, opts EqualOptions) (bool, error) {
+	diff, err := FindDifference(a, b, opts)
+	return err == nil && diff == nil, err
+}
+
+// FindDifference returns location of the first difference between two JSON documents
+// or nil if documents are equal.
+//
+// Documents are scanned in lockstep and comparison stops at the first difference.
+// Offsets point to the start of differing values. If container has extra members or elements,
+// offsets point to the extra member in one document and to container end in another.
+//
+// Objects are compared member by member while keys order is the same.
+// Once keys order or values diverge, members of both objects are buffered
+// and compared again with the last declaration of duplicate key winning, like in parsed objects,
+// so memory usage is bounded by the widest object.
+//
+// Documents are checked only as far as comparison goes,
+// equal scalar tokens are not decoded.
+func FindDifference(a, b []byte, opts EqualOptions) (*Difference, error) {
+	s := equalScanner{a: NewParser(a), b: NewParser(b), opts: opts}
+	if err := s.a.checkEncoding(); err != nil {
+		return nil, err
+	}
+	if err := s.b.checkEncoding(); err != nil {
+		return nil, err
+	}
+
+	ai, aok := s.a.getPosUntilNextNonDelimiter(s.a.start)
+	bi, bok := s.b.getPosUntilNextNonDelimiter(s.b.start)
+	switch {
+	case !aok && !bok:
+		// both documents are empty
+		return nil, nil
+	case !aok:
+		return &Difference{A: s.a.end, B: bi}, nil
+	case !bok:
+		return &Difference{A: ai, B: s.b.end}, nil
+	}
+
+	aEnd, bEnd, diff, err := s.compare(ai, bi)
+	if err != nil || diff != nil {
+		return diff, err
+	}
+
+	for _, t := range []struct {
+		p   *Parser
+		end int
+	}{{s.a, aEnd}, {s.b, bEnd}} {
+		if got, ok := t.p.getPosUntilNextNonDelimiter(t.end + 1); ok {
+			return nil, NewInvalidExprError(got, t.p.end, t.p.src[got:])
+		}
+	}
+	return nil, nil
+}
+
+type equalScanner struct {
+	a, b *Parser
+	opts EqualOptions
+}
+
+// compare compares values at passed positions and returns their end positions.
+func (s equalScanner) compare(ai, bi int) (aEnd, bEnd int, diff *Difference, err error) {
+	ca, cb := s.a.src[ai], s.b.src[bi]
+	switch {
+	case ca == tokenObjectStart && cb == tokenObjectStart:
+		return s.compareObjects(ai, bi)
+	case ca == tokenArrayStart && cb == tokenArrayStart:
+		return s.compareArrays(ai, bi)
+	case ca == tokenString && cb == tokenString:
+		return s.compareStrings(ai, bi)
+	case isCompositeStart(ca) || isCompositeStart(cb):
+		return 0, 0, &Difference{A: ai, B: bi}, nil
+	default:
+		return s.compareScalars(ai, bi)
+	}
+}
+
+func isCompositeStart(char byte) bool {
+	return char == tokenObjectStart || char == tokenArrayStart || char == tokenString
+}
+
+func (s equalScanner) compareStrings(ai, bi int) (aEnd, bEnd int, diff *Difference, err error) {
+	if aEnd, err = s.a.skipString(ai); err != nil {
+		return 0, 0, nil, err
+	}
+	if bEnd, err = s.b.skipString(bi); err != nil {
+		return 0, 0, nil, err
+	}
+
+	equal, err := s.keysEqual(ai, aEnd, bi, bEnd)
+	if err != nil || !equal {
+		return 0, 0, &Difference{A: ai, B: bi}, err
+	}
+	return aEnd, bEnd, nil, nil
+}
+
+// keysEqual compares decoded strings in passed ranges.
+func (s equalScanner) keysEqual(ai, aEnd, bi, bEnd int) (bool, error) {
+	ra, rb := s.a.src[ai:aEnd+1], s.b.src[bi:bEnd+1]
+	if bytes.Equal(ra, rb) {
+		return true, nil
+	}
+
+	if bytes.IndexByte(ra, '\\') == -1 && bytes.IndexByte(rb, '\\') == -1 {
+		return false, nil
+	}
+
+	x, err := unquoteString(ra)
+	if err != nil {
+		return false, NewParseError(newPosition(ai, aEnd), err.Error())
+	}
+
+	y, err := unquoteString(rb)
+	if err != nil {
+		return false, NewParseError(newPosition(bi, bEnd), err.Error())
+	}
+	return x == y, nil
+}
+
+func (s equalScanner) compareScalars(ai, bi int) (aEnd, bEnd int, diff *Difference, err error) {
+	if aEnd, err = s.a.scalarEnd(ai); err != nil {
+		return 0, 0, nil, err
+	}
+	if bEnd, err = s.b.scalarEnd(bi); err != nil {
+		return 0, 0, nil, err
+	}
+
+	if bytes.Equal(s.a.src[ai:aEnd+1], s.b.src[bi:bEnd+1]) {
+		return aEnd, bEnd, nil, nil
+	}
+
+	x, err := s.a.decodeScalarValue(ai, false)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	y, err := s.b.decodeScalarValue(bi, false)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	if !Equal(x, y, s.opts) {
+		return 0, 0, &Difference{A: ai, B: bi}, nil
+	}
+	return aEnd, bEnd, nil, nil
+}
+
+func (s equalScanner) compareArrays(ai, bi int) (aEnd, bEnd int, diff *Difference, err error) {
+	aCur, bCur := ai+1, bi+1
+	for first := true; ; first = false {
+		aPos, aClosed, err := s.a.nextItem(ai, aCur, tokenArrayClose, first)
+		if err != nil {
+			return 0, 0, nil, err
+		}
+
+		bPos, bClosed, err := s.b.nextItem(bi, bCur, tokenArrayClose, first)
+		if err != nil {
+			return 0, 0, nil, err
+		}
+
+		switch {
+		case aClosed && bClosed:
+			return aPos, bPos, nil, nil
+		case aClosed || bClosed:
+			return 0, 0, &Difference{A: aPos, B: bPos}, nil
+		}
+
+		if aEnd, bEnd, diff, err = s.compare(aPos, bPos); err != nil || diff != nil {
+			return 0, 0, diff, err
+		}
+		aCur, bCur = aEnd+1, bEnd+1
+	}
+}
+
+func (s equalScanner) compareObjects(ai, bi int) (aEnd, bEnd int, diff *Difference, err error) {
+	aCur, bCur := ai+1, bi+1
+	for first := true; ; first = false {
+		aPos, aClosed, err := s.a.nextItem(ai, aCur, tokenObjectClose, first)
+		if err != nil {
+			return 0, 0, nil, err
+		}
+
+		bPos, bClosed, err := s.b.nextItem(bi, bCur, tokenObjectClose, first)
+		if err != nil {
+			return 0, 0, nil, err
+		}
+
+		if aClosed && bClosed {
+			return aPos, bPos, nil, nil
+		}
+
+		if !aClosed && !bClosed {
+			aKeyEnd, aVal, err := s.a.objectKey(ai, aPos)
+			if err != nil {
+				return 0, 0, nil, err
+			}
+
+			bKeyEnd, bVal, err := s.b.objectKey(bi, bPos)
+			if err != nil {
+				return 0, 0, nil, err
+			}
+
+			equal, err := s.keysEqual(aPos, aKeyEnd, bPos, bKeyEnd)
+			if err != nil {
+				return 0, 0, nil, err
+			}
+
+			if equal {
+				aEnd, bEnd, diff, err = s.compare(aVal, bVal)
+				if err != nil {
+					return 0, 0, nil, err
+				}
+
+				if diff == nil {
+					aCur, bCur = aEnd+1, bEnd+1
+					continue
+				}
+			}
+		}
+
+		// members diverged, but duplicate keys declared later may replace them
+		return s.compareBuffered(ai, bi)
+	}
+}
+
+// bufferedMember is object member buffered for comparison.
+type bufferedMember struct {
+	key        string
+	keyStart   int
+	valueStart int
+	matched    bool
+}
+
+// compareBuffered compares objects starting at ai and bi by buffered members.
+func (s equalScanner) compareBuffered(ai, bi int) (aEnd, bEnd int, diff *Difference, err error) {
+	aMembers, aIndex, aEnd, err := s.a.bufferMembers(ai)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	bMembers, _, bEnd, err := s.b.bufferMembers(bi)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	if s.opts.OrderedKeys {
+		for i, m := range aMembers {
+			if i == len(bMembers) {
+				return 0, 0, &Difference{A: m.keyStart, B: bEnd}, nil
+			}
+
+			if m.key != bMembers[i].key {
+				return 0, 0, &Difference{A: m.keyStart, B: bMembers[i].keyStart}, nil
+			}
+
+			if _, _, diff, err := s.compare(m.valueStart, bMembers[i].valueStart); err != nil || diff != nil {
+				return 0, 0, diff, err
+			}
+		}
+
+		if len(bMembers) > len(aMembers) {
+			return 0, 0, &Difference{A: aEnd, B: bMembers[len(aMembers)].keyStart}, nil
+		}
+		return aEnd, bEnd, nil, nil
+	}
+
+	for _, m := range bMembers {
+		i, ok := aIndex[m.key]
+		if !ok {
+			return 0, 0, &Difference{A: aEnd, B: m.keyStart}, nil
+		}
+
+		aMembers[i].matched = true
+		if _, _, diff, err := s.compare(aMembers[i].valueStart, m.valueStart); err != nil || diff != nil {
+			return 0, 0, diff, err
+		}
+	}
+
+	for _, m := range aMembers {
+		if !m.matched {
+			return 0, 0, &Difference{A: m.keyStart, B: bEnd}, nil
+		}
+	}
+	return aEnd, bEnd, nil, nil
+}
+
+// bufferMembers returns members of object at passed position in order of first declaration,
+// index of members by key and position of object end.
+//
+// Member of duplicate key has value of the last declaration.
+func (p Parser) bufferMembers(start int) ([]bufferedMember, map[string]int, int, error) {
+	var members []bufferedMember
+	index := make(map[string]int)
+	cur := start + 1
+	for first := true; ; first = false {
+		pos, closed, err := p.nextItem(start, cur, tokenObjectClose, first)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+
+		if closed {
+			return members, index, pos, nil
+		}
+
+		keyEnd, valStart, err := p.objectKey(start, pos)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+
+		key, err := p.keyString(pos, keyEnd)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+
+		valEnd, err := p.skipValue(valStart)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+
+		if i, ok := index[key]; ok {
+			members[i].valueStart = valStart
+		} else {
+			index[key] = len(members)
+			members = append(members, bufferedMember{key: key, keyStart: pos, valueStart: valStart})
+		}
+		cur = valEnd + 1
+	}
+}
+
+// nextItem returns position of the next array element or object member
+// or position of container closing token.
+//
+// Start is container start position, pos is position after previous item.
+func (p Parser) nextItem(start, pos int, closing byte, first bool) (int, bool, error) {
+	pos, ok := p.getPosUntilNextNonDelimiter(pos)
+	if !ok {
+		return 0, false, NewUnexpectedEOFError(newPosition(start, p.end), "unterminated %s", containerName(closing))
+	}
+
+	if p.src[pos] == closing {
+		return pos, true, nil
+	}
+
+	if first {
+		return pos, false, nil
+	}
+
+	if p.src[pos] != tokenDelimiter {
+		return 0, false, NewParseError(newPosition(pos, pos),
+			"expected ',' or '%c' after value at offset %d", closing, pos)
+	}
+
+	if pos, ok = p.getPosUntilNextNonDelimiter(pos + 1); !ok {
+		return 0, false, NewUnexpectedEOFError(newPosition(start, p.end), "unterminated %s", containerName(closing))
+	}
+	return pos, false, nil
+}
+
+func containerName(closing byte) string {
+	if closing == tokenObjectClose {
+		return "object"
+	}
+	return "array statement"
+}
+
+// objectKey returns key end position and value start position of object member.
+func (p Parser) objectKey(start, pos int) (keyEnd, valueStart int, err error) {
+	if p.src[pos] != tokenString {
+		return 0, 0, NewUnexpectedCharacterError(start, pos, p.src[pos])
+	}
+
+	if keyEnd, err = p.skipString(pos); err != nil {
+		return 0, 0, err
+	}
+
+	pos, ok := p.getPosUntilNextNonDelimiter(keyEnd + 1)
+	if !ok {
+		return 0, 0, NewUnexpectedEOFError(newPosition(start, p.end), "unterminated object")
+	}
+	if p.src[pos] != tokenKeyDelimiter {
+		return 0, 0, NewParseError(newPosition(pos, pos),
+			"expected ':' after object key at offset %d", pos)
+	}
+
+	if valueStart, ok = p.getPosUntilNextNonDelimiter(pos + 1); !ok {
+		return 0, 0, NewUnexpectedEOFError(newPosition(start, p.end), "unterminated object")
+	}
+	return keyEnd, valueStart, nil
+}
+
+// keyString returns decoded string in range start:end.
+func (p Parser) keyString(start, end int) (string, error) {
+	raw := p.src[start : end+1]
+	if bytes.IndexByte(raw, '\\') == -1 {
+		return string(raw[1 : len(raw)-1]), nil
+	}
+
+	key, err := unquoteString(raw)
+	if err != nil {
+		return "", NewParseError(newPosition(start, end), err.Error())
+	}
+	return key, nil
+}
+
+// scalarEnd returns end position of scalar value at start position.
+func (p Parser) scalarEnd(start int) (int, error) {
+	switch char := p.src[start]; char {
+	case tokenObjectClose, tokenArrayClose, tokenDelimiter, tokenKeyDelimiter:
+		return 0, NewUnexpectedCharacterError(start, start+1, char)
+	}
+	return p.getPosUntilNextDelimiter(start) - 1, nil
+}
@@ -0,0 +1,243 @@
+package jsonreflect
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	. "github.com/x1unix/jsonreflect/internal/testutil"
+)
+
+func TestEqualBytes(t *testing.T) {
+	cases := map[string]struct {
+		a, b string
+		opts EqualOptions
+		diff *Difference
+		err  ExpectedError
+	}{
+		"empty": {},
+		"one empty": {
+			a:    ` `,
+			b:    `1`,
+			diff: &Difference{A: 1, B: 0},
+		},
+		"scalars": {
+			a: `[1, 1.5, "foo", true, false, null]`,
+			b: `[1, 1.5, "foo", true, false, null]`,
+		},
+		"formatting": {
+			a: `{"a":[1,2],"b":{}}`,
+			b: "{\n  \"a\": [\n    1,\n    2\n  ],\n  \"b\": {}\n}\n",
+		},
+		"numbers by value": {
+			a: `[1, 2.50, -0]`,
+			b: `[1.0, 2.5, 0]`,
+		},
+		"different numbers": {
+			a:    `[1, 2]`,
+			b:    `[1, 3]`,
+			diff: &Difference{A: 4, B: 4},
+		},
+		"escaped strings": {
+			a: `{"a\u0062": "f\u006fo"}`,
+			b: `{"ab": "foo"}`,
+		},
+		"escaped slash": {
+			a: `{"a\/b": "c\/d"}`,
+			b: `{"a/b": "c/d"}`,
+		},
+		"lone surrogate": {
+			a: `["\ud800"]`,
+			b: `["\ufffd"]`,
+		},
+		"different strings": {
+			a:    `{"a": "foo"}`,
+			b:    `{"a": "bar"}`,
+			diff: &Difference{A: 6, B: 6},
+		},
+		"different types": {
+			a:    `{"a": [1]}`,
+			b:    `{"a": {"0": 1}}`,
+			diff: &Difference{A: 6, B: 6},
+		},
+		"scalar types": {
+			a:    `["1"]`,
+			b:    `[1]`,
+			diff: &Difference{A: 1, B: 1},
+		},
+		"extra element": {
+			a:    `[1, 2]`,
+			b:    `[1, 2, 3]`,
+			diff: &Difference{A: 5, B: 7},
+		},
+		"keys order": {
+			a: `{"a": 1, "b": {"c": 2, "d": [3]}, "e": 4}`,
+			b: `{"a": 1, "e": 4, "b": {"d": [3], "c": 2}}`,
+		},
+		"ordered keys": {
+			a:    `{"a": 1, "b": 2}`,
+			b:    `{"b": 2, "a": 1}`,
+			opts: EqualOptions{OrderedKeys: true},
+			diff: &Difference{A: 1, B: 1},
+		},
+		"different value after reorder": {
+			a:    `{"a": 1, "b": 2}`,
+			b:    `{"b": 3, "a": 1}`,
+			diff: &Difference{A: 14, B: 6},
+		},
+		"missing key": {
+			a:    `{"a": 1, "b": 2}`,
+			b:    `{"a": 1, "c": 2}`,
+			diff: &Difference{A: 15, B: 9},
+		},
+		"extra key": {
+			a:    `{"a": 1, "b": 2}`,
+			b:    `{"a": 1}`,
+			diff: &Difference{A: 9, B: 7},
+		},
+		"duplicate keys": {
+			a: `{"a": 1, "b": 2, "a": 3}`,
+			b: `{"b": 2, "a": 3}`,
+		},
+		"duplicate key replaces value": {
+			a: `{"a":1,"a":2}`,
+			b: `{"a":2}`,
+		},
+		"duplicate keys in both": {
+			a: `{"a": 1, "b": 0, "a": 1}`,
+			b: `{"a": 5, "b": 0, "a": 1}`,
+		},
+		"duplicate key in second document": {
+			a:    `{"a": 2}`,
+			b:    `{"a": 2, "a": 1}`,
+			diff: &Difference{A: 6, B: 14},
+		},
+		"ordered duplicate keys": {
+			a:    `{"a": 1, "b": 2, "a": 3}`,
+			b:    `{"a": 3, "b": 2}`,
+			opts: EqualOptions{OrderedKeys: true},
+		},
+		"invalid literal": {
+			a:   `[true]`,
+			b:   `[tru]`,
+			err: `unexpected "tru" (in range 1:4)`,
+		},
+		"unterminated": {
+			a:   `[1, 2`,
+			b:   `[1, 2`,
+			err: `unterminated array statement`,
+		},
+		"missing comma": {
+			a:   `{"a": 1 "b": 2}`,
+			b:   `{"a": 1, "b": 2}`,
+			err: `expected ',' or '}' after value at offset 8`,
+		},
+		"trailing data": {
+			a:   `{} {}`,
+			b:   `{}`,
+			err: `unexpected "{}" (in range 3:5)`,
+		},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			diff, err := FindDifference([]byte(c.a), []byte(c.b), c.opts)
+			if !c.err.AssertError(t, err) {
+				return
+			}
+			require.Equal(t, c.diff, diff)
+
+			equal, err := EqualBytes([]byte(c.a), []byte(c.b), c.opts)
+			require.NoError(t, err)
+			require.Equal(t, c.diff == nil, equal)
+
+			// result should be consistent with tree comparison
+			a, err := ValueOf([]byte(c.a))
+			require.NoError(t, err)
+			b, err := ValueOf([]byte(c.b))
+			require.NoError(t, err)
+			require.Equal(t, equal, Equal(a, b, c.opts))
+		})
+	}
+}
+
+func TestEqual(t *testing.T) {
+	require.True(t, Equal(nil, NewNull(), EqualOptions{}))
+	require.False(t, Equal(NewNumberInt(1), NewString("1"), EqualOptions{}))
+
+	// nil pointers are null
+	nils := []Value{nil, NewNull(), (*Array)(nil), (*Number)(nil), (*String)(nil), (*Object)(nil)}
+	for _, x := range nils {
+		for _, y := range nils {
+			require.True(t, Equal(x, y, EqualOptions{}), "%#v %#v", x, y)
+		}
+		require.False(t, Equal(x, NewArray(), EqualOptions{}), "%#v", x)
+		require.False(t, Equal(NewNumberInt(0), x, EqualOptions{}), "%#v", x)
+	}
+
+	// strings are compared by decoded value
+	require.True(t, Equal(&String{rawValue: []byte(`"a\/b"`)}, NewString("a/b"), EqualOptions{}))
+	require.True(t, Equal(&String{rawValue: []byte(`"\ud800"`)}, NewString("\ufffd"), EqualOptions{}))
+
+	f, err := NewNumberFloat(1)
+	require.NoError(t, err)
+	a := NewObjectFromMembers(Member{Key: "a", Value: NewNumberInt(1)}, Member{Key: "b", Value: NewBoolean(true)})
+	b := NewObjectFromMembers(Member{Key: "b", Value: NewBoolean(true)}, Member{Key: "a", Value: f})
+	require.True(t, Equal(a, b, EqualOptions{}))
+	require.False(t, Equal(a, b, EqualOptions{OrderedKeys: true}))
+
+	// numbers out of float64 precision are compared by digits
+	numbers := map[string]bool{
+		"123456789012345678901234567890|123456789012345678901234567891":    false,
+		"123456789012345678901234567890|1.2345678901234567890123456789e29": true,
+		"0.10000000000000000000000000001|0.1":                              false,
+		"0.12345678901234567890|0.123456789012345678900":                   true,
+		"18446744073709551615|18446744073709551614":                        false,
+		"-18446744073709551615|18446744073709551615":                       false,
+	}
+	for src, want := range numbers {
+		pair := strings.Split(src, "|")
+		x, err := ValueOf([]byte(pair[0]))
+		require.NoError(t, err)
+		y, err := ValueOf([]byte(pair[1]))
+		require.NoError(t, err)
+		require.Equal(t, want, Equal(x, y, EqualOptions{}), src)
+
+		got, err := EqualBytes([]byte(pair[0]), []byte(pair[1]), EqualOptions{})
+		require.NoError(t, err)
+		require.Equal(t, want, got, src)
+	}
+}
+
+func BenchmarkEqualBytes(b *testing.B) {
+	src := newLargeFixture(100000)
+	differing := bytes.Replace(src, []byte(`"id": 10,`), []byte(`"id": 11,`), 1)
+
+	for n, other := range map[string][]byte{"equal": src, "early difference": differing} {
+		b.Run(n+"/bytes", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := EqualBytes(src, other, EqualOptions{}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		b.Run(n+"/tree", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				x, err := ValueOf(src)
+				if err != nil {
+					b.Fatal(err)
+				}
+
+				y, err := ValueOf(other)
+				if err != nil {
+					b.Fatal(err)
+				}
+				Equal(x, y, EqualOptions{})
+			}
+		})
+	}
+}
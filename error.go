@@ -3,17 +3,86 @@ package jsonreflect
 import (
 	"errors"
 	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 )
 
 var (
 	// ErrNotStringable means that value cannot be converted to string representation.
 	ErrNotStringable = errors.New("value not stringable")
+
+	// ErrNilValue means that operation can't be performed on nil value.
+	ErrNilValue = errors.New("nil value")
+
+	// ErrCycleDetected means that value tree contains a reference to its own ancestor.
+	//
+	// Use errors.As with *CycleError to get path of the value.
+	ErrCycleDetected = errors.New("cycle detected")
+
+	// ErrUnexpectedEOF means that document ended inside of unterminated value,
+	// which usually means that document is truncated.
+	//
+	// Returned wrapped by ParseError.
+	ErrUnexpectedEOF = errors.New("unexpected end of document")
+
+	// ErrRecursiveUnmarshal means that Unmarshaler or json.Unmarshaler implementation
+	// unmarshals the same value into the same destination type again.
+	//
+	// Use errors.As with *RecursiveUnmarshalError to get destination type.
+	ErrRecursiveUnmarshal = errors.New("recursive unmarshal")
+
+	// ErrNumberOutOfRange means that number is too large or has too many digits.
+	//
+	// Use errors.As with *NumberRangeError to get number text and position.
+	ErrNumberOutOfRange = errors.New("number out of range")
 )
 
+// Positioned is an error which refers to a range in source document.
+//
+// Use AllPositioned to collect all positioned errors from error tree.
+type Positioned interface {
+	// Pos returns error position in source document.
+	Pos() Position
+}
+
+// AllPositioned unwraps error tree and returns every error which implements Positioned.
+//
+// Both single-error (Unwrap() error) and multi-error (Unwrap() []error) wrappers are supported.
+// Errors are returned in depth-first order.
+func AllPositioned(err error) []Positioned {
+	var out []Positioned
+	collectPositioned(err, &out)
+	return out
+}
+
+func collectPositioned(err error, out *[]Positioned) {
+	if err == nil {
+		return
+	}
+
+	if p, ok := err.(Positioned); ok {
+		*out = append(*out, p)
+	}
+
+	switch t := err.(type) {
+	case interface{ Unwrap() []error }:
+		for _, e := range t.Unwrap() {
+			collectPositioned(e, out)
+		}
+	case interface{ Unwrap() error }:
+		collectPositioned(t.Unwrap(), out)
+	}
+}
+
 type ParseError struct {
 	Position
 
 	Message string
+
+	// Err is error cause, if any. See ErrUnexpectedEOF.
+	Err error
 }
 
 func NewParseError(pos Position, msg string, args ...interface{}) ParseError {
@@ -27,10 +96,27 @@ func NewParseError(pos Position, msg string, args ...interface{}) ParseError {
 	return err
 }
 
+// Pos implements Positioned
+func (p ParseError) Pos() Position {
+	return p.Position
+}
+
 func (p ParseError) Error() string {
 	return fmt.Sprintf("%s (in range %d:%d)", p.Message, p.Start, p.End)
 }
 
+// Unwrap returns error cause
+func (p ParseError) Unwrap() error {
+	return p.Err
+}
+
+// NewUnexpectedEOFError returns parse error caused by end of input inside unterminated value.
+func NewUnexpectedEOFError(pos Position, msg string, args ...interface{}) ParseError {
+	err := NewParseError(pos, msg, args...)
+	err.Err = ErrUnexpectedEOF
+	return err
+}
+
 func NewUnexpectedCharacterError(start, end int, char byte) ParseError {
 	return NewParseError(newPosition(start, end), "unexpected character %q", string(char))
 }
@@ -38,3 +124,363 @@ func NewUnexpectedCharacterError(start, end int, char byte) ParseError {
 func NewInvalidExprError(start, end int, val []byte) ParseError {
 	return NewParseError(newPosition(start, end), "unexpected %q", string(val))
 }
+
+// CycleError is returned when value tree contains a reference to its own ancestor.
+type CycleError struct {
+	// Path is path to the value which closes the cycle.
+	Path string
+}
+
+func (err *CycleError) Error() string {
+	return fmt.Sprintf("cycle detected at %q", err.Path)
+}
+
+// Is reports whether target is ErrCycleDetected.
+func (err *CycleError) Is(target error) bool {
+	return target == ErrCycleDetected
+}
+
+// RecursiveUnmarshalError is returned when Unmarshaler or json.Unmarshaler
+// implementation unmarshals its source into the same destination type again,
+// which would recurse forever.
+//
+// Unmarshaler receives a shallow copy of source value which keeps track of
+// unmarshaler calls, so recursion is detected only if this value is passed
+// to UnmarshalValue or its data is passed to Unmarshal.
+type RecursiveUnmarshalError struct {
+	// Position is source value position
+	Position
+
+	// Type is destination type which implements unmarshaler
+	Type reflect.Type
+}
+
+// Pos implements Positioned
+func (err *RecursiveUnmarshalError) Pos() Position {
+	return err.Position
+}
+
+func (err *RecursiveUnmarshalError) Error() string {
+	return fmt.Sprintf("recursive unmarshal of %s: unmarshaler unmarshals value into the same destination type", err.Type)
+}
+
+// Is reports whether target is ErrRecursiveUnmarshal.
+func (err *RecursiveUnmarshalError) Is(target error) bool {
+	return target == ErrRecursiveUnmarshal
+}
+
+// NumberRangeError is returned when number exceeds float64 range or parser limits,
+// see MaxNumberDigits and MaxNumberExponent.
+type NumberRangeError struct {
+	// Position is number position
+	Position
+
+	// Text is number text
+	Text string
+
+	// Reason describes exceeded bound
+	Reason string
+}
+
+func newNumberRangeError(pos Position, text, reason string, args ...interface{}) *NumberRangeError {
+	return &NumberRangeError{Position: pos, Text: text, Reason: fmt.Sprintf(reason, args...)}
+}
+
+// Pos implements Positioned
+func (err *NumberRangeError) Pos() Position {
+	return err.Position
+}
+
+func (err *NumberRangeError) Error() string {
+	return fmt.Sprintf("number %q is out of range: %s", err.Text, err.Reason)
+}
+
+// Is reports whether target is ErrNumberOutOfRange.
+func (err *NumberRangeError) Is(target error) bool {
+	return target == ErrNumberOutOfRange
+}
+
+// UnknownFieldsError is returned when object contains keys which are not
+// consumed by destination struct and DisallowUnknownFields option is set.
+type UnknownFieldsError struct {
+	// Type is destination struct type
+	Type reflect.Type
+
+	// Fields contains all unknown object members in source order
+	Fields []Member
+
+	// Limit is max count of keys listed in error message.
+	//
+	// All keys are listed if limit is zero.
+	Limit int
+}
+
+// Pos implements Positioned.
+//
+// Returns position of the first unknown key.
+func (err *UnknownFieldsError) Pos() Position {
+	if len(err.Fields) == 0 {
+		return Position{}
+	}
+	return err.Fields[0].KeyPos
+}
+
+func (err *UnknownFieldsError) Error() string {
+	sb := strings.Builder{}
+	sb.WriteString("unknown field")
+	if len(err.Fields) > 1 {
+		sb.WriteString("s")
+	}
+	fmt.Fprintf(&sb, " in %s: ", err.Type)
+
+	fields := err.Fields
+	if err.Limit > 0 && len(fields) > err.Limit {
+		fields = fields[:err.Limit]
+	}
+
+	for i, m := range fields {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+
+		fmt.Fprintf(&sb, "%q", m.Key)
+		if m.KeyPos != (Position{}) {
+			fmt.Fprintf(&sb, " (in range %d:%d)", m.KeyPos.Start, m.KeyPos.End)
+		}
+	}
+
+	if rest := len(err.Fields) - len(fields); rest > 0 {
+		fmt.Fprintf(&sb, " and %d more", rest)
+	}
+	return sb.String()
+}
+
+// EnumError is returned when value is not one of allowed enum values.
+//
+// See UnmarshalEnum.
+type EnumError struct {
+	Position
+
+	// Value is source value
+	Value string
+
+	// Allowed is list of allowed values
+	Allowed []string
+}
+
+// Pos implements Positioned
+func (err *EnumError) Pos() Position {
+	return err.Position
+}
+
+func (err *EnumError) Error() string {
+	sb := strings.Builder{}
+	fmt.Fprintf(&sb, "value %q is not one of allowed values: ", err.Value)
+	for i, val := range err.Allowed {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		fmt.Fprintf(&sb, "%q", val)
+	}
+
+	if err.Position != (Position{}) {
+		fmt.Fprintf(&sb, " (in range %d:%d)", err.Start, err.End)
+	}
+	return sb.String()
+}
+
+// LimitError is returned when unmarshal exceeds limit set by
+// MaxSliceLen or MaxMapEntries option.
+type LimitError struct {
+	// Position is source value position
+	Position
+
+	// Path is path to source value in dotted form. Empty for root value.
+	Path string
+
+	// Name is option name of exceeded limit
+	Name string
+
+	// Limit is limit value
+	Limit int
+
+	// Size is count of elements in source value
+	Size int
+}
+
+// Pos implements Positioned
+func (err *LimitError) Pos() Position {
+	return err.Position
+}
+
+func (err *LimitError) Error() string {
+	if err.Path == "" {
+		return fmt.Sprintf("value has %d elements, exceeds %s limit of %d", err.Size, err.Name, err.Limit)
+	}
+	return fmt.Sprintf("value at %q has %d elements, exceeds %s limit of %d", err.Path, err.Size, err.Name, err.Limit)
+}
+
+// UnmarshalTypeError is returned when source value can't be unmarshaled
+// or converted to destination type.
+type UnmarshalTypeError struct {
+	// Position is source value position
+	Position
+
+	// Source is source value type
+	Source Type
+
+	// Destination is destination value type
+	Destination reflect.Type
+
+	// Err is conversion error, if any
+	Err error
+
+	// Hint describes source container shape if destination expects
+	// container of another shape, like object unmarshaled into a slice.
+	Hint *ShapeHint
+}
+
+// Pos implements Positioned
+func (err *UnmarshalTypeError) Pos() Position {
+	return err.Position
+}
+
+func (err *UnmarshalTypeError) Error() string {
+	if err.Err == nil {
+		msg := fmt.Sprintf("cannot unmarshal %s value to %s", err.Source, err.Destination)
+		if err.Hint != nil {
+			msg += " " + err.Hint.String()
+		}
+		return msg
+	}
+	return fmt.Sprintf("cannot convert %s value to destination value %s: %s", err.Source, err.Destination, err.Err)
+}
+
+// Unwrap returns conversion error
+func (err *UnmarshalTypeError) Unwrap() error {
+	return err.Err
+}
+
+const (
+	// maxHintKeys is max count of keys listed in ShapeHint
+	maxHintKeys = 5
+
+	// maxHintKeyLen is max length of key listed in ShapeHint
+	maxHintKeyLen = 32
+)
+
+// ShapeHint describes shape of source container which doesn't match destination.
+type ShapeHint struct {
+	// Source is source container type
+	Source Type
+
+	// Keys are first keys of source object, up to 5 keys
+	Keys []string
+
+	// MoreKeys is count of source object keys not listed in Keys
+	MoreKeys int
+
+	// Length is length of source array
+	Length int
+
+	// FirstElement is type of the first element of source array
+	FirstElement Type
+
+	// Suggestion is key of source object which holds an array if destination
+	// is a slice or array
+	Suggestion string
+}
+
+// newShapeHint returns shape hint if source container doesn't match destination container shape.
+func newShapeHint(src Value, dstType reflect.Type) *ShapeHint {
+	if dstType.Kind() == reflect.Ptr {
+		dstType = dstType.Elem()
+	}
+
+	switch t := src.(type) {
+	case *Object:
+		if k := dstType.Kind(); t == nil || (k != reflect.Slice && k != reflect.Array) {
+			return nil
+		}
+
+		members := t.Members()
+		hint := &ShapeHint{Source: TypeObject}
+		for i, m := range members {
+			if i < maxHintKeys {
+				hint.Keys = append(hint.Keys, m.Key)
+			}
+
+			if _, ok := m.Value.(*Array); ok && hint.Suggestion == "" {
+				hint.Suggestion = m.Key
+			}
+		}
+
+		if len(members) > maxHintKeys {
+			hint.MoreKeys = len(members) - maxHintKeys
+		}
+		return hint
+	case *Array:
+		if k := dstType.Kind(); t == nil || (k != reflect.Struct && k != reflect.Map) {
+			return nil
+		}
+
+		hint := &ShapeHint{Source: TypeArray, Length: len(t.Items)}
+		if len(t.Items) > 0 {
+			hint.FirstElement = TypeOf(t.Items[0])
+		}
+		return hint
+	default:
+		return nil
+	}
+}
+
+// String returns hint text
+func (h *ShapeHint) String() string {
+	sb := strings.Builder{}
+	if h.Source == TypeArray {
+		fmt.Fprintf(&sb, "(length: %d", h.Length)
+		if h.Length > 0 {
+			fmt.Fprintf(&sb, ", first element: %s", h.FirstElement)
+		}
+		sb.WriteString(")")
+
+		if h.FirstElement == TypeObject {
+			sb.WriteString(", did you mean to decode an element?")
+		}
+		return sb.String()
+	}
+
+	if len(h.Keys) == 0 {
+		return "(empty object)"
+	}
+
+	sb.WriteString("(keys: ")
+	for i, key := range h.Keys {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(strconv.Quote(truncateHintKey(key)))
+	}
+
+	if h.MoreKeys > 0 {
+		fmt.Fprintf(&sb, " and %d more", h.MoreKeys)
+	}
+	sb.WriteString(")")
+
+	if h.Suggestion != "" {
+		fmt.Fprintf(&sb, ", did you mean to decode %q field?", truncateHintKey(h.Suggestion))
+	}
+	return sb.String()
+}
+
+func truncateHintKey(key string) string {
+	if len(key) <= maxHintKeyLen {
+		return key
+	}
+
+	end := maxHintKeyLen
+	for end > 0 && !utf8.RuneStart(key[end]) {
+		end--
+	}
+	return key[:end] + "..."
+}
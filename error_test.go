@@ -0,0 +1,173 @@
+package jsonreflect
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type multiError []error
+
+func (m multiError) Error() string {
+	return fmt.Sprint([]error(m))
+}
+
+func (m multiError) Unwrap() []error {
+	return m
+}
+
+func TestAllPositioned(t *testing.T) {
+	src := []byte(`{"a": [1, "foo"], "b": [1, 2, 3], "c": true}`)
+	unmarshalErr := func(dst interface{}, opts ...UnmarshalOption) error {
+		err := Unmarshal(src, dst, opts...)
+		require.Error(t, err)
+		return err
+	}
+
+	var dst struct {
+		A []int `json:"a"`
+	}
+	typeErr := unmarshalErr(&dst)
+	limitErr := unmarshalErr(&struct {
+		B []int `json:"b"`
+	}{}, MaxSliceLen(2))
+	unknownErr := unmarshalErr(&struct{}{}, DisallowUnknownFields)
+	_, parseErr := NewParser([]byte(`[1, 2`)).Parse()
+
+	cases := map[string]struct {
+		err  error
+		want []Position
+	}{
+		"nil": {},
+		"not positioned": {
+			err: errors.New("foo"),
+		},
+		"parse error": {
+			err:  parseErr,
+			want: []Position{newPosition(0, 5)},
+		},
+		"type error": {
+			err:  typeErr,
+			want: []Position{newPosition(10, 14)},
+		},
+		"limit error": {
+			err:  limitErr,
+			want: []Position{newPosition(23, 31)},
+		},
+		"unknown fields": {
+			err:  unknownErr,
+			want: []Position{newPosition(1, 3)},
+		},
+		"multi error": {
+			err:  multiError{typeErr, errors.New("foo"), fmt.Errorf("wrapped: %w", limitErr)},
+			want: []Position{newPosition(10, 14), newPosition(23, 31)},
+		},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			var got []Position
+			for _, p := range AllPositioned(c.err) {
+				got = append(got, p.Pos())
+			}
+			require.Equal(t, c.want, got)
+		})
+	}
+}
+
+func TestUnmarshalTypeError(t *testing.T) {
+	var dst struct {
+		A bool `json:"a"`
+	}
+	err := Unmarshal([]byte(`{"a": "foo"}`), &dst, NoStrict)
+	require.Error(t, err)
+
+	var typeErr *UnmarshalTypeError
+	require.True(t, errors.As(err, &typeErr))
+	require.Equal(t, TypeString, typeErr.Source)
+	require.Equal(t, newPosition(6, 10), typeErr.Pos())
+	require.Error(t, errors.Unwrap(typeErr))
+}
+
+func TestUnmarshalTypeError_Hint(t *testing.T) {
+	type item struct {
+		ID int `json:"id"`
+	}
+
+	cases := map[string]struct {
+		src  string
+		dst  interface{}
+		err  string
+		hint *ShapeHint
+	}{
+		"object to slice": {
+			src: `{"total": 2, "items": [{"id": 1}, {"id": 2}]}`,
+			dst: &[]item{},
+			err: `cannot unmarshal object value to []jsonreflect.item (keys: "total", "items"), ` +
+				`did you mean to decode "items" field?`,
+			hint: &ShapeHint{Source: TypeObject, Keys: []string{"total", "items"}, Suggestion: "items"},
+		},
+		"object without arrays to array": {
+			src:  `{"a": 1, "b": 2, "c": 3, "d": 4, "e": 5, "f": 6, "g": 7}`,
+			dst:  &[2]int{},
+			err:  `cannot unmarshal object value to [2]int (keys: "a", "b", "c", "d", "e" and 2 more)`,
+			hint: &ShapeHint{Source: TypeObject, Keys: []string{"a", "b", "c", "d", "e"}, MoreKeys: 2},
+		},
+		"long key": {
+			src:  `{"` + strings.Repeat("ж", 20) + `": []}`,
+			dst:  &[]int{},
+			err:  `cannot unmarshal object value to []int (keys: "жжжжжжжжжжжжжжжж..."), did you mean to decode "жжжжжжжжжжжжжжжж..." field?`,
+			hint: &ShapeHint{Source: TypeObject, Keys: []string{strings.Repeat("ж", 20)}, Suggestion: strings.Repeat("ж", 20)},
+		},
+		"empty object": {
+			src:  `{}`,
+			dst:  &[]int{},
+			err:  `cannot unmarshal object value to []int (empty object)`,
+			hint: &ShapeHint{Source: TypeObject},
+		},
+		"array to struct": {
+			src:  `[{"id": 1}, {"id": 2}]`,
+			dst:  &item{},
+			err:  `cannot unmarshal array value to jsonreflect.item (length: 2, first element: object), did you mean to decode an element?`,
+			hint: &ShapeHint{Source: TypeArray, Length: 2, FirstElement: TypeObject},
+		},
+		"array to map": {
+			src:  `[1]`,
+			dst:  &map[string]int{},
+			err:  `cannot unmarshal array value to map[string]int (length: 1, first element: number)`,
+			hint: &ShapeHint{Source: TypeArray, Length: 1, FirstElement: TypeNumber},
+		},
+		"empty array": {
+			src:  `[]`,
+			dst:  &item{},
+			err:  `cannot unmarshal array value to jsonreflect.item (length: 0)`,
+			hint: &ShapeHint{Source: TypeArray},
+		},
+		"scalar": {
+			src: `"foo"`,
+			dst: &[]int{},
+			err: `cannot unmarshal string value to []int`,
+		},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			err := Unmarshal([]byte(c.src), c.dst)
+			require.EqualError(t, err, c.err)
+
+			typeErr := new(UnmarshalTypeError)
+			require.True(t, errors.As(err, &typeErr))
+			require.Equal(t, c.hint, typeErr.Hint)
+		})
+	}
+
+	type page struct {
+		Items []item `json:"items"`
+	}
+	err := Unmarshal([]byte(`{"items": {"items": []}}`), &page{})
+	require.EqualError(t, err, `can't unmarshal field "items" to jsonreflect.page.[]jsonreflect.item: `+
+		`cannot unmarshal object value to []jsonreflect.item (keys: "items"), did you mean to decode "items" field?`)
+}
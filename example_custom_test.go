@@ -0,0 +1,72 @@
+package jsonreflect_test
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/x1unix/jsonreflect"
+)
+
+// Timestamp is custom value which represents UNIX timestamp as date string.
+type Timestamp struct {
+	pos jsonreflect.Position
+	t   time.Time
+}
+
+// Ref implements jsonreflect.CustomValue
+func (ts Timestamp) Ref() jsonreflect.Position {
+	return ts.pos
+}
+
+// Type implements jsonreflect.CustomValue
+func (ts Timestamp) Type() jsonreflect.Type {
+	return jsonreflect.TypeString
+}
+
+// Interface implements jsonreflect.CustomValue
+func (ts Timestamp) Interface() interface{} {
+	str, _ := ts.String()
+	return str
+}
+
+// String implements jsonreflect.CustomValue
+func (ts Timestamp) String() (string, error) {
+	return ts.t.Format(time.RFC3339), nil
+}
+
+// WriteJSON implements jsonreflect.CustomValue
+func (ts Timestamp) WriteJSON(w io.Writer, _ *jsonreflect.MarshalOptions) error {
+	str, _ := ts.String()
+	_, err := io.WriteString(w, strconv.Quote(str))
+	return err
+}
+
+func ExampleAdaptValue() {
+	// Convert all numbers in "time" array to timestamps
+	src := []byte(`{"time": [1609265946, 1609267826]}`)
+	toTimestamp := func(v jsonreflect.Value) (jsonreflect.Value, error) {
+		num, ok := v.(*jsonreflect.Number)
+		if !ok {
+			return v, nil
+		}
+
+		t := time.Unix(num.Int64(), 0).UTC()
+		return jsonreflect.AdaptValue(Timestamp{pos: num.Ref(), t: t}), nil
+	}
+
+	doc, err := jsonreflect.NewParser(src, jsonreflect.WithTransformer(toTimestamp)).Parse()
+	if err != nil {
+		panic(err)
+	}
+
+	out, err := jsonreflect.MarshalValue(doc, nil)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println(string(out))
+	// Output:
+	// {"time":["2020-12-29T18:19:06Z","2020-12-29T18:50:26Z"]}
+}
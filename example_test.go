@@ -49,6 +49,32 @@ func ExampleObject_GroupNumericKeys() {
 	// [3] fan3 310
 }
 
+func ExampleUnmarshalValue_embeddedObject() {
+	// Embedded jsonreflect.Object keeps the whole document,
+	// while typed fields give a view over known keys.
+	type Extended struct {
+		Object
+		Name string `json:"name"`
+	}
+
+	src := []byte(`{"name": "foo", "size": 10, "tags": ["a", "b"]}`)
+	doc, err := NewParser(src).Parse()
+	must(err)
+
+	ext := Extended{}
+	must(UnmarshalValue(doc, &ext))
+
+	fmt.Println(ext.Name)
+	for _, m := range ext.Members() {
+		fmt.Println(m.Key, m.Value.Interface())
+	}
+	// Output:
+	// foo
+	// name foo
+	// size 10
+	// tags [a b]
+}
+
 func must(err error) {
 	if err == nil {
 		return
@@ -0,0 +1,342 @@
+package jsonreflect
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	"github.com/x1unix/jsonreflect/internal/pathutil"
+)
+
+// Extract extracts values at passed paths from JSON document in a single pass.
+//
+// Paths are accepted in dotted form ("foo.bar[0]") or in JSON Pointer form ("/foo/bar/0").
+// Empty path refers to document root.
+//
+// Only matched values are parsed into Value trees, the rest of document is skipped.
+// Skipped values are checked only for balanced brackets and terminated strings.
+//
+// Result is keyed by requested paths, unmatched paths are absent from result.
+// Parser options are applied to matched values.
+func Extract(src []byte, paths []string, opts ...ParserOption) (map[string]Value, error) {
+	root := newExtractNode()
+	for _, path := range paths {
+		if err := root.add(path); err != nil {
+			return nil, err
+		}
+	}
+
+	e := extractor{
+		p:   NewParser(src, opts...),
+		out: make(map[string]Value, len(paths)),
+	}
+	if err := e.p.checkEncoding(); err != nil {
+		return nil, err
+	}
+
+	start, ok := e.p.getPosUntilNextNonDelimiter(e.p.start)
+	if !ok {
+		// empty document
+		return e.out, nil
+	}
+
+	end, err := e.walk(start, root)
+	if err != nil {
+		return nil, err
+	}
+
+	if got, ok := e.p.getPosUntilNextNonDelimiter(end + 1); ok {
+		return nil, NewInvalidExprError(got, e.p.end, e.p.src[got:])
+	}
+	return e.out, nil
+}
+
+// extractNode is a node of requested paths tree.
+type extractNode struct {
+	// paths are requested paths which end at this node
+	paths []string
+
+	keys    map[string]*extractNode
+	indexes map[int]*extractNode
+}
+
+func newExtractNode() *extractNode {
+	return &extractNode{
+		keys:    map[string]*extractNode{},
+		indexes: map[int]*extractNode{},
+	}
+}
+
+// splitPath splits path in dotted or JSON Pointer form into list of segments.
+func splitPath(path string) ([]string, error) {
+	if strings.HasPrefix(path, "/") {
+		return pathutil.SplitPointer(path)
+	}
+	return pathutil.Split(path)
+}
+
+func (n *extractNode) add(path string) error {
+	segments, err := splitPath(path)
+	if err != nil {
+		return err
+	}
+
+	node := n
+	for _, seg := range segments {
+		child, ok := node.keys[seg]
+		if !ok {
+			child = newExtractNode()
+			node.keys[seg] = child
+			if i, err := strconv.Atoi(seg); err == nil && i >= 0 {
+				node.indexes[i] = child
+			}
+		}
+		node = child
+	}
+
+	node.paths = append(node.paths, path)
+	return nil
+}
+
+type extractor struct {
+	p   *Parser
+	out map[string]Value
+}
+
+// walk walks value at start position and returns value end position.
+func (e extractor) walk(start int, node *extractNode) (int, error) {
+	if len(node.paths) > 0 {
+		v, pos, err := e.p.parseValue(start, false)
+		if err != nil {
+			return 0, err
+		}
+
+		e.resolve(v, node)
+		return pos.End, nil
+	}
+
+	switch e.p.src[start] {
+	case tokenObjectStart:
+		return e.walkObject(start, node)
+	case tokenArrayStart:
+		return e.walkArray(start, node)
+	default:
+		return e.p.skipValue(start)
+	}
+}
+
+// resolve stores value and its descendants matched by node.
+func (e extractor) resolve(v Value, node *extractNode) {
+	for _, path := range node.paths {
+		e.out[path] = v
+	}
+
+	for key, child := range node.keys {
+		var (
+			val Value
+			ok  bool
+		)
+		switch t := v.(type) {
+		case *Object:
+			val, ok = t.Get(key)
+		case *Array:
+			if i, err := strconv.Atoi(key); err == nil && i >= 0 && i < len(t.Items) {
+				val, ok = t.Items[i], true
+			}
+		}
+
+		if ok {
+			e.resolve(val, child)
+		}
+	}
+}
+
+func (e extractor) walkObject(start int, node *extractNode) (int, error) {
+	p := e.p
+	curPos := start + 1
+	for first := true; ; first = false {
+		pos, ok := p.getPosUntilNextNonDelimiter(curPos)
+		if !ok {
+			return 0, NewUnexpectedEOFError(newPosition(start, p.end), "unterminated object")
+		}
+
+		if p.src[pos] == tokenObjectClose && first {
+			return pos, nil
+		}
+
+		if !first {
+			switch p.src[pos] {
+			case tokenObjectClose:
+				return pos, nil
+			case tokenDelimiter:
+			default:
+				return 0, NewParseError(newPosition(pos, pos),
+					"expected ',' or '}' after value at offset %d", pos)
+			}
+
+			if pos, ok = p.getPosUntilNextNonDelimiter(pos + 1); !ok {
+				return 0, NewUnexpectedEOFError(newPosition(start, p.end), "unterminated object")
+			}
+		}
+
+		if p.src[pos] != tokenString {
+			return 0, NewUnexpectedCharacterError(start, pos, p.src[pos])
+		}
+
+		keyEnd, err := p.skipString(pos)
+		if err != nil {
+			return 0, err
+		}
+
+		child, err := e.matchKey(node, pos, keyEnd)
+		if err != nil {
+			return 0, err
+		}
+
+		pos, ok = p.getPosUntilNextNonDelimiter(keyEnd + 1)
+		if !ok {
+			return 0, NewUnexpectedEOFError(newPosition(start, p.end), "unterminated object")
+		}
+		if p.src[pos] != tokenKeyDelimiter {
+			return 0, NewParseError(newPosition(pos, pos),
+				"expected ':' after object key at offset %d", pos)
+		}
+
+		if pos, ok = p.getPosUntilNextNonDelimiter(pos + 1); !ok {
+			return 0, NewUnexpectedEOFError(newPosition(start, p.end), "unterminated object")
+		}
+
+		var end int
+		if child != nil {
+			end, err = e.walk(pos, child)
+		} else {
+			end, err = p.skipValue(pos)
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		curPos = end + 1
+	}
+}
+
+// matchKey returns child node for object key in range start:end.
+func (e extractor) matchKey(node *extractNode, start, end int) (*extractNode, error) {
+	raw := e.p.src[start+1 : end]
+	if bytes.IndexByte(raw, '\\') == -1 {
+		// lookup without allocation
+		return node.keys[string(raw)], nil
+	}
+
+	key, err := newString(newPosition(start, end), e.p.src[start:end+1]).String()
+	if err != nil {
+		return nil, NewParseError(newPosition(start, end), err.Error())
+	}
+	return node.keys[key], nil
+}
+
+func (e extractor) walkArray(start int, node *extractNode) (int, error) {
+	p := e.p
+	curPos := start + 1
+	for i := 0; ; i++ {
+		pos, ok := p.getPosUntilNextNonDelimiter(curPos)
+		if !ok {
+			return 0, NewUnexpectedEOFError(newPosition(start, p.end), "unterminated array statement")
+		}
+
+		if p.src[pos] == tokenArrayClose && i == 0 {
+			return pos, nil
+		}
+
+		if i > 0 {
+			switch p.src[pos] {
+			case tokenArrayClose:
+				return pos, nil
+			case tokenDelimiter:
+			default:
+				return 0, NewParseError(newPosition(pos, pos),
+					"expected ',' or ']' after value at offset %d", pos)
+			}
+
+			if pos, ok = p.getPosUntilNextNonDelimiter(pos + 1); !ok {
+				return 0, NewUnexpectedEOFError(newPosition(start, p.end), "unterminated array statement")
+			}
+		}
+
+		var (
+			end int
+			err error
+		)
+		if child := node.indexes[i]; child != nil {
+			end, err = e.walk(pos, child)
+		} else {
+			end, err = p.skipValue(pos)
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		curPos = end + 1
+	}
+}
+
+// skipString returns position of closing quote of string at start position.
+func (p Parser) skipString(start int) (int, error) {
+	for i := start + 1; i < p.end; i++ {
+		switch p.src[i] {
+		case '\\':
+			i++
+		case tokenString:
+			return i, nil
+		}
+	}
+
+	endPos := p.getPosUntilNextDelimiter(start + 1)
+	return 0, NewUnexpectedEOFError(newPosition(start, endPos), "unterminated string '%s'", p.src[start:endPos])
+}
+
+// skipValue returns end position of value at start position without decoding it.
+//
+// Containers are checked only for balanced brackets.
+func (p Parser) skipValue(start int) (int, error) {
+	switch char := p.src[start]; char {
+	case tokenString:
+		return p.skipString(start)
+	case tokenObjectStart, tokenArrayStart:
+	case tokenObjectClose, tokenArrayClose, tokenDelimiter, tokenKeyDelimiter:
+		return 0, NewUnexpectedCharacterError(start, start+1, char)
+	default:
+		return p.getPosUntilNextDelimiter(start) - 1, nil
+	}
+
+	stack := getBuffer(p.pool, 8)
+	defer func() {
+		putBuffer(p.pool, stack)
+	}()
+
+	for i := start; i < p.end; i++ {
+		switch char := p.src[i]; char {
+		case tokenString:
+			end, err := p.skipString(i)
+			if err != nil {
+				return 0, err
+			}
+			i = end
+		case tokenObjectStart:
+			stack = append(stack, tokenObjectClose)
+		case tokenArrayStart:
+			stack = append(stack, tokenArrayClose)
+		case tokenObjectClose, tokenArrayClose:
+			if stack[len(stack)-1] != char {
+				return 0, NewUnexpectedCharacterError(i, i+1, char)
+			}
+
+			stack = stack[:len(stack)-1]
+			if len(stack) == 0 {
+				return i, nil
+			}
+		}
+	}
+
+	return 0, NewUnexpectedEOFError(newPosition(start, p.end), "unterminated value")
+}
@@ -0,0 +1,194 @@
+package jsonreflect
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/x1unix/jsonreflect/internal/pathutil"
+	. "github.com/x1unix/jsonreflect/internal/testutil"
+)
+
+// lookupPath returns value at dotted path in parsed value.
+func lookupPath(t testing.TB, v Value, path string) (Value, bool) {
+	segments, err := pathutil.Split(path)
+	require.NoError(t, err)
+
+	for _, seg := range segments {
+		switch tv := v.(type) {
+		case *Object:
+			var ok bool
+			if v, ok = tv.Get(seg); !ok {
+				return nil, false
+			}
+		case *Array:
+			i, err := strconv.Atoi(seg)
+			if err != nil || i >= len(tv.Items) {
+				return nil, false
+			}
+			v = tv.Items[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+func TestExtract(t *testing.T) {
+	src := `{
+		"id": 1,
+		"skip": {"a": [1, {"b": "}]"}], "c": "\"{"},
+		"user": {"name": "foo", "tags": ["x", "y", {"z": null}], "key.dot": true},
+		"esc\"aped": -2.5,
+		"id": 2,
+		"list": [[1, 2], [3, 4]],
+		"": {"": [1, {"": "deep"}], "a": {"": 3}}
+	}`
+
+	cases := map[string]struct {
+		paths []string
+		want  map[string]string
+		err   string
+	}{
+		"dotted paths": {
+			paths: []string{"id", "user.name", "user.tags[2].z", "list[1][0]", "missing", "user.tags[5]"},
+			want: map[string]string{
+				"id":             "id",
+				"user.name":      "user.name",
+				"user.tags[2].z": "user.tags[2].z",
+				"list[1][0]":     "list[1][0]",
+			},
+		},
+		"pointer paths": {
+			paths: []string{"/user/tags/1", "/user/key.dot", "/esc\"aped", "/skip/x"},
+			want: map[string]string{
+				"/user/tags/1":  "user.tags[1]",
+				"/user/key.dot": `user["key.dot"]`,
+				"/esc\"aped":    `["esc\"aped"]`,
+			},
+		},
+		"nested paths": {
+			paths: []string{"user", "user.tags", "user.tags[0]", `["esc\"aped"]`},
+			want: map[string]string{
+				"user":          "user",
+				"user.tags":     "user.tags",
+				"user.tags[0]":  "user.tags[0]",
+				`["esc\"aped"]`: `["esc\"aped"]`,
+			},
+		},
+		"empty keys": {
+			paths: []string{`[""][""][1][""]`, `[""].a[""]`, "///1/", "/", "/a//"},
+			want: map[string]string{
+				`[""][""][1][""]`: `[""][""][1][""]`,
+				`[""].a[""]`:      `[""].a[""]`,
+				"///1/":           `[""][""][1][""]`,
+				"/":               `[""]`,
+			},
+		},
+		"root": {
+			paths: []string{""},
+			want:  map[string]string{"": ""},
+		},
+		"invalid path": {
+			paths: []string{"user..name"},
+			err:   `invalid path "user..name": unexpected '.' (at 5)`,
+		},
+	}
+
+	full, err := NewParser([]byte(src)).Parse()
+	require.NoError(t, err)
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			got, err := Extract([]byte(src), c.paths)
+			if c.err != "" {
+				require.EqualError(t, err, c.err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Len(t, got, len(c.want))
+			for path, fullPath := range c.want {
+				want, ok := lookupPath(t, full, fullPath)
+				require.True(t, ok, fullPath)
+				require.Equal(t, want, got[path], path)
+			}
+		})
+	}
+}
+
+func TestExtract_Errors(t *testing.T) {
+	cases := map[string]struct {
+		src string
+		err string
+	}{
+		"empty": {
+			src: " ",
+		},
+		"trailing data": {
+			src: `{"a": 1} 2`,
+			err: `unexpected "2" (in range 9:10)`,
+		},
+		"unbalanced skipped value": {
+			src: `{"b": [1}, "a": 1}`,
+			err: `unexpected character "}" (in range 8:9)`,
+		},
+		"unterminated object": {
+			src: `{"b": 1, "a": 1`,
+			err: `unterminated object (in range 0:15)`,
+		},
+		"missing colon": {
+			src: `{"b" 1}`,
+			err: `expected ':' after object key at offset 5 (in range 5:5)`,
+		},
+		"malformed matched value": {
+			src: `{"a": [1,,2]}`,
+			err: `unexpected character "," (in range 8:9)`,
+		},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			got, err := Extract([]byte(c.src), []string{"a"})
+			if c.err != "" {
+				require.EqualError(t, err, c.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Empty(t, got)
+		})
+	}
+}
+
+func newLargeFixture(size int) []byte {
+	return RepeatFixture(`{"items": [`, ",", `], "meta": {"count": `+strconv.Itoa(size)+`, "page": 1}}`, size,
+		`{"id": %[1]d, "name": "item %[1]d", "tags": ["a", "b", "c"], "price": %[1]d.25}`, nil)
+}
+
+func BenchmarkExtract(b *testing.B) {
+	src := newLargeFixture(100000)
+	paths := []string{"meta.count", "meta.page", "items[0].id", "items[500].name", "items[99999].price"}
+	b.Run("extract", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := Extract(src, paths); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("parse and lookup", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			v, err := NewParser(src).Parse()
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			for _, path := range paths {
+				if _, ok := lookupPath(b, v, path); !ok {
+					b.Fatal("path not found:", path)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,153 @@
+package jsonreflect
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Package version.
+const (
+	VersionMajor = 0
+	VersionMinor = 1
+	VersionPatch = 0
+
+	// Version is package version in "vMAJOR.MINOR.PATCH" form.
+	Version = "v0.1.0"
+)
+
+// Feature is a package capability, see Supports.
+//
+// Feature values are stable and are never reused.
+type Feature uint16
+
+const (
+	// FeatureComments is parsing of comments in documents.
+	FeatureComments Feature = 1
+
+	// FeatureJSON5Keys is parsing of unquoted JSON5 object keys.
+	FeatureJSON5Keys Feature = 2
+
+	// FeatureBigNumbers is exact support of numbers out of int64 and float64 precision,
+	// see Number.Decimal.
+	FeatureBigNumbers Feature = 3
+
+	// FeatureExponentNotation is parsing and marshaling of numbers like 1e9, see Number.Notation.
+	FeatureExponentNotation Feature = 4
+
+	// FeatureTransformers is transformation of parsed values, see WithTransformer.
+	FeatureTransformers Feature = 5
+
+	// FeatureCompactObjects is parsing of objects without items map, see CompactObjects.
+	FeatureCompactObjects Feature = 6
+
+	// FeatureCompactNumbers is allocation of numbers in slabs, see CompactNumbers.
+	FeatureCompactNumbers Feature = 7
+
+	// FeatureTruncation is parsing of document previews, see MaxLeaves.
+	FeatureTruncation Feature = 8
+
+	// FeatureNulPadding is parsing of documents padded with NUL bytes, see TrimNulPadding.
+	FeatureNulPadding Feature = 9
+
+	// FeatureScanToFirstValue is skipping of junk before document, see ScanToFirstValue.
+	FeatureScanToFirstValue Feature = 10
+
+	// FeatureParserPool is reuse of parser scratch buffers, see WithParserPool.
+	FeatureParserPool Feature = 11
+
+	// FeatureNumberLimits is configurable number bounds, see MaxNumberDigits and MaxNumberExponent.
+	FeatureNumberLimits Feature = 12
+
+	// FeatureArena is reuse of values memory between parses, see Parser.ParseInto.
+	FeatureArena Feature = 13
+
+	// FeatureSlog is conversion of values to log/slog values, see SlogValue.
+	//
+	// Available only in builds with Go 1.21 or later.
+	FeatureSlog Feature = 14
+)
+
+var featureNames = map[Feature]string{
+	FeatureComments:         "comments",
+	FeatureJSON5Keys:        "JSON5 keys",
+	FeatureBigNumbers:       "big numbers",
+	FeatureExponentNotation: "exponent notation",
+	FeatureTransformers:     "transformers",
+	FeatureCompactObjects:   "compact objects",
+	FeatureCompactNumbers:   "compact numbers",
+	FeatureTruncation:       "truncation",
+	FeatureNulPadding:       "NUL padding",
+	FeatureScanToFirstValue: "scan to first value",
+	FeatureParserPool:       "parser pool",
+	FeatureNumberLimits:     "number limits",
+	FeatureArena:            "arena",
+	FeatureSlog:             "slog",
+}
+
+// String returns feature name
+func (f Feature) String() string {
+	if name, ok := featureNames[f]; ok {
+		return name
+	}
+	return fmt.Sprintf("Feature(%d)", uint16(f))
+}
+
+// parserOptionFeatures maps each ParserOption constructor to feature it provides.
+//
+// New parser options should be added here, test checks that the table
+// matches parser options declared in package.
+var parserOptionFeatures = map[string]Feature{
+	"WithTransformer":   FeatureTransformers,
+	"CompactObjects":    FeatureCompactObjects,
+	"CompactNumbers":    FeatureCompactNumbers,
+	"MaxLeaves":         FeatureTruncation,
+	"TrimNulPadding":    FeatureNulPadding,
+	"ScanToFirstValue":  FeatureScanToFirstValue,
+	"WithParserPool":    FeatureParserPool,
+	"MaxNumberDigits":   FeatureNumberLimits,
+	"MaxNumberExponent": FeatureNumberLimits,
+}
+
+// coreFeatures are supported features which are not bound to parser options.
+var coreFeatures = []Feature{
+	FeatureBigNumbers,
+	FeatureExponentNotation,
+	FeatureArena,
+}
+
+// buildFeatures are features registered by files with build constraints.
+var buildFeatures []Feature
+
+// Features returns features supported by this build, ordered by value.
+func Features() []Feature {
+	set := make(map[Feature]struct{}, len(parserOptionFeatures)+len(coreFeatures)+len(buildFeatures))
+	for _, f := range parserOptionFeatures {
+		set[f] = struct{}{}
+	}
+	for _, f := range coreFeatures {
+		set[f] = struct{}{}
+	}
+	for _, f := range buildFeatures {
+		set[f] = struct{}{}
+	}
+
+	features := make([]Feature, 0, len(set))
+	for f := range set {
+		features = append(features, f)
+	}
+
+	sort.Slice(features, func(i, j int) bool {
+		return features[i] < features[j]
+	})
+	return features
+}
+
+// Supports reports whether feature is supported by this build.
+func Supports(f Feature) bool {
+	for _, supported := range Features() {
+		if supported == f {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package jsonreflect
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	gotoken "go/token"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeatures(t *testing.T) {
+	require.Equal(t, fmt.Sprintf("v%d.%d.%d", VersionMajor, VersionMinor, VersionPatch), Version)
+
+	features := Features()
+	require.True(t, sort.SliceIsSorted(features, func(i, j int) bool {
+		return features[i] < features[j]
+	}))
+
+	for i, f := range features {
+		require.True(t, Supports(f))
+		require.NotContains(t, f.String(), "Feature(", "feature %d has no name", f)
+		if i > 0 {
+			require.NotEqual(t, features[i-1], f)
+		}
+	}
+
+	require.False(t, Supports(FeatureComments))
+	require.False(t, Supports(FeatureJSON5Keys))
+	require.True(t, Supports(FeatureBigNumbers))
+	require.Equal(t, "Feature(1000)", Feature(1000).String())
+}
+
+// TestFeatures_ParserOptions checks that every parser option has a feature entry.
+func TestFeatures_ParserOptions(t *testing.T) {
+	fset := gotoken.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, ".", func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	require.NoError(t, err)
+
+	var options []string
+	for _, fn := range exportedFuncs(pkgs["jsonreflect"]) {
+		results := fn.Type.Results
+		if fn.Recv != nil || results == nil || len(results.List) != 1 {
+			continue
+		}
+
+		if ident, ok := results.List[0].Type.(*ast.Ident); ok && ident.Name == "ParserOption" {
+			options = append(options, fn.Name.Name)
+		}
+	}
+
+	require.NotEmpty(t, options)
+	for _, name := range options {
+		_, ok := parserOptionFeatures[name]
+		require.True(t, ok, "parser option %s has no feature entry", name)
+	}
+	require.Len(t, parserOptionFeatures, len(options), "feature table lists unknown parser options")
+}
+
+func exportedFuncs(pkg *ast.Package) []*ast.FuncDecl {
+	var funcs []*ast.FuncDecl
+	for _, file := range pkg.Files {
+		for _, decl := range file.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Name.IsExported() {
+				funcs = append(funcs, fn)
+			}
+		}
+	}
+	return funcs
+}
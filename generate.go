@@ -0,0 +1,134 @@
+package jsonreflect
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	gotoken "go/token"
+	"reflect"
+	"strconv"
+)
+
+const importPath = "github.com/x1unix/jsonreflect"
+
+type generateParams struct {
+	positions bool
+}
+
+// GenerateOption is Go code generator option
+type GenerateOption func(p *generateParams)
+
+// WithPositions preserves source positions of values in generated code.
+func WithPositions() GenerateOption {
+	return func(p *generateParams) {
+		p.positions = true
+	}
+}
+
+// GenerateGo returns Go source code of a file which declares variable
+// with value tree equal to passed value.
+//
+// Generated code uses exported constructors and is useful to build test fixtures
+// from real documents. Strings are re-encoded, so escape sequences of raw string
+// values might differ from source.
+//
+// Custom values are not supported.
+func GenerateGo(v Value, pkg, varName string, opts ...GenerateOption) (string, error) {
+	if !gotoken.IsIdentifier(pkg) {
+		return "", fmt.Errorf("invalid package name %q", pkg)
+	}
+
+	if !gotoken.IsIdentifier(varName) {
+		return "", fmt.Errorf("invalid variable name %q", varName)
+	}
+
+	p := &generateParams{}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	buff := &bytes.Buffer{}
+	fmt.Fprintf(buff, "// Code generated by jsonreflect.GenerateGo. DO NOT EDIT.\n\n")
+	fmt.Fprintf(buff, "package %s\n\nimport %q\n\n", pkg, importPath)
+	fmt.Fprintf(buff, "var %s jsonreflect.Value = ", varName)
+	if err := p.writeValue(buff, v); err != nil {
+		return "", err
+	}
+	buff.WriteByte('\n')
+
+	src, err := format.Source(buff.Bytes())
+	if err != nil {
+		return "", fmt.Errorf("failed to format generated code: %w", err)
+	}
+	return string(src), nil
+}
+
+func (p *generateParams) writeValue(buff *bytes.Buffer, v Value) error {
+	if v == nil || isNilValue(reflect.ValueOf(v)) {
+		buff.WriteString("nil")
+		return nil
+	}
+
+	if p.positions {
+		buff.WriteString("jsonreflect.SetPosition(")
+		defer func() {
+			buff.WriteString(", ")
+			writePosition(buff, v.Ref())
+			buff.WriteString(")")
+		}()
+	}
+
+	switch t := v.(type) {
+	case *String:
+		str, err := t.String()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(buff, "jsonreflect.NewString(%s)", strconv.Quote(str))
+	case Boolean:
+		fmt.Fprintf(buff, "jsonreflect.NewBoolean(%t)", t.Value)
+	case Null:
+		buff.WriteString("jsonreflect.NewNull()")
+	case *Number:
+		if !t.IsFloat {
+			fmt.Fprintf(buff, "jsonreflect.NewNumberInt(%d)", t.Int64())
+			break
+		}
+
+		// keep original number representation
+		fmt.Fprintf(buff, "jsonreflect.InferScalar(%q).(*jsonreflect.Number)", t.asString())
+	case *Array:
+		buff.WriteString("jsonreflect.NewArray(\n")
+		for _, item := range t.Items {
+			if err := p.writeValue(buff, item); err != nil {
+				return err
+			}
+			buff.WriteString(",\n")
+		}
+		buff.WriteString(")")
+	case *Object:
+		buff.WriteString("jsonreflect.NewObjectFromMembers(\n")
+		for _, m := range t.Members() {
+			fmt.Fprintf(buff, "jsonreflect.Member{Key: %s, ", strconv.Quote(m.Key))
+			if p.positions {
+				buff.WriteString("KeyPos: ")
+				writePosition(buff, m.KeyPos)
+				buff.WriteString(", ")
+			}
+
+			buff.WriteString("Value: ")
+			if err := p.writeValue(buff, m.Value); err != nil {
+				return fmt.Errorf("%q: %w", m.Key, err)
+			}
+			buff.WriteString("},\n")
+		}
+		buff.WriteString(")")
+	default:
+		return fmt.Errorf("unsupported value type %T", v)
+	}
+	return nil
+}
+
+func writePosition(buff *bytes.Buffer, pos Position) {
+	fmt.Fprintf(buff, "jsonreflect.Position{Start: %d, End: %d}", pos.Start, pos.End)
+}
@@ -0,0 +1,314 @@
+package jsonreflect
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	gotoken "go/token"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// GenOpts is GenerateStruct options
+type GenOpts struct {
+	// NamedTypes declares nested objects as separate named types
+	// instead of anonymous structs.
+	//
+	// Nested type name is parent type name followed by field name.
+	NamedTypes bool
+}
+
+// GenerateStruct returns Go source code of struct type declaration
+// which can hold passed object.
+//
+// Array of objects is merged into a single struct type.
+//
+// Field types are inferred from values:
+// integers are int64, floats are float64 and integers mixed with floats are float64.
+// Scalar and object fields which are null in some of merged objects are pointers.
+// Fields with only null or incompatible values are interface{}.
+//
+// Field names are derived from keys, each struct field has json tag with source key.
+// Keys which can't be represented in json tag are listed in comments.
+func GenerateStruct(v Value, typeName string, opts GenOpts) (string, error) {
+	if !gotoken.IsIdentifier(typeName) {
+		return "", fmt.Errorf("invalid type name %q", typeName)
+	}
+
+	t := inferType(v)
+	if t.kind == inferArray && t.elem != nil {
+		t = t.elem
+	}
+
+	if t.kind != inferObject {
+		return "", fmt.Errorf("can't derive struct from %s value", TypeOf(v))
+	}
+
+	g := &structGenerator{
+		opts:  opts,
+		names: map[string]bool{typeName: true},
+	}
+	g.declare(typeName, t)
+
+	src, err := format.Source([]byte(strings.Join(g.decls, "\n")))
+	if err != nil {
+		return "", fmt.Errorf("failed to format generated code: %w", err)
+	}
+	return string(src), nil
+}
+
+type inferKind uint8
+
+const (
+	inferNull inferKind = iota
+	inferBool
+	inferInt
+	inferFloat
+	inferString
+	inferObject
+	inferArray
+	inferAny
+)
+
+// inferredType is Go type inferred from one or more values.
+type inferredType struct {
+	kind     inferKind
+	nullable bool
+
+	// elem is array element type, nil for empty arrays
+	elem *inferredType
+
+	// fields are object fields in order of appearance
+	fields []inferredField
+}
+
+type inferredField struct {
+	key string
+	typ *inferredType
+}
+
+func inferType(v Value) *inferredType {
+	switch t := v.(type) {
+	case Boolean:
+		return &inferredType{kind: inferBool}
+	case *String:
+		return &inferredType{kind: inferString}
+	case *Number:
+		if t.IsFloat {
+			return &inferredType{kind: inferFloat}
+		}
+		return &inferredType{kind: inferInt}
+	case *Array:
+		it := &inferredType{kind: inferArray}
+		for _, item := range t.Items {
+			it.elem = mergeTypes(it.elem, inferType(item))
+		}
+		return it
+	case *Object:
+		it := &inferredType{kind: inferObject}
+		for _, m := range t.Members() {
+			it.fields = append(it.fields, inferredField{key: m.Key, typ: inferType(m.Value)})
+		}
+		return it
+	case Null:
+		return &inferredType{kind: inferNull}
+	default:
+		return &inferredType{kind: inferAny}
+	}
+}
+
+// mergeTypes returns type which can hold values of both types.
+func mergeTypes(a, b *inferredType) *inferredType {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	case a.kind == inferNull:
+		return withNullable(b)
+	case b.kind == inferNull:
+		return withNullable(a)
+	}
+
+	nullable := a.nullable || b.nullable
+	switch {
+	case a.kind == b.kind:
+	case a.kind == inferInt && b.kind == inferFloat, a.kind == inferFloat && b.kind == inferInt:
+		return &inferredType{kind: inferFloat, nullable: nullable}
+	default:
+		return &inferredType{kind: inferAny}
+	}
+
+	merged := &inferredType{kind: a.kind, nullable: nullable}
+	switch a.kind {
+	case inferArray:
+		merged.elem = mergeTypes(a.elem, b.elem)
+	case inferObject:
+		merged.fields = append(merged.fields, a.fields...)
+		for _, f := range b.fields {
+			i := 0
+			for i < len(merged.fields) && merged.fields[i].key != f.key {
+				i++
+			}
+
+			if i == len(merged.fields) {
+				merged.fields = append(merged.fields, f)
+				continue
+			}
+			merged.fields[i].typ = mergeTypes(merged.fields[i].typ, f.typ)
+		}
+	}
+	return merged
+}
+
+func withNullable(t *inferredType) *inferredType {
+	if t.kind == inferNull {
+		return t
+	}
+
+	nt := *t
+	nt.nullable = true
+	return &nt
+}
+
+type structGenerator struct {
+	opts GenOpts
+
+	// decls are type declarations, root type goes first
+	decls []string
+
+	// names are declared type names
+	names map[string]bool
+}
+
+// declare adds named struct type declaration.
+func (g *structGenerator) declare(name string, t *inferredType) {
+	i := len(g.decls)
+	g.decls = append(g.decls, "")
+	g.decls[i] = fmt.Sprintf("type %s %s\n", name, g.structType(name, t))
+}
+
+// uniqueTypeName returns unused type name based on passed name.
+func (g *structGenerator) uniqueTypeName(name string) string {
+	unique := name
+	for i := 2; g.names[unique]; i++ {
+		unique = name + strconv.Itoa(i)
+	}
+
+	g.names[unique] = true
+	return unique
+}
+
+func (g *structGenerator) structType(typeName string, t *inferredType) string {
+	buff := &bytes.Buffer{}
+	buff.WriteString("struct {\n")
+
+	names := make(map[string]bool, len(t.fields))
+	for _, f := range t.fields {
+		if !isTagKeyRepresentable(f.key) {
+			fmt.Fprintf(buff, "// key %s can't be represented in json tag\n", strconv.Quote(f.key))
+			continue
+		}
+
+		name := goFieldName(f.key)
+		for i := 2; names[name]; i++ {
+			name = goFieldName(f.key) + strconv.Itoa(i)
+		}
+		names[name] = true
+
+		fmt.Fprintf(buff, "%s %s %s\n", name, g.goType(f.typ, typeName+name), fieldTag(f.key))
+	}
+
+	buff.WriteString("}")
+	return buff.String()
+}
+
+// goType returns Go type expression. Name hint is used for named nested types.
+func (g *structGenerator) goType(t *inferredType, nameHint string) string {
+	var typ string
+	switch t.kind {
+	case inferBool:
+		typ = "bool"
+	case inferInt:
+		typ = "int64"
+	case inferFloat:
+		typ = "float64"
+	case inferString:
+		typ = "string"
+	case inferArray:
+		if t.elem == nil {
+			return "[]interface{}"
+		}
+		return "[]" + g.goType(t.elem, nameHint)
+	case inferObject:
+		if !g.opts.NamedTypes {
+			typ = g.structType(nameHint, t)
+			break
+		}
+
+		typ = g.uniqueTypeName(nameHint)
+		g.declare(typ, t)
+	default:
+		return "interface{}"
+	}
+
+	if t.nullable {
+		return "*" + typ
+	}
+	return typ
+}
+
+var commonInitialisms = map[string]bool{
+	"API": true, "HTML": true, "HTTP": true, "HTTPS": true, "ID": true, "IP": true,
+	"JSON": true, "SQL": true, "URI": true, "URL": true, "UUID": true, "XML": true,
+}
+
+// goFieldName converts object key to exported Go identifier.
+func goFieldName(key string) string {
+	words := strings.FieldsFunc(key, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	sb := strings.Builder{}
+	for _, w := range words {
+		if up := strings.ToUpper(w); commonInitialisms[up] {
+			sb.WriteString(up)
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(w)
+		sb.WriteRune(unicode.ToUpper(r))
+		sb.WriteString(w[size:])
+	}
+
+	name := sb.String()
+	if r, _ := utf8.DecodeRuneInString(name); !unicode.IsUpper(r) {
+		// name starts with digit or letter without case
+		name = "X" + name
+	}
+	return name
+}
+
+// isTagKeyRepresentable checks if key can be used as json tag name.
+func isTagKeyRepresentable(key string) bool {
+	return key != tagOptionCollectOrphan && key != tagKeyEmpty &&
+		strings.TrimSpace(key) == key && !strings.Contains(key, ",")
+}
+
+func fieldTag(key string) string {
+	switch key {
+	case tagOptionSkip:
+		// "-" without options skips a field
+		key += ","
+	case "":
+		key = tagKeyEmpty
+	}
+
+	tag := tagNameJSON + ":" + strconv.Quote(key)
+	if strings.Contains(tag, "`") {
+		return strconv.Quote(tag)
+	}
+	return "`" + tag + "`"
+}
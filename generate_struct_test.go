@@ -0,0 +1,171 @@
+package jsonreflect
+
+import (
+	"go/ast"
+	"go/parser"
+	gotoken "go/token"
+	"go/types"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	. "github.com/x1unix/jsonreflect/internal/testutil"
+)
+
+// compileStruct type-checks generated code and returns reflect type of declared struct.
+func compileStruct(t *testing.T, src, typeName string) reflect.Type {
+	t.Helper()
+	fset := gotoken.NewFileSet()
+	f, err := parser.ParseFile(fset, "gen.go", "package gen\n\n"+src, 0)
+	require.NoError(t, err)
+
+	pkg, err := (&types.Config{}).Check("gen", fset, []*ast.File{f}, nil)
+	require.NoError(t, err)
+
+	obj := pkg.Scope().Lookup(typeName)
+	require.NotNil(t, obj)
+	return reflectTypeOf(t, obj.Type())
+}
+
+func reflectTypeOf(t *testing.T, typ types.Type) reflect.Type {
+	switch tt := typ.(type) {
+	case *types.Named:
+		return reflectTypeOf(t, tt.Underlying())
+	case *types.Basic:
+		switch tt.Kind() {
+		case types.Bool:
+			return reflect.TypeOf(false)
+		case types.Int64:
+			return reflect.TypeOf(int64(0))
+		case types.Float64:
+			return reflect.TypeOf(float64(0))
+		case types.String:
+			return reflect.TypeOf("")
+		}
+	case *types.Pointer:
+		return reflect.PtrTo(reflectTypeOf(t, tt.Elem()))
+	case *types.Slice:
+		return reflect.SliceOf(reflectTypeOf(t, tt.Elem()))
+	case *types.Interface:
+		return reflect.TypeOf((*interface{})(nil)).Elem()
+	case *types.Struct:
+		fields := make([]reflect.StructField, tt.NumFields())
+		for i := range fields {
+			fields[i] = reflect.StructField{
+				Name: tt.Field(i).Name(),
+				Type: reflectTypeOf(t, tt.Field(i).Type()),
+				Tag:  reflect.StructTag(tt.Tag(i)),
+			}
+		}
+		return reflect.StructOf(fields)
+	}
+
+	t.Fatalf("unsupported type %s", typ)
+	return nil
+}
+
+func TestGenerateStruct(t *testing.T) {
+	cases := map[string]struct {
+		src  string
+		opts GenOpts
+		want string
+		err  string
+	}{
+		"anonymous structs": {
+			src: `[
+				{"id": 1, "user_name": "foo", "score": 1, "meta": {"url": "x"}, "tags": ["a"], "extra": null},
+				{"id": 2, "user_name": null, "score": 2.5, "meta": null, "tags": [], "extra": 1, "new": [{"a": 1}, {"b": true}]}
+			]`,
+			want: "type Doc struct {\n" +
+				"\tID       int64   `json:\"id\"`\n" +
+				"\tUserName *string `json:\"user_name\"`\n" +
+				"\tScore    float64 `json:\"score\"`\n" +
+				"\tMeta     *struct {\n" +
+				"\t\tURL string `json:\"url\"`\n" +
+				"\t} `json:\"meta\"`\n" +
+				"\tTags  []string `json:\"tags\"`\n" +
+				"\tExtra *int64   `json:\"extra\"`\n" +
+				"\tNew   []struct {\n" +
+				"\t\tA int64 `json:\"a\"`\n" +
+				"\t\tB bool  `json:\"b\"`\n" +
+				"\t} `json:\"new\"`\n" +
+				"}\n",
+		},
+		"named types": {
+			src:  `{"user": {"address": {"city": "x"}}, "items": [{"id": 1}], "UserAddress": {}}`,
+			opts: GenOpts{NamedTypes: true},
+			want: "type Doc struct {\n" +
+				"\tUser        DocUser         `json:\"user\"`\n" +
+				"\tItems       []DocItems      `json:\"items\"`\n" +
+				"\tUserAddress DocUserAddress2 `json:\"UserAddress\"`\n" +
+				"}\n\n" +
+				"type DocUser struct {\n" +
+				"\tAddress DocUserAddress `json:\"address\"`\n" +
+				"}\n\n" +
+				"type DocUserAddress struct {\n" +
+				"\tCity string `json:\"city\"`\n" +
+				"}\n\n" +
+				"type DocItems struct {\n" +
+				"\tID int64 `json:\"id\"`\n" +
+				"}\n\n" +
+				"type DocUserAddress2 struct {\n" +
+				"}\n",
+		},
+		"special keys": {
+			src: `{"1st": 1, "a-b": 1, "a b": 2, "AB": 3, "-": 4, "back` + "`" + `tick": 5, "a,b": 6, "": 7, "мир": 8, "世界": 9}`,
+			want: "type Doc struct {\n" +
+				"\tX1st     int64 `json:\"1st\"`\n" +
+				"\tAB       int64 `json:\"a-b\"`\n" +
+				"\tAB2      int64 `json:\"a b\"`\n" +
+				"\tAB3      int64 `json:\"AB\"`\n" +
+				"\tX        int64 `json:\"-,\"`\n" +
+				"\tBackTick int64 \"json:\\\"back`tick\\\"\"\n" +
+				"\t// key \"a,b\" can't be represented in json tag\n" +
+				"\tX2  int64 `json:\"''\"`\n" +
+				"\tМир int64 `json:\"мир\"`\n" +
+				"\tX世界 int64 `json:\"世界\"`\n" +
+				"}\n",
+		},
+		"scalar": {
+			src: `1`,
+			err: "can't derive struct from number value",
+		},
+		"array of scalars": {
+			src: `[{"a": 1}, 2]`,
+			err: "can't derive struct from array value",
+		},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			v, err := ValueOf([]byte(c.src))
+			require.NoError(t, err)
+
+			got, err := GenerateStruct(v, "Doc", c.opts)
+			if c.err != "" {
+				require.EqualError(t, err, c.err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, c.want, got)
+			compileStruct(t, got, "Doc")
+		})
+	}
+}
+
+func TestGenerateStruct_RoundTrip(t *testing.T) {
+	for _, name := range []string{"obj_simple.json", "test_coins.json", "obj_nested.json"} {
+		for _, named := range []bool{false, true} {
+			src := TestdataFixture(name).ProvideFixture(t)
+			v, err := ValueOf(src)
+			require.NoError(t, err)
+
+			code, err := GenerateStruct(v, "Doc", GenOpts{NamedTypes: named})
+			require.NoError(t, err)
+
+			dst := reflect.New(compileStruct(t, code, "Doc"))
+			require.NoError(t, UnmarshalValue(v, dst.Interface(), DisallowUnknownFields), name)
+		}
+	}
+}
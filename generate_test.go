@@ -0,0 +1,69 @@
+package jsonreflect
+
+import (
+	"go/parser"
+	gotoken "go/token"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateGo(t *testing.T) {
+	src := []byte(`{"b": [1, -0.50, true], "a": {"c": null, "d": "x\ny"}}`)
+	v, err := ValueOf(src)
+	require.NoError(t, err)
+
+	got, err := GenerateGo(v, "fixtures", "doc")
+	require.NoError(t, err)
+	require.Equal(t, `// Code generated by jsonreflect.GenerateGo. DO NOT EDIT.
+
+package fixtures
+
+import "github.com/x1unix/jsonreflect"
+
+var doc jsonreflect.Value = jsonreflect.NewObjectFromMembers(
+	jsonreflect.Member{Key: "b", Value: jsonreflect.NewArray(
+		jsonreflect.NewNumberInt(1),
+		jsonreflect.InferScalar("-0.50").(*jsonreflect.Number),
+		jsonreflect.NewBoolean(true),
+	)},
+	jsonreflect.Member{Key: "a", Value: jsonreflect.NewObjectFromMembers(
+		jsonreflect.Member{Key: "c", Value: jsonreflect.NewNull()},
+		jsonreflect.Member{Key: "d", Value: jsonreflect.NewString("x\ny")},
+	)},
+)
+`, got)
+
+	got, err = GenerateGo(v, "fixtures", "doc", WithPositions())
+	require.NoError(t, err)
+	require.Contains(t, got, `jsonreflect.Member{Key: "c", KeyPos: jsonreflect.Position{Start: 30, End: 32}, `+
+		`Value: jsonreflect.SetPosition(jsonreflect.NewNull(), jsonreflect.Position{Start: 35, End: 38})}`)
+
+	_, err = parser.ParseFile(gotoken.NewFileSet(), "doc.go", got, parser.AllErrors)
+	require.NoError(t, err)
+
+	_, err = GenerateGo(v, "fixtures", "1doc")
+	require.EqualError(t, err, `invalid variable name "1doc"`)
+}
+
+func TestGenerateGo_Constructors(t *testing.T) {
+	// value built by generated code should be equal to parsed value
+	src := []byte(`{"a": [1.5, "x"], "a": {"b": false}, "c": null}`)
+	want, err := ValueOf(src)
+	require.NoError(t, err)
+
+	got := NewObjectFromMembers(
+		Member{Key: "a", KeyPos: Position{Start: 1, End: 3}, Value: SetPosition(NewArray(
+			SetPosition(InferScalar("1.5").(*Number), Position{Start: 7, End: 9}),
+			SetPosition(NewString("x"), Position{Start: 12, End: 14}),
+		), Position{Start: 6, End: 15})},
+		Member{Key: "a", KeyPos: Position{Start: 18, End: 20}, Value: SetPosition(NewObjectFromMembers(
+			Member{Key: "b", KeyPos: Position{Start: 24, End: 26}, Value: SetPosition(NewBoolean(false), Position{Start: 29, End: 33})},
+		), Position{Start: 23, End: 34})},
+		Member{Key: "c", KeyPos: Position{Start: 37, End: 39}, Value: SetPosition(NewNull(), Position{Start: 42, End: 45})},
+	)
+	SetPosition(got, want.Ref())
+
+	require.Equal(t, withoutRaw(want).(*Object).Members(), got.Members())
+	require.Equal(t, want.Ref(), got.Ref())
+}
@@ -0,0 +1,210 @@
+package jsonreflect
+
+import (
+	"encoding/binary"
+	"hash"
+	"hash/fnv"
+	"math"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Type tags of canonical value representation used for hashing
+const (
+	hashTagNull   = 'n'
+	hashTagTrue   = 't'
+	hashTagFalse  = 'f'
+	hashTagNumber = 'd'
+	hashTagString = 's'
+	hashTagArray  = 'a'
+	hashTagObject = 'o'
+	hashTagRaw    = 'r'
+)
+
+// maxExactFloatInt is max integer which float64 represents exactly.
+const maxExactFloatInt = 1 << 53
+
+// HashValueInto writes canonical representation of value into hash.
+//
+// Representation doesn't depend on formatting, key order, string escapes
+// or number notation, so values which are equal as JSON produce the same hash.
+// Integral floating point numbers like "1.0" are equal to integers.
+// Nil value is hashed as null. Custom value types are hashed by serialized form.
+//
+// Representation is stable across runs and platforms, but it's not a cryptographic guarantee.
+//
+// Returns error if string has invalid escape sequence or custom value can't be marshaled,
+// and *CycleError if value tree contains a reference to own ancestor.
+// Hash contains partially written value in this case.
+func HashValueInto(h hash.Hash64, v Value) error {
+	hw := hashWriter{h: h}
+	hw.guard.ancestors = hw.ancestors[:0]
+	return hw.writeValue(v)
+}
+
+// FingerprintValue returns 64-bit FNV-1a hash of value canonical representation.
+//
+// See HashValueInto.
+func FingerprintValue(v Value) (uint64, error) {
+	h := fnv.New64a()
+	if err := HashValueInto(h, v); err != nil {
+		return 0, err
+	}
+	return h.Sum64(), nil
+}
+
+type hashWriter struct {
+	h hash.Hash64
+
+	// guard detects cycles in value tree
+	guard cycleGuard
+
+	// ancestors is initial stack of guard, so shallow trees don't allocate it
+	ancestors [8]Value
+
+	// buf is scratch buffer for tags, lengths and decoded runes
+	buf [binary.MaxVarintLen64]byte
+
+	// num is scratch buffer for canonical numbers
+	num [32]byte
+}
+
+func (hw *hashWriter) writeTag(tag byte) {
+	hw.buf[0] = tag
+	_, _ = hw.h.Write(hw.buf[:1])
+}
+
+func (hw *hashWriter) writeLen(n int) {
+	size := binary.PutUvarint(hw.buf[:], uint64(n))
+	_, _ = hw.h.Write(hw.buf[:size])
+}
+
+// writeKey writes length-prefixed string, so adjacent strings are not ambiguous.
+func (hw *hashWriter) writeKey(key string) {
+	hw.writeLen(len(key))
+	_, _ = hw.h.Write(stringBytes(key))
+}
+
+// writeString writes length-prefixed unquoted string like writeKey.
+//
+// String is decoded in place, unescaped parts are written from raw value as-is.
+func (hw *hashWriter) writeString(s *String) error {
+	size, err := s.DecodedLen()
+	if err != nil {
+		return err
+	}
+
+	hw.writeTag(hashTagString)
+	hw.writeLen(size)
+
+	raw := s.rawValue[1 : len(s.rawValue)-1]
+	start := 0
+	for i := 0; i < len(raw); {
+		if raw[i] != '\\' {
+			i++
+			continue
+		}
+
+		_, _ = hw.h.Write(raw[start:i])
+
+		// escape sequences are validated by DecodedLen
+		r, n, _ := decodeEscape(raw[i:])
+		_, _ = hw.h.Write(hw.buf[:utf8.EncodeRune(hw.buf[:], r)])
+		i += n
+		start = i
+	}
+
+	_, _ = hw.h.Write(raw[start:])
+	return nil
+}
+
+func (hw *hashWriter) writeNumber(n *Number) {
+	var num []byte
+	switch f := n.Float64(); {
+	case !n.IsFloat && !n.hasFloat:
+		num = strconv.AppendInt(hw.num[:0], n.Int64(), 10)
+	case f == math.Trunc(f) && math.Abs(f) < maxExactFloatInt:
+		num = strconv.AppendInt(hw.num[:0], int64(f), 10)
+	default:
+		num = strconv.AppendFloat(hw.num[:0], f, 'g', -1, 64)
+	}
+
+	hw.writeTag(hashTagNumber)
+	hw.writeLen(len(num))
+	_, _ = hw.h.Write(num)
+}
+
+func (hw *hashWriter) writeValue(v Value) error {
+	switch t := v.(type) {
+	case Boolean:
+		if t.Value {
+			hw.writeTag(hashTagTrue)
+		} else {
+			hw.writeTag(hashTagFalse)
+		}
+	case *Number:
+		if t == nil {
+			hw.writeTag(hashTagNull)
+			return nil
+		}
+		hw.writeNumber(t)
+	case *String:
+		if t == nil {
+			hw.writeTag(hashTagNull)
+			return nil
+		}
+		return hw.writeString(t)
+	case *Array:
+		if t == nil {
+			hw.writeTag(hashTagNull)
+			return nil
+		}
+
+		if !hw.guard.enter(t) {
+			return hw.guard.newError(t)
+		}
+		defer hw.guard.leave()
+
+		hw.writeTag(hashTagArray)
+		hw.writeLen(len(t.Items))
+		for _, item := range t.Items {
+			if err := hw.writeValue(item); err != nil {
+				return err
+			}
+		}
+	case *Object:
+		if t == nil {
+			hw.writeTag(hashTagNull)
+			return nil
+		}
+
+		if !hw.guard.enter(t) {
+			return hw.guard.newError(t)
+		}
+		defer hw.guard.leave()
+
+		keys := t.Keys()
+		hw.writeTag(hashTagObject)
+		hw.writeLen(len(keys))
+		for _, k := range keys {
+			v, _ := t.Get(k)
+			hw.writeKey(k)
+			if err := hw.writeValue(v); err != nil {
+				return err
+			}
+		}
+	case nil, Null:
+		hw.writeTag(hashTagNull)
+	default:
+		// custom value types are hashed by serialized form
+		data, err := MarshalValue(v, nil)
+		if err != nil {
+			return err
+		}
+
+		hw.writeTag(hashTagRaw)
+		hw.writeLen(len(data))
+		_, _ = hw.h.Write(data)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package jsonreflect
+
+import (
+	"errors"
+	"hash/fnv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFingerprintValue(t *testing.T) {
+	cases := map[string]struct {
+		a, b  string
+		equal bool
+	}{
+		"formatting": {
+			a:     `{"a": [1, 2], "b": null}`,
+			b:     "{\n\t\"a\":[1,2],\n\t\"b\":null\n}",
+			equal: true,
+		},
+		"key order": {
+			a:     `{"a": 1, "b": {"c": true, "d": false}}`,
+			b:     `{"b": {"d": false, "c": true}, "a": 1}`,
+			equal: true,
+		},
+		"number notation": {
+			a:     `[1.50, 2.0, -0.25]`,
+			b:     `[1.5, 2, -0.250]`,
+			equal: true,
+		},
+		"string escapes": {
+			a:     `{"a": "café"}`,
+			b:     `{"a": "caf\u00e9"}`,
+			equal: true,
+		},
+		"surrogate pair": {
+			a:     `["😀\n"]`,
+			b:     `["\ud83d\ude00\u000a"]`,
+			equal: true,
+		},
+		"one character": {
+			a: `{"name": "foo"}`,
+			b: `{"name": "fop"}`,
+		},
+		"one digit": {
+			a: `[1.25]`,
+			b: `[1.26]`,
+		},
+		"array order": {
+			a: `[1, 2]`,
+			b: `[2, 1]`,
+		},
+		"string and number": {
+			a: `["1"]`,
+			b: `[1]`,
+		},
+		"null and string": {
+			a: `[null]`,
+			b: `["null"]`,
+		},
+		"empty containers": {
+			a: `[]`,
+			b: `{}`,
+		},
+		"adjacent strings": {
+			a: `["ab", "c"]`,
+			b: `["a", "bc"]`,
+		},
+		"nesting": {
+			a: `[[1], 2]`,
+			b: `[[1, 2]]`,
+		},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			a, err := ValueOf([]byte(c.a))
+			require.NoError(t, err)
+			b, err := ValueOf([]byte(c.b))
+			require.NoError(t, err)
+
+			sumA, err := FingerprintValue(a)
+			require.NoError(t, err)
+			sumB, err := FingerprintValue(b)
+			require.NoError(t, err)
+
+			if c.equal {
+				require.Equal(t, sumA, sumB)
+				return
+			}
+			require.NotEqual(t, sumA, sumB)
+		})
+	}
+}
+
+func TestHashValueInto(t *testing.T) {
+	v, err := ValueOf([]byte(`{"id": 1, "tags": ["a", "b"], "rate": 0.5, "ok": true, "ref": null}`))
+	require.NoError(t, err)
+
+	h := fnv.New64a()
+	require.NoError(t, HashValueInto(h, v))
+	sum, err := FingerprintValue(v)
+	require.NoError(t, err)
+	require.Equal(t, h.Sum64(), sum)
+
+	// hash is stable across runs
+	require.Equal(t, uint64(0x8c13b71a94216417), sum)
+
+	num, err := NewNumberFloat(0.5)
+	require.NoError(t, err)
+	same := NewObjectFromMembers(
+		Member{Key: "ref", Value: NewNull()},
+		Member{Key: "ok", Value: NewBoolean(true)},
+		Member{Key: "rate", Value: num},
+		Member{Key: "tags", Value: NewArray(NewString("a"), NewString("b"))},
+		Member{Key: "id", Value: NewNumberInt(1)},
+	)
+	got, err := FingerprintValue(same)
+	require.NoError(t, err)
+	require.Equal(t, sum, got)
+
+	null, err := FingerprintValue(NewNull())
+	require.NoError(t, err)
+	got, err = FingerprintValue(nil)
+	require.NoError(t, err)
+	require.Equal(t, null, got)
+
+	// invalid strings are reported
+	_, err = FingerprintValue(NewArray(&String{rawValue: []byte(`"\x"`)}))
+	require.EqualError(t, err, `jsonreflect.String: invalid escape sequence '\x' in '"\x"'`)
+
+	// only writer state and sorted object keys are allocated
+	allocs := testing.AllocsPerRun(100, func() {
+		h.Reset()
+		_ = HashValueInto(h, v)
+	})
+	require.LessOrEqual(t, allocs, 2.0)
+}
+
+func TestHashValueInto_Cycle(t *testing.T) {
+	arr := NewArray(NewNumberInt(1))
+	arr.Items[0] = arr
+
+	_, err := FingerprintValue(arr)
+	require.True(t, errors.Is(err, ErrCycleDetected))
+
+	cycleErr := new(CycleError)
+	require.True(t, errors.As(err, &cycleErr))
+	require.Equal(t, `[0]`, cycleErr.Path)
+
+	obj := NewObject(map[string]Value{"a": NewArray(NewNull())})
+	obj.Items["a"].(*Array).Items[0] = obj
+	err = HashValueInto(fnv.New64a(), obj)
+	require.True(t, errors.As(err, &cycleErr))
+	require.Equal(t, `a[0]`, cycleErr.Path)
+
+	// shared values are not cycles
+	shared := NewArray(NewString("x"))
+	_, err = FingerprintValue(NewArray(shared, shared))
+	require.NoError(t, err)
+}
@@ -0,0 +1,256 @@
+// Package pathutil implements object key path syntax shared by path-producing and path-consuming features.
+//
+// Package supports two path forms.
+//
+// Dotted form is a human-friendly form used in error messages and lookups:
+//
+//	path    = [ segment ] { "." key | "[" index "]" | "[" quoted "]" }
+//	segment = key | "[" index "]" | "[" quoted "]"
+//	key     = 1*( ALPHA | DIGIT | "_" | "-" | "$" )
+//	index   = 1*DIGIT
+//	quoted  = Go-style double-quoted string
+//
+// Keys which can't be written as bare key are quoted, for example:
+//
+//	foo.bar[0]["key.with.dots"]
+//
+// Pointer form is JSON Pointer (RFC 6901) compatible:
+//
+//	/foo/bar/0/key.with.dots
+//
+// Empty key is always quoted in dotted form and is an empty segment in pointer form:
+//
+//	foo[""].bar
+//	/foo//bar
+//
+// Array indexes are represented as decimal string segments in both forms.
+package pathutil
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	charDot          = '.'
+	charBracketOpen  = '['
+	charBracketClose = ']'
+	charQuote        = '"'
+	charEscape       = '\\'
+	charPointerSep   = '/'
+)
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+var pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+// IsBareKey checks if key can be written in path without quotes.
+func IsBareKey(key string) bool {
+	if key == "" {
+		return false
+	}
+
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+			continue
+		case c == '_', c == '-', c == '$':
+			continue
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Quote returns key as a single path segment.
+//
+// Bare keys are returned as-is, other keys are quoted and wrapped in brackets.
+func Quote(key string) string {
+	if IsBareKey(key) {
+		return key
+	}
+
+	return string(charBracketOpen) + strconv.Quote(key) + string(charBracketClose)
+}
+
+// Append appends object key to a dotted path.
+func Append(path, key string) string {
+	if !IsBareKey(key) {
+		return path + Quote(key)
+	}
+
+	if path == "" {
+		return key
+	}
+
+	return path + string(charDot) + key
+}
+
+// AppendIndex appends array index to a dotted path.
+func AppendIndex(path string, index int) string {
+	return path + string(charBracketOpen) + strconv.Itoa(index) + string(charBracketClose)
+}
+
+// Join builds a dotted path from list of keys.
+func Join(keys ...string) string {
+	path := ""
+	for _, k := range keys {
+		path = Append(path, k)
+	}
+	return path
+}
+
+// Split splits dotted path into list of segments.
+func Split(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	var segments []string
+	expectKey := true
+	for i := 0; i < len(path); {
+		switch c := path[i]; c {
+		case charDot:
+			if expectKey {
+				return nil, newSyntaxError(path, i, "unexpected %q", c)
+			}
+			expectKey = true
+			i++
+		case charBracketOpen:
+			seg, n, err := readBracketSegment(path, i)
+			if err != nil {
+				return nil, err
+			}
+
+			segments = append(segments, seg)
+			expectKey = false
+			i += n
+		default:
+			if !expectKey {
+				return nil, newSyntaxError(path, i, "unexpected %q", c)
+			}
+
+			end := i
+			for end < len(path) && path[end] != charDot && path[end] != charBracketOpen {
+				end++
+			}
+
+			key := path[i:end]
+			if !IsBareKey(key) {
+				return nil, newSyntaxError(path, i, "invalid key %q", key)
+			}
+
+			segments = append(segments, key)
+			expectKey = false
+			i = end
+		}
+	}
+
+	if expectKey {
+		return nil, newSyntaxError(path, len(path), "unexpected end of path")
+	}
+
+	return segments, nil
+}
+
+// readBracketSegment reads "[index]" or "[quoted]" segment at start position.
+//
+// Returns segment value and count of consumed bytes.
+func readBracketSegment(path string, start int) (string, int, error) {
+	i := start + 1
+	if i < len(path) && path[i] == charQuote {
+		end := i + 1
+		for ; end < len(path); end++ {
+			if path[end] == charEscape {
+				end++
+				continue
+			}
+
+			if path[end] == charQuote {
+				break
+			}
+		}
+
+		if end >= len(path) {
+			return "", 0, newSyntaxError(path, start, "unterminated quoted key")
+		}
+
+		key, err := strconv.Unquote(path[i : end+1])
+		if err != nil {
+			return "", 0, newSyntaxError(path, i, "invalid quoted key: %s", err)
+		}
+
+		end++
+		if end >= len(path) || path[end] != charBracketClose {
+			return "", 0, newSyntaxError(path, end, "expected %q", charBracketClose)
+		}
+
+		return key, end + 1 - start, nil
+	}
+
+	end := strings.IndexByte(path[i:], charBracketClose)
+	if end == -1 {
+		return "", 0, newSyntaxError(path, start, "unterminated index")
+	}
+
+	index := path[i : i+end]
+	if _, err := strconv.ParseUint(index, 10, 0); err != nil {
+		return "", 0, newSyntaxError(path, i, "invalid index %q", index)
+	}
+
+	return index, end + 2, nil
+}
+
+// Pointer builds JSON Pointer from list of segments.
+func Pointer(segments ...string) string {
+	sb := strings.Builder{}
+	for _, seg := range segments {
+		sb.WriteByte(charPointerSep)
+		sb.WriteString(pointerEscaper.Replace(seg))
+	}
+	return sb.String()
+}
+
+// SplitPointer splits JSON Pointer into list of segments.
+func SplitPointer(ptr string) ([]string, error) {
+	if ptr == "" {
+		return nil, nil
+	}
+
+	if ptr[0] != charPointerSep {
+		return nil, newSyntaxError(ptr, 0, "pointer should start with %q", charPointerSep)
+	}
+
+	segments := strings.Split(ptr[1:], string(charPointerSep))
+	for i, seg := range segments {
+		segments[i] = pointerUnescaper.Replace(seg)
+	}
+	return segments, nil
+}
+
+// SyntaxError is path syntax error
+type SyntaxError struct {
+	// Path is source path
+	Path string
+
+	// Offset is error position in path
+	Offset int
+
+	// Message is error message
+	Message string
+}
+
+func newSyntaxError(path string, offset int, msg string, args ...interface{}) *SyntaxError {
+	return &SyntaxError{
+		Path:    path,
+		Offset:  offset,
+		Message: fmt.Sprintf(msg, args...),
+	}
+}
+
+// Error implements error interface
+func (err *SyntaxError) Error() string {
+	return fmt.Sprintf("invalid path %q: %s (at %d)", err.Path, err.Message, err.Offset)
+}
@@ -0,0 +1,107 @@
+package pathutil
+
+import (
+	"testing"
+	"testing/quick"
+
+	"github.com/stretchr/testify/require"
+	. "github.com/x1unix/jsonreflect/internal/testutil"
+)
+
+func TestAppend(t *testing.T) {
+	cases := map[string]struct {
+		path string
+		key  string
+		want string
+	}{
+		"empty path":       {key: "foo", want: "foo"},
+		"bare key":         {path: "foo", key: "bar_1", want: "foo.bar_1"},
+		"key with dot":     {path: "foo", key: "a.b", want: `foo["a.b"]`},
+		"key with bracket": {path: "foo", key: "a[0]", want: `foo["a[0]"]`},
+		"key with quote":   {path: "foo", key: `a"b`, want: `foo["a\"b"]`},
+		"empty key":        {path: "foo", key: "", want: `foo[""]`},
+		"unicode key":      {key: "ключ", want: `["ключ"]`},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			require.Equal(t, c.want, Append(c.path, c.key))
+		})
+	}
+}
+
+func TestSplit(t *testing.T) {
+	cases := map[string]struct {
+		path string
+		want []string
+		err  ExpectedError
+	}{
+		"empty":               {},
+		"single key":          {path: "foo", want: []string{"foo"}},
+		"dotted":              {path: "foo.bar.baz", want: []string{"foo", "bar", "baz"}},
+		"indexes":             {path: "foo[0][12].bar", want: []string{"foo", "0", "12", "bar"}},
+		"root index":          {path: "[1].foo", want: []string{"1", "foo"}},
+		"quoted":              {path: `foo["a.b"]["c\"d"]`, want: []string{"foo", "a.b", `c"d`}},
+		"empty key":           {path: `[""]`, want: []string{""}},
+		"nested empty key":    {path: `foo[""][""].bar`, want: []string{"foo", "", "", "bar"}},
+		"trailing dot":        {path: "foo.", err: "unexpected end of path"},
+		"double dot":          {path: "foo..bar", err: `unexpected '.'`},
+		"invalid index":       {path: "foo[bar]", err: `invalid index "bar"`},
+		"unterminated index":  {path: "foo[1", err: "unterminated index"},
+		"unterminated quote":  {path: `foo["bar]`, err: "unterminated quoted key"},
+		"unclosed quoted key": {path: `foo["bar"`, err: `expected ']'`},
+		"invalid bare key":    {path: "foo.b ar", err: `invalid key "b ar"`},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			got, err := Split(c.path)
+			if !c.err.AssertError(t, err) {
+				return
+			}
+			require.Equal(t, c.want, got)
+		})
+	}
+}
+
+func TestAppendSplit_RoundTrip(t *testing.T) {
+	roundTrip := func(keys []string) bool {
+		if len(keys) == 0 {
+			return true
+		}
+
+		got, err := Split(Join(keys...))
+		if err != nil {
+			t.Log(err)
+			return false
+		}
+		require.Equal(t, keys, got)
+		return true
+	}
+
+	require.NoError(t, quick.Check(roundTrip, nil))
+}
+
+func TestPointer_RoundTrip(t *testing.T) {
+	require.Equal(t, "/foo/a~1b/c~0d/0", Pointer("foo", "a/b", "c~d", "0"))
+
+	roundTrip := func(keys []string) bool {
+		if len(keys) == 0 {
+			return true
+		}
+
+		got, err := SplitPointer(Pointer(keys...))
+		require.NoError(t, err)
+		require.Equal(t, keys, got)
+		return true
+	}
+
+	require.NoError(t, quick.Check(roundTrip, nil))
+
+	got, err := SplitPointer("/foo//bar/")
+	require.NoError(t, err)
+	require.Equal(t, []string{"foo", "", "bar", ""}, got)
+
+	_, err = SplitPointer("foo")
+	require.Error(t, err)
+}
@@ -0,0 +1,17 @@
+// Package privtype is a test fixture package with unexported types.
+package privtype
+
+type secret struct {
+	Value string
+}
+
+// Secret is alias to unexported type
+type Secret = secret
+
+// Secrets is a slice of unexported type
+type Secrets = []*secret
+
+// Public is exported type
+type Public struct {
+	Value string
+}
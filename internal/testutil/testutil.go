@@ -2,6 +2,7 @@
 package testutil
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -80,3 +81,27 @@ func (j TestdataFixture) ProvideFixture(t *testing.T) []byte {
 type FixtureProvider interface {
 	ProvideFixture(t *testing.T) []byte
 }
+
+// RepeatFixture returns JSON source of count elements joined by separator
+// and wrapped in prefix and suffix.
+//
+// Element is formatted using passed format with element index as argument.
+// Use explicit argument index like "%[1]d" to repeat the index.
+// If args function is passed, element is formatted with returned arguments instead.
+func RepeatFixture(prefix, sep, suffix string, count int, format string, args func(i int) []interface{}) []byte {
+	sb := strings.Builder{}
+	sb.WriteString(prefix)
+	for i := 0; i < count; i++ {
+		if i > 0 {
+			sb.WriteString(sep)
+		}
+
+		if args == nil {
+			fmt.Fprintf(&sb, format, i)
+			continue
+		}
+		fmt.Fprintf(&sb, format, args(i)...)
+	}
+	sb.WriteString(suffix)
+	return []byte(sb.String())
+}
@@ -0,0 +1,88 @@
+package jsonreflect
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// IndexJSONLines returns positions of records in JSON Lines document.
+//
+// Each non-blank line should contain a single complete JSON value.
+// Blank lines, surrounding whitespace and CRLF line endings are tolerated.
+// Records are checked for balanced brackets, terminated strings and trailing data,
+// but nested values are not decoded. Use ParseLine to parse a record.
+//
+// Error of malformed record contains its 1-based line number.
+func IndexJSONLines(src []byte) ([]Position, error) {
+	p := NewParser(src)
+	if err := p.checkEncoding(); err != nil {
+		return nil, err
+	}
+
+	var records []Position
+	line := 0
+	for start := p.start; start < len(src); {
+		line++
+		end := bytes.IndexByte(src[start:], charLineBreak)
+		if end == -1 {
+			end = len(src)
+		} else {
+			end += start
+		}
+
+		pos, ok, err := lineRecord(src[:end], start)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		if ok {
+			records = append(records, pos)
+		}
+		start = end + 1
+	}
+	return records, nil
+}
+
+// lineRecord returns position of record in line at start position.
+//
+// Source ends at the end of line. Returns false if line is blank.
+func lineRecord(src []byte, start int) (Position, bool, error) {
+	p := Parser{src: src, end: len(src)}
+	pos, ok := p.getPosUntilNextNonDelimiter(start)
+	if !ok {
+		return Position{}, false, nil
+	}
+
+	var end int
+	switch p.src[pos] {
+	case tokenObjectStart, tokenArrayStart, tokenString:
+		var err error
+		if end, err = p.skipValue(pos); err != nil {
+			return Position{}, false, err
+		}
+	default:
+		v, err := p.decodeScalarValue(pos, true)
+		if err != nil {
+			return Position{}, false, err
+		}
+		end = v.Ref().End
+	}
+
+	if got, ok := p.getPosUntilNextNonDelimiter(end + 1); ok {
+		return Position{}, false, NewInvalidExprError(got, p.end, p.src[got:])
+	}
+	return newPosition(pos, end), true, nil
+}
+
+// ParseLine parses JSON Lines record at passed position, see IndexJSONLines.
+//
+// Positions of parsed values are offsets in source.
+func ParseLine(src []byte, idx Position, opts ...ParserOption) (Value, error) {
+	if idx.Start < 0 || idx.Start > idx.End || idx.End >= len(src) {
+		return nil, fmt.Errorf("record position %d:%d is out of source range", idx.Start, idx.End)
+	}
+
+	p := NewParser(src[:idx.End+1], opts...)
+	p.start = idx.Start
+	return p.Parse()
+}
@@ -0,0 +1,120 @@
+package jsonreflect
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	. "github.com/x1unix/jsonreflect/internal/testutil"
+)
+
+func TestIndexJSONLines(t *testing.T) {
+	cases := map[string]struct {
+		src  string
+		want []string
+		err  ExpectedError
+	}{
+		"empty": {},
+		"blank lines": {
+			src: "\n  \n\t\r\n",
+		},
+		"lf": {
+			src:  "{\"a\": 1}\n[1, 2]\n\"foo\"\n",
+			want: []string{`{"a": 1}`, `[1, 2]`, `"foo"`},
+		},
+		"crlf": {
+			src:  "{\"a\": 1}\r\n[1, 2]\r\n",
+			want: []string{`{"a": 1}`, `[1, 2]`},
+		},
+		"final line without newline": {
+			src:  "1\ntrue\nnull",
+			want: []string{`1`, `true`, `null`},
+		},
+		"blank lines and whitespace": {
+			src:  "\n  {\"a\": \"b c\"}  \r\n\n\t-1.5\t\n\n",
+			want: []string{`{"a": "b c"}`, `-1.5`},
+		},
+		"bom": {
+			src:  "\xEF\xBB\xBF{}\n[]",
+			want: []string{`{}`, `[]`},
+		},
+		"newline in string": {
+			src: "{\"a\": \"b\nc\"}\n",
+			err: `line 1: unterminated string`,
+		},
+		"record spans lines": {
+			src: "{\"a\": 1}\n{\"a\":\n1}\n",
+			err: `line 2: unterminated value`,
+		},
+		"two records in line": {
+			src: "{}\n\r\n{} {}\n",
+			err: `line 3: unexpected "{}"`,
+		},
+		"malformed scalar": {
+			src: "1\r\n2\r\nfals\r\n",
+			err: `line 3: unexpected "fals"`,
+		},
+		"unbalanced brackets": {
+			src: "[1, 2}\n",
+			err: `line 1: unexpected character "}" (in range 5:6)`,
+		},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			src := []byte(c.src)
+			got, err := IndexJSONLines(src)
+			if !c.err.AssertError(t, err) {
+				return
+			}
+
+			records := make([]string, 0, len(got))
+			for _, pos := range got {
+				records = append(records, string(src[pos.Start:pos.End+1]))
+			}
+			require.Equal(t, len(c.want), len(records))
+			if len(c.want) > 0 {
+				require.Equal(t, c.want, records)
+			}
+		})
+	}
+}
+
+func TestIndexJSONLines_Positioned(t *testing.T) {
+	src := []byte("1\n[1, 2\n")
+	_, err := IndexJSONLines(src)
+	require.Error(t, err)
+
+	positioned := AllPositioned(err)
+	require.NotEmpty(t, positioned)
+	require.Equal(t, 2, positioned[0].Pos().Start)
+}
+
+func TestParseLine(t *testing.T) {
+	// every 10th record is followed by an empty line
+	src := RepeatFixture("", "", "", 1000, "{\"id\": %d, \"tags\": [\"a\"]}\r\n%s", func(i int) []interface{} {
+		if i%10 == 0 {
+			return []interface{}{i, "\n"}
+		}
+		return []interface{}{i, ""}
+	})
+	records, err := IndexJSONLines(src)
+	require.NoError(t, err)
+	require.Len(t, records, 1000)
+
+	v, err := ParseLine(src, records[999])
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"id": 999, "tags": []interface{}{"a"}}, v.Interface())
+	require.Equal(t, records[999], v.Ref())
+
+	got, err := ParseLine(src, records[500])
+	require.NoError(t, err)
+	id, _ := got.(*Object).Get("id")
+	require.Equal(t, int64(500), id.(*Number).Int64())
+
+	_, err = ParseLine(src, Position{Start: 0, End: len(src)})
+	require.EqualError(t, err, fmt.Sprintf("record position 0:%d is out of source range", len(src)))
+
+	_, err = ParseLine(src, Position{Start: records[1].Start, End: records[1].End - 1})
+	require.Error(t, err)
+}
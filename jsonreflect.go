@@ -1,4 +1,18 @@
-package jsonreflect
+// Package jsonreflect provides reflection features for JSON values.
+//
+// Canonical import path of the package is "github.com/x1unix/jsonreflect".
+//
+// Values can be read from several goroutines at once, including Marshal and Unmarshal calls.
+// Modification of a value, like a write to Object.Items, Deduplicate or MaterializeItems call,
+// must not be concurrent with any other access to the value tree.
+// See SnapshotSourceLocked option for unmarshal of concurrently modified trees.
+package jsonreflect // import "github.com/x1unix/jsonreflect"
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+)
 
 // Unmarshal parses the JSON-encoded data and stores the result in the value pointed to by v.
 // Accepts additional options to customise unmarshal process.
@@ -16,14 +30,58 @@ func Unmarshal(src []byte, dst interface{}, opts ...UnmarshalOption) error {
 		return nil
 	}
 
+	if f := jsonUnmarshalerFrame(src); f.isActive() {
+		// data is passed to json.Unmarshaler, continue its stack
+		value = withUnmarshalerFrame(value, f)
+	}
+
 	return UnmarshalValue(value, dst, opts...)
 }
 
+// UnmarshalNext parses next value from a stream of concatenated JSON values
+// and stores the result in the value pointed to by v.
+//
+// Returns io.EOF if there are no more values.
+// Returned error contains index of malformed value.
+// Null value is stored as zero value, even if destination is reused.
+//
+// See Parser.ParseNext and UnmarshalValue documentation for more information.
+func UnmarshalNext(p *Parser, dst interface{}, opts ...UnmarshalOption) error {
+	index := p.count
+	value, err := p.ParseNext()
+	if err == io.EOF {
+		return err
+	}
+
+	if err != nil {
+		return fmt.Errorf("cannot parse value #%d: %w", index, err)
+	}
+
+	if dstVal := reflect.ValueOf(dst); TypeOf(value) == TypeNull && dstVal.Kind() == reflect.Ptr && !dstVal.IsNil() {
+		dstVal.Elem().Set(reflect.Zero(dstVal.Elem().Type()))
+		return nil
+	}
+
+	if err = UnmarshalValue(value, dst, opts...); err != nil {
+		return fmt.Errorf("cannot unmarshal value #%d: %w", index, err)
+	}
+	return nil
+}
+
 // ValueOf parses the JSON-encoded data and returns a document structure.
 //
 // Alias to NewParser().Parse()
-func ValueOf(src []byte) (Value, error) {
-	return NewParser(src).Parse()
+func ValueOf(src []byte, opts ...ParserOption) (Value, error) {
+	return NewParser(src, opts...).Parse()
+}
+
+// ParseString parses JSON string without copying it to a byte slice.
+//
+// Parsed values refer to memory of passed string,
+// so byte slices returned by Raw must not be modified.
+// Use Detach to release the source string.
+func ParseString(s string, opts ...ParserOption) (Value, error) {
+	return NewParser(stringBytes(s), opts...).Parse()
 }
 
 // TypeOf returns value type.
@@ -35,3 +93,11 @@ func TypeOf(v Value) Type {
 	}
 	return v.Type()
 }
+
+// positionOf returns value position or zero position for nil value.
+func positionOf(v Value) Position {
+	if v == nil {
+		return Position{}
+	}
+	return v.Ref()
+}
@@ -0,0 +1,117 @@
+// Package jsonreflecttest provides test helpers for code built on top of jsonreflect.
+package jsonreflecttest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/x1unix/jsonreflect"
+)
+
+// diffContext is count of equal lines printed around difference
+const diffContext = 3
+
+// AssertEqualValues checks that values are equal using jsonreflect.Equal.
+//
+// Object keys order is ignored. Failure message contains a line diff
+// of both values. Returns true if values are equal.
+func AssertEqualValues(t testing.TB, want, got jsonreflect.Value) bool {
+	t.Helper()
+	if want == nil && got == nil {
+		return true
+	}
+
+	if want != nil && got != nil && jsonreflect.Equal(want, got, jsonreflect.EqualOptions{}) {
+		return true
+	}
+
+	t.Errorf("values are not equal:\n%s", diffText(want, got))
+	return false
+}
+
+// AssertParses parses source and fails test immediately if source is malformed.
+func AssertParses(t testing.TB, src string, opts ...jsonreflect.ParserOption) jsonreflect.Value {
+	t.Helper()
+	v, err := jsonreflect.ParseString(src, opts...)
+	if err != nil {
+		t.Fatalf("failed to parse %q: %s", src, err)
+	}
+	return v
+}
+
+// AssertUnmarshalError checks that unmarshal of source into destination
+// returns an error which contains passed string.
+//
+// Returns true if error matches.
+func AssertUnmarshalError(t testing.TB, src string, dst interface{}, contains string, opts ...jsonreflect.UnmarshalOption) bool {
+	t.Helper()
+	err := jsonreflect.Unmarshal([]byte(src), dst, opts...)
+	if err == nil {
+		t.Errorf("expected unmarshal error which contains %q but got no errors", contains)
+		return false
+	}
+
+	if !strings.Contains(err.Error(), contains) {
+		t.Errorf("expected unmarshal error which contains %q but got %q", contains, err)
+		return false
+	}
+	return true
+}
+
+// diffText returns line diff of indented values with sorted keys.
+func diffText(want, got jsonreflect.Value) string {
+	a, b := formatLines(want), formatLines(got)
+
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	sb := &strings.Builder{}
+	sb.WriteString("--- want\n+++ got\n")
+	writeLines(sb, "  ", a[max(prefix-diffContext, 0):prefix])
+	writeLines(sb, "- ", a[prefix:len(a)-suffix])
+	writeLines(sb, "+ ", b[prefix:len(b)-suffix])
+	writeLines(sb, "  ", a[len(a)-suffix:min(len(a)-suffix+diffContext, len(a))])
+	return sb.String()
+}
+
+func formatLines(v jsonreflect.Value) []string {
+	if v == nil {
+		return []string{"<nil>"}
+	}
+
+	data, err := jsonreflect.MarshalValueOpts(v, jsonreflect.WithIndent("  "),
+		jsonreflect.WithSortedKeys(), jsonreflect.WithTruncatedValues())
+	if err != nil {
+		return []string{"<" + err.Error() + ">"}
+	}
+	return strings.Split(string(data), "\n")
+}
+
+func writeLines(sb *strings.Builder, prefix string, lines []string) {
+	for _, line := range lines {
+		sb.WriteString(prefix)
+		sb.WriteString(line)
+		sb.WriteByte('\n')
+	}
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
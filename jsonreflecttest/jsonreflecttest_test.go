@@ -0,0 +1,106 @@
+package jsonreflecttest
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/x1unix/jsonreflect"
+)
+
+// recorder records test failures instead of failing a test.
+type recorder struct {
+	testing.TB
+	errors []string
+	fatal  bool
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.Errorf(format, args...)
+	r.fatal = true
+	runtime.Goexit()
+}
+
+// record runs fn with recorder in a separate goroutine, so Fatalf can stop it.
+func record(t *testing.T, fn func(t testing.TB)) *recorder {
+	r := &recorder{TB: t}
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		fn(r)
+	}()
+	wg.Wait()
+	return r
+}
+
+func TestAssertEqualValues(t *testing.T) {
+	want := AssertParses(t, `{"a": 1, "b": [1, 2, 3], "c": "foo"}`)
+	got := AssertParses(t, `{"c": "foo", "b": [1, 2, 3], "a": 1.0}`)
+	require.True(t, AssertEqualValues(t, want, got))
+	require.True(t, AssertEqualValues(t, nil, nil))
+
+	got = AssertParses(t, `{"a": 1, "b": [1, 5, 3], "c": "foo"}`)
+	r := record(t, func(t testing.TB) {
+		require.False(t, AssertEqualValues(t, want, got))
+	})
+	require.Equal(t, []string{
+		"values are not equal:\n" +
+			"--- want\n" +
+			"+++ got\n" +
+			"    \"a\": 1,\n" +
+			"    \"b\": [\n" +
+			"      1,\n" +
+			"-     2,\n" +
+			"+     5,\n" +
+			"      3\n" +
+			"    ],\n" +
+			"    \"c\": \"foo\"\n",
+	}, r.errors)
+
+	r = record(t, func(t testing.TB) {
+		AssertEqualValues(t, want, nil)
+	})
+	require.Len(t, r.errors, 1)
+	require.Contains(t, r.errors[0], "+ <nil>\n")
+}
+
+func TestAssertParses(t *testing.T) {
+	v := AssertParses(t, `[true]`)
+	require.Equal(t, []interface{}{true}, v.Interface())
+
+	v = AssertParses(t, `{"a": 1}`, jsonreflect.CompactObjects())
+	require.Equal(t, map[string]interface{}{"a": 1}, v.Interface())
+
+	r := record(t, func(t testing.TB) {
+		AssertParses(t, `[1,`)
+		t.Errorf("unreachable")
+	})
+	require.True(t, r.fatal)
+	require.Len(t, r.errors, 1)
+	require.Contains(t, r.errors[0], `failed to parse "[1,": unterminated array statement`)
+}
+
+func TestAssertUnmarshalError(t *testing.T) {
+	type dst struct {
+		Count int `json:"count"`
+	}
+
+	require.True(t, AssertUnmarshalError(t, `{"count": "1"}`, new(dst), "cannot unmarshal string"))
+
+	r := record(t, func(t testing.TB) {
+		AssertUnmarshalError(t, `{"count": 1}`, new(dst), "cannot unmarshal")
+		AssertUnmarshalError(t, `{"count": "1"}`, new(dst), "foo")
+	})
+	require.Len(t, r.errors, 2)
+	require.Equal(t, `expected unmarshal error which contains "cannot unmarshal" but got no errors`, r.errors[0])
+	require.Contains(t, r.errors[1], `expected unmarshal error which contains "foo" but got "`)
+}
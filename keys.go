@@ -7,6 +7,9 @@ import (
 	"strconv"
 )
 
+// maxMaterializeLen is max count of values in matrix built by Materialize.
+const maxMaterializeLen = 1 << 20
+
 type GroupedNumericKey struct {
 	Order []int
 	Key   string
@@ -44,10 +47,14 @@ func (gks GroupedNumbericKeys) Less(i, j int) bool {
 // Using this call:
 //	re := regexp.MustCompile(`^fan([\d]+)[_]?([\d]+)?$`)
 //	result, err := obj.GroupNumericKeys(re, 2)
-func (o Object) GroupNumericKeys(regex *regexp.Regexp, matchCount int) (GroupedNumbericKeys, error) {
+func (o *Object) GroupNumericKeys(regex *regexp.Regexp, matchCount int) (GroupedNumbericKeys, error) {
+	if o == nil {
+		return nil, nil
+	}
+
 	groupCount := matchCount + 1
 	var out GroupedNumbericKeys
-	for k := range o.Items {
+	for _, k := range o.Keys() {
 		match := regex.FindStringSubmatch(k)
 		if len(match) < groupCount {
 			continue
@@ -75,3 +82,112 @@ func (o Object) GroupNumericKeys(regex *regexp.Regexp, matchCount int) (GroupedN
 	sort.Sort(out)
 	return out, nil
 }
+
+// Materialize builds dense matrix of object values indexed by key order segments.
+//
+// Dims is count of order segments in each key and should be 1 or 2.
+// Segments are used as indexes as-is, for 1 dimension result contains a single row.
+// Gaps are filled with null values.
+//
+// Returns error if segment is negative or if matrix has more than 1048576 values,
+// like for keys with large indexes such as "temp1000000_1000000".
+//
+// Example:
+//	// keys: "temp1", "temp2_1", "temp2_2"
+//	keys, err := obj.GroupNumericKeys(regexp.MustCompile(`^temp([\d]+)[_]?([\d]+)?$`), 2)
+//	matrix, err := keys.Materialize(obj, 2)
+//	// matrix[1][0] is "temp1", matrix[2][1] is "temp2_1"
+func (gks GroupedNumbericKeys) Materialize(o *Object, dims int) ([][]Value, error) {
+	if dims != 1 && dims != 2 {
+		return nil, fmt.Errorf("unsupported dimensions count %d, should be 1 or 2", dims)
+	}
+
+	if o == nil {
+		return nil, ErrNilValue
+	}
+
+	rows, cols := 0, 0
+	for _, k := range gks {
+		if len(k.Order) != dims {
+			return nil, fmt.Errorf("key %q has %d order segments, expected %d", k.Key, len(k.Order), dims)
+		}
+
+		row, col := k.index()
+		if row < 0 || col < 0 {
+			return nil, fmt.Errorf("key %q has negative order segment", k.Key)
+		}
+
+		if row >= maxMaterializeLen || col >= maxMaterializeLen {
+			return nil, fmt.Errorf("key %q has order segment out of range, max index is %d", k.Key, maxMaterializeLen-1)
+		}
+
+		if row >= rows {
+			rows = row + 1
+		}
+		if col >= cols {
+			cols = col + 1
+		}
+	}
+
+	if int64(rows)*int64(cols) > maxMaterializeLen {
+		return nil, fmt.Errorf("matrix of %dx%d values exceeds limit of %d values", rows, cols, maxMaterializeLen)
+	}
+
+	out := make([][]Value, rows)
+	for i := range out {
+		out[i] = make([]Value, cols)
+	}
+
+	for _, k := range gks {
+		v, ok := o.Get(k.Key)
+		if !ok {
+			return nil, fmt.Errorf("key %q not found in object", k.Key)
+		}
+
+		row, col := k.index()
+		out[row][col] = v
+	}
+
+	for _, row := range out {
+		for i, v := range row {
+			if v == nil {
+				row[i] = NewNull()
+			}
+		}
+	}
+	return out, nil
+}
+
+// MaterializeFloat64 builds dense matrix of numbers, see Materialize.
+//
+// Gaps and null values are filled with zeros.
+func (gks GroupedNumbericKeys) MaterializeFloat64(o *Object, dims int) ([][]float64, error) {
+	values, err := gks.Materialize(o, dims)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([][]float64, len(values))
+	for i, row := range values {
+		out[i] = make([]float64, len(row))
+		for j, v := range row {
+			switch t := v.(type) {
+			case Null:
+				continue
+			case *Number:
+				out[i][j] = t.Float64()
+			default:
+				return nil, fmt.Errorf("value at [%d][%d] is %s, expected number", i, j, TypeOf(v))
+			}
+		}
+	}
+	return out, nil
+}
+
+// index returns row and column of key in materialized matrix.
+func (g GroupedNumericKey) index() (int, int) {
+	if len(g.Order) == 1 {
+		return 0, g.Order[0]
+	}
+	return g.Order[0], g.Order[1]
+}
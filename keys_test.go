@@ -86,3 +86,92 @@ func TestObject_GroupNumericKeys(t *testing.T) {
 		})
 	}
 }
+
+func TestGroupedNumericKeys_Materialize(t *testing.T) {
+	src := []byte(`{"temp1": 10, "temp2_1": 21, "temp2_2": null, "temp3_2": 32, "fan1": "x"}`)
+	cases := map[string]struct {
+		re   *regexp.Regexp
+		dims int
+		want [][]float64
+		err  ExpectedError
+	}{
+		"two dimensions": {
+			re:   regexp.MustCompile(`^temp([\d]+)[_]?([\d]+)?$`),
+			dims: 2,
+			want: [][]float64{
+				{0, 0, 0},
+				{10, 0, 0},
+				{0, 21, 0},
+				{0, 0, 32},
+			},
+		},
+		"one dimension": {
+			re:   regexp.MustCompile(`^temp([\d]+)$`),
+			dims: 1,
+			want: [][]float64{{0, 10}},
+		},
+		"dimension mismatch": {
+			re:   regexp.MustCompile(`^temp([\d]+)[_]?([\d]+)?$`),
+			dims: 1,
+			err:  `key "temp1" has 2 order segments, expected 1`,
+		},
+		"unsupported dimensions": {
+			re:   regexp.MustCompile(`^temp([\d]+)$`),
+			dims: 3,
+			err:  `unsupported dimensions count 3, should be 1 or 2`,
+		},
+		"not a number": {
+			re:   regexp.MustCompile(`^fan([\d]+)$`),
+			dims: 1,
+			err:  `value at [0][1] is string, expected number`,
+		},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			v, err := NewParser(src).Parse()
+			require.NoError(t, err)
+			obj := v.(*Object)
+
+			keys, err := obj.GroupNumericKeys(c.re, len(c.re.SubexpNames())-1)
+			require.NoError(t, err)
+
+			got, err := keys.MaterializeFloat64(obj, c.dims)
+			if !c.err.AssertError(t, err) {
+				return
+			}
+			require.Equal(t, c.want, got)
+		})
+	}
+
+	v, err := NewParser(src).Parse()
+	require.NoError(t, err)
+	obj := v.(*Object)
+	keys, err := obj.GroupNumericKeys(regexp.MustCompile(`^temp([\d]+)[_]?([\d]+)?$`), 2)
+	require.NoError(t, err)
+
+	got, err := keys.Materialize(obj, 2)
+	require.NoError(t, err)
+	require.Len(t, got, 4)
+	require.Same(t, obj.Items["temp2_1"], got[2][1])
+	require.Equal(t, TypeNull, got[0][0].Type())
+
+	// large indexes don't allocate huge matrix
+	obj = NewObject(map[string]Value{"temp2000_1000": NewNumberInt(1)})
+	keys, err = obj.GroupNumericKeys(regexp.MustCompile(`^temp([\d]+)[_]?([\d]+)?$`), 2)
+	require.NoError(t, err)
+	_, err = keys.Materialize(obj, 2)
+	require.EqualError(t, err, `matrix of 2001x1001 values exceeds limit of 1048576 values`)
+
+	obj = NewObject(map[string]Value{"temp9223372036854775807": NewNumberInt(1)})
+	keys, err = obj.GroupNumericKeys(regexp.MustCompile(`^temp([\d]+)$`), 1)
+	require.NoError(t, err)
+	_, err = keys.Materialize(obj, 1)
+	require.EqualError(t, err, `key "temp9223372036854775807" has order segment out of range, max index is 1048575`)
+
+	obj = NewObject(map[string]Value{"temp-1": NewNumberInt(1)})
+	keys, err = obj.GroupNumericKeys(regexp.MustCompile(`^temp(-?[\d]+)$`), 1)
+	require.NoError(t, err)
+	_, err = keys.Materialize(obj, 1)
+	require.EqualError(t, err, `key "temp-1" has negative order segment`)
+}
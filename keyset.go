@@ -0,0 +1,135 @@
+package jsonreflect
+
+import "sort"
+
+// linearKeySetSize is a max number of members which are looked up
+// without sorted index.
+const linearKeySetSize = 16
+
+// keySet is a compact set of object keys.
+//
+// Set refers to existing members list and doesn't copy keys.
+// Lookup uses index of members sorted by key, which is built on first lookup
+// if there are more than linearKeySetSize members.
+//
+// Set takes 4 bytes of index and 1 bit of marks per member regardless of key length,
+// so tracking 1M keys takes about 4 MB, while map[string]struct{} takes over 100 MB.
+type keySet struct {
+	members []Member
+
+	// index is a list of member indexes sorted by key
+	index []int32
+
+	// marks is a bitset of marked member indexes
+	marks []uint64
+}
+
+func newKeySet(members []Member) *keySet {
+	return &keySet{members: members}
+}
+
+// fork returns empty set of the same members which shares lookup index.
+func (s *keySet) fork() *keySet {
+	if s.index == nil && len(s.members) > linearKeySetSize {
+		s.buildIndex()
+	}
+	return &keySet{members: s.members, index: s.index}
+}
+
+// indexOf returns index of member with passed key or -1.
+func (s *keySet) indexOf(key string) int {
+	if len(s.members) <= linearKeySetSize {
+		for i, m := range s.members {
+			if m.Key == key {
+				return i
+			}
+		}
+		return -1
+	}
+
+	if s.index == nil {
+		s.buildIndex()
+	}
+
+	j := sort.Search(len(s.index), func(j int) bool {
+		return s.members[s.index[j]].Key >= key
+	})
+	if j < len(s.index) && s.members[s.index[j]].Key == key {
+		return int(s.index[j])
+	}
+	return -1
+}
+
+func (s *keySet) buildIndex() {
+	s.index = make([]int32, len(s.members))
+	for i := range s.index {
+		s.index[i] = int32(i)
+	}
+
+	sort.Slice(s.index, func(a, b int) bool {
+		return s.members[s.index[a]].Key < s.members[s.index[b]].Key
+	})
+}
+
+// contains reports whether key is one of members.
+func (s *keySet) contains(key string) bool {
+	return s.indexOf(key) != -1
+}
+
+// add adds key to the set.
+//
+// Keys which are not members are ignored.
+func (s *keySet) add(key string) {
+	if i := s.indexOf(key); i != -1 {
+		s.mark(i)
+	}
+}
+
+// mark adds key of member at passed index to the set.
+func (s *keySet) mark(i int) {
+	if s.marks == nil {
+		s.marks = make([]uint64, (len(s.members)+63)/64)
+	}
+	s.marks[i/64] |= 1 << uint(i%64)
+}
+
+// addAll adds all members to the set.
+func (s *keySet) addAll() {
+	for i := range s.members {
+		s.mark(i)
+	}
+}
+
+// union adds keys of passed set of the same members.
+func (s *keySet) union(other *keySet) {
+	for i, w := range other.marks {
+		if w == 0 {
+			continue
+		}
+		if s.marks == nil {
+			s.marks = make([]uint64, len(other.marks))
+		}
+		s.marks[i] |= w
+	}
+}
+
+// empty reports whether set has no keys.
+func (s *keySet) empty() bool {
+	for _, w := range s.marks {
+		if w != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// marked reports whether key of member at passed index is in the set.
+func (s *keySet) marked(i int) bool {
+	return s.marks != nil && s.marks[i/64]&(1<<uint(i%64)) != 0
+}
+
+// has reports whether key is in the set.
+func (s *keySet) has(key string) bool {
+	i := s.indexOf(key)
+	return i != -1 && s.marked(i)
+}
@@ -0,0 +1,82 @@
+package jsonreflect
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeySet(t *testing.T) {
+	cases := map[string]int{
+		"linear": linearKeySetSize,
+		"index":  linearKeySetSize*4 + 3,
+	}
+
+	for n, size := range cases {
+		t.Run(n, func(t *testing.T) {
+			members := newBenchmarkObject(size).Members()
+			s := newKeySet(members)
+			require.True(t, s.empty())
+			require.False(t, s.contains("missing"))
+
+			for i := 0; i < size; i += 3 {
+				s.add("key_" + strconv.Itoa(i))
+			}
+			s.add("missing")
+			require.False(t, s.empty())
+
+			for i := 0; i < size; i++ {
+				key := "key_" + strconv.Itoa(i)
+				require.True(t, s.contains(key), key)
+				require.Equal(t, i%3 == 0, s.has(key), key)
+				require.Equal(t, i%3 == 0, s.marked(i), key)
+			}
+			require.False(t, s.has("missing"))
+
+			other := s.fork()
+			require.True(t, other.empty())
+			other.add("key_1")
+			s.union(other)
+			require.True(t, s.has("key_1"))
+			require.False(t, s.has("key_2"))
+
+			other.addAll()
+			require.True(t, other.has("key_2"))
+		})
+	}
+}
+
+func BenchmarkKeySet(b *testing.B) {
+	members := newBenchmarkObject(1000000).Members()
+
+	b.Run("keySet", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			s := newKeySet(members)
+			for _, m := range members {
+				s.add(m.Key)
+			}
+			for _, m := range members {
+				if !s.has(m.Key) {
+					b.Fatal("missing key", m.Key)
+				}
+			}
+		}
+	})
+
+	b.Run("map", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			s := make(map[string]struct{})
+			for _, m := range members {
+				s[m.Key] = struct{}{}
+			}
+			for _, m := range members {
+				if _, ok := s[m.Key]; !ok {
+					b.Fatal("missing key", m.Key)
+				}
+			}
+		}
+	})
+}
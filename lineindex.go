@@ -0,0 +1,121 @@
+package jsonreflect
+
+import (
+	"fmt"
+	"sort"
+	"unicode/utf8"
+)
+
+// LineIndex maps source offsets to line and column numbers.
+//
+// Index is built once in a single pass over source, lookups take O(log n) of line count.
+// Lines are separated by "\n", so CRLF line endings are supported as well.
+type LineIndex struct {
+	src []byte
+
+	// starts are offsets of line starts
+	starts []int
+}
+
+// NewLineIndex builds line index of passed source.
+func NewLineIndex(src []byte) *LineIndex {
+	starts := make([]int, 1, 64)
+	for i, c := range src {
+		if c == charLineBreak && i+1 < len(src) {
+			starts = append(starts, i+1)
+		}
+	}
+	return &LineIndex{src: src, starts: starts}
+}
+
+// Lines returns count of lines in source.
+//
+// Line break at the end of source doesn't start a new line.
+func (idx *LineIndex) Lines() int {
+	if len(idx.src) == 0 {
+		return 0
+	}
+	return len(idx.starts)
+}
+
+// line returns zero-based line number and line start offset of passed offset.
+func (idx *LineIndex) line(offset int) (int, int) {
+	if offset > len(idx.src) {
+		offset = len(idx.src)
+	}
+
+	line := sort.Search(len(idx.starts), func(i int) bool {
+		return idx.starts[i] > offset
+	}) - 1
+	if line < 0 {
+		line = 0
+	}
+	return line, idx.starts[line]
+}
+
+// LineCol returns 1-based line and column of source offset.
+//
+// Column is counted in runes, see LineByteCol for column in bytes.
+// Offsets past end of source point after the last character.
+func (idx *LineIndex) LineCol(offset int) (line, col int) {
+	line, start := idx.line(offset)
+	if offset < start {
+		return line + 1, 1
+	}
+
+	if offset > len(idx.src) {
+		offset = len(idx.src)
+	}
+	return line + 1, utf8.RuneCount(idx.src[start:offset]) + 1
+}
+
+// LineByteCol returns 1-based line and column of source offset.
+//
+// Column is counted in bytes.
+func (idx *LineIndex) LineByteCol(offset int) (line, col int) {
+	line, start := idx.line(offset)
+	if offset < start {
+		return line + 1, 1
+	}
+
+	if offset > len(idx.src) {
+		offset = len(idx.src)
+	}
+	return line + 1, offset - start + 1
+}
+
+// LineSpan returns position of 1-based line including line terminator.
+//
+// Returns zero position if line is out of range.
+func (idx *LineIndex) LineSpan(line int) Position {
+	if line < 1 || line > idx.Lines() {
+		return Position{}
+	}
+
+	end := len(idx.src) - 1
+	if line < len(idx.starts) {
+		end = idx.starts[line] - 1
+	}
+	return newPosition(idx.starts[line-1], end)
+}
+
+// FormatError returns error message prefixed with line and column of error position.
+//
+// Position of the outermost Positioned error in error tree is used.
+// Error message is returned as is if error has no position.
+func (idx *LineIndex) FormatError(err error) string {
+	positioned := AllPositioned(err)
+	if len(positioned) == 0 {
+		return err.Error()
+	}
+
+	line, col := idx.LineCol(positioned[0].Pos().Start)
+	return fmt.Sprintf("%d:%d: %s", line, col, err)
+}
+
+// FormatError returns error message prefixed with line and column of error position in source.
+//
+// Use LineIndex to format multiple errors of the same source.
+func FormatError(src []byte, err error) string {
+	return NewLineIndex(src).FormatError(err)
+}
@@ -0,0 +1,119 @@
+package jsonreflect
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLineIndex(t *testing.T) {
+	type lineCol struct {
+		line, col, byteCol int
+	}
+
+	cases := map[string]struct {
+		src   string
+		lines int
+		want  map[int]lineCol
+		spans map[int]Position
+	}{
+		"empty": {
+			src:  "",
+			want: map[int]lineCol{0: {1, 1, 1}, 5: {1, 1, 1}},
+			spans: map[int]Position{
+				1: {},
+			},
+		},
+		"lf": {
+			src:   "{\n  \"a\": 1\n}\n",
+			lines: 3,
+			want: map[int]lineCol{
+				0:  {1, 1, 1},
+				1:  {1, 2, 2},
+				2:  {2, 1, 1},
+				4:  {2, 3, 3},
+				11: {3, 1, 1},
+				13: {3, 3, 3},
+			},
+			spans: map[int]Position{
+				1: {Start: 0, End: 1},
+				2: {Start: 2, End: 10},
+				3: {Start: 11, End: 12},
+				4: {},
+			},
+		},
+		"crlf without final newline": {
+			src:   "[\r\n1,\r\n\r\n2]",
+			lines: 4,
+			want: map[int]lineCol{
+				1:  {1, 2, 2},
+				3:  {2, 1, 1},
+				6:  {2, 4, 4},
+				7:  {3, 1, 1},
+				9:  {4, 1, 1},
+				10: {4, 2, 2},
+				11: {4, 3, 3},
+			},
+			spans: map[int]Position{
+				1: {Start: 0, End: 2},
+				3: {Start: 7, End: 8},
+				4: {Start: 9, End: 10},
+				0: {},
+			},
+		},
+		"multi-byte": {
+			src:   "{\"ключ\": \"😀\", \"b\": 1}\n[]",
+			lines: 2,
+			want: map[int]lineCol{
+				2:  {1, 3, 3},
+				4:  {1, 4, 5},
+				11: {1, 8, 12},
+				18: {1, 12, 19},
+				29: {2, 1, 1},
+			},
+			spans: map[int]Position{
+				1: {Start: 0, End: 28},
+				2: {Start: 29, End: 30},
+			},
+		},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			idx := NewLineIndex([]byte(c.src))
+			require.Equal(t, c.lines, idx.Lines())
+			for offset, want := range c.want {
+				line, col := idx.LineCol(offset)
+				require.Equal(t, want.line, line, "line of offset %d", offset)
+				require.Equal(t, want.col, col, "column of offset %d", offset)
+
+				_, col = idx.LineByteCol(offset)
+				require.Equal(t, want.byteCol, col, "byte column of offset %d", offset)
+			}
+
+			for line, want := range c.spans {
+				require.Equal(t, want, idx.LineSpan(line), "span of line %d", line)
+			}
+		})
+	}
+}
+
+func TestFormatError(t *testing.T) {
+	src := []byte("{\r\n  \"ключ\": [1, 2,]\r\n}")
+	_, err := NewParser(src).Parse()
+	require.Error(t, err)
+	require.Equal(t, `2:16: unexpected character "," (in range 22:23)`, FormatError(src, err))
+
+	require.Equal(t, "foo", FormatError(src, errors.New("foo")))
+
+	doc, err := NewDocument([]byte("{\n\"a\": \"x\"}"))
+	require.NoError(t, err)
+
+	var dst struct {
+		A int `json:"a"`
+	}
+	err = UnmarshalValue(doc.Root, &dst)
+	require.Error(t, err)
+	require.Equal(t, "2:6: "+err.Error(), doc.FormatError(err))
+}
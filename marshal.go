@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"reflect"
 	"strconv"
 )
 
@@ -13,9 +14,21 @@ const (
 )
 
 type marshalFormatter struct {
-	isRoot bool
-	indent []byte
-	level  int
+	isRoot     bool
+	indent     []byte
+	lineEnding []byte
+	level      int
+	recorder   *positionRecorder
+	sortKeys   bool
+	escapeHTML bool
+
+	floatFormat byte
+	floatPrec   int
+
+	allowTruncated bool
+
+	// guard tracks containers being marshaled to detect cycles
+	guard *cycleGuard
 }
 
 func (mf *marshalFormatter) writePrefix(w io.Writer) error {
@@ -36,7 +49,11 @@ func (mf *marshalFormatter) noIndent() bool {
 }
 
 func (mf *marshalFormatter) writePropertyName(w io.Writer, name string) error {
-	quotedName := []byte(strconv.Quote(name))
+	quotedName := quoteString(name)
+	if mf != nil && mf.escapeHTML {
+		quotedName = escapeHTML(quotedName)
+	}
+
 	if mf.noIndent() {
 		_, err := w.Write(append(quotedName, tokenKeyDelimiter))
 		return err
@@ -51,7 +68,7 @@ func (mf *marshalFormatter) writeOpenClause(w io.Writer, chr byte) (err error) {
 		return err
 	}
 
-	_, err = w.Write([]byte{chr, charLineBreak})
+	_, err = w.Write(append([]byte{chr}, mf.lineEnding...))
 	return err
 }
 
@@ -65,11 +82,11 @@ func (mf *marshalFormatter) writeElementDelimiter(w io.Writer, isLast bool) erro
 	}
 
 	if isLast {
-		_, err := w.Write([]byte{charLineBreak})
+		_, err := w.Write(mf.lineEnding)
 		return err
 	}
 
-	_, err := w.Write([]byte{tokenDelimiter, charLineBreak})
+	_, err := w.Write(append([]byte{tokenDelimiter}, mf.lineEnding...))
 	return err
 }
 
@@ -88,37 +105,441 @@ func (mf *marshalFormatter) write(w io.Writer, data []byte) error {
 	return err
 }
 
+// options returns marshal options which correspond to formatter.
+//
+// Returns nil for compact output.
+func (mf *marshalFormatter) options() *MarshalOptions {
+	if mf.noIndent() {
+		return nil
+	}
+
+	return &MarshalOptions{
+		Indent:     string(mf.indent),
+		LineEnding: string(mf.lineEnding),
+		Level:      mf.level,
+	}
+}
+
 func (mf *marshalFormatter) childFormatter() *marshalFormatter {
-	if mf == nil {
+	if mf.noIndent() {
+		// compact output doesn't depend on nesting level
+		return mf
+	}
+	return &marshalFormatter{
+		isRoot:     false,
+		indent:     mf.indent,
+		lineEnding: mf.lineEnding,
+		level:      mf.level + 1,
+		recorder:   mf.recorder,
+		sortKeys:   mf.sortKeys,
+		escapeHTML: mf.escapeHTML,
+
+		floatFormat: mf.floatFormat,
+		floatPrec:   mf.floatPrec,
+
+		allowTruncated: mf.allowTruncated,
+		guard:          mf.guard,
+	}
+}
+
+// enter registers container being marshaled.
+//
+// Returns *CycleError if container is own ancestor.
+func (mf *marshalFormatter) enter(v Value) error {
+	if mf == nil || mf.guard == nil {
 		return nil
 	}
-	return &marshalFormatter{isRoot: false, indent: mf.indent, level: mf.level + 1}
+
+	if !mf.guard.enter(v) {
+		return mf.guard.newError(v)
+	}
+	return nil
 }
 
-// MarshalOptions contains additional marshal options
+// leave unregisters last container entered by enter.
+func (mf *marshalFormatter) leave() {
+	if mf != nil && mf.guard != nil {
+		mf.guard.leave()
+	}
+}
+
+// Marshaler is the interface implemented by values that can marshal themselves into valid JSON.
+//
+// Custom value types can embed jsonreflect.Value and implement this interface
+// to override serialization of embedded value.
+type Marshaler interface {
+	MarshalJSONValue() ([]byte, error)
+}
+
+// marshalValue serializes value using Marshaler implementation if available
+func marshalValue(w io.Writer, v Value, mf *marshalFormatter) error {
+	if v == nil {
+		return ErrNilValue
+	}
+
+	if mf != nil && mf.recorder != nil {
+		entry := mf.recorder.begin(v)
+		defer mf.recorder.end(entry)
+	}
+
+	m, ok := v.(Marshaler)
+	if !ok {
+		return v.marshal(w, mf)
+	}
+
+	data, err := m.MarshalJSONValue()
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+	return err
+}
+
+// MarshalOptions contains additional marshal options.
+//
+// New options are available only as MarshalOption, see MarshalValueOpts.
 type MarshalOptions struct {
 	// Indent is indentation to apply for output
 	Indent string
+
+	// LineEnding is line break sequence used for indented output.
+	//
+	// Default is "\n".
+	LineEnding string
+
+	// TrailingNewline appends line ending at the end of output.
+	TrailingNewline bool
+
+	// Level is nesting level of indented output.
+	//
+	// Lines after the first one are indented as if value is nested
+	// into Level containers. Options passed to CustomValue.WriteJSON
+	// have level of custom value, so custom values can pass them
+	// to MarshalValue to keep indentation of parent value.
+	Level int
+
+	// NilSliceAsEmptyArray converts nil slices to empty array instead of null.
+	//
+	// Affects only Marshal and ValueFrom.
+	NilSliceAsEmptyArray bool
+
+	// NilMapAsEmptyObject converts nil maps to empty object instead of null.
+	//
+	// Affects only Marshal and ValueFrom.
+	NilMapAsEmptyObject bool
+
+	// StringerFallback converts values of unsupported types which implement
+	// fmt.Stringer to strings instead of returning an error.
+	//
+	// Affects only Marshal and ValueFrom.
+	StringerFallback bool
 }
 
-func (opts *MarshalOptions) formatter() *marshalFormatter {
-	if opts == nil {
-		return nil
+type marshalParams struct {
+	indent               string
+	lineEnding           string
+	trailingNewline      bool
+	level                int
+	nilSliceAsEmptyArray bool
+	nilMapAsEmptyObject  bool
+	stringerFallback     bool
+	sortKeys             bool
+	escapeHTML           bool
+	floatFormat          byte
+	floatPrec            int
+	allowTruncated       bool
+	pool                 Pool
+}
+
+func newMarshalParams(opts []MarshalOption) *marshalParams {
+	p := &marshalParams{}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+func (p *marshalParams) lineEndingBytes() []byte {
+	if p.lineEnding == "" {
+		return []byte{charLineBreak}
 	}
 
+	return []byte(p.lineEnding)
+}
+
+func (p *marshalParams) formatter() *marshalFormatter {
 	return &marshalFormatter{
-		isRoot: true,
-		indent: []byte(opts.Indent),
+		isRoot:     true,
+		indent:     []byte(p.indent),
+		lineEnding: p.lineEndingBytes(),
+		level:      p.level,
+		sortKeys:   p.sortKeys,
+		escapeHTML: p.escapeHTML,
+
+		floatFormat: p.floatFormat,
+		floatPrec:   p.floatPrec,
+
+		allowTruncated: p.allowTruncated,
+		guard:          &cycleGuard{},
+	}
+}
+
+// MarshalOption is marshal option
+type MarshalOption func(p *marshalParams)
+
+// WithIndent sets indentation to apply for output.
+func WithIndent(indent string) MarshalOption {
+	return func(p *marshalParams) {
+		p.indent = indent
+	}
+}
+
+// WithLineEnding sets line break sequence used for indented output.
+//
+// Default is "\n".
+func WithLineEnding(lineEnding string) MarshalOption {
+	return func(p *marshalParams) {
+		p.lineEnding = lineEnding
+	}
+}
+
+// WithTrailingNewline appends line ending at the end of output.
+func WithTrailingNewline() MarshalOption {
+	return func(p *marshalParams) {
+		p.trailingNewline = true
+	}
+}
+
+// WithSortedKeys writes object keys in sorted order instead of source order.
+func WithSortedKeys() MarshalOption {
+	return func(p *marshalParams) {
+		p.sortKeys = true
+	}
+}
+
+// WithEscapeHTML escapes '<', '>' and '&' characters in strings
+// like encoding/json does.
+func WithEscapeHTML() MarshalOption {
+	return func(p *marshalParams) {
+		p.escapeHTML = true
+	}
+}
+
+// WithFloatFormat sets default strconv.FormatFloat format and precision
+// for floating point numbers without source text, like numbers created by NewNumberFloat.
+//
+// Numbers with own format are not affected, see Number.SetFormat for supported formats.
+// Unsupported formats are ignored.
+func WithFloatFormat(f byte, prec int) MarshalOption {
+	return func(p *marshalParams) {
+		if !isFloatFormat(f) {
+			p.floatFormat, p.floatPrec = 0, 0
+			return
+		}
+
+		p.floatFormat, p.floatPrec = f, prec
+	}
+}
+
+// WithTruncatedValues allows marshal of values truncated by parser, see MaxLeaves.
+//
+// Truncated array ends with "…" string item and truncated object ends
+// with "…" key with null value.
+func WithTruncatedValues() MarshalOption {
+	return func(p *marshalParams) {
+		p.allowTruncated = true
+	}
+}
+
+// MarshalStyleStable is diff-friendly output style for generated documents
+// which are reviewed or stored in version control.
+//
+// Objects and arrays are always written one item per line with 2-space indent,
+// only empty containers are written as "{}" and "[]". Object keys are sorted
+// and output ends with a line break.
+//
+// Other options can be applied after the preset to override it.
+var MarshalStyleStable MarshalOption = func(p *marshalParams) {
+	p.indent = "  "
+	p.lineEnding = ""
+	p.sortKeys = true
+	p.trailingNewline = true
+}
+
+// WithNilSliceAsEmptyArray converts nil slices to empty array instead of null.
+//
+// Affects only Marshal and ValueFrom.
+func WithNilSliceAsEmptyArray() MarshalOption {
+	return func(p *marshalParams) {
+		p.nilSliceAsEmptyArray = true
+	}
+}
+
+// WithNilMapAsEmptyObject converts nil maps to empty object instead of null.
+//
+// Affects only Marshal and ValueFrom.
+func WithNilMapAsEmptyObject() MarshalOption {
+	return func(p *marshalParams) {
+		p.nilMapAsEmptyObject = true
+	}
+}
+
+// WithStringerFallback converts values of unsupported types which implement
+// fmt.Stringer to strings instead of returning an error.
+//
+// Affects only Marshal and ValueFrom.
+func WithStringerFallback() MarshalOption {
+	return func(p *marshalParams) {
+		p.stringerFallback = true
+	}
+}
+
+// WithMarshalOptions applies options from MarshalOptions struct.
+//
+// Nil options are ignored.
+func WithMarshalOptions(opts *MarshalOptions) MarshalOption {
+	return func(p *marshalParams) {
+		if opts == nil {
+			return
+		}
+
+		p.indent = opts.Indent
+		p.lineEnding = opts.LineEnding
+		p.trailingNewline = opts.TrailingNewline
+		p.level = opts.Level
+		p.nilSliceAsEmptyArray = opts.NilSliceAsEmptyArray
+		p.nilMapAsEmptyObject = opts.NilMapAsEmptyObject
+		p.stringerFallback = opts.StringerFallback
 	}
 }
 
 // MarshalValue returns the JSON encoding of passed jsonreflect.Value
 //
 // Accepts optional argument which allows to specify indent.
+// See MarshalValueOpts for more options.
 func MarshalValue(v Value, opts *MarshalOptions) ([]byte, error) {
-	buff := &bytes.Buffer{}
-	if err := v.marshal(buff, opts.formatter()); err != nil {
-		return nil, fmt.Errorf("failed to marshal JSON %s: %w", v.Type(), err)
+	return MarshalValueOpts(v, WithMarshalOptions(opts))
+}
+
+// MarshalValueOpts returns the JSON encoding of passed jsonreflect.Value
+// with specified options.
+//
+// Returns *CycleError if value tree contains a reference to own ancestor.
+func MarshalValueOpts(v Value, opts ...MarshalOption) ([]byte, error) {
+	p := newMarshalParams(opts)
+	var buff outputBuffer = &bytes.Buffer{}
+	if p.pool != nil {
+		buff = newPoolBuffer(p.pool)
+	}
+
+	if err := marshalValue(buff, v, p.formatter()); err != nil {
+		if pb, ok := buff.(*poolBuffer); ok {
+			pb.release()
+		}
+		return nil, fmt.Errorf("failed to marshal JSON %s: %w", TypeOf(v), err)
+	}
+
+	if p.trailingNewline {
+		_, _ = buff.Write(p.lineEndingBytes())
 	}
 	return buff.Bytes(), nil
 }
+
+// outputBuffer is a buffer of marshal output.
+type outputBuffer interface {
+	io.Writer
+	Bytes() []byte
+}
+
+// EncodedLen returns length of MarshalValue output without building the output.
+//
+// Value is serialized into a writer which only counts written bytes,
+// so result is exact and respects all options.
+func EncodedLen(v Value, opts *MarshalOptions) (int, error) {
+	return EncodedLenOpts(v, WithMarshalOptions(opts))
+}
+
+// EncodedLenOpts returns length of MarshalValueOpts output without building the output.
+func EncodedLenOpts(v Value, opts ...MarshalOption) (int, error) {
+	p := newMarshalParams(opts)
+	w := &countWriter{}
+	if err := marshalValue(w, v, p.formatter()); err != nil {
+		return 0, fmt.Errorf("failed to marshal JSON %s: %w", TypeOf(v), err)
+	}
+
+	if p.trailingNewline {
+		_, _ = w.Write(p.lineEndingBytes())
+	}
+	return w.n, nil
+}
+
+// countWriter counts written bytes and discards them.
+type countWriter struct {
+	n int
+}
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	w.n += len(p)
+	return len(p), nil
+}
+
+// MarshalScalar returns the JSON encoding of scalar value.
+//
+// Result is the same as of MarshalValue without options,
+// but value is formatted directly into a string. Returns an error for arrays and objects.
+func MarshalScalar(v Value) (string, error) {
+	if t := TypeOf(v); t == TypeObject || t == TypeArray {
+		return "", fmt.Errorf("failed to marshal JSON %s: value is not a scalar", t)
+	}
+
+	if v == nil || isNilValue(reflect.ValueOf(v)) {
+		return "", fmt.Errorf("failed to marshal JSON %s: %w", TypeOf(v), ErrNilValue)
+	}
+
+	if _, ok := v.(Marshaler); ok {
+		data, err := MarshalValue(v, nil)
+		return string(data), err
+	}
+
+	switch t := v.(type) {
+	case *String:
+		return string(t.rawValue), nil
+	case *Number:
+		return t.asString(), nil
+	case Boolean:
+		return strconv.FormatBool(t.Value), nil
+	case Null:
+		return "null", nil
+	default:
+		data, err := MarshalValue(v, nil)
+		return string(data), err
+	}
+}
+
+// QuoteJSONString returns JSON-quoted string using the same escaping as marshaler.
+//
+// Control characters, line and paragraph separators are escaped
+// and invalid UTF-8 sequences are replaced with U+FFFD.
+func QuoteJSONString(s string) string {
+	return bytesString(quoteString(s))
+}
+
+// Marshal returns the JSON encoding of passed Go value.
+//
+// See ValueFrom documentation for information about conversion behavior.
+func Marshal(v interface{}, opts *MarshalOptions) ([]byte, error) {
+	return MarshalOpts(v, WithMarshalOptions(opts))
+}
+
+// MarshalOpts returns the JSON encoding of passed Go value with specified options.
+//
+// See ValueFrom documentation for information about conversion behavior.
+func MarshalOpts(v interface{}, opts ...MarshalOption) ([]byte, error) {
+	p := newMarshalParams(opts)
+	val, err := valueFrom(reflect.ValueOf(v), p, nil)
+	if err != nil {
+		return nil, err
+	}
+	return MarshalValueOpts(val, opts...)
+}
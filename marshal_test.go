@@ -1,6 +1,8 @@
 package jsonreflect
 
 import (
+	"errors"
+	"io"
 	"io/ioutil"
 	"path/filepath"
 	"testing"
@@ -39,3 +41,336 @@ func TestMarshalValue(t *testing.T) {
 		})
 	}
 }
+
+func TestMarshalValue_LineEndings(t *testing.T) {
+	src := []byte(`{"a": [1, true], "b": {}}`)
+	cases := map[string]struct {
+		opts *MarshalOptions
+		want string
+	}{
+		"compact": {
+			want: `{"a":[1,true],"b":{}}`,
+		},
+		"compact with empty options": {
+			opts: &MarshalOptions{LineEnding: "\r\n"},
+			want: `{"a":[1,true],"b":{}}`,
+		},
+		"compact with trailing newline": {
+			opts: &MarshalOptions{TrailingNewline: true},
+			want: "{\"a\":[1,true],\"b\":{}}\n",
+		},
+		"indent with LF": {
+			opts: &MarshalOptions{Indent: "  ", TrailingNewline: true},
+			want: "{\n  \"a\": [\n    1,\n    true\n  ],\n  \"b\": {}\n}\n",
+		},
+		"indent with CRLF": {
+			opts: &MarshalOptions{Indent: "  ", LineEnding: "\r\n", TrailingNewline: true},
+			want: "{\r\n  \"a\": [\r\n    1,\r\n    true\r\n  ],\r\n  \"b\": {}\r\n}\r\n",
+		},
+		"indent without trailing newline": {
+			opts: &MarshalOptions{Indent: "\t", LineEnding: "\r\n"},
+			want: "{\r\n\t\"a\": [\r\n\t\t1,\r\n\t\ttrue\r\n\t],\r\n\t\"b\": {}\r\n}",
+		},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			val, err := ValueOf(src)
+			require.NoError(t, err)
+
+			got, err := MarshalValue(val, c.opts)
+			require.NoError(t, err)
+			require.Equal(t, c.want, string(got))
+		})
+	}
+}
+
+// testObjectValue is custom value which writes object using MarshalValue.
+type testObjectValue struct {
+	obj *Object
+}
+
+func (v testObjectValue) Ref() Position           { return v.obj.Ref() }
+func (v testObjectValue) Type() Type              { return TypeObject }
+func (v testObjectValue) Interface() interface{}  { return v.obj.Interface() }
+func (v testObjectValue) String() (string, error) { return v.obj.String() }
+
+func (v testObjectValue) WriteJSON(w io.Writer, opts *MarshalOptions) error {
+	data, err := MarshalValue(v.obj, opts)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+	return err
+}
+
+func TestMarshalValue_CustomValueLevel(t *testing.T) {
+	obj, err := ValueOf([]byte(`{"b": [1]}`))
+	require.NoError(t, err)
+
+	v := NewObject(map[string]Value{
+		"a": NewArray(AdaptValue(testObjectValue{obj.(*Object)})),
+	})
+
+	got, err := MarshalValue(v, &MarshalOptions{Indent: "  "})
+	require.NoError(t, err)
+	require.Equal(t, "{\n  \"a\": [\n    {\n      \"b\": [\n        1\n      ]\n    }\n  ]\n}", string(got))
+}
+
+func TestMarshalValueOpts(t *testing.T) {
+	src := []byte(`{"b": "<a&b>", "a": {"z": 1, "<y>": 2}}`)
+	cases := map[string]struct {
+		opts []MarshalOption
+		want string
+	}{
+		"no options": {
+			want: `{"b":"<a&b>","a":{"z":1,"<y>":2}}`,
+		},
+		"sorted keys": {
+			opts: []MarshalOption{WithSortedKeys()},
+			want: `{"a":{"<y>":2,"z":1},"b":"<a&b>"}`,
+		},
+		"escape html": {
+			opts: []MarshalOption{WithEscapeHTML()},
+			want: `{"b":"\u003ca\u0026b\u003e","a":{"z":1,"\u003cy\u003e":2}}`,
+		},
+		"indent": {
+			opts: []MarshalOption{WithIndent(" "), WithSortedKeys(), WithTrailingNewline()},
+			want: "{\n \"a\": {\n  \"<y>\": 2,\n  \"z\": 1\n },\n \"b\": \"<a&b>\"\n}\n",
+		},
+		"struct adapter": {
+			opts: []MarshalOption{WithMarshalOptions(&MarshalOptions{Indent: " ", LineEnding: "\r\n"})},
+			want: "{\r\n \"b\": \"<a&b>\",\r\n \"a\": {\r\n  \"z\": 1,\r\n  \"<y>\": 2\r\n }\r\n}",
+		},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			val, err := ValueOf(src)
+			require.NoError(t, err)
+
+			got, err := MarshalValueOpts(val, c.opts...)
+			require.NoError(t, err)
+			require.Equal(t, c.want, string(got))
+
+			// source order is kept after sorted output
+			require.Equal(t, "b", val.(*Object).Members()[0].Key)
+		})
+	}
+}
+
+func TestMarshalOpts(t *testing.T) {
+	type foo struct {
+		Items []int           `json:"items"`
+		Attrs map[string]bool `json:"attrs"`
+	}
+
+	got, err := MarshalOpts(foo{}, WithNilSliceAsEmptyArray(), WithNilMapAsEmptyObject())
+	require.NoError(t, err)
+	require.Equal(t, `{"items":[],"attrs":{}}`, string(got))
+}
+
+func TestWithFloatFormat(t *testing.T) {
+	parsed, err := ValueOf([]byte(`[1.50, 2]`))
+	require.NoError(t, err)
+
+	large, err := NewNumberFloat(1e21)
+	require.NoError(t, err)
+
+	fixed, err := NewNumberFloat(0.125)
+	require.NoError(t, err)
+	fixed.SetFormat('f', 2)
+
+	arr := NewArray(parsed.(*Array).Items[0], parsed.(*Array).Items[1], large, fixed)
+	got, err := MarshalValueOpts(arr, WithFloatFormat('g', -1))
+	require.NoError(t, err)
+
+	// format is applied only to numbers without source text and own format
+	require.Equal(t, `[1.50,2,1e+21,0.12]`, string(got))
+
+	got, err = MarshalValueOpts(arr)
+	require.NoError(t, err)
+	require.Equal(t, `[1.50,2,1e+21,0.12]`, string(got))
+}
+
+func TestMarshalValue_Cycle(t *testing.T) {
+	shared := NewString("shared")
+	root := NewObject(map[string]Value{"a": shared})
+	child := NewArray(NewNumberInt(1), shared)
+	root.Items["b.c"] = NewObject(map[string]Value{"items": child})
+	child.Items = append(child.Items, root)
+
+	_, err := MarshalValue(root, nil)
+	require.Error(t, err)
+	require.True(t, errors.Is(err, ErrCycleDetected))
+
+	cycleErr := new(CycleError)
+	require.True(t, errors.As(err, &cycleErr))
+	require.Equal(t, `["b.c"].items[2]`, cycleErr.Path)
+
+	_, _, err = MarshalSubtree(child)
+	require.True(t, errors.Is(err, ErrCycleDetected))
+
+	_, err = Rebase(root)
+	require.True(t, errors.As(err, &cycleErr))
+	require.Equal(t, `["b.c"].items[2]`, cycleErr.Path)
+
+	_, err = EncodedLen(root, &MarshalOptions{Indent: "  "})
+	require.True(t, errors.Is(err, ErrCycleDetected))
+
+	_, err = ToURLValues(root, "/")
+	require.True(t, errors.Is(err, ErrCycleDetected))
+
+	// shared values are not cycles
+	child.Items = child.Items[:2]
+	got, err := MarshalValue(root, nil)
+	require.NoError(t, err)
+	require.Equal(t, `{"a":"shared","b.c":{"items":[1,"shared"]}}`, string(got))
+}
+
+func TestMarshalScalar(t *testing.T) {
+	parsed, err := ValueOf([]byte(`["foo \"bar\" baz", -1.050, 42, true, null]`))
+	require.NoError(t, err)
+
+	f, err := NewNumberFloat(0.1)
+	require.NoError(t, err)
+
+	values := append(parsed.(*Array).Items, NewString("a\nb "), f, NewBoolean(false), NewNull(),
+		testDateValue{Value: NewString("2020-01-01")})
+	for _, v := range values {
+		want, err := MarshalValue(v, nil)
+		require.NoError(t, err)
+
+		got, err := MarshalScalar(v)
+		require.NoError(t, err)
+		require.Equal(t, string(want), got)
+	}
+
+	_, err = MarshalScalar(NewArray())
+	require.EqualError(t, err, "failed to marshal JSON array: value is not a scalar")
+
+	_, err = MarshalScalar(NewObject(nil))
+	require.EqualError(t, err, "failed to marshal JSON object: value is not a scalar")
+
+	_, err = MarshalScalar(nil)
+	require.EqualError(t, err, "failed to marshal JSON null: nil value")
+
+	_, err = MarshalScalar((*String)(nil))
+	require.True(t, errors.Is(err, ErrNilValue))
+
+	allocs := map[Value]float64{
+		values[0]: 1, // string
+		values[2]: 1, // integer
+		values[3]: 0, // boolean
+		values[4]: 0, // null
+	}
+	for v, want := range allocs {
+		got := testing.AllocsPerRun(10, func() {
+			_, _ = MarshalScalar(v)
+		})
+		require.LessOrEqual(t, got, want, v.Type())
+	}
+}
+
+func TestQuoteJSONString(t *testing.T) {
+	for _, str := range []string{"", "foo", "a\"b\\c", "\n\t\x01", "привет ", "\xff"} {
+		want, err := MarshalValue(NewString(str), nil)
+		require.NoError(t, err)
+		require.Equal(t, string(want), QuoteJSONString(str))
+	}
+	require.Equal(t, `"\u0001\ufffd"`, QuoteJSONString("\x01\xff"))
+}
+
+func TestEncodedLen(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*.json"))
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	optsList := map[string][]MarshalOption{
+		"compact":       nil,
+		"indent":        {WithIndent("  ")},
+		"crlf":          {WithIndent("\t"), WithLineEnding("\r\n"), WithTrailingNewline()},
+		"sorted html":   {WithSortedKeys(), WithEscapeHTML()},
+		"float format":  {WithFloatFormat('e', 3), WithIndent(" ")},
+		"struct values": {WithMarshalOptions(&MarshalOptions{Indent: "    ", TrailingNewline: true})},
+	}
+
+	for _, file := range files {
+		src, err := ioutil.ReadFile(file)
+		require.NoError(t, err)
+		v, err := ValueOf(src)
+		require.NoError(t, err, file)
+
+		for n, opts := range optsList {
+			want, err := MarshalValueOpts(v, opts...)
+			require.NoError(t, err)
+
+			got, err := EncodedLenOpts(v, opts...)
+			require.NoError(t, err)
+			require.Equal(t, len(want), got, "%s: %s", file, n)
+		}
+
+		want, err := MarshalValue(v, &MarshalOptions{Indent: "  "})
+		require.NoError(t, err)
+		got, err := EncodedLen(v, &MarshalOptions{Indent: "  "})
+		require.NoError(t, err)
+		require.Equal(t, len(want), got)
+	}
+
+	arr := NewArray()
+	arr.Items = append(arr.Items, arr)
+	_, err = EncodedLen(arr, nil)
+	require.True(t, errors.Is(err, ErrCycleDetected))
+}
+
+func TestMarshalStyleStable(t *testing.T) {
+	src, err := ioutil.ReadFile(filepath.Join("testdata", "obj_simple.json"))
+	require.NoError(t, err)
+	v, err := ValueOf(src)
+	require.NoError(t, err)
+
+	obj := v.(*Object)
+	obj.Items["single"] = NewArray(NewObject(map[string]Value{"z": NewNumberInt(1)}))
+	obj.Items["empty"] = NewObject(nil)
+	obj.Items["empty_list"] = NewArray()
+
+	want := `{
+  "active": true,
+  "age": 32,
+  "created_at": "2009-11-10T23:00:00Z",
+  "empty": {},
+  "empty_list": [],
+  "id": 10,
+  "meta": {
+    "first_name": "John",
+    "last_name": "Doe"
+  },
+  "rating": -3.1415,
+  "ref": null,
+  "roles": [
+    "root",
+    "owner"
+  ],
+  "single": [
+    {
+      "z": 1
+    }
+  ],
+  "user": "admin",
+  "x-meta-salt": "d3b07384d113edec49eaa6238ad5ff00"
+}
+`
+	got, err := MarshalValueOpts(v, MarshalStyleStable)
+	require.NoError(t, err)
+	require.Equal(t, want, string(got))
+
+	got, err = MarshalValueOpts(NewArray(), MarshalStyleStable)
+	require.NoError(t, err)
+	require.Equal(t, "[]\n", string(got))
+
+	// preset can be overridden
+	got, err = MarshalValueOpts(NewArray(NewBoolean(true)), MarshalStyleStable, WithIndent("\t"), WithLineEnding("\r\n"))
+	require.NoError(t, err)
+	require.Equal(t, "[\r\n\ttrue\r\n]\r\n", string(got))
+}
@@ -0,0 +1,282 @@
+package jsonreflect
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+type matchParams struct {
+	tolerance float64
+}
+
+// MatchOption is Matches and Mismatch option.
+type MatchOption func(p *matchParams)
+
+// MatchTolerance sets max absolute difference between number and float value
+// which are considered equal.
+//
+// By default, number is converted to float64 or float32 and compared exactly.
+// Tolerance also applies to integer values, numbers are compared as float64 then.
+func MatchTolerance(delta float64) MatchOption {
+	return func(p *matchParams) {
+		p.tolerance = delta
+	}
+}
+
+// Matches reports whether value matches Go value.
+//
+// Supported Go values are:
+//
+// - nil, nil pointer, map or slice match null.
+//
+// - bool matches boolean, string matches string with the same decoded value.
+//
+// - integers match numbers with the same integer value, like 1, 1.0 or 1e0, regardless of Go type.
+//
+// - floats match numbers with the same value after conversion to float type, see MatchTolerance.
+//
+// - slices and arrays match arrays with matching items.
+//
+// - maps with string keys match objects with the same keys and matching values.
+//
+// - Value matches equal value, see Equal.
+//
+// Pointers are dereferenced. See Mismatch to find out why value doesn't match.
+func Matches(v Value, want interface{}, opts ...MatchOption) bool {
+	return Mismatch(v, want, opts...) == ""
+}
+
+// Mismatch returns description of the first difference between value and Go value,
+// or empty string if value matches Go value, see Matches.
+//
+// Description starts with path to the different value, e.g.:
+//
+//	items[1].id: got number 2, want 3 (int)
+func Mismatch(v Value, want interface{}, opts ...MatchOption) string {
+	p := matchParams{}
+	for _, opt := range opts {
+		opt(&p)
+	}
+
+	return p.mismatch(v, want, nil)
+}
+
+func (p matchParams) mismatch(v Value, want interface{}, path []pathSegment) string {
+	if wantValue, ok := want.(Value); ok {
+		if Equal(v, wantValue, EqualOptions{}) {
+			return ""
+		}
+		return mismatchf(path, "got %s, want %s", describeMatchValue(v), describeMatchValue(wantValue))
+	}
+
+	rv := reflect.ValueOf(want)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+
+	if isNilMatch(rv) {
+		if TypeOf(v) == TypeNull {
+			return ""
+		}
+		return mismatchf(path, "got %s, want null", describeMatchValue(v))
+	}
+
+	switch rv.Kind() {
+	case reflect.Bool:
+		if b, ok := v.(Boolean); ok && b.Value == rv.Bool() {
+			return ""
+		}
+	case reflect.String:
+		if s, ok := v.(*String); ok && s != nil && decodedString(s) == rv.String() {
+			return ""
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		if n, ok := v.(*Number); ok && n != nil && p.numberMatches(n, rv) {
+			return ""
+		}
+	case reflect.Slice, reflect.Array:
+		arr, ok := v.(*Array)
+		if !ok || arr == nil {
+			break
+		}
+
+		if len(arr.Items) != rv.Len() {
+			return mismatchf(path, "got array of %d items, want %d items", len(arr.Items), rv.Len())
+		}
+
+		for i, item := range arr.Items {
+			itemPath := append(path[:len(path):len(path)], pathSegment{index: i, isIndex: true})
+			if msg := p.mismatch(item, rv.Index(i).Interface(), itemPath); msg != "" {
+				return msg
+			}
+		}
+		return ""
+	case reflect.Map:
+		obj, ok := v.(*Object)
+		if !ok || obj == nil || rv.Type().Key().Kind() != reflect.String {
+			break
+		}
+		return p.objectMismatch(obj, rv, path)
+	default:
+		return mismatchf(path, "unsupported type %T", want)
+	}
+
+	return mismatchf(path, "got %s, want %s", describeMatchValue(v), describeGoValue(rv))
+}
+
+func (p matchParams) objectMismatch(obj *Object, rv reflect.Value, path []pathSegment) string {
+	keys := make([]string, 0, rv.Len())
+	for _, k := range rv.MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		val, ok := obj.Get(k)
+		if !ok {
+			return mismatchf(path, "missing key %q", k)
+		}
+
+		wantVal := rv.MapIndex(reflect.ValueOf(k).Convert(rv.Type().Key()))
+		keyPath := append(path[:len(path):len(path)], pathSegment{key: k})
+		if msg := p.mismatch(val, wantVal.Interface(), keyPath); msg != "" {
+			return msg
+		}
+	}
+
+	for _, m := range obj.Members() {
+		if !rv.MapIndex(reflect.ValueOf(m.Key).Convert(rv.Type().Key())).IsValid() {
+			return mismatchf(path, "unexpected key %q", m.Key)
+		}
+	}
+	return ""
+}
+
+func (p matchParams) numberMatches(n *Number, rv reflect.Value) bool {
+	switch rv.Kind() {
+	case reflect.Float32:
+		if p.tolerance > 0 {
+			return math.Abs(n.Float64()-rv.Float()) <= p.tolerance
+		}
+		return n.Float32() == float32(rv.Float())
+	case reflect.Float64:
+		if p.tolerance > 0 {
+			return math.Abs(n.Float64()-rv.Float()) <= p.tolerance
+		}
+		return n.Float64() == rv.Float()
+	}
+
+	want := new(big.Int)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		want.SetInt64(rv.Int())
+	default:
+		want.SetUint64(rv.Uint())
+	}
+
+	if p.tolerance > 0 {
+		f, _ := new(big.Float).SetInt(want).Float64()
+		return math.Abs(n.Float64()-f) <= p.tolerance
+	}
+
+	got, ok := integerValue(n)
+	return ok && got.Cmp(want) == 0
+}
+
+// maxIntegerDigits is count of digits of the largest uint64 value
+const maxIntegerDigits = 20
+
+// integerValue returns exact value of integer number.
+//
+// Returns false if number has fractional part or is out of uint64 and int64 range.
+func integerValue(n *Number) (*big.Int, bool) {
+	coefficient, scale, err := n.Decimal()
+	if err != nil {
+		return nil, false
+	}
+
+	if coefficient.Sign() == 0 {
+		return coefficient, true
+	}
+
+	if scale < 0 {
+		if -int64(scale) > maxIntegerDigits {
+			return nil, false
+		}
+		pow := new(big.Int).Exp(big.NewInt(10), big.NewInt(-int64(scale)), nil)
+		return coefficient.Mul(coefficient, pow), true
+	}
+
+	if int64(scale) > int64(len(coefficient.String())) {
+		return nil, false
+	}
+
+	pow := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil)
+	q, r := new(big.Int).QuoRem(coefficient, pow, new(big.Int))
+	return q, r.Sign() == 0
+}
+
+func isNilMatch(rv reflect.Value) bool {
+	if !rv.IsValid() {
+		return true
+	}
+
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return rv.IsNil()
+	default:
+		return false
+	}
+}
+
+func mismatchf(path []pathSegment, msg string, args ...interface{}) string {
+	msg = fmt.Sprintf(msg, args...)
+	if len(path) == 0 {
+		return msg
+	}
+	return formatPath(path) + ": " + msg
+}
+
+// describeMatchValue returns short description of value used in mismatch messages.
+func describeMatchValue(v Value) string {
+	switch t := v.(type) {
+	case *Number:
+		if t != nil {
+			return "number " + t.asString()
+		}
+	case *String:
+		if t != nil {
+			return "string " + strconv.Quote(decodedString(t))
+		}
+	case Boolean:
+		return "boolean " + strconv.FormatBool(t.Value)
+	case *Array:
+		if t != nil {
+			return fmt.Sprintf("array of %d items", len(t.Items))
+		}
+	case *Object:
+		if t != nil {
+			return fmt.Sprintf("object of %d keys", t.Len())
+		}
+	}
+	return TypeOf(v).String()
+}
+
+func describeGoValue(rv reflect.Value) string {
+	switch rv.Kind() {
+	case reflect.String:
+		return fmt.Sprintf("%q (%s)", rv.String(), rv.Type())
+	case reflect.Slice, reflect.Array:
+		return fmt.Sprintf("%s of %d items", rv.Type(), rv.Len())
+	case reflect.Map:
+		return fmt.Sprintf("%s of %d keys", rv.Type(), rv.Len())
+	default:
+		return fmt.Sprintf("%v (%s)", rv.Interface(), rv.Type())
+	}
+}
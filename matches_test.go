@@ -0,0 +1,66 @@
+package jsonreflect
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatches(t *testing.T) {
+	type id int64
+
+	src := `{"id": 1, "name": "foo", "ok": true, "none": null, "pi": 3.14, "big": 1e3,
+		"items": [{"id": 18446744073709551615}, {"id": -2.0}], "tags": ["a", "b"]}`
+	v, err := NewParser([]byte(src)).Parse()
+	require.NoError(t, err)
+
+	name := "foo"
+	want := map[string]interface{}{
+		"id":   id(1),
+		"name": &name,
+		"ok":   true,
+		"none": nil,
+		"pi":   3.14,
+		"big":  1000,
+		"items": []interface{}{
+			map[string]uint64{"id": math.MaxUint64},
+			map[string]int8{"id": -2},
+		},
+		"tags": [2]string{"a", "b"},
+	}
+	require.True(t, Matches(v, want), Mismatch(v, want))
+
+	cases := map[string]struct {
+		src  string
+		want interface{}
+		opts []MatchOption
+		msg  string
+	}{
+		"int vs float":  {src: `1.5`, want: 1, msg: "got number 1.5, want 1 (int)"},
+		"float exact":   {src: `0.1`, want: float32(0.1)},
+		"float differs": {src: `3.14`, want: 3.1415, msg: "got number 3.14, want 3.1415 (float64)"},
+		"tolerance":     {src: `3.14`, want: 3.1415, opts: []MatchOption{MatchTolerance(0.01)}},
+		"int tolerance": {src: `2.999`, want: 3, opts: []MatchOption{MatchTolerance(0.01)}},
+		"huge integer":  {src: `1e30`, want: int64(math.MaxInt64), msg: "got number 1e30, want 9223372036854775807 (int64)"},
+		"null":          {src: `0`, want: nil, msg: "got number 0, want null"},
+		"nil slice":     {src: `null`, want: []int(nil)},
+		"not null":      {src: `null`, want: "", msg: `got null, want "" (string)`},
+		"string":        {src: `"1"`, want: 1, msg: `got string "1", want 1 (int)`},
+		"array length":  {src: `[1, 2]`, want: []int{1}, msg: "got array of 2 items, want 1 items"},
+		"array item":    {src: `[{"a": [true]}]`, want: []interface{}{map[string][]bool{"a": {false}}}, msg: "[0].a[0]: got boolean true, want false (bool)"},
+		"missing key":   {src: `{"a": 1}`, want: map[string]int{"a": 1, "b": 2}, msg: `missing key "b"`},
+		"unexpected":    {src: `{"x": {"a": 1, "c": 3}}`, want: map[string]interface{}{"x": map[string]int{"a": 1}}, msg: `x: unexpected key "c"`},
+		"value":         {src: `{"a": [1.0]}`, want: map[string]interface{}{"a": NewArray(NewNumberInt(1))}},
+		"unsupported":   {src: `{}`, want: struct{}{}, msg: "unsupported type struct {}"},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			v, err := NewParser([]byte(c.src)).Parse()
+			require.NoError(t, err)
+			require.Equal(t, c.msg, Mismatch(v, c.want, c.opts...))
+			require.Equal(t, c.msg == "", Matches(v, c.want, c.opts...))
+		})
+	}
+}
@@ -0,0 +1,7 @@
+//go:build !race
+// +build !race
+
+package jsonreflect
+
+// raceEnabled reports whether tests are built with race detector
+const raceEnabled = false
@@ -1,12 +1,47 @@
 package jsonreflect
 
 import (
+	"fmt"
 	"io"
 	"math"
+	"math/big"
+	"math/bits"
 	"strconv"
 	"strings"
 )
 
+const (
+	// maxInt64Float is the smallest float64 value out of int64 range
+	maxInt64Float = 1 << 63
+
+	// maxUint64Float is the smallest float64 value out of uint64 range
+	maxUint64Float = 1 << 64
+)
+
+// Notation is number notation in source text.
+//
+// Notation is a set of flags, plain notation has no flags.
+type Notation uint8
+
+const (
+	// NotationPlain is plain decimal notation like 1000000000
+	NotationPlain Notation = 0
+
+	// NotationExponent is exponent notation with lowercase mark like 1e9
+	NotationExponent Notation = 1 << 0
+
+	// NotationUpperExponent is exponent notation with uppercase mark like 1E9
+	NotationUpperExponent Notation = 1 << 1
+
+	// NotationExplicitPlus is set when positive exponent has explicit plus sign like 1e+9
+	NotationExplicitPlus Notation = 1 << 2
+)
+
+// IsExponent reports whether notation is exponent notation
+func (nt Notation) IsExponent() bool {
+	return nt&(NotationExponent|NotationUpperExponent) != 0
+}
+
 // Number represents json float64 number value
 type Number struct {
 	baseValue
@@ -14,7 +49,33 @@ type Number struct {
 	exponent uint64
 	expoLen  int
 
-	// IsFloat is floating point number flag
+	// exp10 is decimal exponent of number in exponent notation.
+	//
+	// Other fields keep number part before exponent mark.
+	exp10    int
+	notation Notation
+
+	// float is exact value of number created from float64, see hasFloat.
+	float    float64
+	hasFloat bool
+
+	// parsedFloat is set when float keeps value parsed from source text
+	// of number which can't be computed from fields with a single rounding.
+	parsedFloat bool
+
+	// decimal is exact text of number which doesn't fit into fields above.
+	//
+	// Float keeps approximate value of such number.
+	decimal string
+
+	// format and prec are strconv.FormatFloat arguments, see SetFormat.
+	format byte
+	prec   int
+
+	// IsFloat is floating point number flag.
+	//
+	// Flag is kept for numbers with zero fraction part like "1.0",
+	// such numbers are marshaled with fraction part.
 	IsFloat bool
 
 	// IsSigned is signed number flag
@@ -22,83 +83,461 @@ type Number struct {
 }
 
 // Type implements jsonreflect.Value
-func (_ Number) Type() Type {
+func (n *Number) Type() Type {
 	return TypeNumber
 }
 
+// Ref implements jsonreflect.Value
+func (n *Number) Ref() Position {
+	if n == nil {
+		return Position{}
+	}
+
+	n.gen.check()
+
+	return n.Position
+}
+
 // Interface() implements json.Value
-func (n Number) Interface() interface{} {
+func (n *Number) Interface() interface{} {
+	if n == nil {
+		return nil
+	}
+
+	n.gen.check()
+
 	if n.IsFloat {
 		return n.Float64()
 	}
 	return n.Int()
 }
 
-func (n Number) asString() string {
+// SetFormat sets strconv.FormatFloat format and precision used to marshal a number.
+//
+// Format should be one of 'e', 'E', 'f', 'g' or 'G', other formats reset number
+// to default format. Use -1 precision to get the smallest number of digits
+// necessary to represent the value exactly.
+//
+// Format overrides source text of parsed number.
+func (n *Number) SetFormat(f byte, prec int) {
+	if n == nil {
+		return
+	}
+
+	if !isFloatFormat(f) {
+		n.format, n.prec = 0, 0
+		return
+	}
+
+	n.format, n.prec = f, prec
+}
+
+// Notation returns number notation in source text.
+//
+// Number is marshaled in the same notation, unless format is set with SetFormat.
+func (n *Number) Notation() Notation {
+	if n == nil {
+		return NotationPlain
+	}
+	return n.notation
+}
+
+func isFloatFormat(f byte) bool {
+	switch f {
+	case 'e', 'E', 'f', 'g', 'G':
+		return true
+	default:
+		return false
+	}
+}
+
+func (n *Number) asString() string {
+	// numbers out of float64 range are formatted as exact text
+	if f := n.Float64(); n.format != 0 && !math.IsInf(f, 0) {
+		return strconv.FormatFloat(f, n.format, n.prec, 64)
+	}
+
+	if n.decimal != "" {
+		return n.decimal
+	}
+
+	if n.hasFloat {
+		return formatFloat(n.float)
+	}
+
+	if n.notation.IsExponent() {
+		return n.exponentString()
+	}
+
 	if !n.IsFloat {
 		return strconv.Itoa(n.Int())
 	}
 	sb := strings.Builder{}
+	if n.IsSigned && n.mantissa == 0 {
+		// sign of values like "-0.5" is stored only in flag
+		sb.WriteRune(charNumberNegative)
+	}
 	sb.WriteString(strconv.Itoa(n.Int()))
 	sb.WriteRune('.')
-	sb.WriteString(strconv.FormatUint(n.exponent, 10))
+
+	// restore leading zeros of fractional part
+	fraction := strconv.FormatUint(n.exponent, 10)
+	if pad := n.expoLen - len(fraction); pad > 0 {
+		sb.WriteString(strings.Repeat("0", pad))
+	}
+	sb.WriteString(fraction)
+	return sb.String()
+}
+
+// formatFloat returns shortest representation of float.
+//
+// Like in encoding/json, values from 1e21 are formatted
+// in exponent notation.
+func formatFloat(f float64) string {
+	if math.Abs(f) >= 1e21 {
+		return strconv.FormatFloat(f, 'e', -1, 64)
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// exponentString returns number in original exponent notation.
+func (n *Number) exponentString() string {
+	base := *n
+	base.notation, base.exp10 = NotationPlain, 0
+	base.IsFloat = base.expoLen > 0
+
+	sb := strings.Builder{}
+	sb.WriteString(base.asString())
+	if n.notation&NotationUpperExponent != 0 {
+		sb.WriteByte('E')
+	} else {
+		sb.WriteByte('e')
+	}
+
+	if n.notation&NotationExplicitPlus != 0 && n.exp10 >= 0 {
+		sb.WriteByte('+')
+	}
+	sb.WriteString(strconv.Itoa(n.exp10))
 	return sb.String()
 }
 
 // String implements jsonreflect.Value
-func (n Number) String() (string, error) {
+func (n *Number) String() (string, error) {
+	if n == nil {
+		return "", nil
+	}
+
+	n.gen.check()
+
 	return n.asString(), nil
 }
 
-func (n Number) marshal(w io.Writer, _ *marshalFormatter) error {
+func (n *Number) marshal(w io.Writer, mf *marshalFormatter) error {
+	if n == nil {
+		return ErrNilValue
+	}
+
+	n.gen.check()
+
+	if mf != nil && mf.floatFormat != 0 && n.format == 0 && n.raw == nil && (n.IsFloat || n.hasFloat) {
+		// default format is applied only to numbers without source text
+		_, err := w.Write([]byte(strconv.FormatFloat(n.Float64(), mf.floatFormat, mf.floatPrec, 64)))
+		return err
+	}
+
 	_, err := w.Write([]byte(n.asString()))
 	return err
 }
 
 // Float64 returns value as float64 number
-func (n Number) Float64() float64 {
-	if n.exponent == 0 {
+//
+// Returns positive or negative infinity for numbers out of float64 range,
+// use Decimal to get exact value of such numbers.
+func (n *Number) Float64() float64 {
+	if n == nil {
+		return 0
+	}
+
+	if n.hasFloat || n.parsedFloat {
+		return n.float
+	}
+
+	if n.expoLen == 0 && n.exp10 == 0 {
 		return float64(n.mantissa)
 	}
 
-	exponent := float64(n.exponent) / math.Pow10(n.expoLen)
-	if n.mantissa < 0 {
-		exponent *= -1
+	if !n.isExactFloat() {
+		// number can't be computed with a single rounding, see parseNumberInto
+		f := float64(n.mantissa)
+		if n.exponent != 0 {
+			exponent := float64(n.exponent) / math.Pow10(n.expoLen)
+			if n.IsSigned || n.mantissa < 0 {
+				exponent *= -1
+			}
+			f += exponent
+		}
+		return scaleFloat(f, n.exp10)
+	}
+
+	mantissa := n.mantissa
+	if mantissa < 0 {
+		mantissa = -mantissa
+	}
+
+	// all digits fit into float64 mantissa, so only scaling is rounded
+	f := scaleFloat(float64(mantissa*int64(math.Pow10(n.expoLen))+int64(n.exponent)), n.exp10-n.expoLen)
+	if n.IsSigned || n.mantissa < 0 {
+		f = -f
+	}
+	return f
+}
+
+// maxExactDigits is max count of decimal digits which fit into float64 mantissa
+const maxExactDigits = 15
+
+// maxExactPow10 is max power of 10 which is exactly represented by float64
+const maxExactPow10 = 22
+
+// isExactFloat reports whether float value of number is computed from its fields
+// with a single rounding, like strconv.ParseFloat does.
+func (n *Number) isExactFloat() bool {
+	if n.hasFloat || n.parsedFloat || (n.expoLen == 0 && n.exp10 == 0) {
+		return true
+	}
+
+	mantissa := n.mantissa
+	if mantissa < 0 {
+		mantissa = -mantissa
+	}
+
+	scale := n.exp10 - n.expoLen
+	return n.expoLen <= maxExactDigits && mantissa >= 0 && mantissa < int64(math.Pow10(maxExactDigits-n.expoLen)) &&
+		scale >= -maxExactPow10 && scale <= maxExactPow10
+}
+
+func scaleFloat(f float64, exp10 int) float64 {
+	switch {
+	case exp10 > 0:
+		return f * math.Pow10(exp10)
+	case exp10 < 0:
+		return f / math.Pow10(-exp10)
+	default:
+		return f
+	}
+}
+
+// Decimal returns exact value of number as coefficient * 10^-scale.
+//
+// Unlike Float64, value is computed from number digits and isn't rounded.
+// Numbers with format set by SetFormat return formatted value.
+//
+// Returns error if scale is out of int32 range.
+func (n *Number) Decimal() (coefficient *big.Int, scale int32, err error) {
+	if n == nil {
+		return new(big.Int), 0, nil
+	}
+
+	n.gen.check()
+
+	str := n.asString()
+	if n.hasFloat && n.decimal == "" && n.format == 0 {
+		// shortest representation of float is exact, unlike fixed point one
+		str = strconv.FormatFloat(n.float, 'g', -1, 64)
+	}
+
+	return parseDecimal(str)
+}
+
+// parseDecimal splits number text into coefficient and scale.
+func parseDecimal(str string) (*big.Int, int32, error) {
+	digits, exp := str, int64(0)
+	if i := strings.IndexAny(str, "eE"); i != -1 {
+		var err error
+		digits = str[:i]
+		exp, err = strconv.ParseInt(str[i+1:], 10, 64)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid exponent of number %q", str)
+		}
+	}
+
+	scale := int64(0)
+	if dot := strings.IndexByte(digits, '.'); dot != -1 {
+		scale = int64(len(digits) - dot - 1)
+		digits = digits[:dot] + digits[dot+1:]
+	}
+
+	scale -= exp
+	if scale > math.MaxInt32 || scale < math.MinInt32 {
+		return nil, 0, fmt.Errorf("scale of number %q is out of range", str)
+	}
+
+	coefficient, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return nil, 0, fmt.Errorf("invalid number %q", str)
 	}
-	return float64(n.mantissa) + exponent
+	return coefficient, int32(scale), nil
 }
 
 // Float32 returns value as float32 number
-func (n Number) Float32() float32 {
+func (n *Number) Float32() float32 {
 	return float32(n.Float64())
 }
 
 // Int returns value as integer number
-func (n Number) Int() int {
-	return int(n.mantissa)
+func (n *Number) Int() int {
+	return int(n.Int64())
 }
 
-// Int64 returns value as int64 number
-func (n Number) Int64() int64 {
-	return n.mantissa
+// Int64 returns value as int64 number.
+//
+// Values out of int64 range are clamped.
+func (n *Number) Int64() int64 {
+	v, _ := n.int64Value()
+	return v
 }
 
 // Int32 returns value as int32 number
-func (n Number) Int32() int32 {
-	return int32(n.mantissa)
+func (n *Number) Int32() int32 {
+	return int32(n.Int64())
 }
 
-// Uint returns value as unsigned integer number
-func (n Number) Uint() uint {
-	return uint(n.mantissa)
+// Uint returns value as unsigned integer number, see Uint64.
+func (n *Number) Uint() uint {
+	return uint(n.Uint64())
 }
 
-// Uint32 returns value as uint32 number
-func (n Number) Uint32() uint32 {
-	return uint32(n.mantissa)
+// Uint32 returns value as uint32 number, see Uint64.
+func (n *Number) Uint32() uint32 {
+	return uint32(n.Uint64())
 }
 
-// Uint64 returns value as uint64 number
-func (n Number) Uint64() uint64 {
-	return uint64(n.mantissa)
+// Uint64 returns value as uint64 number.
+//
+// Values above uint64 range are clamped, negative values are converted from Int64.
+func (n *Number) Uint64() uint64 {
+	if v, ok := n.uint64Value(); ok {
+		return v
+	}
+
+	if i := n.Int64(); i < 0 {
+		return uint64(i)
+	}
+	return math.MaxUint64
+}
+
+// int64Value returns integer part of number.
+//
+// Returns clamped value and false if value is out of int64 range.
+func (n *Number) int64Value() (int64, bool) {
+	switch {
+	case n == nil:
+		return 0, true
+	case n.decimal != "":
+		// mantissa of such numbers is clamped
+		return decimalIntPart(n.decimal)
+	case n.hasFloat:
+		if n.float >= maxInt64Float || n.float < -maxInt64Float {
+			return clampInt64(n.float < 0), false
+		}
+		return int64(n.float), true
+	}
+
+	negative := n.IsSigned || n.mantissa < 0
+	v, ok := n.integerPart()
+	switch {
+	case negative && (!ok || v > 1<<63):
+		return math.MinInt64, false
+	case negative:
+		return -int64(v), true
+	case !ok || v > math.MaxInt64:
+		return math.MaxInt64, false
+	default:
+		return int64(v), true
+	}
+}
+
+// uint64Value returns integer part of number.
+//
+// Returns false if value is out of uint64 range.
+func (n *Number) uint64Value() (uint64, bool) {
+	switch {
+	case n == nil:
+		return 0, true
+	case n.decimal != "":
+		i, ok := decimalInteger(n.decimal)
+		if !ok || i.Sign() < 0 || !i.IsUint64() {
+			return 0, false
+		}
+		return i.Uint64(), true
+	case n.hasFloat:
+		if n.float <= -1 || n.float >= maxUint64Float {
+			return 0, false
+		}
+		return uint64(n.float), true
+	}
+
+	v, ok := n.integerPart()
+	if !ok || (v != 0 && (n.IsSigned || n.mantissa < 0)) {
+		return 0, false
+	}
+	return v, true
+}
+
+// integerPart returns absolute value of integer part of number computed from its fields.
+//
+// Returns false if value is out of uint64 range.
+func (n *Number) integerPart() (uint64, bool) {
+	// two's complement negation is correct for math.MinInt64 too
+	mantissa := uint64(n.mantissa)
+	if n.mantissa < 0 {
+		mantissa = -mantissa
+	}
+
+	if n.exp10 <= 0 {
+		// fraction part doesn't affect integer part
+		return divPow10(mantissa, -n.exp10), true
+	}
+
+	scale := n.exp10 - n.expoLen
+	if scale < 0 {
+		// only first digits of fraction move to integer part
+		intPart, ok := mulPow10(mantissa, n.exp10)
+		sum, carry := bits.Add64(intPart, divPow10(n.exponent, -scale), 0)
+		return sum, ok && carry == 0
+	}
+
+	digits, ok := mulPow10(mantissa, n.expoLen)
+	digits, carry := bits.Add64(digits, n.exponent, 0)
+	if !ok || carry != 0 {
+		return 0, false
+	}
+	return mulPow10(digits, scale)
+}
+
+// pow10 contains powers of 10 which fit into uint64
+var pow10 = [...]uint64{
+	1, 1e1, 1e2, 1e3, 1e4, 1e5, 1e6, 1e7, 1e8, 1e9,
+	1e10, 1e11, 1e12, 1e13, 1e14, 1e15, 1e16, 1e17, 1e18, 1e19,
+}
+
+// mulPow10 returns v * 10^exp, returns false on overflow.
+func mulPow10(v uint64, exp int) (uint64, bool) {
+	if v == 0 {
+		return 0, true
+	}
+	if exp >= len(pow10) {
+		return 0, false
+	}
+
+	hi, lo := bits.Mul64(v, pow10[exp])
+	return lo, hi == 0
+}
+
+// divPow10 returns v / 10^exp.
+func divPow10(v uint64, exp int) uint64 {
+	if exp >= len(pow10) {
+		// any uint64 value is less than 10^20
+		return 0
+	}
+	return v / pow10[exp]
 }
@@ -0,0 +1,94 @@
+package jsonreflect
+
+const (
+	defaultMaxNumberDigits   = 1000
+	defaultMaxNumberExponent = 9999
+)
+
+// MaxNumberDigits limits count of digits before exponent mark in numbers, 1000 by default.
+//
+// Parser returns *NumberRangeError for numbers with more digits.
+// Zero or negative value disables the limit.
+func MaxNumberDigits(n int) ParserOption {
+	return func(p *Parser) {
+		p.maxNumberDigits = limitValue(n)
+	}
+}
+
+// MaxNumberExponent limits absolute value of exponent in numbers like 1e300, 9999 by default.
+//
+// Parser returns *NumberRangeError for numbers with larger exponent.
+// Numbers which exceed float64 range are kept as exact decimal text, see Number.Float64.
+// Zero or negative value disables the limit.
+func MaxNumberExponent(n int) ParserOption {
+	return func(p *Parser) {
+		p.maxNumberExponent = limitValue(n)
+	}
+}
+
+// limitValue converts option value to limit field.
+//
+// Zero or negative value disables the limit and is stored as -1,
+// as zero field means default limit.
+func limitValue(n int) int {
+	if n <= 0 {
+		return -1
+	}
+	return n
+}
+
+// checkNumberLimits checks number text against parser limits.
+//
+// Mark is offset of exponent mark in text or negative value if there is no exponent.
+func (p Parser) checkNumberLimits(pos Position, text []byte, mark int) error {
+	base, exp := text, []byte(nil)
+	if mark >= 0 {
+		base, exp = text[:mark], text[mark+1:]
+	}
+
+	maxDigits := p.maxNumberDigits
+	if maxDigits == 0 {
+		maxDigits = defaultMaxNumberDigits
+	}
+
+	if maxDigits > 0 {
+		digits := 0
+		for _, c := range base {
+			if c >= '0' && c <= '9' {
+				digits++
+			}
+		}
+
+		if digits > maxDigits {
+			return newNumberRangeError(pos, string(text), "more than %d digits", maxDigits)
+		}
+	}
+
+	maxExp := p.maxNumberExponent
+	if maxExp == 0 {
+		maxExp = defaultMaxNumberExponent
+	}
+
+	if maxExp < 0 || len(exp) == 0 {
+		return nil
+	}
+
+	if exp[0] == '+' || exp[0] == charNumberNegative {
+		exp = exp[1:]
+	}
+
+	// value is accumulated only until limit is exceeded, so long exponents don't overflow
+	value := 0
+	for _, c := range exp {
+		if c < '0' || c > '9' {
+			// invalid numbers are reported by parser
+			return nil
+		}
+
+		value = value*10 + int(c-'0')
+		if value > maxExp {
+			return newNumberRangeError(pos, string(text), "exponent exceeds %d", maxExp)
+		}
+	}
+	return nil
+}
@@ -1,37 +1,95 @@
 package jsonreflect
 
 import (
+	"fmt"
 	"io"
 	"sort"
+	"strconv"
+	"strings"
 )
 
+// Member is object key-value pair
+type Member struct {
+	// Key is object key
+	Key string
+
+	// KeyPos is key declaration position.
+	//
+	// Zero for keys which are not present in source.
+	KeyPos Position
+
+	// Value is key value
+	Value Value
+}
+
 // Object represents key-value pair of object field and value
 type Object struct {
 	baseValue
 
-	// Items is key-value pair of object values
+	// Items is key-value pair of object values.
+	//
+	// Items is nil for compact objects, see CompactObjects.
 	Items map[string]Value
+
+	// members contains unique object members in source order
+	members []Member
+
+	// truncated is set when object has unparsed items, see MaxLeaves
+	truncated bool
+
+	// compact is set when object has no Items map and members are
+	// looked up using index sorted by key, see CompactObjects
+	compact bool
+	index   []int32
 }
 
-func newObject(start, end int, items map[string]Value) *Object {
+func newObject(start, end int, items map[string]Value, members []Member) *Object {
 	return &Object{
 		baseValue: newBaseValue(start, end),
 		Items:     items,
+		members:   members,
 	}
 }
 
 // Type implements jsonreflect.Value
-func (_ Object) Type() Type {
+func (o *Object) Type() Type {
 	return TypeObject
 }
 
+// Ref implements jsonreflect.Value
+func (o *Object) Ref() Position {
+	if o == nil {
+		return Position{}
+	}
+
+	o.gen.check()
+
+	return o.Position
+}
+
+// String implements jsonreflect.Value
+func (o *Object) String() (string, error) {
+	return "", ErrNotStringable
+}
+
 // Keys returns sorted list of object keys
-func (o Object) Keys() []string {
-	if len(o.Items) == 0 {
+func (o *Object) Keys() []string {
+	if o != nil {
+		o.gen.check()
+	}
+
+	if o.Len() == 0 {
 		return nil
 	}
 
-	keys := make([]string, 0, len(o.Items))
+	keys := make([]string, 0, o.Len())
+	if o.compact {
+		for _, i := range o.index {
+			keys = append(keys, o.members[i].Key)
+		}
+		return keys
+	}
+
 	for k := range o.Items {
 		keys = append(keys, k)
 	}
@@ -39,33 +97,141 @@ func (o Object) Keys() []string {
 	return keys
 }
 
+// Members returns list of object members in source order.
+//
+// Values are taken from Items, so members removed from Items are omitted
+// and keys added to Items after parse are appended in sorted order.
+//
+// If key declared multiple times in source, member has position of first
+// declaration and value of last one.
+func (o *Object) Members() []Member {
+	if o != nil {
+		o.gen.check()
+	}
+
+	if o.Len() == 0 {
+		return nil
+	}
+
+	if o.compact {
+		return append([]Member(nil), o.members...)
+	}
+
+	members := make([]Member, 0, len(o.Items))
+	for _, m := range o.members {
+		v, ok := o.Items[m.Key]
+		if !ok {
+			continue
+		}
+
+		members = append(members, Member{Key: m.Key, KeyPos: m.KeyPos, Value: v})
+	}
+
+	if len(members) == len(o.Items) {
+		return members
+	}
+
+	// keys added to Items directly
+	seen := newKeySet(members)
+	added := make([]string, 0, len(o.Items)-len(members))
+	for k := range o.Items {
+		if !seen.contains(k) {
+			added = append(added, k)
+		}
+	}
+
+	sort.Strings(added)
+	for _, k := range added {
+		members = append(members, Member{Key: k, Value: o.Items[k]})
+	}
+	return members
+}
+
+// orderedKeys returns list of object keys in source order.
+func (o *Object) orderedKeys() []string {
+	members := o.Members()
+	if len(members) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(members))
+	for _, m := range members {
+		keys = append(keys, m.Key)
+	}
+	return keys
+}
+
+// set sets key value and keeps keys insertion order
+func (o *Object) set(key string, val Value) {
+	o.MaterializeItems()
+	if o.Items == nil {
+		o.Items = make(map[string]Value)
+	}
+
+	if _, ok := o.Items[key]; !ok {
+		o.members = append(o.members, Member{Key: key, Value: val})
+	}
+	o.Items[key] = val
+}
+
 // HasKey checks if key exists in object
-func (o Object) HasKey(keyName string) bool {
-	_, ok := o.Items[keyName]
+func (o *Object) HasKey(keyName string) bool {
+	if o == nil {
+		return false
+	}
+
+	_, ok := o.Get(keyName)
 	return ok
 }
 
-func (o Object) marshal(w io.Writer, mf *marshalFormatter) error {
-	if len(o.Items) == 0 {
-		return mf.write(w, []byte{tokenObjectStart, tokenObjectClose})
+func (o *Object) marshal(w io.Writer, mf *marshalFormatter) error {
+	if o == nil {
+		return ErrNilValue
 	}
 
+	o.gen.check()
+
+	if o.truncated && (mf == nil || !mf.allowTruncated) {
+		return ErrTruncatedValue
+	}
+
+	if o.Len() == 0 && !o.truncated {
+		// empty value is written in-place, without indentation
+		_, err := w.Write([]byte{tokenObjectStart, tokenObjectClose})
+		return err
+	}
+
+	if err := mf.enter(o); err != nil {
+		return err
+	}
+	defer mf.leave()
+
 	err := mf.writeOpenClause(w, tokenObjectStart)
 	if err != nil {
 		return err
 	}
 
-	keys := o.Keys()
+	members := o.Members()
+	if mf != nil && mf.sortKeys {
+		sort.SliceStable(members, func(i, j int) bool {
+			return members[i].Key < members[j].Key
+		})
+	}
+
 	childFmt := mf.childFormatter()
-	lastIndex := len(keys) - 1
-	for i, key := range keys {
-		value := o.Items[key]
-		err = childFmt.writePropertyName(w, key)
+	lastIndex := len(members) - 1
+	if o.truncated {
+		// marker is the last item
+		lastIndex++
+	}
+
+	for i, m := range members {
+		err = childFmt.writePropertyName(w, m.Key)
 		if err != nil {
 			return err
 		}
 
-		err = value.marshal(w, childFmt)
+		err = marshalValue(w, m.Value, childFmt)
 		if err != nil {
 			return err
 		}
@@ -76,19 +242,118 @@ func (o Object) marshal(w io.Writer, mf *marshalFormatter) error {
 		}
 	}
 
+	if o.truncated {
+		if err = childFmt.writePropertyName(w, truncatedMarker); err != nil {
+			return err
+		}
+
+		if _, err = w.Write(nullVal); err != nil {
+			return err
+		}
+
+		if err = mf.writeElementDelimiter(w, true); err != nil {
+			return err
+		}
+	}
+
 	return mf.write(w, []byte{tokenObjectClose})
 }
 
 // ToMap returns key-value pair of items as interface value
-func (o Object) ToMap() map[string]interface{} {
-	m := make(map[string]interface{}, len(o.Items))
+func (o *Object) ToMap() map[string]interface{} {
+	if o == nil {
+		return nil
+	}
+
+	m := make(map[string]interface{}, o.Len())
+	if o.compact {
+		for _, member := range o.members {
+			m[member.Key] = member.Value.Interface()
+		}
+		return m
+	}
+
 	for k, v := range o.Items {
 		m[k] = v.Interface()
 	}
 	return m
 }
 
+// ToArray converts object with keys which are decimal indexes from 0 to n-1,
+// like {"0": "a", "1": "b"}, to array with values ordered by index.
+//
+// Keys with leading zeros, signs or other characters are not indexes.
+// Array items are values of object, values are not copied.
+//
+// Returns error listing missing indexes, indexes out of range and invalid keys
+// if object is not an array.
+func (o *Object) ToArray() (*Array, error) {
+	if o == nil {
+		return nil, nil
+	}
+
+	o.gen.check()
+
+	members := o.Members()
+	items := make([]Value, len(members))
+	var outOfRange, invalid []string
+	for _, m := range members {
+		if !isDecimalIndex(m.Key) {
+			invalid = append(invalid, strconv.Quote(m.Key))
+			continue
+		}
+
+		i, err := strconv.Atoi(m.Key)
+		if err != nil || i >= len(items) {
+			outOfRange = append(outOfRange, m.Key)
+			continue
+		}
+		items[i] = m.Value
+	}
+
+	if len(invalid) == 0 && len(outOfRange) == 0 {
+		return &Array{baseValue: baseValue{Position: o.Position}, Length: len(items), Items: items}, nil
+	}
+
+	var missing []string
+	for i, item := range items {
+		if item == nil {
+			missing = append(missing, strconv.Itoa(i))
+		}
+	}
+
+	// each misplaced key leaves a missing index
+	reasons := []string{"missing indexes " + strings.Join(missing, ", ")}
+	if len(outOfRange) > 0 {
+		reasons = append(reasons, "indexes out of range "+strings.Join(outOfRange, ", "))
+	}
+	if len(invalid) > 0 {
+		reasons = append(reasons, "invalid keys "+strings.Join(invalid, ", "))
+	}
+	return nil, fmt.Errorf("object is not an array: %s", strings.Join(reasons, "; "))
+}
+
+// isDecimalIndex checks if key is a decimal number without sign and leading zeros.
+func isDecimalIndex(key string) bool {
+	if key == "" || (key[0] == '0' && len(key) > 1) {
+		return false
+	}
+
+	for i := 0; i < len(key); i++ {
+		if key[i] < '0' || key[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
 // Interface() implements json.Value
-func (o Object) Interface() interface{} {
+func (o *Object) Interface() interface{} {
+	if o == nil {
+		return nil
+	}
+
+	o.gen.check()
+
 	return o.ToMap()
 }
@@ -0,0 +1,82 @@
+package jsonreflect
+
+import "reflect"
+
+// OrphanReport is a summary of keys received by orphan collectors, see OrphanStats.
+type OrphanReport struct {
+	// Count is total count of keys in all entries.
+	Count int
+
+	// Entries contains a record per orphan collector which received
+	// at least one key, in unmarshal order.
+	Entries []OrphanEntry
+}
+
+// OrphanEntry describes keys received by a single orphan collector.
+type OrphanEntry struct {
+	// Path is path to source object in dotted form. Empty for root value.
+	Path string
+
+	// Type is struct type which owns orphan collector.
+	Type reflect.Type
+
+	// Field is orphan collector field name.
+	Field string
+
+	// Keys are received keys in source order.
+	Keys []string
+}
+
+// ByType returns count of received orphan keys per struct type.
+func (r *OrphanReport) ByType() map[reflect.Type]int {
+	if r == nil {
+		return nil
+	}
+
+	m := make(map[reflect.Type]int, len(r.Entries))
+	for _, e := range r.Entries {
+		m[e.Type] += len(e.Keys)
+	}
+	return m
+}
+
+// OrphanStats fills report with keys received by `json:"..."` orphan collectors.
+//
+// Report is reset on each unmarshal call. Struct orphan collector passes keys
+// not consumed by its fields to its own collector, so such keys are listed in both entries.
+//
+// Useful to detect new source keys which are not mapped to struct fields yet.
+func OrphanStats(report *OrphanReport) UnmarshalOption {
+	return func(p *unmarshalParams) {
+		if report != nil {
+			*report = OrphanReport{}
+		}
+		p.orphanReport = report
+	}
+}
+
+// reportOrphans records keys of source object which are passed to orphan collector.
+func (p unmarshalParams) reportOrphans(touchedKeys *keySet, structType reflect.Type, field string) {
+	if p.orphanReport == nil {
+		return
+	}
+
+	var keys []string
+	for i, m := range touchedKeys.members {
+		if !touchedKeys.marked(i) {
+			keys = append(keys, m.Key)
+		}
+	}
+
+	if len(keys) == 0 {
+		return
+	}
+
+	p.orphanReport.Count += len(keys)
+	p.orphanReport.Entries = append(p.orphanReport.Entries, OrphanEntry{
+		Path:  formatPath(p.path),
+		Type:  structType,
+		Field: field,
+		Keys:  keys,
+	})
+}
@@ -0,0 +1,66 @@
+package jsonreflect
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrphanStats(t *testing.T) {
+	type meta struct {
+		Author string            `json:"author"`
+		Rest   map[string]string `json:"..."`
+	}
+
+	type extra struct {
+		Version int   `json:"version"`
+		Meta    *meta `json:"..."`
+	}
+
+	type Base struct {
+		Kind string                 `json:"kind"`
+		Rest map[string]interface{} `json:"..."`
+	}
+
+	type item struct {
+		Base
+		Name string `json:"name"`
+	}
+
+	type dst struct {
+		ID    int    `json:"id"`
+		Items []item `json:"items"`
+		Extra extra  `json:"..."`
+	}
+
+	src := []byte(`{
+		"id": 1, "version": 2, "author": "foo", "tag": "bar",
+		"items": [{"kind": "a", "name": "x", "size": 3}, {"kind": "b"}]
+	}`)
+
+	report := OrphanReport{Count: 100}
+	require.NoError(t, Unmarshal(src, new(dst), OrphanStats(&report)))
+	require.Equal(t, OrphanReport{
+		Count: 8,
+		Entries: []OrphanEntry{
+			// embedded struct collector doesn't see keys of outer struct fields
+			{Path: "items[0]", Type: reflect.TypeOf(Base{}), Field: "Rest", Keys: []string{"name", "size"}},
+			{Path: "", Type: reflect.TypeOf(dst{}), Field: "Extra", Keys: []string{"version", "author", "tag"}},
+			{Path: "", Type: reflect.TypeOf(extra{}), Field: "Meta", Keys: []string{"author", "tag"}},
+			{Path: "", Type: reflect.TypeOf(meta{}), Field: "Rest", Keys: []string{"tag"}},
+		},
+	}, report)
+
+	require.Equal(t, map[reflect.Type]int{
+		reflect.TypeOf(Base{}):  2,
+		reflect.TypeOf(dst{}):   3,
+		reflect.TypeOf(extra{}): 2,
+		reflect.TypeOf(meta{}):  1,
+	}, report.ByType())
+
+	// report is reset on each call
+	require.NoError(t, Unmarshal([]byte(`{"id": 1}`), new(dst), OrphanStats(&report)))
+	require.Equal(t, OrphanReport{}, report)
+	require.NoError(t, Unmarshal(src, new(dst), OrphanStats(nil)))
+}
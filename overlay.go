@@ -0,0 +1,178 @@
+package jsonreflect
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const defaultOverlaySeparator = "."
+
+type overlayNode struct {
+	// key is source key of a leaf node
+	key      string
+	value    Value
+	children map[string]*overlayNode
+}
+
+func (n *overlayNode) isLeaf() bool {
+	return n.value != nil
+}
+
+// OverlayFromPairs builds an object from flat key-value pairs
+// like environment variables or command line flags.
+//
+// Keys are split by separator into nested object keys, "." is used if separator is empty.
+// Values are converted into scalar values using InferScalar.
+//
+// Object with only array indexes as keys is converted into an array,
+// for example "hosts.0" and "hosts.1" keys produce "hosts" array with 2 items.
+// Array indexes should be contiguous and start from zero.
+//
+// Example:
+//
+//	obj, err := OverlayFromPairs(map[string]string{
+//		"server.port": "8080",
+//		"debug":       "true",
+//	}, ".")
+//
+// Result:
+//
+//	{"debug": true, "server": {"port": 8080}}
+func OverlayFromPairs(pairs map[string]string, sep string) (*Object, error) {
+	if sep == "" {
+		sep = defaultOverlaySeparator
+	}
+
+	keys := make([]string, 0, len(pairs))
+	for k := range pairs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	root := &overlayNode{children: make(map[string]*overlayNode)}
+	for _, key := range keys {
+		if err := root.insert(key, strings.Split(key, sep), InferScalar(pairs[key])); err != nil {
+			return nil, fmt.Errorf("%s=%q: %w", key, pairs[key], err)
+		}
+	}
+
+	v, err := root.toValue(nil)
+	if err != nil {
+		return nil, err
+	}
+	return v.(*Object), nil
+}
+
+func (n *overlayNode) insert(key string, segments []string, value Value) error {
+	node := n
+	for i, seg := range segments {
+		if seg == "" {
+			return fmt.Errorf("empty key segment at position %d", i)
+		}
+
+		child, ok := node.children[seg]
+		if i == len(segments)-1 {
+			if ok && child.isLeaf() {
+				return fmt.Errorf("value conflicts with value of %q", child.key)
+			}
+			if ok {
+				return fmt.Errorf("value conflicts with nested key %q", child.firstKey())
+			}
+
+			node.children[seg] = &overlayNode{key: key, value: value}
+			return nil
+		}
+
+		if !ok {
+			child = &overlayNode{children: make(map[string]*overlayNode)}
+			node.children[seg] = child
+		}
+
+		if child.isLeaf() {
+			return fmt.Errorf("key conflicts with value of %q", child.key)
+		}
+		node = child
+	}
+	return nil
+}
+
+// firstKey returns source key of the first leaf in sorted order.
+func (n *overlayNode) firstKey() string {
+	for !n.isLeaf() {
+		keys := make([]string, 0, len(n.children))
+		for k := range n.children {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		n = n.children[keys[0]]
+	}
+	return n.key
+}
+
+func (n *overlayNode) toValue(path []pathSegment) (Value, error) {
+	if n.isLeaf() {
+		return n.value, nil
+	}
+
+	keys := make([]string, 0, len(n.children))
+	for k := range n.children {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	if len(path) > 0 && isArrayIndexes(keys) {
+		return n.toArray(path, keys)
+	}
+
+	obj := NewObject(make(map[string]Value, len(keys)))
+	for _, k := range keys {
+		item, err := n.children[k].toValue(appendPathSegment(path, pathSegment{key: k}))
+		if err != nil {
+			return nil, err
+		}
+		obj.set(k, item)
+	}
+	return obj, nil
+}
+
+func (n *overlayNode) toArray(path []pathSegment, keys []string) (Value, error) {
+	indexes := make([]int, 0, len(keys))
+	for _, k := range keys {
+		i, _ := strconv.Atoi(k)
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	items := make([]Value, 0, len(indexes))
+	for i, index := range indexes {
+		if index != i {
+			if i == 0 {
+				return nil, fmt.Errorf("%s: array indexes should be contiguous and start from zero (first index is %d)",
+					formatPath(path), index)
+			}
+
+			return nil, fmt.Errorf("%s: array indexes should be contiguous and start from zero (index %d follows index %d)",
+				formatPath(path), index, indexes[i-1])
+		}
+
+		item, err := n.children[strconv.Itoa(index)].toValue(appendPathSegment(path, pathSegment{index: index, isIndex: true}))
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return NewArray(items...), nil
+}
+
+// isArrayIndexes reports whether all keys are canonical non-negative integers.
+func isArrayIndexes(keys []string) bool {
+	for _, k := range keys {
+		i, err := strconv.Atoi(k)
+		if err != nil || i < 0 || strconv.Itoa(i) != k {
+			return false
+		}
+	}
+	return len(keys) > 0
+}
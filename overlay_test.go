@@ -0,0 +1,96 @@
+package jsonreflect
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	. "github.com/x1unix/jsonreflect/internal/testutil"
+)
+
+func TestOverlayFromPairs(t *testing.T) {
+	cases := map[string]struct {
+		pairs   map[string]string
+		sep     string
+		want    string
+		wantErr ExpectedError
+	}{
+		"empty": {
+			want: `{}`,
+		},
+		"nested typed values": {
+			pairs: map[string]string{
+				"server.port": "8080",
+				"server.host": "localhost",
+				"debug":       "true",
+				"proxy":       "null",
+			},
+			want: `{"debug":true,"proxy":null,"server":{"host":"localhost","port":8080}}`,
+		},
+		"arrays": {
+			pairs: map[string]string{
+				"hosts.0":      "a",
+				"hosts.1":      "b",
+				"hosts.2.port": "1",
+				"hosts.10":     "x",
+				"hosts.3":      "c",
+				"hosts.4":      "d",
+				"hosts.5":      "e",
+				"hosts.6":      "f",
+				"hosts.7":      "g",
+				"hosts.8":      "h",
+				"hosts.9":      "i",
+			},
+			want: `{"hosts":["a","b",{"port":1},"c","d","e","f","g","h","i","x"]}`,
+		},
+		"custom separator": {
+			pairs: map[string]string{"APP__DB__PORT": "5432", "APP__NAME": "svc"},
+			sep:   "__",
+			want:  `{"APP":{"DB":{"PORT":5432},"NAME":"svc"}}`,
+		},
+		"numeric root keys": {
+			pairs: map[string]string{"0": "a"},
+			want:  `{"0":"a"}`,
+		},
+		"mixed keys": {
+			pairs: map[string]string{"a.0": "1", "a.b": "2"},
+			want:  `{"a":{"0":1,"b":2}}`,
+		},
+		"non-canonical index": {
+			pairs: map[string]string{"a.01": "1"},
+			want:  `{"a":{"01":1}}`,
+		},
+		"index gap": {
+			pairs:   map[string]string{"hosts.0": "a", "hosts.2": "b"},
+			wantErr: `hosts: array indexes should be contiguous and start from zero (index 2 follows index 0)`,
+		},
+		"nested index gap": {
+			pairs:   map[string]string{"a.0.b.1": "a", "a.0.b.10": "b", "a.0.b.0": "c"},
+			wantErr: `a[0].b: array indexes should be contiguous and start from zero (index 10 follows index 1)`,
+		},
+		"missing first index": {
+			pairs:   map[string]string{"hosts.1": "a"},
+			wantErr: `hosts: array indexes should be contiguous and start from zero (first index is 1)`,
+		},
+		"value and nested keys": {
+			pairs:   map[string]string{"server": "x", "server.port": "1"},
+			wantErr: `server.port="1": key conflicts with value of "server"`,
+		},
+		"empty segment": {
+			pairs:   map[string]string{"server..port": "1"},
+			wantErr: `server..port="1": empty key segment at position 1`,
+		},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			got, err := OverlayFromPairs(c.pairs, c.sep)
+			if !c.wantErr.AssertError(t, err) {
+				return
+			}
+
+			data, err := MarshalValueOpts(got, WithSortedKeys())
+			require.NoError(t, err)
+			require.Equal(t, c.want, string(data))
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package jsonreflect
+
+import "bytes"
+
+// TrimNulPadding makes parser ignore trailing NUL bytes of source.
+//
+// Some message queues deliver payloads padded with NUL bytes,
+// which are rejected by default.
+func TrimNulPadding() ParserOption {
+	return func(p *Parser) {
+		p.trimNul = true
+	}
+}
+
+// ScanToFirstValue makes parser skip leading bytes of source
+// until the first object or array start token.
+//
+// Useful for payloads with framing junk before JSON.
+// Source is scanned only if it doesn't start with a value after leading whitespace,
+// so documents with scalar root value are parsed as-is, but scalar after junk is not detected.
+// Source is not changed if it has no object or array start token.
+//
+// Value positions are relative to the original source.
+// Count of skipped bytes is returned by Parser.Skipped and stored in Document.Skipped.
+func ScanToFirstValue() ParserOption {
+	return func(p *Parser) {
+		p.scanToValue = true
+	}
+}
+
+// Skipped returns count of leading source bytes skipped by ScanToFirstValue option.
+//
+// Byte order mark and leading whitespace are not counted.
+func (p *Parser) Skipped() int {
+	return p.skipped
+}
+
+// trimSource applies TrimNulPadding and ScanToFirstValue options to source.
+func (p *Parser) trimSource() {
+	if p.trimNul {
+		p.src = bytes.TrimRight(p.src, "\x00")
+		p.end = len(p.src)
+	}
+
+	p.skipped = 0
+	if !p.scanToValue {
+		return
+	}
+
+	start, ok := p.getPosUntilNextNonDelimiter(p.start)
+	if !ok || isValueStart(p.src[start]) {
+		return
+	}
+
+	i := bytes.IndexAny(p.src[start:p.end], "{[")
+	if i == -1 {
+		return
+	}
+
+	p.skipped = i
+	p.start = start + i
+	p.offset = p.start
+}
+
+// isValueStart reports whether character can start a JSON value.
+func isValueStart(char byte) bool {
+	switch char {
+	case tokenObjectStart, tokenArrayStart, tokenString, charNumberNegative, 't', 'f', 'n':
+		return true
+	default:
+		return '0' <= char && char <= '9'
+	}
+}
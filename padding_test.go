@@ -0,0 +1,107 @@
+package jsonreflect
+
+import (
+	"testing"
+
+	. "github.com/x1unix/jsonreflect/internal/testutil"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParser_SourceGarbage(t *testing.T) {
+	cases := map[string]struct {
+		src         string
+		opts        []ParserOption
+		wantErr     ExpectedError
+		want        interface{}
+		wantPos     Position
+		wantSkipped int
+	}{
+		"trailing nul is rejected by default": {
+			src:     "{\"a\": 1}\x00\x00",
+			wantErr: `unexpected "\x00\x00"`,
+		},
+		"trailing nul": {
+			src:     "{\"a\": 1}\x00\x00\x00",
+			opts:    []ParserOption{TrimNulPadding()},
+			want:    map[string]interface{}{"a": 1},
+			wantPos: newPosition(0, 7),
+		},
+		"nul only": {
+			src:  "\x00\x00",
+			opts: []ParserOption{TrimNulPadding()},
+		},
+		"leading junk is rejected by default": {
+			src:     "\x02\x10ab[1, 2]",
+			wantErr: "unexpected character",
+		},
+		"leading junk": {
+			src:         "\x02\x10ab[1, 2]",
+			opts:        []ParserOption{ScanToFirstValue()},
+			want:        []interface{}{1, 2},
+			wantPos:     newPosition(4, 9),
+			wantSkipped: 4,
+		},
+		"leading junk after bom": {
+			src:         "\xef\xbb\xbf\x01{\"a\": true}",
+			opts:        []ParserOption{ScanToFirstValue()},
+			want:        map[string]interface{}{"a": true},
+			wantPos:     newPosition(4, 14),
+			wantSkipped: 1,
+		},
+		"leading and trailing junk": {
+			src:         "\x00\x00\x01{}\x00",
+			opts:        []ParserOption{ScanToFirstValue(), TrimNulPadding()},
+			want:        map[string]interface{}{},
+			wantPos:     newPosition(3, 4),
+			wantSkipped: 3,
+		},
+		"leading whitespace and junk": {
+			src:         " \n\x01 [1]",
+			opts:        []ParserOption{ScanToFirstValue()},
+			want:        []interface{}{1},
+			wantPos:     newPosition(4, 6),
+			wantSkipped: 2,
+		},
+		"scalar root": {
+			src:     ` "{}"`,
+			opts:    []ParserOption{ScanToFirstValue()},
+			want:    "{}",
+			wantPos: newPosition(1, 4),
+		},
+		"scalar root with brackets": {
+			src:     `"a[1]"`,
+			opts:    []ParserOption{ScanToFirstValue()},
+			want:    "a[1]",
+			wantPos: newPosition(0, 5),
+		},
+		"no value start": {
+			src:     "\x01\x02 42",
+			opts:    []ParserOption{ScanToFirstValue()},
+			wantErr: "unexpected character",
+		},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			p := NewParser([]byte(c.src), c.opts...)
+			got, err := p.Parse()
+			if !c.wantErr.AssertError(t, err) {
+				return
+			}
+
+			require.Equal(t, c.wantSkipped, p.Skipped())
+			if c.want == nil {
+				require.Nil(t, got)
+				return
+			}
+
+			require.Equal(t, c.want, got.Interface())
+			require.Equal(t, c.wantPos, got.Ref())
+
+			doc, err := NewDocument([]byte(c.src), c.opts...)
+			require.NoError(t, err)
+			require.Equal(t, c.wantSkipped, doc.Skipped)
+		})
+	}
+}
@@ -1,8 +1,10 @@
 package jsonreflect
 
 import (
+	"bytes"
 	"io"
 	"io/ioutil"
+	"strings"
 	"unicode"
 )
 
@@ -29,27 +31,115 @@ const (
 	charNumberNegative = '-'
 )
 
+// TransformFunc is parsed value transformer.
+//
+// Transformer receives parsed value and returns a value which should replace it.
+type TransformFunc = func(v Value) (Value, error)
+
+// ParserOption is parser option
+type ParserOption func(p *Parser)
+
+// WithTransformer adds a transformer which is applied to every parsed value.
+//
+// Transformers are applied bottom-up, so nested values are transformed before parent.
+// Object keys are not transformed.
+//
+// Custom value types can embed original value and implement Marshaler
+// to customize serialization.
+func WithTransformer(fn TransformFunc) ParserOption {
+	return func(p *Parser) {
+		p.transformers = append(p.transformers, fn)
+	}
+}
+
 // Parser is JSON parser
 type Parser struct {
-	src []byte
-	end int
+	src          []byte
+	end          int
+	transformers []TransformFunc
+
+	// offset is ParseNext cursor position
+	offset int
+
+	// count is count of values returned by ParseNext
+	count int
+
+	// start is document start position after byte order mark
+	start int
+
+	// encoding is detected source encoding
+	encoding Encoding
+
+	// leaves is scalar values budget, see MaxLeaves
+	leaves *leafBudget
+
+	// compactObjects disables Object.Items map, see CompactObjects
+	compactObjects bool
+
+	// numbers is numbers slab, see CompactNumbers
+	numbers *numberSlab
+
+	// arena is values memory of document, see ParseInto
+	arena *valueArena
+
+	// pool is scratch buffers pool, see WithParserPool
+	pool Pool
+
+	// trimNul enables TrimNulPadding option
+	trimNul bool
+
+	// scanToValue enables ScanToFirstValue option
+	scanToValue bool
+
+	// skipped is count of leading bytes skipped by ScanToFirstValue
+	skipped int
+
+	// maxNumberDigits and maxNumberExponent are number limits,
+	// zero means default limit and negative value disables the limit.
+	maxNumberDigits   int
+	maxNumberExponent int
 }
 
 // NewParser creates a new parser instance
-func NewParser(src []byte) *Parser {
-	return &Parser{
+func NewParser(src []byte, opts ...ParserOption) *Parser {
+	p := &Parser{
 		src: src,
 		end: len(src),
 	}
+
+	enc, hasBOM := DetectEncoding(src)
+	p.encoding = enc
+	if enc == EncodingUTF8 && hasBOM {
+		p.start = len(bomUTF8)
+		p.offset = p.start
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	p.trimSource()
+	return p
 }
 
 // NewParserFromReader reads data from passed reader and returns reader instance
-func NewParserFromReader(r io.Reader) (*Parser, error) {
+func NewParserFromReader(r io.Reader, opts ...ParserOption) (*Parser, error) {
 	data, err := ioutil.ReadAll(r)
 	if err != nil {
 		return nil, err
 	}
-	return NewParser(data), nil
+	return NewParser(data, opts...), nil
+}
+
+// checkEncoding returns an error if source is not UTF-8 encoded.
+//
+// UTF-16 and UTF-32 documents should be transcoded with ToUTF8 before parsing.
+func (p Parser) checkEncoding() error {
+	if p.encoding == EncodingUTF8 {
+		return nil
+	}
+	return NewParseError(newPosition(0, 0),
+		"unsupported document encoding %s, document should be transcoded to UTF-8", p.encoding)
 }
 
 func (p Parser) hasElem(idx int) bool {
@@ -63,7 +153,11 @@ func (p Parser) hasElem(idx int) bool {
 //
 // If passed JSON is empty, a nil value returned
 func (p *Parser) Parse() (Value, error) {
-	v, err := p.parseValue(0, true)
+	if err := p.checkEncoding(); err != nil {
+		return nil, err
+	}
+
+	v, pos, err := p.parseValue(p.start, true)
 	if err != nil {
 		return nil, err
 	}
@@ -73,8 +167,12 @@ func (p *Parser) Parse() (Value, error) {
 		return nil, nil
 	}
 
+	if p.leaves.isExhausted() {
+		// rest of document is skipped
+		return v, nil
+	}
+
 	// throw error if something left after JSON contents
-	pos := v.Ref()
 	if p.end > pos.End {
 		got, ok := p.getPosUntilNextNonDelimiter(pos.End + 1)
 		if ok {
@@ -84,6 +182,41 @@ func (p *Parser) Parse() (Value, error) {
 	return v, nil
 }
 
+// ParseNext parses next value from a stream of concatenated JSON values
+// like JSON Lines document.
+//
+// Returns io.EOF if there are no more values.
+// Cursor is not moved if value is malformed.
+func (p *Parser) ParseNext() (Value, error) {
+	if err := p.checkEncoding(); err != nil {
+		return nil, err
+	}
+
+	v, pos, err := p.parseValue(p.offset, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if v == nil {
+		p.offset = p.end
+		return nil, io.EOF
+	}
+
+	p.offset = pos.End + 1
+	if p.leaves.isExhausted() {
+		// position of next value is unknown
+		p.offset = p.end
+	}
+
+	p.count++
+	return v, nil
+}
+
+// Offset returns position in source after last value returned by ParseNext.
+func (p *Parser) Offset() int {
+	return p.offset
+}
+
 func (p Parser) getStartTokenAtPos(start int) (token, int, bool) {
 	for i := start; i < p.end; i++ {
 		switch t := p.src[i]; t {
@@ -101,7 +234,35 @@ func (p Parser) getStartTokenAtPos(start int) (token, int, bool) {
 	return 0, start, true
 }
 
-func (p *Parser) parseValue(start int, root bool) (Value, error) {
+// parseValue parses value at start position and applies transformers to it.
+//
+// Returns value and its original position in source.
+func (p Parser) parseValue(start int, root bool) (Value, Position, error) {
+	v, err := p.decodeValue(start, root)
+	if err != nil || v == nil {
+		return nil, Position{}, err
+	}
+
+	pos := v.Ref()
+	p.leaves.add(v)
+	if !Truncated(v) {
+		setRaw(v, p.src[pos.Start:pos.End+1])
+	}
+
+	for _, fn := range p.transformers {
+		v, err = fn(v)
+		if err != nil {
+			return nil, pos, NewParseError(pos, "transform failed: %s", err)
+		}
+
+		if v == nil {
+			return nil, pos, NewParseError(pos, "transformer returned nil value")
+		}
+	}
+	return v, pos, nil
+}
+
+func (p Parser) decodeValue(start int, root bool) (Value, error) {
 	tkn, pos, end := p.getStartTokenAtPos(start)
 	if end {
 		// return nil for empty document
@@ -126,23 +287,34 @@ const (
 	objectExpectKey = iota
 	objectExpectDelimiter
 	objectExpectValue
+	objectExpectComma
 )
 
 func (p Parser) decodeObject(start int) (*Object, error) {
-	var lastKey string
-	elems := make(map[string]Value, 0)
+	var (
+		lastKey    string
+		lastKeyPos Position
+		members    []Member
+	)
+	var elems map[string]Value
+	if !p.compactObjects {
+		elems = p.newItemsMap()
+	}
+
+	mark := p.scratchMark()
+
 	curPos := start + 1 // next element should be after "{"
 	expect := objectExpectKey
 	hadComma := false
 loop:
 	for {
 		if !p.hasElem(curPos) {
-			return nil, NewParseError(newPosition(start, curPos), "unterminated object")
+			return nil, NewUnexpectedEOFError(newPosition(start, curPos), "unterminated object")
 		}
 
 		pos, ok := p.getPosUntilNextNonDelimiter(curPos)
 		if !ok {
-			return nil, NewParseError(newPosition(start, curPos), "unterminated object")
+			return nil, NewUnexpectedEOFError(newPosition(start, curPos), "unterminated object")
 		}
 
 		char := p.src[pos]
@@ -150,10 +322,11 @@ loop:
 		switch expect {
 		case objectExpectDelimiter:
 			if char != tokenKeyDelimiter {
-				return nil, NewInvalidExprError(start, pos, []byte{char})
+				return nil, NewParseError(newPosition(pos, pos),
+					"expected ':' after object key at offset %d", pos)
 			}
 			expect = objectExpectValue
-			curPos++
+			curPos = pos + 1
 		case objectExpectKey:
 			switch char {
 			case tokenObjectClose:
@@ -164,51 +337,143 @@ loop:
 				curPos = pos
 				break loop
 			case tokenDelimiter:
-				if len(elems) == 0 || hadComma {
-					// no multiple commas after prop
-					return nil, NewUnexpectedCharacterError(start, pos, char)
-				}
-				hadComma = true
-				curPos++
+				// no multiple commas after prop
+				return nil, NewUnexpectedCharacterError(start, pos, char)
 			case tokenString:
 				hadComma = false
-				str, err := p.decodeString(pos)
+				end, err := p.stringEnd(pos)
 				if err != nil {
 					return nil, err
 				}
 
-				lastKey, err = str.String()
+				lastKeyPos = newPosition(pos, end)
+				lastKey, err = p.decodeKey(lastKeyPos)
 				if err != nil {
 					return nil, NewParseError(newPosition(start, pos), err.Error())
 				}
 
-				curPos = str.Position.End + 1
+				curPos = end + 1
 				expect = objectExpectDelimiter
 			default:
 				return nil, NewUnexpectedCharacterError(start, pos, char)
 			}
 		case objectExpectValue:
-			val, err := p.parseValue(pos, false)
+			if p.leaves.reached() && isScalarStart(char) {
+				// key without value is dropped
+				p.leaves.truncate()
+				obj := p.newObject(start, lastKeyPos.Start-1, elems, members, mark)
+				obj.truncated = true
+				return obj, nil
+			}
+
+			val, valPos, err := p.parseValue(pos, false)
 			if err != nil {
 				return nil, err
 			}
 
-			curPos = val.Ref().End + 1
-			elems[lastKey] = val
-			expect = objectExpectKey
+			curPos = valPos.End + 1
+			members = p.scratchMembers(members, mark)
+			if _, ok := elems[lastKey]; !ok {
+				members = p.appendMember(members, mark, Member{Key: lastKey, KeyPos: lastKeyPos, Value: val})
+			}
+			if elems != nil {
+				// duplicates of compact object are removed after parse
+				elems[lastKey] = val
+			}
+			expect = objectExpectComma
+			if p.leaves.isExhausted() {
+				obj := p.newObject(start, valPos.End, elems, members, mark)
+				obj.truncated = true
+				return obj, nil
+			}
+		case objectExpectComma:
+			switch char {
+			case tokenObjectClose:
+				curPos = pos
+				break loop
+			case tokenDelimiter:
+				hadComma = true
+				expect = objectExpectKey
+				curPos = pos + 1
+			default:
+				return nil, NewParseError(newPosition(pos, pos),
+					"expected ',' or '}' after value at offset %d", pos)
+			}
+		}
+	}
+
+	return p.newObject(start, curPos, elems, members, mark), nil
+}
+
+// decodeKey returns unquoted object key at passed position.
+func (p Parser) decodeKey(pos Position) (string, error) {
+	raw := p.src[pos.Start : pos.End+1]
+	if p.arena != nil {
+		if key, ok := p.arena.key(raw); ok {
+			return key, nil
 		}
 	}
+	return newString(pos, raw).String()
+}
 
-	return newObject(start, curPos, elems), nil
+// newItemsMap returns map for object items.
+func (p Parser) newItemsMap() map[string]Value {
+	if p.arena != nil {
+		return p.arena.maps.alloc()
+	}
+	return make(map[string]Value, 0)
+}
+
+// scratchMark returns start of container items on arena scratch stack.
+func (p Parser) scratchMark() int {
+	if p.arena == nil {
+		return 0
+	}
+	return len(p.arena.scratchMembers)
+}
+
+// scratchMembers returns members of object being parsed.
+//
+// Nested containers may reallocate arena scratch stack,
+// so members slice should be refreshed before being modified.
+func (p Parser) scratchMembers(members []Member, mark int) []Member {
+	if p.arena == nil {
+		return members
+	}
+	return p.arena.scratchMembers[mark:]
+}
+
+func (p Parser) appendMember(members []Member, mark int, m Member) []Member {
+	if p.arena == nil {
+		return append(members, m)
+	}
+	p.arena.scratchMembers = append(p.arena.scratchMembers, m)
+	return p.arena.scratchMembers[mark:]
+}
+
+func (p Parser) newObject(start, end int, items map[string]Value, members []Member, mark int) *Object {
+	if p.arena != nil {
+		return p.arena.newObject(start, end, items, mark, p.compactObjects)
+	}
+	if p.compactObjects {
+		return newCompactObject(start, end, members)
+	}
+	return newObject(start, end, items, members)
 }
 
 func (p Parser) decodeArray(start int) (*Array, error) {
 	var elems []Value
+	mark := 0
+	if p.arena != nil {
+		mark = len(p.arena.scratchValues)
+	}
+
 	curPos := start + 1      // next element should be after "[" char
 	prevIsDelimiter := false // handle trailing commas
+	prevIsValue := false     // values should be separated by commas
 	for {
 		if !p.hasElem(curPos) {
-			return nil, NewParseError(newPosition(start, curPos), "unterminated array statement")
+			return nil, NewUnexpectedEOFError(newPosition(start, curPos), "unterminated array statement")
 		}
 
 		switch char := p.src[curPos]; char {
@@ -221,31 +486,79 @@ func (p Parser) decodeArray(start int) (*Array, error) {
 			}
 
 			prevIsDelimiter = true
+			prevIsValue = false
 			curPos++
 		case tokenArrayClose:
 			if prevIsDelimiter {
 				return nil, NewUnexpectedCharacterError(curPos-1, curPos, tokenDelimiter)
 			}
-			return newArray(newPosition(start, curPos), elems...), nil
+			return p.newArray(newPosition(start, curPos), elems, mark), nil
 		default:
+			if prevIsValue {
+				return nil, NewParseError(newPosition(curPos, curPos),
+					"expected ',' or ']' after value at offset %d", curPos)
+			}
+
+			if p.leaves.reached() && isScalarStart(char) {
+				p.leaves.truncate()
+				arr := p.newArray(newPosition(start, curPos-1), elems, mark)
+				arr.truncated = true
+				return arr, nil
+			}
+
 			prevIsDelimiter = false
-			val, err := p.parseValue(curPos, false)
+			prevIsValue = true
+			val, valPos, err := p.parseValue(curPos, false)
 			if err != nil {
 				return nil, err
 			}
 
-			if elems == nil {
-				// allocate slice of values only if necessary
-				elems = make([]Value, 0, 2)
+			curPos = valPos.End + 1
+			elems = p.appendItem(elems, val)
+			if p.leaves.isExhausted() {
+				arr := p.newArray(newPosition(start, valPos.End), elems, mark)
+				arr.truncated = true
+				return arr, nil
 			}
-
-			curPos = val.Ref().End + 1
-			elems = append(elems, val)
 		}
 	}
 }
 
+func (p Parser) appendItem(elems []Value, v Value) []Value {
+	if p.arena != nil {
+		p.arena.scratchValues = append(p.arena.scratchValues, v)
+		return nil
+	}
+
+	if elems == nil {
+		// allocate slice of values only if necessary
+		elems = make([]Value, 0, 2)
+	}
+	return append(elems, v)
+}
+
+func (p Parser) newArray(pos Position, elems []Value, mark int) *Array {
+	if p.arena != nil {
+		return p.arena.newArray(pos, mark)
+	}
+	return newArray(pos, elems...)
+}
+
 func (p Parser) decodeString(start int) (*String, error) {
+	end, err := p.stringEnd(start)
+	if err != nil {
+		return nil, err
+	}
+
+	pos := newPosition(start, end)
+	if p.arena != nil {
+		return p.arena.newString(pos, p.src[start:end+1]), nil
+	}
+	return newString(pos, p.src[start:end+1]), nil
+}
+
+// stringEnd returns position of closing quote of string at start position.
+func (p Parser) stringEnd(start int) (int, error) {
 	end := start
 	hasEscape := false
 	complete := false
@@ -260,6 +573,7 @@ outer:
 				break outer
 			}
 
+			hasEscape = false
 			continue
 		case '\\':
 			if hasEscape {
@@ -277,42 +591,46 @@ outer:
 	}
 
 	if !complete {
-		endPos := p.getPosUntilNextDelimiter(start)
-		return nil, NewParseError(newPosition(start, endPos), "unterminated string '%s'", p.src[start:endPos])
+		endPos := p.getPosUntilNextDelimiter(start + 1)
+		return 0, NewUnexpectedEOFError(newPosition(start, endPos), "unterminated string '%s'", p.src[start:endPos])
 	}
 
-	return newString(newPosition(start, end), p.src[start:end+1]), nil
+	return end, nil
 }
 
 func (p Parser) decodeNumber(start int) (*Number, error) {
-	// keep track of chars that should appear only once
-	once := make(map[byte]struct{}, 2)
+	// chars '.' and exponent mark should appear once in numbers,
+	// sign is allowed only at number start and after exponent mark
+	hasDot, hasExp := false, false
+	expPos := -1
 
 	var end int
 outer:
 	for i := start; i < p.end; i++ {
 		char := p.src[i]
 		switch char {
-		case '\t', '\r', '\n', ' ', ',', tokenObjectClose, tokenArrayClose:
+		case '\t', '\r', '\n', ' ', ',', tokenObjectClose, tokenArrayClose, tokenString, tokenKeyDelimiter:
 			break outer
-		case '.', charNumberNegative:
-			// chars '-' and '.' should appear once in numbers
-			if _, ok := once[char]; ok {
-				endPos := p.getPosUntilNextDelimiter(start)
-				return nil, NewInvalidExprError(start, endPos, p.src[start:endPos])
+		case '.':
+			if hasDot || hasExp {
+				return nil, p.newInvalidNumberError(start)
+			}
+			hasDot = true
+		case 'e', 'E':
+			if hasExp || i == start {
+				return nil, p.newInvalidNumberError(start)
+			}
+			hasExp, expPos = true, i
+		case charNumberNegative, '+':
+			if !(char == charNumberNegative && i == start) && i != expPos+1 {
+				return nil, p.newInvalidNumberError(start)
 			}
-
-			once[char] = struct{}{}
-			end = i
 		default:
-			if unicode.IsNumber(rune(char)) {
-				end = i
-				continue
+			if !unicode.IsNumber(rune(char)) {
+				return nil, p.newInvalidNumberError(start)
 			}
-
-			endPos := p.getPosUntilNextDelimiter(start)
-			return nil, NewInvalidExprError(start, endPos, p.src[start:endPos])
 		}
+		end = i
 	}
 
 	str := p.src[start : end+1]
@@ -320,7 +638,24 @@ outer:
 		Start: start,
 		End:   end,
 	}
-	return numberValueFromString(pos, string(str), 64)
+	if end+1 == p.end && strings.IndexByte(".eE+-", str[len(str)-1]) != -1 {
+		// number is cut at the end of document
+		return nil, NewUnexpectedEOFError(pos, "unterminated number %q", str)
+	}
+
+	if err := p.checkNumberLimits(pos, str, expPos-start); err != nil {
+		return nil, err
+	}
+
+	if p.arena != nil {
+		return p.arena.decodeNumber(pos, str)
+	}
+	return p.numbers.decode(pos, str)
+}
+
+func (p Parser) newInvalidNumberError(start int) error {
+	endPos := p.getPosUntilNextDelimiter(start)
+	return NewInvalidExprError(start, endPos, p.src[start:endPos])
 }
 
 func (p Parser) decodeScalarValue(start int, root bool) (Value, error) {
@@ -330,27 +665,26 @@ func (p Parser) decodeScalarValue(start int, root bool) (Value, error) {
 	}
 
 	// other possible scalar values are: false, true and null
-	var (
-		match          []byte = nil
-		possibleResult Value
-	)
+	var match []byte = nil
 
 	char := p.src[start]
 	exprEnd := p.getPosUntilNextDelimiter(start)
 	switch char {
 	case trueVal[0]:
 		match = trueVal
-		possibleResult = newBoolean(newPosition(start, start+len(trueVal)-1), true)
 	case falseVal[0]:
 		match = falseVal
-		possibleResult = newBoolean(newPosition(start, start+len(falseVal)-1), false)
 	case nullVal[0]:
 		match = nullVal
-		possibleResult = newNull(newPosition(start, start+len(nullVal)-1))
 	default:
 		return nil, NewUnexpectedCharacterError(start, start+1, char)
 	}
 
+	if str := p.src[start:exprEnd]; exprEnd == p.end && len(str) < len(match) && bytes.HasPrefix(match, str) {
+		// literal is cut at the end of document
+		return nil, NewUnexpectedEOFError(newPosition(start, exprEnd), "unexpected %q", str)
+	}
+
 	if root {
 		// expression might start correctly but contain invalid values like:
 		// "nullsomething" or "fals"
@@ -365,7 +699,21 @@ func (p Parser) decodeScalarValue(start int, root bool) (Value, error) {
 		return nil, NewInvalidExprError(start, exprEnd, p.src[start:exprEnd])
 	}
 
-	return possibleResult, nil
+	pos := newPosition(start, start+len(match)-1)
+	return p.newLiteral(pos, char), nil
+}
+
+// newLiteral returns boolean or null value by first char of literal.
+func (p Parser) newLiteral(pos Position, char byte) Value {
+	lit := literalValue{Position: pos, parsed: true}
+	if p.arena != nil {
+		lit.gen = p.arena.generation()
+	}
+
+	if char == nullVal[0] {
+		return Null{lit}
+	}
+	return Boolean{literalValue: lit, Value: char == trueVal[0]}
 }
 
 func (p Parser) getPosUntilNextNonDelimiter(start int) (int, bool) {
@@ -384,10 +732,8 @@ func (p Parser) getPosUntilNextDelimiter(start int) int {
 	lastChar := start
 	for i := start; i < p.end; i++ {
 		switch p.src[i] {
-		case '\t', '\r', '\n', ' ', tokenDelimiter, tokenArrayClose, tokenObjectClose:
-			//if i == start {
-			//	return start
-			//}
+		case '\t', '\r', '\n', ' ', tokenDelimiter, tokenArrayClose, tokenObjectClose,
+			tokenString, tokenKeyDelimiter:
 			return i
 		default:
 			lastChar = i + 1
@@ -1,9 +1,16 @@
 package jsonreflect
 
 import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -16,6 +23,12 @@ func TestNewParser(t *testing.T) {
 	require.Equal(t, len(input), p.end)
 }
 
+// parserModes are parser options which should not change parse result
+var parserModes = map[string][]ParserOption{
+	"default":         nil,
+	"compact numbers": {CompactNumbers()},
+}
+
 func TestParser_Parse(t *testing.T) {
 	cases := map[string]struct {
 		skip    bool
@@ -141,6 +154,10 @@ func TestParser_Parse(t *testing.T) {
 			src:  FixtureFromString("\t\"foo\\nbar\\\\baz\"\n"),
 			want: newString(newPosition(1, 15), []byte(`"foo\nbar\\baz"`)),
 		},
+		"escaped quote before closing quote": {
+			src:  FixtureFromString(`"foo \"bar\""`),
+			want: newString(newPosition(0, 12), []byte(`"foo \"bar\""`)),
+		},
 		"unterminated single string": {
 			//skip: true,
 			src:     FixtureFromString("\t\"foo\\nbar"),
@@ -190,7 +207,7 @@ func TestParser_Parse(t *testing.T) {
 		},
 		"empty object": {
 			src:  FixtureFromString("{}"),
-			want: newObject(0, 1, map[string]Value{}),
+			want: newTestObject(0, 1),
 		},
 		"object - unterminated prop name": {
 			src:     FixtureFromString(`{"foo`),
@@ -210,7 +227,35 @@ func TestParser_Parse(t *testing.T) {
 		},
 		"object - invalid key-value separator": {
 			src:     FixtureFromString(`{"foo"-32}`),
-			wantErr: ExpectedError(`unexpected "-" (in range 0:6)`),
+			wantErr: ExpectedError(`expected ':' after object key at offset 6 (in range 6:6)`),
+		},
+		"object - missing colon": {
+			src:     FixtureFromString(`{"foo" "bar"}`),
+			wantErr: ExpectedError(`expected ':' after object key at offset 7 (in range 7:7)`),
+		},
+		"object - missing comma between members": {
+			src:     FixtureFromString(`{"a": 1 "b": 2}`),
+			wantErr: ExpectedError(`expected ',' or '}' after value at offset 8 (in range 8:8)`),
+		},
+		"object - number glued to next key": {
+			src:     FixtureFromString(`{"a": 1"b": 2}`),
+			wantErr: ExpectedError(`expected ',' or '}' after value at offset 7 (in range 7:7)`),
+		},
+		"object - value glued to string": {
+			src:     FixtureFromString(`{"a": true"x"}`),
+			wantErr: ExpectedError(`expected ',' or '}' after value at offset 10 (in range 10:10)`),
+		},
+		"array - missing comma": {
+			src:     FixtureFromString(`[1 2]`),
+			wantErr: ExpectedError(`expected ',' or ']' after value at offset 3 (in range 3:3)`),
+		},
+		"array - value glued to string": {
+			src:     FixtureFromString(`[null"x"]`),
+			wantErr: ExpectedError(`expected ',' or ']' after value at offset 5 (in range 5:5)`),
+		},
+		"root - value glued to string": {
+			src:     FixtureFromString(`true"x"`),
+			wantErr: ExpectedError(`unexpected "\"x\"" (in range 4:7)`),
 		},
 		"object - non string literal key": {
 			src:     FixtureFromString(`{10: 32}`),
@@ -218,7 +263,7 @@ func TestParser_Parse(t *testing.T) {
 		},
 		"object - invalid string literal key": {
 			src:     FixtureFromString(`{"\c": 32}`),
-			wantErr: ExpectedError(`jsonreflect.String: failed to unquote raw string value '"\c"': invalid syntax (in range 0:1)`),
+			wantErr: ExpectedError(`jsonreflect.String: failed to unquote raw string value '"\c"': invalid escape sequence '\c' (in range 0:1)`),
 		},
 		"object - unterminated with padding": {
 			src:     FixtureFromString("{\"foo\":\t\n"),
@@ -230,63 +275,83 @@ func TestParser_Parse(t *testing.T) {
 		},
 		"object with one prop": {
 			src: FixtureFromString(`{"foo": 10}`),
-			want: newObject(0, 10, map[string]Value{
-				"foo": &Number{
+			want: newTestObject(0, 10,
+				Member{Key: "foo", KeyPos: newPosition(1, 5), Value: &Number{
 					baseValue: newBaseValue(8, 9),
 					mantissa:  10,
-				},
-			}),
+				}},
+			),
 		},
 		"object with two prop": {
 			src: FixtureFromString(`{"foo": 10,"bar":true}`),
-			want: newObject(0, 21, map[string]Value{
-				"foo": &Number{
+			want: newTestObject(0, 21,
+				Member{Key: "foo", KeyPos: newPosition(1, 5), Value: &Number{
 					baseValue: newBaseValue(8, 9),
 					mantissa:  10,
+				}},
+				Member{Key: "bar", KeyPos: newPosition(11, 15), Value: newBoolean(newPosition(17, 20), true)},
+			),
+		},
+		"object with duplicate key": {
+			src: FixtureFromString(`{"foo": 1,"bar":true,"foo":2}`),
+			want: &Object{
+				baseValue: newBaseValue(0, 28),
+				Items: map[string]Value{
+					"foo": &Number{baseValue: newBaseValue(27, 27), mantissa: 2},
+					"bar": newBoolean(newPosition(16, 19), true),
+				},
+				members: []Member{
+					{Key: "foo", KeyPos: newPosition(1, 5), Value: &Number{baseValue: newBaseValue(8, 8), mantissa: 1}},
+					{Key: "bar", KeyPos: newPosition(10, 14), Value: newBoolean(newPosition(16, 19), true)},
 				},
-				"bar": newBoolean(newPosition(17, 20), true),
-			}),
+			},
+		},
+		"object with space before colon": {
+			src: FixtureFromString(`{"foo" : true}`),
+			want: newTestObject(0, 13,
+				Member{Key: "foo", KeyPos: newPosition(1, 5), Value: newBoolean(newPosition(9, 12), true)},
+			),
 		},
 		"nested object": {
 			src: TestdataFixture("obj_nested.json"),
-			want: newObject(0, 34, map[string]Value{
-				"foo": newObject(11, 32, map[string]Value{
-					"bar": newString(newPosition(24, 28), []byte(`"baz"`)),
-				}),
-			}),
+			want: newTestObject(0, 34,
+				Member{Key: "foo", KeyPos: newPosition(4, 8), Value: newTestObject(11, 32,
+					Member{Key: "bar", KeyPos: newPosition(17, 21), Value: newString(newPosition(24, 28), []byte(`"baz"`))},
+				)},
+			),
 		},
 		"plain object with values": {
 			src: TestdataFixture("obj_simple.json"),
-			want: newObject(0, 288, map[string]Value{
-				"id": &Number{
+			want: newTestObject(0, 288,
+				Member{Key: "id", KeyPos: newPosition(4, 7), Value: &Number{
 					baseValue: newBaseValue(10, 11),
 					mantissa:  10,
-				},
-				"user": newString(newPosition(24, 30), []byte(`"admin"`)),
-				"age": &Number{
+				}},
+				Member{Key: "user", KeyPos: newPosition(16, 21), Value: newString(newPosition(24, 30), []byte(`"admin"`))},
+				Member{Key: "age", KeyPos: newPosition(35, 39), Value: &Number{
 					baseValue: newBaseValue(42, 43),
 					mantissa:  32,
-				},
-				"created_at": newString(newPosition(62, 83), []byte(`"2009-11-10T23:00:00Z"`)),
-				"roles": newArray(newPosition(97, 113),
+				}},
+				Member{Key: "created_at", KeyPos: newPosition(48, 59), Value: newString(newPosition(62, 83), []byte(`"2009-11-10T23:00:00Z"`))},
+				Member{Key: "roles", KeyPos: newPosition(88, 94), Value: newArray(newPosition(97, 113),
 					newString(newPosition(98, 103), []byte(`"root"`)),
-					newString(newPosition(106, 112), []byte(`"owner"`))),
-				"active": newBoolean(newPosition(128, 131), true),
-				"rating": &Number{
+					newString(newPosition(106, 112), []byte(`"owner"`)))},
+				Member{Key: "active", KeyPos: newPosition(118, 125), Value: newBoolean(newPosition(128, 131), true)},
+				Member{Key: "rating", KeyPos: newPosition(136, 143), Value: &Number{
 					baseValue: newBaseValue(146, 152),
 					mantissa:  -3,
 					expoLen:   4,
 					exponent:  1415,
 					IsFloat:   true,
 					IsSigned:  true,
-				},
-				"ref":         newNull(newPosition(164, 167)),
-				"x-meta-salt": newString(newPosition(187, 220), []byte(`"d3b07384d113edec49eaa6238ad5ff00"`)),
-				"meta": newObject(233, 286, map[string]Value{
-					"first_name": newString(newPosition(253, 258), []byte(`"John"`)),
-					"last_name":  newString(newPosition(278, 282), []byte(`"Doe"`)),
-				}),
-			}),
+				}},
+				Member{Key: "ref", KeyPos: newPosition(157, 161), Value: newNull(newPosition(164, 167))},
+				Member{Key: "x-meta-salt", KeyPos: newPosition(172, 184), Value: newString(newPosition(187, 220), []byte(`"d3b07384d113edec49eaa6238ad5ff00"`))},
+				Member{Key: "meta", KeyPos: newPosition(225, 230), Value: newTestObject(233, 286,
+					Member{Key: "first_name", KeyPos: newPosition(239, 250), Value: newString(newPosition(253, 258), []byte(`"John"`))},
+					Member{Key: "last_name", KeyPos: newPosition(265, 275), Value: newString(newPosition(278, 282), []byte(`"Doe"`))},
+				)},
+			),
 		},
 	}
 
@@ -307,17 +372,19 @@ func TestParser_Parse(t *testing.T) {
 		}
 		t.Run(n, func(t *testing.T) {
 			src := c.src.ProvideFixture(t)
-			got, err := NewParser(src).Parse()
-			if !c.wantErr.AssertError(t, err) {
-				require.Nil(t, got)
-				return
-			}
+			for mode, opts := range parserModes {
+				got, err := NewParser(src, opts...).Parse()
+				if !c.wantErr.AssertError(t, err) {
+					require.Nil(t, got, mode)
+					continue
+				}
 
-			require.Equal(t, c.want, got)
-			if c.want == nil {
-				return
+				require.Equal(t, c.want, withoutRaw(got), mode)
+				if c.want == nil {
+					continue
+				}
+				require.Equal(t, c.want.Type(), got.Type(), "%s: type mismatch", mode)
 			}
-			require.Equal(t, c.want.Type(), got.Type(), "type mismatch")
 		})
 	}
 }
@@ -340,3 +407,240 @@ func TestUnmarshalCheck(t *testing.T) {
 		})
 	}
 }
+
+// newTestObject creates a new object with members in specified order
+func newTestObject(start, end int, members ...Member) *Object {
+	items := make(map[string]Value, len(members))
+	for _, m := range members {
+		items[m.Key] = m.Value
+	}
+	return newObject(start, end, items, members)
+}
+
+// withoutRaw removes parsed value source to compare it with values built by hand.
+func withoutRaw(v Value) Value {
+	setRaw(v, nil)
+	switch t := v.(type) {
+	case Boolean:
+		t.parsed = false
+		return t
+	case Null:
+		t.parsed = false
+		return t
+	case *Array:
+		for i, item := range t.Items {
+			t.Items[i] = withoutRaw(item)
+		}
+	case *Object:
+		for i, m := range t.members {
+			t.members[i].Value = withoutRaw(m.Value)
+		}
+		for k, item := range t.Items {
+			t.Items[k] = withoutRaw(item)
+		}
+	}
+	return v
+}
+
+type testDateValue struct {
+	Value
+	t time.Time
+}
+
+func (d testDateValue) Interface() interface{} {
+	return d.t
+}
+
+func (d testDateValue) MarshalJSONValue() ([]byte, error) {
+	return []byte(strconv.Quote(d.t.Format("2006-01-02"))), nil
+}
+
+func TestParser_WithTransformer(t *testing.T) {
+	var visited []Type
+	dateTransformer := func(v Value) (Value, error) {
+		visited = append(visited, v.Type())
+		str, ok := v.(*String)
+		if !ok {
+			return v, nil
+		}
+
+		s, err := str.String()
+		if err != nil {
+			return nil, err
+		}
+
+		tm, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return v, nil
+		}
+		return testDateValue{Value: str, t: tm}, nil
+	}
+
+	src := []byte(`{"name": "foo", "dates": ["2009-11-10T23:00:00Z", 1]}`)
+	v, err := NewParser(src, WithTransformer(dateTransformer)).Parse()
+	require.NoError(t, err)
+	require.Equal(t, []Type{TypeString, TypeString, TypeNumber, TypeArray, TypeObject}, visited)
+
+	dates := v.(*Object).Items["dates"].(*Array)
+	require.Equal(t, time.Date(2009, 11, 10, 23, 0, 0, 0, time.UTC), dates.Items[0].Interface())
+	require.Equal(t, newPosition(26, 47), dates.Items[0].Ref())
+
+	got, err := MarshalValue(v, nil)
+	require.NoError(t, err)
+	require.Equal(t, `{"name":"foo","dates":["2009-11-10",1]}`, string(got))
+
+	_, err = NewParser(src, WithTransformer(func(v Value) (Value, error) {
+		return nil, errors.New("some error")
+	})).Parse()
+	require.EqualError(t, err, "transform failed: some error (in range 9:13)")
+}
+
+func TestParser_ParseNext(t *testing.T) {
+	p := NewParser([]byte(`1 "foo"[true] {}`))
+	var got []interface{}
+	var offsets []int
+	for {
+		v, err := p.ParseNext()
+		if err == io.EOF {
+			break
+		}
+
+		require.NoError(t, err)
+		got = append(got, v.Interface())
+		offsets = append(offsets, p.Offset())
+	}
+
+	require.Equal(t, []interface{}{1, "foo", []interface{}{true}, map[string]interface{}{}}, got)
+	require.Equal(t, []int{1, 7, 13, 16}, offsets)
+}
+
+func TestParser_UnexpectedEOF(t *testing.T) {
+	src := TestdataFixture("obj_simple.json").ProvideFixture(t)
+	end := bytes.LastIndexByte(src, '}')
+	for i := 1; i < end; i++ {
+		_, err := NewParser(src[:i]).Parse()
+		require.Error(t, err, "offset %d", i)
+		require.True(t, errors.Is(err, ErrUnexpectedEOF), "offset %d: %s", i, err)
+
+		var perr ParseError
+		require.True(t, errors.As(err, &perr))
+	}
+
+	// bad bytes with remaining input are not reported as truncation
+	malformed := map[string]struct {
+		from string
+		to   string
+	}{
+		"bad char":        {from: `"age": 32`, to: `"age": 3#2`},
+		"bad literal":     {from: `"active": true`, to: `"active": trux`},
+		"missing comma":   {from: `"ref": null`, to: `"ref": null "x"`},
+		"bad array delim": {from: `"roles": ["root",`, to: `"roles": ["root";`},
+	}
+	for n, c := range malformed {
+		doc := bytes.Replace(src, []byte(c.from), []byte(c.to), 1)
+		require.NotEqual(t, src, doc, n)
+
+		_, err := NewParser(doc).Parse()
+		require.Error(t, err, n)
+		require.False(t, errors.Is(err, ErrUnexpectedEOF), "%s: %s", n, err)
+	}
+}
+
+func TestParser_NumberLimits(t *testing.T) {
+	digits := func(n int) string {
+		return "1" + strings.Repeat("0", n-1)
+	}
+
+	cases := map[string]struct {
+		src     string
+		opts    []ParserOption
+		wantErr string
+	}{
+		"max digits":           {src: "-0." + digits(999)},
+		"too many digits":      {src: "-0." + digits(1000), wantErr: "more than 1000 digits"},
+		"exponent digits":      {src: "1." + digits(999) + "e-5"},
+		"custom digits":        {src: "1.2345e10", opts: []ParserOption{MaxNumberDigits(5)}},
+		"custom digits exceed": {src: "12.3456", opts: []ParserOption{MaxNumberDigits(5)}, wantErr: "more than 5 digits"},
+		"no digits limit":      {src: "0." + digits(5000), opts: []ParserOption{MaxNumberDigits(0)}},
+		"max exponent":         {src: "1e-9999"},
+		"large exponent":       {src: "1E+10000", wantErr: "exponent exceeds 9999"},
+		"custom exponent":      {src: "1e-10", opts: []ParserOption{MaxNumberExponent(9)}, wantErr: "exponent exceeds 9"},
+		"long exponent":        {src: "1e" + digits(30), wantErr: "exponent exceeds 9999"},
+		"no exponent limit":    {src: "1e-999999999", opts: []ParserOption{MaxNumberExponent(-1)}},
+		"exponent overflow":    {src: "1e" + digits(30), opts: []ParserOption{MaxNumberExponent(0)}, wantErr: "exponent overflows int"},
+		"max float":            {src: "1.7976931348623157e308"},
+		"huge float":           {src: "1.8e308"},
+		"huge exponent":        {src: "-1e400"},
+		"long integer":         {src: digits(401)},
+		"max integer digits":   {src: digits(1000)},
+		"integer digits limit": {src: digits(1001), wantErr: "more than 1000 digits"},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			src := "[" + c.src + "]"
+			v, err := NewParser([]byte(src), c.opts...).Parse()
+			if c.wantErr == "" {
+				require.NoError(t, err)
+				got, err := MarshalValue(v, nil)
+				require.NoError(t, err)
+				require.Equal(t, src, string(got))
+				return
+			}
+
+			require.True(t, errors.Is(err, ErrNumberOutOfRange), err)
+			rangeErr := new(NumberRangeError)
+			require.True(t, errors.As(err, &rangeErr))
+			require.Equal(t, c.src, rangeErr.Text)
+			require.Equal(t, newPosition(1, len(c.src)), rangeErr.Pos())
+			require.Equal(t, c.wantErr, rangeErr.Reason)
+		})
+	}
+}
+
+func TestParser_NumberPrecision(t *testing.T) {
+	floats := []string{
+		"0.1", "1.1", "0.3", "-2.675", "123456789012345.6", "0.1234567890123456789",
+		"9007199254740993.5", "1.7976931348623157e308", "5e-324", "2.2250738585072014e-308",
+		"0.000001e-300", "123.456e-20", "4.35e22", "1e23", "-0.0",
+	}
+
+	for _, src := range floats {
+		v, err := NewParser([]byte(src)).Parse()
+		require.NoError(t, err, src)
+
+		want, err := strconv.ParseFloat(src, 64)
+		require.NoError(t, err, src)
+		require.Equal(t, want, v.(*Number).Float64(), src)
+
+		// digits which fit into number fields are not kept as text
+		require.Empty(t, v.(*Number).decimal, src)
+	}
+
+	integers := map[string]int64{
+		"9007199254740993":       9007199254740993,
+		"9223372036854775807":    math.MaxInt64,
+		"-9223372036854775808":   math.MinInt64,
+		"12345678901234567e2":    1234567890123456700,
+		"-1234567890123456.78e2": -123456789012345678,
+		"9223372036854775.807e3": math.MaxInt64,
+		"123456789e-5":           1234,
+	}
+
+	for src, want := range integers {
+		v, err := NewParser([]byte(src)).Parse()
+		require.NoError(t, err, src)
+		require.Equal(t, want, v.(*Number).Int64(), src)
+	}
+
+	// integer out of int64 range is exact only in uint64
+	src := []byte("18446744073709551615")
+	var i int64
+	err := Unmarshal(src, &i)
+	require.True(t, errors.Is(err, ErrNumberOutOfRange), err)
+	require.EqualError(t, err, `number "18446744073709551615" is out of range: value overflows int64`)
+
+	var u uint64
+	require.NoError(t, Unmarshal(src, &u))
+	require.Equal(t, uint64(math.MaxUint64), u)
+}
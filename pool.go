@@ -0,0 +1,215 @@
+package jsonreflect
+
+import (
+	"math/bits"
+	"sync"
+)
+
+// Pool is a pool of byte buffers used to reuse allocations between
+// parse and marshal calls.
+//
+// Pool must be safe for concurrent use if it's shared between goroutines.
+//
+// Ownership contract:
+//
+// - Get returns an empty buffer with capacity of at least size bytes.
+// After Get, the buffer is owned by the caller.
+//
+// - Put returns buffer to the pool. After Put, the buffer must not be used
+// by the caller, since it may be returned by next Get call.
+//
+// Parser uses pooled buffers only as scratch space and returns them to the pool
+// before parse call returns. Parsed values never refer to pooled memory.
+//
+// Marshaler grows output in pooled buffers, returns replaced buffers to the pool
+// and returns the last one to the caller, see WithMarshalPool.
+//
+// Pool which also implements SlabPool reuses values memory of ParseInto,
+// see Document.Release.
+type Pool interface {
+	// Get returns an empty buffer with capacity of at least size bytes.
+	Get(size int) []byte
+
+	// Put returns buffer to the pool.
+	Put(b []byte)
+}
+
+// SlabPool is implemented by pools which reuse memory of values parsed by ParseInto.
+//
+// Ownership contract:
+//
+// - GetSlab returns a slab or nil if pool is empty. After GetSlab,
+// the slab is owned by the document which is parsed into it.
+//
+// - PutSlab is called by Document.Release. After PutSlab, values of released
+// document must not be used, since slab memory is reused by next ParseInto call.
+type SlabPool interface {
+	// GetSlab returns values memory or nil.
+	GetSlab() *Slab
+
+	// PutSlab returns values memory to the pool.
+	PutSlab(s *Slab)
+}
+
+// Slab is memory of values parsed by ParseInto.
+//
+// Contents of a slab are opaque, zero slab is empty.
+type Slab struct {
+	arena valueArena
+}
+
+// NewSyncPool returns Pool backed by sync.Pool.
+//
+// Buffers are grouped by capacity in power of two classes,
+// so small buffers are kept for small requests instead of being dropped.
+//
+// Returned pool also implements SlabPool.
+func NewSyncPool() Pool {
+	return &syncPool{}
+}
+
+type syncPool struct {
+	// classes contains *[]byte buffers, buffer capacity of class i is at least 1<<i
+	classes [bits.UintSize]sync.Pool
+
+	// headers contains empty *[]byte values to avoid an allocation in Put
+	headers sync.Pool
+
+	// slabs contains *Slab values
+	slabs sync.Pool
+}
+
+// Get implements Pool
+func (p *syncPool) Get(size int) []byte {
+	if size <= 0 {
+		size = 1
+	}
+
+	// smallest class which fits the size
+	class := bits.Len(uint(size - 1))
+	if class >= len(p.classes)-1 {
+		return make([]byte, 0, size)
+	}
+
+	h, ok := p.classes[class].Get().(*[]byte)
+	if !ok {
+		return make([]byte, 0, 1<<uint(class))
+	}
+
+	b := (*h)[:0]
+	*h = nil
+	p.headers.Put(h)
+	return b
+}
+
+// Put implements Pool
+func (p *syncPool) Put(b []byte) {
+	if cap(b) == 0 {
+		return
+	}
+
+	h, ok := p.headers.Get().(*[]byte)
+	if !ok {
+		h = new([]byte)
+	}
+
+	*h = b[:0]
+
+	// largest class which capacity is guaranteed by the buffer
+	p.classes[bits.Len(uint(cap(b)))-1].Put(h)
+}
+
+// GetSlab implements SlabPool
+func (p *syncPool) GetSlab() *Slab {
+	s, _ := p.slabs.Get().(*Slab)
+	return s
+}
+
+// PutSlab implements SlabPool
+func (p *syncPool) PutSlab(s *Slab) {
+	if s != nil {
+		p.slabs.Put(s)
+	}
+}
+
+// WithParserPool sets pool used by parser for scratch buffers.
+//
+// Scratch buffers are used by Extract and StreamArray. Parse and ValueOf
+// don't use scratch buffers, as parsed values refer to source instead.
+//
+// If pool implements SlabPool, ParseInto takes values memory of a document
+// from the pool, see Document.Release.
+func WithParserPool(pool Pool) ParserOption {
+	return func(p *Parser) {
+		p.pool = pool
+	}
+}
+
+// WithMarshalPool sets pool used for marshal output buffer.
+//
+// Output is written into buffers taken from the pool. When output doesn't fit
+// the buffer, it's copied into a larger one and the smaller buffer is returned
+// to the pool. Returned JSON is owned by the caller, who may return it
+// to the pool using Pool.Put once it's no longer used, so next marshal call
+// of similar size doesn't allocate the output.
+func WithMarshalPool(pool Pool) MarshalOption {
+	return func(p *marshalParams) {
+		p.pool = pool
+	}
+}
+
+// getBuffer returns buffer from pool or allocates a new one if pool is nil.
+func getBuffer(pool Pool, size int) []byte {
+	if pool == nil {
+		return make([]byte, 0, size)
+	}
+	return pool.Get(size)[:0]
+}
+
+// putBuffer returns buffer to pool if pool is not nil.
+func putBuffer(pool Pool, b []byte) {
+	if pool != nil {
+		pool.Put(b)
+	}
+}
+
+// poolBufferSize is initial size of buffer taken from pool by poolBuffer.
+const poolBufferSize = 64
+
+// poolBuffer is a writer which grows output in buffers of pool.
+type poolBuffer struct {
+	pool Pool
+	buf  []byte
+}
+
+func newPoolBuffer(pool Pool) *poolBuffer {
+	return &poolBuffer{pool: pool, buf: pool.Get(poolBufferSize)[:0]}
+}
+
+// Write implements io.Writer
+func (b *poolBuffer) Write(p []byte) (int, error) {
+	if len(b.buf)+len(p) > cap(b.buf) {
+		size := 2 * cap(b.buf)
+		if size < len(b.buf)+len(p) {
+			size = len(b.buf) + len(p)
+		}
+
+		buf := append(b.pool.Get(size)[:0], b.buf...)
+		b.pool.Put(b.buf)
+		b.buf = buf
+	}
+
+	b.buf = append(b.buf, p...)
+	return len(p), nil
+}
+
+// Bytes returns written bytes.
+func (b *poolBuffer) Bytes() []byte {
+	return b.buf
+}
+
+// release returns buffer to the pool.
+func (b *poolBuffer) release() {
+	b.pool.Put(b.buf)
+	b.buf = nil
+}
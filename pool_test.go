@@ -0,0 +1,204 @@
+package jsonreflect
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type countingPool struct {
+	Pool
+	gets int32
+	puts int32
+}
+
+func (p *countingPool) Get(size int) []byte {
+	atomic.AddInt32(&p.gets, 1)
+	return p.Pool.Get(size)
+}
+
+func (p *countingPool) Put(b []byte) {
+	atomic.AddInt32(&p.puts, 1)
+	p.Pool.Put(b)
+}
+
+type slabListPool struct {
+	Pool
+	slabs []*Slab
+}
+
+func (p *slabListPool) GetSlab() *Slab {
+	if len(p.slabs) == 0 {
+		return nil
+	}
+
+	s := p.slabs[len(p.slabs)-1]
+	p.slabs = p.slabs[:len(p.slabs)-1]
+	return s
+}
+
+func (p *slabListPool) PutSlab(s *Slab) {
+	p.slabs = append(p.slabs, s)
+}
+
+func TestSyncPool(t *testing.T) {
+	pool := NewSyncPool()
+	b := pool.Get(16)
+	require.Empty(t, b)
+	require.GreaterOrEqual(t, cap(b), 16)
+
+	pool.Put(append(b, "foo"...))
+	require.Empty(t, pool.Get(1))
+	require.GreaterOrEqual(t, cap(pool.Get(1024)), 1024)
+
+	for _, size := range []int{0, 1, 17, 1000, 1 << 20} {
+		b := pool.Get(size)
+		require.Empty(t, b)
+		require.GreaterOrEqual(t, cap(b), size)
+		pool.Put(b)
+	}
+
+	// reused buffers don't allocate
+	allocs := testing.AllocsPerRun(100, func() {
+		b := pool.Get(64)
+		pool.Put(append(b, "foo"...))
+	})
+	require.Zero(t, allocs)
+}
+
+func TestWithParserPool(t *testing.T) {
+	src := `{"skip": {"a": [1, 2]}, "items": [{"b": ["x"]}, 2], "a": "foo"}`
+	pool := &countingPool{Pool: NewSyncPool()}
+
+	got, err := Extract([]byte(src), []string{"a"}, WithParserPool(pool))
+	require.NoError(t, err)
+	require.Equal(t, "foo", got["a"].Interface())
+
+	var items []interface{}
+	err = StreamArray(strings.NewReader(src), "items", func(_ int, v Value) error {
+		items = append(items, v.Interface())
+		return nil
+	}, WithParserPool(pool))
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{map[string]interface{}{"b": []interface{}{"x"}}, 2}, items)
+
+	// scratch buffers are returned to the pool
+	require.NotZero(t, pool.gets)
+	require.Equal(t, pool.gets, pool.puts)
+}
+
+func TestWithMarshalPool(t *testing.T) {
+	pool := &countingPool{Pool: NewSyncPool()}
+	out, err := MarshalValueOpts(NewObject(map[string]Value{"a": NewNumberInt(1)}), WithMarshalPool(pool))
+	require.NoError(t, err)
+	require.Equal(t, `{"a":1}`, string(out))
+
+	// output buffer is owned by the caller
+	require.Equal(t, int32(1), pool.gets)
+	require.Zero(t, pool.puts)
+}
+
+func TestWithMarshalPool_Allocs(t *testing.T) {
+	items := make([]Value, 0, 100)
+	for i := 0; i < 100; i++ {
+		items = append(items, NewString(strings.Repeat("x", i)))
+	}
+	v := NewArray(items...)
+	want, err := MarshalValueOpts(v)
+	require.NoError(t, err)
+
+	pool := NewSyncPool()
+	withPool := testing.AllocsPerRun(100, func() {
+		out, err := MarshalValueOpts(v, WithMarshalPool(pool))
+		require.NoError(t, err)
+		require.Equal(t, len(want), len(out))
+		pool.Put(out)
+	})
+
+	withoutPool := testing.AllocsPerRun(100, func() {
+		_, err := MarshalValueOpts(v)
+		require.NoError(t, err)
+	})
+
+	// output buffers are taken from the pool instead of growing
+	require.Less(t, withPool, withoutPool)
+}
+
+func TestParser_ParseInto_SlabPool(t *testing.T) {
+	src := []byte(`{"a": [1, 2, {"b": "c"}], "d": 1.5}`)
+	pool := &slabListPool{Pool: NewSyncPool()}
+
+	doc := &Document{}
+	require.NoError(t, NewParser(src, WithParserPool(pool)).ParseInto(doc))
+	slab := doc.slab
+	want := doc.Root.Interface()
+	doc.Release()
+	require.Nil(t, doc.Root)
+
+	// released memory is reused by another document
+	other := &Document{}
+	require.NoError(t, NewParser(src, WithParserPool(pool)).ParseInto(other))
+	require.Equal(t, want, other.Root.Interface())
+	require.Same(t, slab, other.slab)
+	require.Empty(t, pool.slabs)
+
+	// documents without pool own their memory
+	doc = &Document{}
+	require.NoError(t, NewParser(src).ParseInto(doc))
+	require.Nil(t, doc.slabs)
+	doc.Release()
+}
+
+func TestPool_Concurrent(t *testing.T) {
+	src := []byte(`{"meta": {"id": 1}, "items": [{"name": "foo"}, {"name": "bar"}], "tags": ["a", "b\n"]}`)
+	want := `{"name":"foo"}`
+	pool := NewSyncPool()
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		values = map[int][]Value{}
+	)
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				got, err := Extract(src, []string{"items[0]", "tags"}, WithParserPool(pool))
+				require.NoError(t, err)
+
+				out, err := MarshalValueOpts(got["items[0]"], WithMarshalPool(pool))
+				require.NoError(t, err)
+				require.Equal(t, want, string(out))
+				pool.Put(out)
+
+				doc := &Document{}
+				require.NoError(t, NewParser(src, WithParserPool(pool)).ParseInto(doc))
+				out, err = MarshalValueOpts(doc.Root.(*Object).Items["items"].(*Array).Items[0])
+				require.NoError(t, err)
+				require.Equal(t, want, string(out))
+				doc.Release()
+
+				err = StreamArray(strings.NewReader(string(src)), "/items", func(i int, v Value) error {
+					mu.Lock()
+					values[i] = append(values[i], v)
+					mu.Unlock()
+					return nil
+				}, WithParserPool(pool))
+				require.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// values retained after concurrent use don't refer to pooled memory
+	for i, name := range []string{"foo", "bar"} {
+		require.Len(t, values[i], 8*200)
+		for _, v := range values[i] {
+			require.Equal(t, name, v.(*Object).Items["name"].Interface())
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package jsonreflect
+
+import (
+	"bytes"
+	"reflect"
+	"strconv"
+)
+
+func setRaw(v Value, raw []byte) {
+	switch t := v.(type) {
+	case *Number:
+		t.raw = raw
+	case *Array:
+		t.raw = raw
+	case *Object:
+		t.raw = raw
+	}
+}
+
+// Detach copies source bytes referenced by parsed value tree,
+// so value no longer shares memory with parser source.
+//
+// Parsed values keep references to parser source to avoid copies.
+// Source can't be garbage collected while any of values is alive and
+// changes of source are visible through values.
+// Values parsed by ParseString refer to memory of a string.
+//
+// Source bytes are copied once for the whole tree. Value is modified in place and returned.
+// Value should not contain cycles.
+func Detach(v Value) Value {
+	root := rawField(v)
+	if root == nil {
+		root = []byte{}
+	}
+
+	d := detacher{
+		root:  root,
+		buf:   append([]byte(nil), root...),
+		start: positionOf(v).Start,
+	}
+	d.detach(v)
+	return v
+}
+
+// rawField returns source bytes of a value without validity check.
+func rawField(v Value) []byte {
+	switch t := v.(type) {
+	case *String:
+		return t.rawValue
+	case *Number:
+		return t.raw
+	case *Array:
+		return t.raw
+	case *Object:
+		return t.raw
+	default:
+		return nil
+	}
+}
+
+type detacher struct {
+	// root is source bytes of root value and buf is its copy
+	root []byte
+	buf  []byte
+
+	// start is root value start position
+	start int
+}
+
+// copy returns copy of raw value bytes at passed position.
+//
+// Bytes of root value are re-sliced from a shared copy.
+func (d detacher) copy(raw []byte, pos Position) []byte {
+	if raw == nil {
+		return nil
+	}
+
+	off := pos.Start - d.start
+	if off >= 0 && off+len(raw) <= len(d.root) && len(raw) > 0 &&
+		reflect.ValueOf(raw).Pointer() == reflect.ValueOf(d.root[off:]).Pointer() {
+		return d.buf[off : off+len(raw) : off+len(raw)]
+	}
+	return append([]byte(nil), raw...)
+}
+
+func (d detacher) detach(v Value) {
+	switch t := v.(type) {
+	case *String:
+		if t != nil {
+			t.rawValue = d.copy(t.rawValue, t.Position)
+		}
+	case *Number:
+		if t != nil {
+			t.raw = d.copy(t.raw, t.Position)
+		}
+	case *Array:
+		if t == nil {
+			return
+		}
+
+		t.raw = d.copy(t.raw, t.Position)
+		for _, item := range t.Items {
+			d.detach(item)
+		}
+	case *Object:
+		if t == nil {
+			return
+		}
+
+		t.raw = d.copy(t.raw, t.Position)
+		for _, m := range t.Members() {
+			d.detach(m.Value)
+		}
+	}
+}
+
+// Raw returns source bytes of a parsed value.
+//
+// Returns false if value was not produced by parser or if value or any
+// of its children was modified or replaced after parse.
+// Strings are always returned as they are stored in quoted form.
+//
+// Returned slice shares memory with parser source and should not be modified.
+// Booleans and nulls don't refer to source, their literal is returned as a copy.
+func Raw(v Value) ([]byte, bool) {
+	switch t := v.(type) {
+	case *String:
+		if t == nil {
+			return nil, false
+		}
+		return t.rawValue, true
+	case Boolean:
+		if !t.parsed {
+			return nil, false
+		}
+		return []byte(strconv.FormatBool(t.Value)), true
+	case Null:
+		if !t.parsed {
+			return nil, false
+		}
+		return []byte("null"), true
+	case *Number:
+		if t == nil || t.raw == nil || t.format != 0 || t.IsSigned != (t.raw[0] == charNumberNegative) ||
+			t.IsFloat != (bytes.IndexByte(t.raw, '.') >= 0) {
+			return nil, false
+		}
+		return t.raw, true
+	case *Array:
+		if t == nil || !t.isRawValid() {
+			return nil, false
+		}
+		return t.raw, true
+	case *Object:
+		if t == nil || !t.isRawValid() {
+			return nil, false
+		}
+		return t.raw, true
+	default:
+		return nil, false
+	}
+}
+
+// isRawValid checks that array items weren't modified after parse.
+//
+// Items should be located in the same source in original order
+// and separated only by commas and whitespace.
+func (arr *Array) isRawValid() bool {
+	if arr.raw == nil {
+		return false
+	}
+
+	// offset of the next byte after previous item, skip "["
+	offset := 1
+	for _, item := range arr.Items {
+		raw, ok := Raw(item)
+		if !ok {
+			return false
+		}
+
+		start := item.Ref().Start - arr.Position.Start
+		if start < offset || start+len(raw) > len(arr.raw) || !isItemRaw(item, raw, arr.raw[start:start+len(raw)]) {
+			return false
+		}
+
+		if !isSeparator(arr.raw[offset:start]) {
+			return false
+		}
+		offset = start + len(raw)
+	}
+
+	// skip "]"
+	return isSeparator(arr.raw[offset : len(arr.raw)-1])
+}
+
+// isRawValid checks that object items weren't modified after parse.
+func (o *Object) isRawValid() bool {
+	if o.raw == nil || o.Len() != len(o.members) {
+		return false
+	}
+
+	for _, m := range o.members {
+		v, ok := o.Get(m.Key)
+		if !ok {
+			return false
+		}
+
+		if _, ok := Raw(v); !ok || v != m.Value {
+			return false
+		}
+	}
+	return true
+}
+
+func isSeparator(data []byte) bool {
+	for _, c := range data {
+		switch c {
+		case '\t', '\r', '\n', ' ', tokenDelimiter:
+			continue
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// isItemRaw checks if item source bytes are located at passed container source slice.
+//
+// Literals don't refer to source, so only their contents are compared.
+func isItemRaw(item Value, raw, src []byte) bool {
+	switch item.(type) {
+	case Boolean, Null:
+		return bytes.Equal(raw, src)
+	default:
+		return isSameBytes(raw, src)
+	}
+}
+
+// isSameBytes checks if slices point to the same memory.
+func isSameBytes(a, b []byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	if len(a) == 0 {
+		return true
+	}
+
+	return reflect.ValueOf(a).Pointer() == reflect.ValueOf(b).Pointer()
+}
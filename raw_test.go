@@ -0,0 +1,227 @@
+package jsonreflect
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRaw(t *testing.T) {
+	src := `{ "a" : [1.50, -2 ,true], "b": null, "c": "foo" }`
+	cases := map[string]struct {
+		modify func(v *Object)
+		path   func(v *Object) Value
+		want   string
+		ok     bool
+	}{
+		"object": {
+			path: func(v *Object) Value { return v },
+			want: src,
+			ok:   true,
+		},
+		"array": {
+			path: func(v *Object) Value { return v.Items["a"] },
+			want: `[1.50, -2 ,true]`,
+			ok:   true,
+		},
+		"number": {
+			path: func(v *Object) Value { return v.Items["a"].(*Array).Items[0] },
+			want: `1.50`,
+			ok:   true,
+		},
+		"null": {
+			path: func(v *Object) Value { return v.Items["b"] },
+			want: `null`,
+			ok:   true,
+		},
+		"string": {
+			path: func(v *Object) Value { return v.Items["c"] },
+			want: `"foo"`,
+			ok:   true,
+		},
+		"modified boolean": {
+			modify: func(v *Object) {
+				b := v.Items["a"].(*Array).Items[2].(Boolean)
+				b.Value = false
+				v.Items["a"].(*Array).Items[2] = b
+			},
+			path: func(v *Object) Value { return v },
+		},
+		"modified number": {
+			modify: func(v *Object) {
+				v.Items["a"].(*Array).Items[1].(*Number).IsSigned = false
+			},
+			path: func(v *Object) Value { return v.Items["a"] },
+		},
+		"replaced array item": {
+			modify: func(v *Object) {
+				v.Items["a"].(*Array).Items[0] = NewNumberInt(1)
+			},
+			path: func(v *Object) Value { return v },
+		},
+		"swapped array items": {
+			modify: func(v *Object) {
+				items := v.Items["a"].(*Array).Items
+				items[0], items[1] = items[1], items[0]
+			},
+			path: func(v *Object) Value { return v.Items["a"] },
+		},
+		"removed array item": {
+			modify: func(v *Object) {
+				arr := v.Items["a"].(*Array)
+				arr.Items = arr.Items[:2]
+			},
+			path: func(v *Object) Value { return v.Items["a"] },
+		},
+		"replaced object item": {
+			modify: func(v *Object) {
+				v.Items["b"] = NewNull()
+			},
+			path: func(v *Object) Value { return v },
+		},
+		"added object item": {
+			modify: func(v *Object) {
+				v.Items["d"] = NewNull()
+			},
+			path: func(v *Object) Value { return v },
+		},
+		"removed object item": {
+			modify: func(v *Object) {
+				delete(v.Items, "b")
+			},
+			path: func(v *Object) Value { return v },
+		},
+		"synthetic value": {
+			path: func(_ *Object) Value { return NewNumberInt(1) },
+		},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			v, err := NewParser([]byte(src)).Parse()
+			require.NoError(t, err)
+
+			obj := v.(*Object)
+			if c.modify != nil {
+				c.modify(obj)
+			}
+
+			got, ok := Raw(c.path(obj))
+			require.Equal(t, c.ok, ok)
+			if !c.ok {
+				require.Nil(t, got)
+				return
+			}
+			require.Equal(t, c.want, string(got))
+		})
+	}
+}
+
+type rawJSON string
+
+func (r *rawJSON) UnmarshalJSON(data []byte) error {
+	*r = rawJSON(data)
+	return nil
+}
+
+func TestUnmarshalValue_RawJSONUnmarshaler(t *testing.T) {
+	v, err := NewParser([]byte(`{"a": [1.50,  2], "b": 1.50}`)).Parse()
+	require.NoError(t, err)
+
+	var dst struct {
+		A rawJSON
+		B rawJSON
+	}
+	require.NoError(t, UnmarshalValue(v, &dst))
+	require.Equal(t, rawJSON(`[1.50,  2]`), dst.A)
+	require.Equal(t, rawJSON(`1.50`), dst.B)
+
+	// modified values are marshaled again
+	v.(*Object).Items["a"].(*Array).Items[1] = NewNumberInt(3)
+	require.NoError(t, UnmarshalValue(v, &dst))
+	require.Equal(t, rawJSON(`[1.50,3]`), dst.A)
+
+	// objects with duplicate keys are marshaled again
+	v, err = ValueOf([]byte(`{"a": 1, "a": 2}`))
+	require.NoError(t, err)
+
+	var obj rawJSON
+	require.NoError(t, UnmarshalValue(v, &obj))
+	require.Equal(t, rawJSON(`{"a":2}`), obj)
+}
+
+func TestDetach(t *testing.T) {
+	src := []byte(`{"a": [1.50, "foo", true, null], "b": {"c": "bar"}}`)
+	v, err := ValueOf(src)
+	require.NoError(t, err)
+
+	// synthetic values are copied separately
+	v.(*Object).Items["d"] = NewString("baz")
+	require.Equal(t, v, Detach(v))
+
+	for i := range src {
+		src[i] = ' '
+	}
+
+	arr := v.(*Object).Items["a"]
+	got, ok := Raw(arr)
+	require.True(t, ok)
+	require.Equal(t, `[1.50, "foo", true, null]`, string(got))
+	require.Equal(t, []interface{}{1.5, "foo", true, nil}, arr.Interface())
+
+	got, ok = Raw(v.(*Object).Items["b"])
+	require.True(t, ok)
+	require.Equal(t, `{"c": "bar"}`, string(got))
+
+	out, err := MarshalValue(v, &MarshalOptions{})
+	require.NoError(t, err)
+	require.Contains(t, string(out), `"d":"baz"`)
+
+	require.Nil(t, Detach(nil))
+}
+
+func TestParseString(t *testing.T) {
+	src := `{"a": "foo", "b": [1, 2]}`
+	v, err := ParseString(src)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"a": "foo", "b": []interface{}{1, 2}}, v.Interface())
+
+	// raw value refers to source string memory
+	raw, ok := Raw(v.(*Object).Items["a"])
+	require.True(t, ok)
+	require.Equal(t, `"foo"`, string(raw))
+	require.Equal(t, reflect.ValueOf(stringBytes(src)[6:]).Pointer(), reflect.ValueOf(raw).Pointer())
+
+	Detach(v)
+	raw, _ = Raw(v.(*Object).Items["a"])
+	require.NotEqual(t, reflect.ValueOf(stringBytes(src)[6:]).Pointer(), reflect.ValueOf(raw).Pointer())
+
+	v, err = ParseString("")
+	require.NoError(t, err)
+	require.Nil(t, v)
+
+	_, err = ParseString(`{"a": }`)
+	require.Error(t, err)
+}
+
+func BenchmarkParseString(b *testing.B) {
+	src := string(newLargeFixture(10000))
+	b.Run("ParseString", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := ParseString(src); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("ValueOf", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := ValueOf([]byte(src)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
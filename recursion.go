@@ -0,0 +1,184 @@
+package jsonreflect
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// unmarshalerFrame is unmarshaler call in progress.
+//
+// Frames form a stack which is carried in unmarshalParams. Unmarshaler implementation
+// calls UnmarshalValue without access to unmarshal params, so unmarshaler receives
+// a copy of source value which refers to the stack, and UnmarshalValue continues it.
+type unmarshalerFrame struct {
+	// src identifies source value, see sourceIdentity
+	src uintptr
+
+	// typ is destination type without pointer
+	typ reflect.Type
+
+	parent *unmarshalerFrame
+
+	// done is set when unmarshaler call returns,
+	// as value passed to unmarshaler might be retained.
+	done uint32
+}
+
+// enterUnmarshaler pushes unmarshaler call of value into the stack.
+//
+// Returns *RecursiveUnmarshalError if the same source is already unmarshaled
+// into the same destination type, as such call would recurse forever.
+func (p unmarshalParams) enterUnmarshaler(v Value, target reflect.Type) (*unmarshalerFrame, error) {
+	typ := target
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	src := sourceIdentity(v)
+	if src != 0 {
+		for f := p.unmarshalers; f != nil; f = f.parent {
+			if f.src == src && f.typ == typ {
+				return nil, &RecursiveUnmarshalError{Position: positionOf(v), Type: target}
+			}
+		}
+	}
+
+	f := &unmarshalerFrame{src: src, typ: typ, parent: p.unmarshalers}
+	if src == 0 {
+		// value without identity is identified by frame which it's passed with
+		f.src = uintptr(unsafe.Pointer(f))
+	}
+	return f, nil
+}
+
+// leave marks unmarshaler call as finished.
+func (f *unmarshalerFrame) leave() {
+	atomic.StoreUint32(&f.done, 1)
+}
+
+// isActive reports whether unmarshaler call is in progress.
+func (f *unmarshalerFrame) isActive() bool {
+	return f != nil && atomic.LoadUint32(&f.done) == 0
+}
+
+// sourceIdentity returns identity of source value.
+//
+// Value passed to unmarshaler is identified as the original value.
+// Returns zero for values without identity, like booleans.
+func sourceIdentity(v Value) uintptr {
+	if f := valueFrame(v); f != nil {
+		return f.src
+	}
+
+	switch t := v.(type) {
+	case *Object, *Array, *String, *Number:
+		return reflect.ValueOf(t).Pointer()
+	default:
+		return 0
+	}
+}
+
+// valueFrame returns unmarshaler call which value was passed to.
+func valueFrame(v Value) *unmarshalerFrame {
+	switch t := v.(type) {
+	case *Object:
+		return t.unmarshaler
+	case *Array:
+		return t.unmarshaler
+	case *String:
+		return t.unmarshaler
+	case *Number:
+		return t.unmarshaler
+	case Boolean:
+		return t.unmarshaler
+	case Null:
+		return t.unmarshaler
+	default:
+		return nil
+	}
+}
+
+// withUnmarshalerFrame returns shallow copy of value which refers to unmarshaler call.
+//
+// Values of other packages are returned as is, so their recursion isn't detected.
+func withUnmarshalerFrame(v Value, f *unmarshalerFrame) Value {
+	switch t := v.(type) {
+	case *Object:
+		if t == nil {
+			return v
+		}
+		c := *t
+		c.unmarshaler = f
+		return &c
+	case *Array:
+		if t == nil {
+			return v
+		}
+		c := *t
+		c.unmarshaler = f
+		return &c
+	case *String:
+		if t == nil {
+			return v
+		}
+		c := *t
+		c.unmarshaler = f
+		return &c
+	case *Number:
+		if t == nil {
+			return v
+		}
+		c := *t
+		c.unmarshaler = f
+		return &c
+	case Boolean:
+		t.unmarshaler = f
+		return t
+	case Null:
+		t.unmarshaler = f
+		return t
+	default:
+		return v
+	}
+}
+
+var (
+	// jsonUnmarshalers is a number of json.Unmarshaler calls in progress.
+	jsonUnmarshalers int64
+
+	// jsonUnmarshalerData maps data passed to json.Unmarshaler to its call.
+	//
+	// json.Unmarshaler receives bytes which can't refer to the stack,
+	// so nested Unmarshal looks up the call by address of passed data.
+	// Map is read only while json.Unmarshaler calls are in progress.
+	jsonUnmarshalerData sync.Map
+)
+
+// enterJSONUnmarshaler registers data passed to json.Unmarshaler call.
+//
+// Data must not be empty.
+func enterJSONUnmarshaler(data []byte, f *unmarshalerFrame) {
+	atomic.AddInt64(&jsonUnmarshalers, 1)
+	jsonUnmarshalerData.Store(&data[0], f)
+}
+
+// leaveJSONUnmarshaler unregisters data passed to json.Unmarshaler call.
+func leaveJSONUnmarshaler(data []byte) {
+	jsonUnmarshalerData.Delete(&data[0])
+	atomic.AddInt64(&jsonUnmarshalers, -1)
+}
+
+// jsonUnmarshalerFrame returns json.Unmarshaler call which data was passed to.
+func jsonUnmarshalerFrame(data []byte) *unmarshalerFrame {
+	if len(data) == 0 || atomic.LoadInt64(&jsonUnmarshalers) == 0 {
+		return nil
+	}
+
+	f, ok := jsonUnmarshalerData.Load(&data[0])
+	if !ok {
+		return nil
+	}
+	return f.(*unmarshalerFrame)
+}
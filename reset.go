@@ -0,0 +1,60 @@
+package jsonreflect
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// reset zeroes destination if ResetDestination option is set.
+func (p unmarshalParams) reset(dst reflect.Value) {
+	if p.resetDestination {
+		p.resetValue(dst)
+	}
+}
+
+// resetValue zeroes value which is set by unmarshal.
+func (p unmarshalParams) resetValue(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Struct:
+		if isValueStruct(v.Type()) || isUnmarshaler(reflect.PtrTo(v.Type())) {
+			break
+		}
+
+		p.resetStruct(v)
+		return
+	case reflect.Array:
+		if isUnmarshaler(reflect.PtrTo(v.Type())) {
+			break
+		}
+
+		for i := 0; i < v.Len(); i++ {
+			p.resetValue(v.Index(i))
+		}
+		return
+	}
+
+	v.Set(reflect.Zero(v.Type()))
+}
+
+// resetStruct zeroes struct fields which are set by unmarshal, see unmarshalStruct.
+func (p unmarshalParams) resetStruct(v reflect.Value) {
+	for i := 0; i < v.NumField(); i++ {
+		fType := v.Type().Field(i)
+		fVal := v.Field(i)
+
+		td := parseTagData(fType)
+		if td != nil && td.skipValue {
+			continue
+		}
+
+		if !fVal.CanSet() {
+			if !(p.dangerouslySetPrivateFields && td != nil) || checkForeignPrivateField(v.Type(), fType, p) != nil {
+				continue
+			}
+
+			fVal = reflect.NewAt(fVal.Type(), unsafe.Pointer(fVal.UnsafeAddr())).Elem()
+		}
+
+		p.resetValue(fVal)
+	}
+}
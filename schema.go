@@ -0,0 +1,171 @@
+package jsonreflect
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// schemaDraft07 is JSON Schema draft-07 meta-schema URI
+const schemaDraft07 = "http://json-schema.org/draft-07/schema#"
+
+const (
+	schemaTypeNull    = "null"
+	schemaTypeBoolean = "boolean"
+	schemaTypeInteger = "integer"
+	schemaTypeNumber  = "number"
+	schemaTypeString  = "string"
+	schemaTypeObject  = "object"
+	schemaTypeArray   = "array"
+)
+
+// InferSchema returns minimal JSON Schema (draft-07) object which describes passed value.
+//
+// Schema uses only "type", "properties", "required", "items" and "anyOf" keywords.
+// Numbers are "integer" unless any of merged numbers is float.
+//
+// Elements of arrays are merged into a single "items" schema.
+// Object keys are required only if they present in all merged objects.
+// Values of different types are merged using "anyOf".
+func InferSchema(v Value) (*Object, error) {
+	if v == nil || isNilValue(reflect.ValueOf(v)) {
+		return nil, ErrNilValue
+	}
+
+	shape := &schemaShape{}
+	if err := shape.add(v); err != nil {
+		return nil, err
+	}
+
+	members := []Member{{Key: "$schema", Value: NewString(schemaDraft07)}}
+	return NewObjectFromMembers(append(members, shape.schema().Members()...)...), nil
+}
+
+// schemaShape is merged shape of one or more values.
+type schemaShape struct {
+	// types are value types in order of appearance
+	types []string
+
+	// objects is count of merged objects
+	objects  int
+	keys     []string
+	props    map[string]*schemaShape
+	presence map[string]int
+
+	// items is merged shape of array elements, nil if no elements were merged
+	items *schemaShape
+}
+
+func (s *schemaShape) add(v Value) error {
+	var typ string
+	switch t := v.(type) {
+	case Null:
+		typ = schemaTypeNull
+	case Boolean:
+		typ = schemaTypeBoolean
+	case *String:
+		typ = schemaTypeString
+	case *Number:
+		typ = schemaTypeInteger
+		if t.IsFloat {
+			typ = schemaTypeNumber
+		}
+	case *Object:
+		typ = schemaTypeObject
+		s.objects++
+		for _, m := range t.Members() {
+			if err := s.addProperty(m.Key, m.Value); err != nil {
+				return err
+			}
+		}
+	case *Array:
+		typ = schemaTypeArray
+		for i, item := range t.Items {
+			if s.items == nil {
+				s.items = &schemaShape{}
+			}
+
+			if err := s.items.add(item); err != nil {
+				return wrapElementError(err, pathSegment{index: i, isIndex: true})
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported value type %T", v)
+	}
+
+	s.addType(typ)
+	return nil
+}
+
+func (s *schemaShape) addProperty(key string, v Value) error {
+	if s.props == nil {
+		s.props = make(map[string]*schemaShape)
+		s.presence = make(map[string]int)
+	}
+
+	prop, ok := s.props[key]
+	if !ok {
+		prop = &schemaShape{}
+		s.props[key] = prop
+		s.keys = append(s.keys, key)
+	}
+
+	s.presence[key]++
+	if err := prop.add(v); err != nil {
+		return wrapElementError(err, pathSegment{key: key})
+	}
+	return nil
+}
+
+// addType adds type to list of types. Integer type is merged into number type.
+func (s *schemaShape) addType(typ string) {
+	for i, t := range s.types {
+		switch {
+		case t == typ:
+			return
+		case t == schemaTypeNumber && typ == schemaTypeInteger:
+			return
+		case t == schemaTypeInteger && typ == schemaTypeNumber:
+			s.types[i] = typ
+			return
+		}
+	}
+
+	s.types = append(s.types, typ)
+}
+
+func (s *schemaShape) schema() *Object {
+	if len(s.types) == 1 {
+		return s.typeSchema(s.types[0])
+	}
+
+	variants := make([]Value, len(s.types))
+	for i, typ := range s.types {
+		variants[i] = s.typeSchema(typ)
+	}
+	return NewObjectFromMembers(Member{Key: "anyOf", Value: NewArray(variants...)})
+}
+
+func (s *schemaShape) typeSchema(typ string) *Object {
+	members := []Member{{Key: "type", Value: NewString(typ)}}
+	switch typ {
+	case schemaTypeObject:
+		props := make([]Member, len(s.keys))
+		var required []Value
+		for i, key := range s.keys {
+			props[i] = Member{Key: key, Value: s.props[key].schema()}
+			if s.presence[key] == s.objects {
+				required = append(required, NewString(key))
+			}
+		}
+
+		members = append(members, Member{Key: "properties", Value: NewObjectFromMembers(props...)})
+		if len(required) > 0 {
+			members = append(members, Member{Key: "required", Value: NewArray(required...)})
+		}
+	case schemaTypeArray:
+		if s.items != nil {
+			members = append(members, Member{Key: "items", Value: s.items.schema()})
+		}
+	}
+	return NewObjectFromMembers(members...)
+}
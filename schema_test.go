@@ -0,0 +1,68 @@
+package jsonreflect
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInferSchema(t *testing.T) {
+	cases := map[string]struct {
+		src  string
+		want string
+	}{
+		"scalar": {
+			src:  `1`,
+			want: `{"$schema":"http://json-schema.org/draft-07/schema#","type":"integer"}`,
+		},
+		"object": {
+			src: `{"id": 1, "name": "foo", "rate": 1.5, "ok": true, "ref": null, "tags": []}`,
+			want: `{"$schema":"http://json-schema.org/draft-07/schema#","type":"object","properties":{` +
+				`"id":{"type":"integer"},"name":{"type":"string"},"rate":{"type":"number"},` +
+				`"ok":{"type":"boolean"},"ref":{"type":"null"},"tags":{"type":"array"}},` +
+				`"required":["id","name","rate","ok","ref","tags"]}`,
+		},
+		"array of objects": {
+			src: `[{"id": 1, "score": 2, "meta": {"a": "x"}}, {"id": 2, "score": 2.5, "extra": [1, "a"]}]`,
+			want: `{"$schema":"http://json-schema.org/draft-07/schema#","type":"array","items":{"type":"object","properties":{` +
+				`"id":{"type":"integer"},"score":{"type":"number"},` +
+				`"meta":{"type":"object","properties":{"a":{"type":"string"}},"required":["a"]},` +
+				`"extra":{"type":"array","items":{"anyOf":[{"type":"integer"},{"type":"string"}]}}},` +
+				`"required":["id","score"]}}`,
+		},
+		"heterogeneous array": {
+			src: `[null, {"a": 1}, [true], 1, {"b": 2}]`,
+			want: `{"$schema":"http://json-schema.org/draft-07/schema#","type":"array","items":{"anyOf":[` +
+				`{"type":"null"},` +
+				`{"type":"object","properties":{"a":{"type":"integer"},"b":{"type":"integer"}}},` +
+				`{"type":"array","items":{"type":"boolean"}},` +
+				`{"type":"integer"}]}}`,
+		},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			v, err := ValueOf([]byte(c.src))
+			require.NoError(t, err)
+
+			schema, err := InferSchema(v)
+			require.NoError(t, err)
+
+			got, err := MarshalValue(schema, nil)
+			require.NoError(t, err)
+			require.Equal(t, c.want, string(got))
+		})
+	}
+}
+
+type customValue struct {
+	Null
+}
+
+func TestInferSchema_Errors(t *testing.T) {
+	_, err := InferSchema(nil)
+	require.Equal(t, ErrNilValue, err)
+
+	_, err = InferSchema(NewObject(map[string]Value{"a": NewArray(NewNull(), customValue{NewNull()})}))
+	require.EqualError(t, err, `a[1]: unsupported value type jsonreflect.customValue`)
+}
@@ -0,0 +1,417 @@
+package jsonreflect
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// SchemaViolation is a document value which doesn't conform to schema.
+//
+// See ValidateSchema.
+type SchemaViolation struct {
+	// Position is position of value in document
+	Position
+
+	// InstancePath is path to value in document in dotted form. Empty for root value.
+	InstancePath string
+
+	// SchemaPath is path to failed keyword in schema in dotted form.
+	SchemaPath string
+
+	// Keyword is failed schema keyword
+	Keyword string
+
+	// Message is human-readable description
+	Message string
+}
+
+// Pos implements Positioned
+func (v SchemaViolation) Pos() Position {
+	return v.Position
+}
+
+// Error implements error interface
+func (v SchemaViolation) Error() string {
+	if v.InstancePath == "" {
+		return fmt.Sprintf("%s (in range %d:%d)", v.Message, v.Start, v.End)
+	}
+	return fmt.Sprintf("%s: %s (in range %d:%d)", v.InstancePath, v.Message, v.Start, v.End)
+}
+
+// SchemaOption is ValidateSchema option
+type SchemaOption func(sv *schemaValidator)
+
+// WithSchemaDiagnostics sets a callback which receives keywords ignored by validator.
+//
+// Diagnostic path is a path of keyword in schema.
+func WithSchemaDiagnostics(fn func(Diagnostic)) SchemaOption {
+	return func(sv *schemaValidator) {
+		sv.onDiagnostic = fn
+	}
+}
+
+// schemaAnnotations are keywords which don't affect validation.
+var schemaAnnotations = map[string]bool{
+	"$schema": true, "$id": true, "$comment": true, "title": true,
+	"description": true, "default": true, "examples": true, "definitions": true,
+}
+
+// ValidateSchema validates document against JSON Schema (draft-07) and returns list of violations.
+//
+// Only subset of keywords is supported: "type", "properties", "required", "items", "enum",
+// "minimum", "maximum", "minLength", "maxLength", "pattern" and "anyOf".
+// Unsupported keywords and keywords with invalid values are ignored,
+// use WithSchemaDiagnostics to get notified about them.
+//
+// Patterns use Go regexp syntax.
+func ValidateSchema(doc, schema Value, opts ...SchemaOption) []SchemaViolation {
+	sv := &schemaValidator{
+		patterns: map[string]*regexp.Regexp{},
+		reported: map[string]bool{},
+	}
+	for _, opt := range opts {
+		opt(sv)
+	}
+
+	sv.validate(doc, schema, nil, nil)
+	return sv.violations
+}
+
+type schemaValidator struct {
+	onDiagnostic func(Diagnostic)
+	violations   []SchemaViolation
+
+	// patterns is compiled patterns cache
+	patterns map[string]*regexp.Regexp
+
+	// reported contains schema paths of reported diagnostics
+	reported map[string]bool
+}
+
+func (sv *schemaValidator) violation(doc Value, docPath, schemaPath []pathSegment, keyword, msg string, args ...interface{}) {
+	sv.violations = append(sv.violations, SchemaViolation{
+		Position:     positionOf(doc),
+		InstancePath: formatPath(docPath),
+		SchemaPath:   formatPath(appendPathSegment(schemaPath, pathSegment{key: keyword})),
+		Keyword:      keyword,
+		Message:      fmt.Sprintf(msg, args...),
+	})
+}
+
+// ignore reports ignored schema keyword once.
+func (sv *schemaValidator) ignore(schemaPath []pathSegment, msg string, args ...interface{}) {
+	path := formatPath(schemaPath)
+	if sv.onDiagnostic == nil || sv.reported[path] {
+		return
+	}
+
+	sv.reported[path] = true
+	sv.onDiagnostic(Diagnostic{
+		Kind:    DiagnosticSchemaKeywordIgnored,
+		Path:    path,
+		Message: fmt.Sprintf(msg, args...),
+	})
+}
+
+func appendPathSegment(path []pathSegment, segments ...pathSegment) []pathSegment {
+	newPath := make([]pathSegment, len(path), len(path)+len(segments))
+	copy(newPath, path)
+	return append(newPath, segments...)
+}
+
+func (sv *schemaValidator) validate(doc, schema Value, docPath, schemaPath []pathSegment) {
+	if b, ok := schema.(Boolean); ok {
+		if !b.Value {
+			sv.violations = append(sv.violations, SchemaViolation{
+				Position:     positionOf(doc),
+				InstancePath: formatPath(docPath),
+				SchemaPath:   formatPath(schemaPath),
+				Message:      "value is not allowed by false schema",
+			})
+		}
+		return
+	}
+
+	obj, ok := schema.(*Object)
+	if !ok {
+		sv.ignore(schemaPath, "schema should be an object or boolean, got %s", TypeOf(schema))
+		return
+	}
+
+	for _, m := range obj.Members() {
+		kwPath := appendPathSegment(schemaPath, pathSegment{key: m.Key})
+		ok := true
+		switch m.Key {
+		case "type":
+			ok = sv.validateType(doc, m.Value, docPath, schemaPath)
+		case "properties":
+			ok = sv.validateProperties(doc, m.Value, docPath, kwPath)
+		case "required":
+			ok = sv.validateRequired(doc, m.Value, docPath, schemaPath)
+		case "items":
+			ok = sv.validateItems(doc, m.Value, docPath, kwPath)
+		case "enum":
+			ok = sv.validateEnum(doc, m.Value, docPath, schemaPath)
+		case "minimum", "maximum":
+			ok = sv.validateRange(doc, m.Key, m.Value, docPath, schemaPath)
+		case "minLength", "maxLength":
+			ok = sv.validateLength(doc, m.Key, m.Value, docPath, schemaPath)
+		case "pattern":
+			ok = sv.validatePattern(doc, m.Value, docPath, schemaPath)
+		case "anyOf":
+			ok = sv.validateAnyOf(doc, m.Value, docPath, schemaPath, kwPath)
+		default:
+			if !schemaAnnotations[m.Key] {
+				sv.ignore(kwPath, "unsupported keyword %q ignored", m.Key)
+			}
+		}
+
+		if !ok {
+			sv.ignore(kwPath, "invalid value of keyword %q ignored", m.Key)
+		}
+	}
+}
+
+// schemaTypeOf returns schema type names which match value.
+func schemaTypeOf(v Value) []string {
+	switch t := v.(type) {
+	case *Number:
+		if f := t.Float64(); !t.IsFloat || f == math.Trunc(f) {
+			return []string{schemaTypeNumber, schemaTypeInteger}
+		}
+		return []string{schemaTypeNumber}
+	case Boolean:
+		return []string{schemaTypeBoolean}
+	case *String:
+		return []string{schemaTypeString}
+	case *Object:
+		return []string{schemaTypeObject}
+	case *Array:
+		return []string{schemaTypeArray}
+	default:
+		return []string{schemaTypeNull}
+	}
+}
+
+func (sv *schemaValidator) validateType(doc, kw Value, docPath, schemaPath []pathSegment) bool {
+	var want []string
+	switch t := kw.(type) {
+	case *String:
+		want = []string{decodedString(t)}
+	case *Array:
+		for _, item := range t.Items {
+			str, ok := item.(*String)
+			if !ok {
+				return false
+			}
+			want = append(want, decodedString(str))
+		}
+	default:
+		return false
+	}
+
+	got := schemaTypeOf(doc)
+	for _, w := range want {
+		for _, g := range got {
+			if w == g {
+				return true
+			}
+		}
+	}
+
+	sv.violation(doc, docPath, schemaPath, "type", "expected %s, got %s", strings.Join(want, " or "), got[0])
+	return true
+}
+
+func (sv *schemaValidator) validateProperties(doc, kw Value, docPath, kwPath []pathSegment) bool {
+	props, ok := kw.(*Object)
+	if !ok {
+		return false
+	}
+
+	obj, ok := doc.(*Object)
+	if !ok {
+		return true
+	}
+
+	for _, m := range props.Members() {
+		if v, ok := obj.Get(m.Key); ok {
+			sv.validate(v, m.Value, appendPathSegment(docPath, pathSegment{key: m.Key}),
+				appendPathSegment(kwPath, pathSegment{key: m.Key}))
+		}
+	}
+	return true
+}
+
+func (sv *schemaValidator) validateRequired(doc, kw Value, docPath, schemaPath []pathSegment) bool {
+	keys, ok := kw.(*Array)
+	if !ok {
+		return false
+	}
+
+	obj, isObj := doc.(*Object)
+	for _, item := range keys.Items {
+		key, ok := item.(*String)
+		if !ok {
+			return false
+		}
+
+		if isObj && !obj.HasKey(decodedString(key)) {
+			sv.violation(doc, docPath, schemaPath, "required", "missing required property %q", decodedString(key))
+		}
+	}
+	return true
+}
+
+func (sv *schemaValidator) validateItems(doc, kw Value, docPath, kwPath []pathSegment) bool {
+	arr, isArr := doc.(*Array)
+	switch t := kw.(type) {
+	case *Object, Boolean:
+		if !isArr {
+			return true
+		}
+
+		for i, item := range arr.Items {
+			sv.validate(item, kw, appendPathSegment(docPath, pathSegment{index: i, isIndex: true}), kwPath)
+		}
+	case *Array:
+		if !isArr {
+			return true
+		}
+
+		for i, item := range arr.Items {
+			if i >= len(t.Items) {
+				break
+			}
+
+			seg := pathSegment{index: i, isIndex: true}
+			sv.validate(item, t.Items[i], appendPathSegment(docPath, seg), appendPathSegment(kwPath, seg))
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+func (sv *schemaValidator) validateEnum(doc, kw Value, docPath, schemaPath []pathSegment) bool {
+	allowed, ok := kw.(*Array)
+	if !ok {
+		return false
+	}
+
+	for _, item := range allowed.Items {
+		if Equal(doc, item, EqualOptions{}) {
+			return true
+		}
+	}
+
+	sv.violation(doc, docPath, schemaPath, "enum", "value is not one of allowed values")
+	return true
+}
+
+func (sv *schemaValidator) validateRange(doc Value, keyword string, kw Value, docPath, schemaPath []pathSegment) bool {
+	limit, ok := kw.(*Number)
+	if !ok {
+		return false
+	}
+
+	num, ok := doc.(*Number)
+	if !ok {
+		return true
+	}
+
+	val, lim := num.Float64(), limit.Float64()
+	switch {
+	case keyword == "minimum" && val < lim:
+		sv.violation(doc, docPath, schemaPath, keyword, "value %s is less than minimum %s", num.asString(), limit.asString())
+	case keyword == "maximum" && val > lim:
+		sv.violation(doc, docPath, schemaPath, keyword, "value %s is greater than maximum %s", num.asString(), limit.asString())
+	}
+	return true
+}
+
+func (sv *schemaValidator) validateLength(doc Value, keyword string, kw Value, docPath, schemaPath []pathSegment) bool {
+	limit, ok := kw.(*Number)
+	if !ok || limit.IsSigned || limit.Float64() != math.Trunc(limit.Float64()) {
+		return false
+	}
+
+	str, ok := doc.(*String)
+	if !ok {
+		return true
+	}
+
+	length, lim := utf8.RuneCountInString(decodedString(str)), int(limit.Float64())
+	switch {
+	case keyword == "minLength" && length < lim:
+		sv.violation(doc, docPath, schemaPath, keyword, "string length %d is less than %d", length, lim)
+	case keyword == "maxLength" && length > lim:
+		sv.violation(doc, docPath, schemaPath, keyword, "string length %d is greater than %d", length, lim)
+	}
+	return true
+}
+
+func (sv *schemaValidator) validatePattern(doc, kw Value, docPath, schemaPath []pathSegment) bool {
+	pattern, ok := kw.(*String)
+	if !ok {
+		return false
+	}
+
+	expr := decodedString(pattern)
+	re, ok := sv.patterns[expr]
+	if !ok {
+		var err error
+		if re, err = regexp.Compile(expr); err != nil {
+			re = nil
+		}
+		sv.patterns[expr] = re
+	}
+
+	if re == nil {
+		return false
+	}
+
+	if str, ok := doc.(*String); ok && !re.MatchString(decodedString(str)) {
+		sv.violation(doc, docPath, schemaPath, "pattern", "string doesn't match pattern %q", expr)
+	}
+	return true
+}
+
+func (sv *schemaValidator) validateAnyOf(doc, kw Value, docPath, schemaPath, kwPath []pathSegment) bool {
+	schemas, ok := kw.(*Array)
+	if !ok || len(schemas.Items) == 0 {
+		return false
+	}
+
+	for i, schema := range schemas.Items {
+		sub := &schemaValidator{
+			onDiagnostic: sv.onDiagnostic,
+			patterns:     sv.patterns,
+			reported:     sv.reported,
+		}
+
+		sub.validate(doc, schema, docPath, appendPathSegment(kwPath, pathSegment{index: i, isIndex: true}))
+		if len(sub.violations) == 0 {
+			return true
+		}
+	}
+
+	sv.violation(doc, docPath, schemaPath, "anyOf", "value doesn't match any of schemas")
+	return true
+}
+
+// decodedString returns decoded string value or raw value if string is malformed.
+func decodedString(s *String) string {
+	if s == nil {
+		return ""
+	}
+
+	str, err := unquoteString(s.rawValue)
+	if err != nil {
+		return s.RawString()
+	}
+	return str
+}
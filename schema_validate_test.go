@@ -0,0 +1,107 @@
+package jsonreflect
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	. "github.com/x1unix/jsonreflect/internal/testutil"
+)
+
+func TestValidateSchema_Suite(t *testing.T) {
+	suite, err := ValueOf(TestdataFixture("schema_suite.json").ProvideFixture(t))
+	require.NoError(t, err)
+
+	for _, group := range suite.(*Array).Items {
+		group := group.(*Object)
+		desc, _ := group.Get("description")
+		schema, _ := group.Get("schema")
+		tests, _ := group.Get("tests")
+		t.Run(desc.Interface().(string), func(t *testing.T) {
+			for _, tc := range tests.(*Array).Items {
+				tc := tc.(*Object)
+				desc, _ := tc.Get("description")
+				data, _ := tc.Get("data")
+				valid, _ := tc.Get("valid")
+
+				var ignored []string
+				got := ValidateSchema(data, schema, WithSchemaDiagnostics(func(d Diagnostic) {
+					ignored = append(ignored, d.String())
+				}))
+				require.Empty(t, ignored, desc)
+				require.Equal(t, valid.Interface(), len(got) == 0, "%s: %v", desc, got)
+			}
+		})
+	}
+}
+
+func TestValidateSchema(t *testing.T) {
+	schema, err := ValueOf([]byte(`{
+		"$schema": "http://json-schema.org/draft-07/schema#",
+		"type": "object",
+		"required": ["id", "name"],
+		"properties": {
+			"id": {"type": "integer", "minimum": 1},
+			"tags": {"items": {"type": "string", "maxLength": 3}, "uniqueItems": true},
+			"kind": {"enum": ["a", "b"], "format": "x"},
+			"code": {"pattern": "(", "minLength": -1}
+		},
+		"additionalProperties": false
+	}`))
+	require.NoError(t, err)
+
+	doc, err := ValueOf([]byte(`{"id": 0, "tags": ["foo", "quux", 1], "kind": "c", "code": ""}`))
+	require.NoError(t, err)
+
+	var ignored []string
+	got := ValidateSchema(doc, schema, WithSchemaDiagnostics(func(d Diagnostic) {
+		ignored = append(ignored, d.String())
+	}))
+
+	require.Equal(t, []SchemaViolation{
+		{
+			Position:   newPosition(0, 61),
+			SchemaPath: "required",
+			Keyword:    "required",
+			Message:    `missing required property "name"`,
+		},
+		{
+			Position:     newPosition(7, 7),
+			InstancePath: "id",
+			SchemaPath:   "properties.id.minimum",
+			Keyword:      "minimum",
+			Message:      "value 0 is less than minimum 1",
+		},
+		{
+			Position:     newPosition(26, 31),
+			InstancePath: "tags[1]",
+			SchemaPath:   "properties.tags.items.maxLength",
+			Keyword:      "maxLength",
+			Message:      "string length 4 is greater than 3",
+		},
+		{
+			Position:     newPosition(34, 34),
+			InstancePath: "tags[2]",
+			SchemaPath:   "properties.tags.items.type",
+			Keyword:      "type",
+			Message:      "expected string, got number",
+		},
+		{
+			Position:     newPosition(46, 48),
+			InstancePath: "kind",
+			SchemaPath:   "properties.kind.enum",
+			Keyword:      "enum",
+			Message:      "value is not one of allowed values",
+		},
+	}, got)
+
+	require.Equal(t, []string{
+		`schema keyword ignored: properties.tags.uniqueItems: unsupported keyword "uniqueItems" ignored`,
+		`schema keyword ignored: properties.kind.format: unsupported keyword "format" ignored`,
+		`schema keyword ignored: properties.code.pattern: invalid value of keyword "pattern" ignored`,
+		`schema keyword ignored: properties.code.minLength: invalid value of keyword "minLength" ignored`,
+		`schema keyword ignored: additionalProperties: unsupported keyword "additionalProperties" ignored`,
+	}, ignored)
+
+	require.Len(t, AllPositioned(got[1]), 1)
+	require.EqualError(t, got[1], "id: value 0 is less than minimum 1 (in range 7:7)")
+}
@@ -0,0 +1,69 @@
+package jsonreflect
+
+import "reflect"
+
+var typeEmptyStruct = reflect.TypeOf(struct{}{})
+
+// isSetElem reports whether type is a set element.
+//
+// Only unnamed struct{} is an element, named types might have own unmarshalers.
+func isSetElem(t reflect.Type) bool {
+	return t == typeEmptyStruct
+}
+
+// isSetDestination reports whether map destination accepts array of strings.
+//
+// Arrays are accepted by map[string]struct{} and by map[string]bool without strict mode.
+func (p unmarshalParams) isSetDestination(t reflect.Type) bool {
+	if t.Key().Kind() != reflect.String {
+		return false
+	}
+
+	elem := t.Elem()
+	return isSetElem(elem) || (elem.Kind() == reflect.Bool && !p.strict)
+}
+
+// unmarshalSetArray fills set map from array of strings, duplicate elements are merged.
+func unmarshalSetArray(src *Array, dst reflect.Value, p unmarshalParams) error {
+	if err := p.checkLimit(src, limitMaxMapEntries, p.maxMapEntries, len(src.Items)); err != nil {
+		return err
+	}
+
+	keyType, elemType := dst.Type().Key(), dst.Type().Elem()
+	present := reflect.New(elemType).Elem()
+	if elemType.Kind() == reflect.Bool {
+		present.SetBool(true)
+	}
+
+	m := reflect.MakeMapWithSize(dst.Type(), len(src.Items))
+	for i, item := range src.Items {
+		str, ok := item.(*String)
+		if !ok || str == nil {
+			return wrapElementError(newUnmarshalTypeErr(item, keyType), pathSegment{index: i, isIndex: true})
+		}
+
+		m.SetMapIndex(reflect.ValueOf(decodedString(str)).Convert(keyType), present)
+	}
+
+	dst.Set(m)
+	return nil
+}
+
+// isSetMember reports whether object member value marks key as a member of set.
+//
+// True values are members, false and null are not, see AllKeysAsSetMembers.
+// Object values are handled by caller.
+func (p unmarshalParams) isSetMember(v Value, elemType reflect.Type) (bool, error) {
+	if p.allKeysAsSetMembers {
+		return true, nil
+	}
+
+	if t, ok := v.(Boolean); ok {
+		return t.Value, nil
+	}
+
+	if TypeOf(v) == TypeNull {
+		return false, nil
+	}
+	return false, newUnmarshalTypeErr(v, elemType)
+}
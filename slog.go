@@ -0,0 +1,186 @@
+//go:build go1.21
+// +build go1.21
+
+package jsonreflect
+
+import (
+	"log/slog"
+)
+
+const (
+	defaultSlogMaxDepth     = 16
+	defaultSlogMaxGroupSize = 100
+)
+
+func init() {
+	buildFeatures = append(buildFeatures, FeatureSlog)
+}
+
+type slogParams struct {
+	maxDepth     int
+	maxGroupSize int
+}
+
+// SlogOption is SlogValue option.
+type SlogOption func(p *slogParams)
+
+// SlogMaxDepth sets max nesting depth of converted containers, 16 by default.
+//
+// Deeper arrays and objects are replaced by "…" string.
+// Zero or negative value disables the limit.
+func SlogMaxDepth(n int) SlogOption {
+	return func(p *slogParams) {
+		p.maxDepth = n
+	}
+}
+
+// SlogMaxGroupSize sets max count of converted object members and array items, 100 by default.
+//
+// Object with more members gets "…" attribute with count of omitted members.
+// Array with more items gets "…" string as a last item.
+// Zero or negative value disables the limit.
+func SlogMaxGroupSize(n int) SlogOption {
+	return func(p *slogParams) {
+		p.maxGroupSize = n
+	}
+}
+
+// SlogValue converts value tree to slog.Value, so parsed documents can be attached
+// to log records without marshaling to a string.
+//
+// Objects are converted to groups in source order, except empty objects which are
+// converted to empty map as handlers skip empty groups. Arrays are converted to slices,
+// numbers to Int64 or Float64 values. Nested objects in arrays are converted to maps.
+//
+// Handlers inline groups with empty key, so members with empty key
+// and object value are merged into parent group.
+func SlogValue(v Value, opts ...SlogOption) slog.Value {
+	p := slogParams{maxDepth: defaultSlogMaxDepth, maxGroupSize: defaultSlogMaxGroupSize}
+	for _, opt := range opts {
+		opt(&p)
+	}
+
+	return p.value(v, 0)
+}
+
+func (p slogParams) value(v Value, depth int) slog.Value {
+	switch t := v.(type) {
+	case *Object:
+		if t == nil {
+			return slog.AnyValue(nil)
+		}
+
+		if p.depthReached(depth) {
+			return slog.StringValue(truncatedMarker)
+		}
+
+		members := t.Members()
+		if len(members) == 0 {
+			return slog.AnyValue(map[string]interface{}{})
+		}
+
+		members, omitted := p.capMembers(members, t.truncated)
+		attrs := make([]slog.Attr, 0, len(members)+1)
+		for _, m := range members {
+			attrs = append(attrs, slog.Attr{Key: m.Key, Value: p.value(m.Value, depth+1)})
+		}
+
+		if omitted > 0 {
+			attrs = append(attrs, slog.Int(truncatedMarker, omitted))
+		}
+		return slog.GroupValue(attrs...)
+	case *Array, Null:
+		return slog.AnyValue(p.any(v, depth))
+	case Boolean:
+		return slog.BoolValue(t.Value)
+	case *Number:
+		if t == nil {
+			return slog.AnyValue(nil)
+		}
+
+		if t.IsFloat {
+			return slog.Float64Value(t.Float64())
+		}
+		return slog.Int64Value(t.Int64())
+	case *String:
+		if t == nil {
+			return slog.AnyValue(nil)
+		}
+		return slog.StringValue(decodedString(t))
+	default:
+		return slog.AnyValue(nil)
+	}
+}
+
+// any converts value to Go value used inside slice values.
+func (p slogParams) any(v Value, depth int) interface{} {
+	switch t := v.(type) {
+	case *Array:
+		if t == nil {
+			return nil
+		}
+
+		if p.depthReached(depth) {
+			return truncatedMarker
+		}
+
+		items := t.Items
+		truncated := t.truncated
+		if p.maxGroupSize > 0 && len(items) > p.maxGroupSize {
+			items, truncated = items[:p.maxGroupSize], true
+		}
+
+		out := make([]interface{}, 0, len(items)+1)
+		for _, item := range items {
+			out = append(out, p.any(item, depth+1))
+		}
+
+		if truncated {
+			out = append(out, truncatedMarker)
+		}
+		return out
+	case *Object:
+		if t == nil {
+			return nil
+		}
+
+		if p.depthReached(depth) {
+			return truncatedMarker
+		}
+
+		members, omitted := p.capMembers(t.Members(), t.truncated)
+		out := make(map[string]interface{}, len(members)+1)
+		for _, m := range members {
+			out[m.Key] = p.any(m.Value, depth+1)
+		}
+
+		if omitted > 0 {
+			out[truncatedMarker] = omitted
+		}
+		return out
+	case nil, Null:
+		return nil
+	default:
+		return p.value(v, depth).Any()
+	}
+}
+
+func (p slogParams) depthReached(depth int) bool {
+	return p.maxDepth > 0 && depth >= p.maxDepth
+}
+
+// capMembers returns members within group size limit and count of omitted members.
+//
+// Count is at least 1 for objects truncated by parser.
+func (p slogParams) capMembers(members []Member, truncated bool) ([]Member, int) {
+	omitted := 0
+	if p.maxGroupSize > 0 && len(members) > p.maxGroupSize {
+		omitted = len(members) - p.maxGroupSize
+		members = members[:p.maxGroupSize]
+	}
+
+	if truncated && omitted == 0 {
+		omitted = 1
+	}
+	return members, omitted
+}
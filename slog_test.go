@@ -0,0 +1,100 @@
+//go:build go1.21
+// +build go1.21
+
+package jsonreflect
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// slogJSON returns JSON handler output of value attribute.
+func slogJSON(t *testing.T, v slog.Value) string {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(buf, nil))
+	logger.LogAttrs(context.Background(), slog.LevelInfo, "test", slog.Attr{Key: "doc", Value: v})
+
+	record := map[string]json.RawMessage{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	return string(record["doc"])
+}
+
+func TestSlogValue(t *testing.T) {
+	cases := map[string]string{
+		"object":       `{"id": 1, "name": "foo", "ok": true, "none": null, "pi": 3.14}`,
+		"nested":       `{"user": {"tags": ["a", {"b": [1, 2.5]}], "meta": {}}, "list": [[], {}]}`,
+		"escaped":      `{"text": "say \"hi\"\n", "unié": "☃"}`,
+		"array":        `[1, "a", {"b": null}]`,
+		"scalar":       `-42`,
+		"float number": `1.5e-3`,
+	}
+
+	for n, src := range cases {
+		t.Run(n, func(t *testing.T) {
+			v, err := NewParser([]byte(src)).Parse()
+			require.NoError(t, err)
+
+			want, err := MarshalValue(v, nil)
+			require.NoError(t, err)
+			require.JSONEq(t, string(want), slogJSON(t, SlogValue(v)))
+		})
+	}
+
+	v, err := NewParser([]byte(`{"a": 1, "b": 2.0}`)).Parse()
+	require.NoError(t, err)
+	attrs := SlogValue(v).Group()
+	require.Len(t, attrs, 2)
+	require.Equal(t, slog.KindInt64, attrs[0].Value.Kind())
+	require.Equal(t, slog.KindFloat64, attrs[1].Value.Kind())
+}
+
+func TestSlogValue_Feature(t *testing.T) {
+	require.True(t, Supports(FeatureSlog))
+}
+
+func TestSlogValue_Limits(t *testing.T) {
+	cases := map[string]struct {
+		src  string
+		opts []SlogOption
+		want string
+	}{
+		"depth": {
+			src:  `{"a": {"b": {"c": 1}, "d": [[1]]}, "e": 2}`,
+			opts: []SlogOption{SlogMaxDepth(2)},
+			want: `{"a": {"b": "…", "d": "…"}, "e": 2}`,
+		},
+		"group size": {
+			src:  `{"a": 1, "b": [1, 2, 3], "c": {"x": 1, "y": 2}}`,
+			opts: []SlogOption{SlogMaxGroupSize(2)},
+			want: `{"a": 1, "b": [1, 2, "…"], "…": 1}`,
+		},
+		"objects in arrays": {
+			src:  `[{"x": 1, "y": 2, "z": 3}]`,
+			opts: []SlogOption{SlogMaxGroupSize(2)},
+			want: `[{"x": 1, "y": 2, "…": 1}]`,
+		},
+		"no limits": {
+			src:  `{"a": {"b": {"c": [1, 2, 3]}}}`,
+			opts: []SlogOption{SlogMaxDepth(0), SlogMaxGroupSize(0)},
+			want: `{"a": {"b": {"c": [1, 2, 3]}}}`,
+		},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			v, err := NewParser([]byte(c.src)).Parse()
+			require.NoError(t, err)
+			require.JSONEq(t, c.want, slogJSON(t, SlogValue(v, c.opts...)))
+		})
+	}
+
+	v, err := NewParser([]byte(`{"a": [1, 2], "b": {"c": 1}}`), MaxLeaves(1)).Parse()
+	require.NoError(t, err)
+	require.JSONEq(t, `{"a": [1, "…"], "…": 1}`, slogJSON(t, SlogValue(v)))
+}
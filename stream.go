@@ -0,0 +1,388 @@
+package jsonreflect
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// ErrStopStream can be returned by StreamArray callback to stop iteration without error.
+var ErrStopStream = errors.New("stop stream")
+
+// StreamArray locates array at passed path in JSON document and calls fn
+// for each array element in order.
+//
+// Path is accepted in dotted or JSON Pointer form, empty path refers to document root.
+//
+// Elements are read and parsed one at a time, so memory usage is bound by the largest element.
+// Element positions are relative to element start.
+//
+// Values preceding the array are checked only for balanced brackets and terminated strings,
+// document contents after the array are not read.
+//
+// Iteration stops when fn returns an error. ErrStopStream stops iteration without error.
+//
+// Parser options are applied to each element.
+func StreamArray(r io.Reader, path string, fn func(i int, v Value) error, opts ...ParserOption) error {
+	segments, err := splitPath(path)
+	if err != nil {
+		return err
+	}
+
+	s := &streamScanner{r: bufio.NewReader(r), opts: opts, pool: NewParser(nil, opts...).pool}
+	if err := s.skipBOM(); err != nil {
+		return err
+	}
+
+	char, err := s.next()
+	if err != nil {
+		return err
+	}
+
+	for _, seg := range segments {
+		found, err := s.seek(char, seg)
+		if err != nil {
+			return err
+		}
+
+		if !found {
+			return fmt.Errorf("value at path %q not found", path)
+		}
+
+		if char, err = s.next(); err != nil {
+			return err
+		}
+	}
+
+	if char != tokenArrayStart {
+		return fmt.Errorf("value at path %q is not an array", path)
+	}
+
+	err = s.streamArray(fn)
+	if err == ErrStopStream {
+		return nil
+	}
+	return err
+}
+
+// streamScanner is forward-only JSON scanner over a reader.
+type streamScanner struct {
+	r      *bufio.Reader
+	offset int
+	opts   []ParserOption
+
+	// pool is scratch buffers pool, see WithParserPool
+	pool Pool
+}
+
+func (s *streamScanner) skipBOM() error {
+	prefix, err := s.r.Peek(len(bomUTF8))
+	if err != nil && err != io.EOF {
+		return err
+	}
+
+	if bytes.Equal(prefix, bomUTF8) {
+		_, err = s.r.Discard(len(bomUTF8))
+		s.offset += len(bomUTF8)
+	}
+	return err
+}
+
+func (s *streamScanner) readByte() (byte, error) {
+	char, err := s.r.ReadByte()
+	if err == io.EOF {
+		return 0, NewUnexpectedEOFError(newPosition(s.offset, s.offset), "unexpected end of document")
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	s.offset++
+	return char, nil
+}
+
+func (s *streamScanner) unreadByte() {
+	_ = s.r.UnreadByte()
+	s.offset--
+}
+
+// next returns next non-whitespace character.
+func (s *streamScanner) next() (byte, error) {
+	for {
+		char, err := s.readByte()
+		if err != nil {
+			return 0, err
+		}
+
+		switch char {
+		case '\t', '\r', '\n', ' ':
+			continue
+		default:
+			return char, nil
+		}
+	}
+}
+
+// expect reads next non-whitespace character and checks that it's one of passed characters.
+func (s *streamScanner) expect(chars ...byte) (byte, error) {
+	char, err := s.next()
+	if err != nil {
+		return 0, err
+	}
+
+	if bytes.IndexByte(chars, char) == -1 {
+		pos := s.offset - 1
+		return 0, NewParseError(newPosition(pos, pos), "expected one of %q but got %q at offset %d", chars, char, pos)
+	}
+	return char, nil
+}
+
+// seek moves scanner to value with passed key or index
+// inside container which starts with passed character.
+func (s *streamScanner) seek(char byte, seg string) (bool, error) {
+	switch char {
+	case tokenObjectStart:
+		return s.seekKey(seg)
+	case tokenArrayStart:
+		index, err := strconv.Atoi(seg)
+		if err != nil || index < 0 {
+			return false, nil
+		}
+		return s.seekIndex(index)
+	default:
+		return false, nil
+	}
+}
+
+func (s *streamScanner) seekKey(key string) (bool, error) {
+	buf := getBuffer(s.pool, 64)
+	defer func() {
+		putBuffer(s.pool, buf)
+	}()
+
+	for first := true; ; first = false {
+		if !first {
+			char, err := s.expect(tokenDelimiter, tokenObjectClose)
+			if err != nil || char == tokenObjectClose {
+				return false, err
+			}
+		}
+
+		char, err := s.next()
+		if err != nil {
+			return false, err
+		}
+
+		if char == tokenObjectClose && first {
+			return false, nil
+		}
+
+		if char != tokenString {
+			return false, NewUnexpectedCharacterError(s.offset-1, s.offset, char)
+		}
+
+		start := s.offset - 1
+		if buf, err = s.consume(char, buf[:0], true); err != nil {
+			return false, err
+		}
+
+		if _, err = s.expect(tokenKeyDelimiter); err != nil {
+			return false, err
+		}
+
+		str, err := newString(newPosition(start, s.offset-1), buf).String()
+		if err != nil {
+			return false, NewParseError(newPosition(start, start), err.Error())
+		}
+
+		if str == key {
+			return true, nil
+		}
+
+		if char, err = s.next(); err != nil {
+			return false, err
+		}
+
+		if _, err = s.consume(char, nil, false); err != nil {
+			return false, err
+		}
+	}
+}
+
+func (s *streamScanner) seekIndex(index int) (bool, error) {
+	for i := 0; ; i++ {
+		if i > 0 {
+			char, err := s.expect(tokenDelimiter, tokenArrayClose)
+			if err != nil || char == tokenArrayClose {
+				return false, err
+			}
+		}
+
+		char, err := s.next()
+		if err != nil {
+			return false, err
+		}
+
+		if char == tokenArrayClose && i == 0 {
+			return false, nil
+		}
+
+		if i == index {
+			s.unreadByte()
+			return true, nil
+		}
+
+		if _, err = s.consume(char, nil, false); err != nil {
+			return false, err
+		}
+	}
+}
+
+// streamArray parses elements of array after array start character.
+func (s *streamScanner) streamArray(fn func(i int, v Value) error) error {
+	var size int
+	for i := 0; ; i++ {
+		if i > 0 {
+			char, err := s.expect(tokenDelimiter, tokenArrayClose)
+			if err != nil || char == tokenArrayClose {
+				return err
+			}
+		}
+
+		char, err := s.next()
+		if err != nil {
+			return err
+		}
+
+		if char == tokenArrayClose && i == 0 {
+			return nil
+		}
+
+		// buffer is not reused, since parsed values refer to it
+		start := s.offset - 1
+		buf, err := s.consume(char, make([]byte, 0, size), true)
+		if err != nil {
+			return err
+		}
+
+		size = len(buf)
+		v, err := NewParser(buf, s.opts...).Parse()
+		if err != nil {
+			return fmt.Errorf("invalid array element #%d at offset %d: %w", i, start, err)
+		}
+
+		if err = fn(i, v); err != nil {
+			return err
+		}
+	}
+}
+
+// consume reads value which starts with passed character.
+//
+// If capture is true, value contents are appended to buf.
+// Containers are checked only for balanced brackets.
+func (s *streamScanner) consume(char byte, buf []byte, capture bool) ([]byte, error) {
+	if capture {
+		buf = append(buf, char)
+	}
+
+	switch char {
+	case tokenString:
+		return s.consumeString(buf, capture)
+	case tokenObjectStart, tokenArrayStart:
+	case tokenObjectClose, tokenArrayClose, tokenDelimiter, tokenKeyDelimiter:
+		return nil, NewUnexpectedCharacterError(s.offset-1, s.offset, char)
+	default:
+		return s.consumeScalar(buf, capture)
+	}
+
+	stack := append(getBuffer(s.pool, 8), closingToken(char))
+	defer func() {
+		putBuffer(s.pool, stack)
+	}()
+
+	for len(stack) > 0 {
+		char, err := s.readByte()
+		if err != nil {
+			return nil, err
+		}
+
+		if capture {
+			buf = append(buf, char)
+		}
+
+		switch char {
+		case tokenString:
+			if buf, err = s.consumeString(buf, capture); err != nil {
+				return nil, err
+			}
+		case tokenObjectStart, tokenArrayStart:
+			stack = append(stack, closingToken(char))
+		case tokenObjectClose, tokenArrayClose:
+			if stack[len(stack)-1] != char {
+				return nil, NewUnexpectedCharacterError(s.offset-1, s.offset, char)
+			}
+			stack = stack[:len(stack)-1]
+		}
+	}
+	return buf, nil
+}
+
+// consumeString reads string contents after opening quote.
+func (s *streamScanner) consumeString(buf []byte, capture bool) ([]byte, error) {
+	escaped := false
+	for {
+		char, err := s.readByte()
+		if err != nil {
+			return nil, err
+		}
+
+		if capture {
+			buf = append(buf, char)
+		}
+
+		switch {
+		case escaped:
+			escaped = false
+		case char == '\\':
+			escaped = true
+		case char == tokenString:
+			return buf, nil
+		}
+	}
+}
+
+// consumeScalar reads scalar value until next delimiter.
+func (s *streamScanner) consumeScalar(buf []byte, capture bool) ([]byte, error) {
+	for {
+		char, err := s.r.ReadByte()
+		if err == io.EOF {
+			return buf, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch char {
+		case '\t', '\r', '\n', ' ', tokenDelimiter, tokenArrayClose, tokenObjectClose,
+			tokenString, tokenKeyDelimiter:
+			_ = s.r.UnreadByte()
+			return buf, nil
+		}
+
+		s.offset++
+		if capture {
+			buf = append(buf, char)
+		}
+	}
+}
+
+func closingToken(char byte) byte {
+	if char == tokenObjectStart {
+		return tokenObjectClose
+	}
+	return tokenArrayClose
+}
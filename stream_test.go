@@ -0,0 +1,179 @@
+package jsonreflect
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamArray(t *testing.T) {
+	src := "\xef\xbb\xbf" + `{
+		"meta": {"a": [1, "]"], "b\"": "x"},
+		"data": {"items": [1, "x", {"a": [1, 2]}, [], null, -2.5]},
+		"list": [[1], [2, 3]],
+		"scalar": 1,
+		"": {"": [true]}
+	}`
+
+	cases := map[string]struct {
+		src  string
+		path string
+		want []interface{}
+		err  string
+	}{
+		"dotted path": {
+			path: "data.items",
+			want: []interface{}{1, "x", map[string]interface{}{"a": []interface{}{1, 2}}, []interface{}{}, nil, -2.5},
+		},
+		"pointer path": {
+			path: "/list/1",
+			want: []interface{}{2, 3},
+		},
+		"empty keys": {
+			path: `[""][""]`,
+			want: []interface{}{true},
+		},
+		"empty keys pointer": {
+			path: "//",
+			want: []interface{}{true},
+		},
+		"root": {
+			src:  `[ {"a": 1} , "b" ]`,
+			want: []interface{}{map[string]interface{}{"a": 1}, "b"},
+		},
+		"empty array": {
+			src:  ` [ ] `,
+			want: nil,
+		},
+		"not found": {
+			path: "data.other",
+			err:  `value at path "data.other" not found`,
+		},
+		"index out of range": {
+			path: "list[2]",
+			err:  `value at path "list[2]" not found`,
+		},
+		"not an array": {
+			path: "scalar",
+			err:  `value at path "scalar" is not an array`,
+		},
+		"invalid element": {
+			src: `[1, tru, 3]`,
+			err: `invalid array element #1 at offset 4: unexpected "tru" (in range 0:3)`,
+		},
+		"unterminated array": {
+			src: `[1, 2`,
+			err: `unexpected end of document (in range 5:5)`,
+		},
+		"missing delimiter": {
+			src: `[1 2]`,
+			err: `expected one of ",]" but got '2' at offset 3 (in range 3:3)`,
+		},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			if c.src == "" {
+				c.src = src
+			}
+
+			var got []interface{}
+			err := StreamArray(strings.NewReader(c.src), c.path, func(i int, v Value) error {
+				require.Equal(t, len(got), i)
+				got = append(got, v.Interface())
+				return nil
+			})
+			if c.err != "" {
+				require.EqualError(t, err, c.err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, c.want, got)
+		})
+	}
+}
+
+func TestStreamArray_Stop(t *testing.T) {
+	errCustom := errors.New("custom")
+	for _, stopErr := range []error{ErrStopStream, errCustom} {
+		count := 0
+		err := StreamArray(strings.NewReader(`[1, 2, 3, 4`), "", func(i int, v Value) error {
+			count++
+			if i == 1 {
+				return stopErr
+			}
+			return nil
+		})
+
+		require.Equal(t, 2, count)
+		if stopErr == ErrStopStream {
+			require.NoError(t, err)
+			continue
+		}
+		require.Equal(t, errCustom, err)
+	}
+}
+
+// arrayReader generates JSON document with array of n elements on the fly.
+type arrayReader struct {
+	n   int
+	i   int
+	buf []byte
+}
+
+func (r *arrayReader) Read(p []byte) (int, error) {
+	if len(r.buf) == 0 {
+		switch {
+		case r.i == 0:
+			r.buf = []byte(`{"items": [`)
+		case r.i <= r.n:
+			r.buf = []byte(fmt.Sprintf(`{"id": %d, "name": "item %d", "tags": ["a", "b"]},`, r.i, r.i))
+			if r.i == r.n {
+				r.buf = r.buf[:len(r.buf)-1]
+			}
+		case r.i == r.n+1:
+			r.buf = []byte(`]}`)
+		default:
+			return 0, io.EOF
+		}
+		r.i++
+	}
+
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+func TestStreamArray_Memory(t *testing.T) {
+	streamAllocs := func(n int) (allocsPerElem float64, maxHeapGrowth uint64) {
+		var before, stats runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+
+		err := StreamArray(&arrayReader{n: n}, "items", func(i int, v Value) error {
+			if i%20000 == 0 {
+				runtime.GC()
+				runtime.ReadMemStats(&stats)
+				if stats.HeapAlloc > before.HeapAlloc && stats.HeapAlloc-before.HeapAlloc > maxHeapGrowth {
+					maxHeapGrowth = stats.HeapAlloc - before.HeapAlloc
+				}
+			}
+			return nil
+		})
+		require.NoError(t, err)
+
+		runtime.ReadMemStats(&stats)
+		return float64(stats.Mallocs-before.Mallocs) / float64(n), maxHeapGrowth
+	}
+
+	smallAllocs, _ := streamAllocs(1000)
+	allocs, heapGrowth := streamAllocs(100000)
+	require.InDelta(t, smallAllocs, allocs, smallAllocs*0.1)
+	require.Less(t, heapGrowth, uint64(1<<20))
+}
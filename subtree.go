@@ -0,0 +1,184 @@
+package jsonreflect
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Rebase returns a copy of value tree with positions shifted
+// so the value starts at zero offset.
+//
+// Useful when nested value is passed to a component which reports
+// errors relative to the value itself instead of a parent document.
+//
+// Custom values are not copied and keep original positions.
+// Returns *CycleError if value tree contains a reference to own ancestor.
+func Rebase(v Value) (Value, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	r := &rebaser{offset: v.Ref().Start, failOnCycle: true}
+	out := r.rebase(v)
+	if r.err != nil {
+		return nil, r.err
+	}
+	return out, nil
+}
+
+func rebasePosition(pos Position, offset int) Position {
+	return Position{Start: pos.Start - offset, End: pos.End - offset}
+}
+
+// rebaser copies value trees with shifted positions.
+type rebaser struct {
+	offset int
+	guard  cycleGuard
+
+	// failOnCycle stops copy on reference to own ancestor and sets err,
+	// otherwise such references are kept as-is.
+	failOnCycle bool
+	err         error
+}
+
+func (r *rebaser) rebase(v Value) Value {
+	offset := r.offset
+	switch t := v.(type) {
+	case *String:
+		if t == nil {
+			return t
+		}
+		return newString(rebasePosition(t.Position, offset), t.rawValue)
+	case Boolean:
+		return newBoolean(rebasePosition(t.Position, offset), t.Value)
+	case Null:
+		return newNull(rebasePosition(t.Position, offset))
+	case *Number:
+		if t == nil {
+			return t
+		}
+		num := *t
+		num.gen = valueGeneration{}
+		num.Position = rebasePosition(t.Position, offset)
+		return &num
+	case *Array:
+		if t == nil || !r.enter(t) {
+			return t
+		}
+		defer r.guard.leave()
+
+		items := make([]Value, 0, len(t.Items))
+		for _, item := range t.Items {
+			items = append(items, r.rebase(item))
+		}
+		return newArray(rebasePosition(t.Position, offset), items...)
+	case *Object:
+		if t == nil || !r.enter(t) {
+			return t
+		}
+		defer r.guard.leave()
+
+		members := t.Members()
+		items := make(map[string]Value, len(members))
+		for i, m := range members {
+			m.Value = r.rebase(m.Value)
+			if m.KeyPos != (Position{}) {
+				m.KeyPos = rebasePosition(m.KeyPos, offset)
+			}
+			members[i] = m
+			items[m.Key] = m.Value
+		}
+		pos := rebasePosition(t.Position, offset)
+		return newObject(pos.Start, pos.End, items, members)
+	default:
+		return v
+	}
+}
+
+// enter registers container being copied.
+//
+// Returns false if container should not be copied.
+func (r *rebaser) enter(v Value) bool {
+	if r.err != nil {
+		return false
+	}
+
+	if r.guard.enter(v) {
+		return true
+	}
+
+	if r.failOnCycle {
+		r.err = r.guard.newError(v)
+	}
+	return false
+}
+
+// PositionMapEntry is a pair of value position in serialized output and its original position.
+type PositionMapEntry struct {
+	// Output is value position in serialized output
+	Output Position
+
+	// Original is value position in source document
+	Original Position
+}
+
+// PositionMap maps positions of values in serialized output to original positions.
+type PositionMap struct {
+	// Entries contains value positions in serialization order
+	Entries []PositionMapEntry
+}
+
+// LocateOffset returns original position of the innermost value
+// which contains passed offset of serialized output.
+func (m *PositionMap) LocateOffset(offset int) (Position, bool) {
+	if m == nil {
+		return Position{}, false
+	}
+
+	found := -1
+	for i, e := range m.Entries {
+		if e.Output.Start > offset {
+			// entries are sorted by output start, no more candidates
+			break
+		}
+
+		if offset <= e.Output.End {
+			found = i
+		}
+	}
+
+	if found == -1 {
+		return Position{}, false
+	}
+	return m.Entries[found].Original, true
+}
+
+// positionRecorder collects output positions of marshaled values
+type positionRecorder struct {
+	buff *bytes.Buffer
+	dst  *PositionMap
+}
+
+func (r *positionRecorder) begin(v Value) int {
+	r.dst.Entries = append(r.dst.Entries, PositionMapEntry{
+		Output:   Position{Start: r.buff.Len()},
+		Original: v.Ref(),
+	})
+	return len(r.dst.Entries) - 1
+}
+
+func (r *positionRecorder) end(index int) {
+	r.dst.Entries[index].Output.End = r.buff.Len() - 1
+}
+
+// MarshalSubtree returns compact JSON encoding of passed value
+// and map of output positions to original positions of each value.
+func MarshalSubtree(v Value) ([]byte, *PositionMap, error) {
+	buff := &bytes.Buffer{}
+	recorder := &positionRecorder{buff: buff, dst: &PositionMap{}}
+	mf := &marshalFormatter{isRoot: true, recorder: recorder, guard: &cycleGuard{}}
+	if err := marshalValue(buff, v, mf); err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal JSON %s: %w", TypeOf(v), err)
+	}
+	return buff.Bytes(), recorder.dst, nil
+}
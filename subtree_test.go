@@ -0,0 +1,79 @@
+package jsonreflect
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	. "github.com/x1unix/jsonreflect/internal/testutil"
+)
+
+func TestRebase(t *testing.T) {
+	src := TestdataFixture("obj_simple.json").ProvideFixture(t)
+	doc, err := ValueOf(src)
+	require.NoError(t, err)
+
+	meta := doc.(*Object).Items["meta"]
+	got, err := Rebase(meta)
+	require.NoError(t, err)
+	require.Equal(t, newTestObject(0, 53,
+		Member{Key: "first_name", KeyPos: newPosition(6, 17), Value: newString(newPosition(20, 25), []byte(`"John"`))},
+		Member{Key: "last_name", KeyPos: newPosition(32, 42), Value: newString(newPosition(45, 49), []byte(`"Doe"`))},
+	), got)
+
+	// original value should be untouched
+	require.Equal(t, newPosition(233, 286), meta.Ref())
+
+	roles, err := Rebase(doc.(*Object).Items["roles"])
+	require.NoError(t, err)
+	require.Equal(t, newArray(newPosition(0, 16),
+		newString(newPosition(1, 6), []byte(`"root"`)),
+		newString(newPosition(9, 15), []byte(`"owner"`))), roles)
+
+	got, err = Rebase(nil)
+	require.NoError(t, err)
+	require.Nil(t, got)
+}
+
+func TestMarshalSubtree(t *testing.T) {
+	src := TestdataFixture("obj_simple.json").ProvideFixture(t)
+	doc, err := ValueOf(src)
+	require.NoError(t, err)
+
+	obj := doc.(*Object)
+	got, posMap, err := MarshalSubtree(obj)
+	require.NoError(t, err)
+
+	want, err := MarshalValue(obj, nil)
+	require.NoError(t, err)
+	require.Equal(t, string(want), string(got))
+
+	// every value in output should point to the same value in source
+	require.NotEmpty(t, posMap.Entries)
+	for _, e := range posMap.Entries {
+		orig, ok := posMap.LocateOffset(e.Output.Start)
+		require.True(t, ok)
+		require.Equal(t, e.Original, orig)
+
+		outVal := got[e.Output.Start : e.Output.End+1]
+		if outVal[0] == tokenObjectStart || outVal[0] == tokenArrayStart {
+			// containers are formatted differently
+			continue
+		}
+		require.Equal(t, string(src[orig.Start:orig.End+1]), string(outVal))
+	}
+
+	// offset between values resolves to parent
+	delimOffset := bytes.Index(got, []byte(`["root",`)) + len(`["root"`)
+	require.Equal(t, byte(tokenDelimiter), got[delimOffset])
+	orig, ok := posMap.LocateOffset(delimOffset)
+	require.True(t, ok)
+	require.Equal(t, obj.Items["roles"].Ref(), orig)
+
+	orig, ok = posMap.LocateOffset(bytes.Index(got, []byte(`"user"`)))
+	require.True(t, ok)
+	require.Equal(t, obj.Ref(), orig)
+
+	_, ok = posMap.LocateOffset(len(got))
+	require.False(t, ok)
+}
@@ -0,0 +1,78 @@
+package jsonreflect
+
+// Structural tokens and quote character of JSON grammar.
+const (
+	TokenObjectStart  byte = tokenObjectStart
+	TokenObjectClose  byte = tokenObjectClose
+	TokenArrayStart   byte = tokenArrayStart
+	TokenArrayClose   byte = tokenArrayClose
+	TokenKeyDelimiter byte = tokenKeyDelimiter
+	TokenDelimiter    byte = tokenDelimiter
+	TokenQuote        byte = tokenString
+)
+
+// Literal spellings of JSON values.
+//
+// Slices are copies of parser literals, changing them doesn't affect parser.
+var (
+	LiteralTrue  = []byte("true")
+	LiteralFalse = []byte("false")
+	LiteralNull  = []byte("null")
+)
+
+// ByteClass is a class of source byte, see ClassifyByte.
+type ByteClass uint8
+
+const (
+	// ByteOther is any byte of other classes, like letters of literals,
+	// number sign, fraction and exponent characters or bytes of strings.
+	ByteOther ByteClass = iota
+
+	// ByteWhitespace is insignificant whitespace: space, tab, line feed or carriage return.
+	ByteWhitespace
+
+	// ByteStructural is one of structural tokens: {, }, [, ], : or comma.
+	ByteStructural
+
+	// ByteQuote is string quote.
+	ByteQuote
+
+	// ByteDigit is decimal digit.
+	ByteDigit
+)
+
+// String returns byte class name
+func (c ByteClass) String() string {
+	switch c {
+	case ByteWhitespace:
+		return "whitespace"
+	case ByteStructural:
+		return "structural"
+	case ByteQuote:
+		return "quote"
+	case ByteDigit:
+		return "digit"
+	default:
+		return "other"
+	}
+}
+
+// ClassifyByte returns class of a byte outside of string, in the same way as parser does.
+//
+// Numbers start with a digit or '-', literals start with the first byte of
+// LiteralTrue, LiteralFalse or LiteralNull.
+func ClassifyByte(b byte) ByteClass {
+	switch b {
+	case '\t', '\r', '\n', ' ':
+		return ByteWhitespace
+	case tokenObjectStart, tokenObjectClose, tokenArrayStart, tokenArrayClose, tokenKeyDelimiter, tokenDelimiter:
+		return ByteStructural
+	case tokenString:
+		return ByteQuote
+	}
+
+	if b >= '0' && b <= '9' {
+		return ByteDigit
+	}
+	return ByteOther
+}
@@ -0,0 +1,51 @@
+package jsonreflect
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyByte(t *testing.T) {
+	// documents which require structural token in place of "%"
+	structural := map[byte]string{
+		TokenObjectStart:  `[%}]`,
+		TokenObjectClose:  `[{%]`,
+		TokenArrayStart:   `{"a":%]}`,
+		TokenArrayClose:   `{"a":[%}`,
+		TokenKeyDelimiter: `{"a"%1}`,
+		TokenDelimiter:    `[1%2]`,
+	}
+
+	for i := 0; i < 256; i++ {
+		b := byte(i)
+		class := ClassifyByte(b)
+
+		v, err := NewParser([]byte{b, '1', b}).Parse()
+		require.Equal(t, class == ByteWhitespace, err == nil && Equal(v, NewNumberInt(1), EqualOptions{}), "%q is %s", b, class)
+
+		v, err = NewParser([]byte{b, 'x', b}).Parse()
+		require.Equal(t, class == ByteQuote, err == nil && Equal(v, NewString("x"), EqualOptions{}), "%q is %s", b, class)
+
+		v, err = NewParser([]byte{b}).Parse()
+		require.Equal(t, class == ByteDigit, err == nil && Equal(v, NewNumberInt(int64(b)-'0'), EqualOptions{}), "%q is %s", b, class)
+
+		for token, src := range structural {
+			want, err := NewParser([]byte(strings.Replace(src, "%", string(token), 1))).Parse()
+			require.NoError(t, err)
+
+			v, err := NewParser([]byte(strings.Replace(src, "%", string([]byte{b}), 1))).Parse()
+			require.Equal(t, b == token, err == nil && Equal(v, want, EqualOptions{}), "%q in %s", b, src)
+		}
+	}
+
+	for _, lit := range [][]byte{LiteralTrue, LiteralFalse, LiteralNull} {
+		require.Equal(t, ByteOther, ClassifyByte(lit[0]))
+		v, err := NewParser(lit).Parse()
+		require.NoError(t, err)
+		out, err := MarshalValue(v, nil)
+		require.NoError(t, err)
+		require.Equal(t, lit, out)
+	}
+}
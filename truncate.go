@@ -0,0 +1,89 @@
+package jsonreflect
+
+import "errors"
+
+// ErrTruncatedValue means that value was truncated by parser and can't be marshaled.
+//
+// See MaxLeaves and WithTruncatedValues.
+var ErrTruncatedValue = errors.New("value is truncated")
+
+// truncatedMarker is appended to truncated arrays and used as a key of truncated objects on marshal.
+const truncatedMarker = "…"
+
+// leafBudget tracks count of parsed scalar values.
+type leafBudget struct {
+	max   int
+	count int
+
+	// exhausted is set when a container was truncated
+	exhausted bool
+}
+
+// MaxLeaves stops building value tree after n scalar values.
+//
+// Arrays and objects which have unparsed items are marked as truncated, see Truncated.
+// Rest of document is not parsed or validated, so truncated tree is lossy
+// and intended only for previews. Marshal of truncated tree returns ErrTruncatedValue
+// unless WithTruncatedValues option is passed.
+//
+// Empty containers don't count as values and are parsed after limit is reached.
+// End position of truncated container is an offset before the first skipped item.
+// Zero or negative value disables the limit.
+func MaxLeaves(n int) ParserOption {
+	return func(p *Parser) {
+		if n <= 0 {
+			p.leaves = nil
+			return
+		}
+
+		p.leaves = &leafBudget{max: n}
+	}
+}
+
+// Truncated reports whether array or object was truncated by parser or contains truncated values.
+//
+// See MaxLeaves.
+func Truncated(v Value) bool {
+	switch t := v.(type) {
+	case *Array:
+		return t != nil && t.truncated
+	case *Object:
+		return t != nil && t.truncated
+	default:
+		return false
+	}
+}
+
+// isScalarStart reports whether value which starts with char is not a container.
+//
+// Containers are parsed after budget is reached, as they might be empty.
+func isScalarStart(char byte) bool {
+	return char != tokenArrayStart && char != tokenObjectStart
+}
+
+// reached reports whether next scalar value should not be parsed.
+func (b *leafBudget) reached() bool {
+	return b != nil && b.count >= b.max
+}
+
+// isExhausted reports whether parse was stopped.
+func (b *leafBudget) isExhausted() bool {
+	return b != nil && b.exhausted
+}
+
+func (b *leafBudget) add(v Value) {
+	if b == nil {
+		return
+	}
+
+	switch v.(type) {
+	case *Array, *Object:
+	default:
+		b.count++
+	}
+}
+
+// truncate marks parse as stopped.
+func (b *leafBudget) truncate() {
+	b.exhausted = true
+}
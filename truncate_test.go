@@ -0,0 +1,127 @@
+package jsonreflect
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	. "github.com/x1unix/jsonreflect/internal/testutil"
+)
+
+func TestMaxLeaves(t *testing.T) {
+	cases := map[string]struct {
+		src       string
+		max       int
+		want      string
+		truncated bool
+	}{
+		"array": {
+			src:       `[1, 2, 3, 4]`,
+			max:       2,
+			want:      `[1,2,"…"]`,
+			truncated: true,
+		},
+		"object": {
+			src:       `{"a": 1, "b": 2, "c": 3}`,
+			max:       1,
+			want:      `{"a":1,"…":null}`,
+			truncated: true,
+		},
+		"nested": {
+			src:       `{"a": {"b": [1, {"c": 2, "d": 3}], "e": 4}, "f": 5}`,
+			max:       2,
+			want:      `{"a":{"b":[1,{"c":2,"…":null},"…"],"…":null},"…":null}`,
+			truncated: true,
+		},
+		"empty container": {
+			src:       `[1, [2]]`,
+			max:       1,
+			want:      `[1,["…"],"…"]`,
+			truncated: true,
+		},
+		"exact leaves count": {
+			src:  `[1, {"a": 2}, []]`,
+			max:  2,
+			want: `[1,{"a":2},[]]`,
+		},
+		"rest is not parsed": {
+			src:       `[1, 2, {"a": 3, broken]`,
+			max:       2,
+			want:      `[1,2,{"…":null},"…"]`,
+			truncated: true,
+		},
+		"no limit": {
+			src:  `[1, 2]`,
+			want: `[1,2]`,
+		},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			v, err := ValueOf([]byte(c.src), MaxLeaves(c.max))
+			require.NoError(t, err)
+			require.Equal(t, c.truncated, Truncated(v))
+
+			got, err := MarshalValueOpts(v, WithTruncatedValues())
+			require.NoError(t, err)
+			require.Equal(t, c.want, string(got))
+
+			_, err = MarshalValue(v, nil)
+			if !c.truncated {
+				require.NoError(t, err)
+				return
+			}
+
+			require.Error(t, err)
+			require.True(t, errors.Is(err, ErrTruncatedValue))
+			_, ok := Raw(v)
+			require.False(t, ok)
+		})
+	}
+}
+
+func TestMaxLeaves_LargeDocument(t *testing.T) {
+	src := RepeatFixture(`{"items": [`, ",", `]}`, 100000,
+		`{"id": %[1]d, "name": "item %[1]d", "tags": ["a", "b"], "meta": {"active": true}}`, nil)
+
+	v, err := ValueOf(src, MaxLeaves(20))
+	require.NoError(t, err)
+	require.True(t, Truncated(v))
+
+	items := v.(*Object).Items["items"].(*Array)
+	require.True(t, Truncated(items))
+	require.Len(t, items.Items, 5)
+	require.Equal(t, 20, countLeaves(v))
+
+	// only last item is truncated
+	for _, item := range items.Items[:4] {
+		require.False(t, Truncated(item))
+	}
+	require.True(t, Truncated(items.Items[4]))
+
+	p := NewParser([]byte(`[1, 2, 3] [4]`), MaxLeaves(1))
+	_, err = p.ParseNext()
+	require.NoError(t, err)
+	_, err = p.ParseNext()
+	require.Equal(t, io.EOF, err)
+}
+
+func countLeaves(v Value) int {
+	switch t := v.(type) {
+	case *Array:
+		count := 0
+		for _, item := range t.Items {
+			count += countLeaves(item)
+		}
+		return count
+	case *Object:
+		count := 0
+		for _, m := range t.Members() {
+			count += countLeaves(m.Value)
+		}
+		return count
+	default:
+		return 1
+	}
+}
@@ -1,13 +1,19 @@
 package jsonreflect
 
 import (
+	"encoding"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/iancoleman/strcase"
+	"io"
+	"math"
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
 	"unsafe"
 )
 
@@ -16,10 +22,42 @@ const (
 
 	tagOptionSkip          = "-"
 	tagOptionCollectOrphan = "..."
+	tagOptionOmitEmpty     = "omitempty"
+	tagOptionEmptyArray    = "emptyarray"
+	tagOptionEmptyObject   = "emptyobject"
+	tagOptionJSONString    = "jsonstring"
+
+	tagKeyMemberKey   = "$key"
+	tagKeyMemberValue = "$value"
+
+	// tagKeyEmpty is a tag name which binds field to empty key
+	tagKeyEmpty = "''"
+)
+
+const defaultUnknownFieldsLimit = 10
+
+const (
+	limitMaxSliceLen   = "MaxSliceLen"
+	limitMaxMapEntries = "MaxMapEntries"
 )
 
 var (
-	typeJsonRawMessage = reflect.TypeOf((*json.RawMessage)(nil)).Elem
+	typeJsonRawMessage  = reflect.TypeOf((*json.RawMessage)(nil)).Elem()
+	typeValue           = reflect.TypeOf((*Value)(nil)).Elem()
+	typeMemberSlice     = reflect.TypeOf([]Member(nil))
+	typeUnmarshaler     = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
+	typeJsonUnmarshaler = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	typeTextUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+	// valueStructTypes are value types which can be used by value
+	valueStructTypes = map[reflect.Type]struct{}{
+		reflect.TypeOf(Object{}):  {},
+		reflect.TypeOf(Array{}):   {},
+		reflect.TypeOf(String{}):  {},
+		reflect.TypeOf(Number{}):  {},
+		reflect.TypeOf(Boolean{}): {},
+		reflect.TypeOf(Null{}):    {},
+	}
 )
 
 // Unmarshaler is the interface implemented by types that can unmarshal a JSON value description of themselves.
@@ -30,10 +68,102 @@ type Unmarshaler interface {
 type unmarshalParams struct {
 	strict                      bool
 	dangerouslySetPrivateFields bool
+	reallyDangerously           bool
+	preserveValues              bool
+	quotedNulls                 bool
+	allowComplexNumbers         bool
+	disableNameGuessing         bool
+	disallowUnknownFields       bool
+	resetDestination            bool
+	unknownFieldsLimit          int
+	onNameGuess                 NameGuessFunc
+	keyCandidates               KeyCandidatesFunc
+	onDiagnostic                func(Diagnostic)
+	traceFields                 io.Writer
+	snapshot                    bool
+	snapshotLock                sync.Locker
+	caseInsensitiveEnums        bool
+	maxSliceLen                 int
+	maxMapEntries               int
+	nullElements                NullElementPolicy
+	decodeJSONStrings           bool
+	orphanReport                *OrphanReport
+	objectsAsArrays             bool
+	allKeysAsSetMembers         bool
+	castReport                  *CastReport
+
+	// path is path to the current value, tracked only for diagnostics, reports and limit errors.
+	path []pathSegment
+
+	// unmarshalers is a stack of unmarshaler calls in progress
+	unmarshalers *unmarshalerFrame
+}
+
+// NameGuessFunc is called when struct field without tag is bound to
+// a source key with different name.
+//
+// Receives struct type, field name and source key.
+type NameGuessFunc = func(structType reflect.Type, field, key string)
+
+// KeyCandidatesFunc returns source keys which are tried in order
+// to find a value for struct field without tag.
+type KeyCandidatesFunc = func(field reflect.StructField) []string
+
+// DefaultKeyCandidates returns default source key candidates for struct field without tag.
+//
+// Candidates are field name as is, in lower camel case, in snake case
+// and in screaming snake case, e.g. "UserName", "userName", "user_name" and "USER_NAME".
+//
+// Use WithKeyCandidates option to extend or replace candidates.
+func DefaultKeyCandidates(field reflect.StructField) []string {
+	keys := make([]string, len(defaultCandidateSources))
+	for i := range keys {
+		keys[i] = defaultKeyCandidate(field.Name, i)
+	}
+	return keys
+}
+
+// defaultCandidateSources are descriptions of DefaultKeyCandidates used in field resolution trace.
+var defaultCandidateSources = [...]string{"name", "camel case", "snake case", "screaming snake case"}
+
+// defaultKeyCandidate returns i-th key candidate of DefaultKeyCandidates.
+//
+// Candidates are built one by one, so keys after the matched one aren't computed.
+func defaultKeyCandidate(field string, i int) string {
+	switch i {
+	case 0:
+		return field
+	case 1:
+		return strcase.ToLowerCamel(field)
+	case 2:
+		return strcase.ToSnake(field)
+	default:
+		return strcase.ToScreamingSnake(field)
+	}
+}
+
+// isDuplicateKey reports whether key is already in the list of tried candidates.
+func isDuplicateKey(tried []string, key string) bool {
+	for _, k := range tried {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// keyCandidateSource returns candidate description used in field resolution trace.
+func keyCandidateSource(field, key string) string {
+	for i, source := range defaultCandidateSources {
+		if key == defaultKeyCandidate(field, i) {
+			return source
+		}
+	}
+	return "candidate"
 }
 
 func newUnmarshalParams(opts []UnmarshalOption) unmarshalParams {
-	p := unmarshalParams{strict: true}
+	p := unmarshalParams{strict: true, unknownFieldsLimit: defaultUnknownFieldsLimit}
 	if len(opts) == 0 {
 		return p
 	}
@@ -44,6 +174,31 @@ func newUnmarshalParams(opts []UnmarshalOption) unmarshalParams {
 	return p
 }
 
+// withPath returns params for unmarshal of child value.
+//
+// Path is tracked only when diagnostics, reports or limits are enabled.
+func (p unmarshalParams) withPath(seg pathSegment) unmarshalParams {
+	if p.onDiagnostic == nil && p.orphanReport == nil && p.castReport == nil && p.maxSliceLen == 0 && p.maxMapEntries == 0 && !p.decodeJSONStrings {
+		return p
+	}
+
+	path := make([]pathSegment, len(p.path), len(p.path)+1)
+	copy(path, p.path)
+	p.path = append(path, seg)
+	return p
+}
+
+// checkLimit returns *LimitError if size exceeds limit.
+//
+// Zero limit means no limit.
+func (p unmarshalParams) checkLimit(src Value, name string, limit, size int) error {
+	if limit <= 0 || size <= limit {
+		return nil
+	}
+
+	return &LimitError{Position: positionOf(src), Path: formatPath(p.path), Name: name, Limit: limit, Size: size}
+}
+
 // UnmarshalOption is unmarshal option
 type UnmarshalOption func(fn *unmarshalParams)
 
@@ -61,13 +216,40 @@ var (
 	//	// Any numeric value can be casted to string
 	//  any numeric value -> string
 	//
-	//	// Any valid boolean can be casted to string (and vice versa)
+	//	// Any valid boolean can be casted to string (and vice versa).
+	//	// Strings "true", "false" (in any case), "1" and "0" are valid booleans,
+	//	// as well as values accepted by strconv.ParseBool.
 	//	boolean <-> string
 	//
+	//	// Null is casted to empty string
+	//	null -> string
+	//
+	// Quoted nulls are not accepted by NoStrict, see QuotedNulls option.
+	// Use ReportCasts option to find out which values are cast.
 	NoStrict UnmarshalOption = func(fn *unmarshalParams) {
 		fn.strict = false
 	}
 
+	// QuotedNulls makes unmarshaler treat "null" (in any case) and empty strings
+	// as null when destination is a pointer, map, slice or interface,
+	// so such destinations are reset to nil.
+	//
+	// Useful for loosely typed sources which encode all values as strings.
+	// Option is independent of NoStrict. Value and json.RawMessage destinations
+	// receive source string as is.
+	QuotedNulls UnmarshalOption = func(fn *unmarshalParams) {
+		fn.quotedNulls = true
+	}
+
+	// DecodeJSONStrings makes unmarshaler parse content of string values
+	// as JSON document when destination is a struct, map, slice or array.
+	//
+	// Useful for double-encoded payloads like {"payload": "{\"id\": 1}"}.
+	// Use `json:"payload,jsonstring"` tag to decode only specific fields.
+	DecodeJSONStrings UnmarshalOption = func(fn *unmarshalParams) {
+		fn.decodeJSONStrings = true
+	}
+
 	// DangerouslySetPrivateFields allows unmarshaler to modify private fields
 	// which have `json` tag.
 	//
@@ -80,36 +262,390 @@ var (
 	DangerouslySetPrivateFields UnmarshalOption = func(fn *unmarshalParams) {
 		fn.dangerouslySetPrivateFields = true
 	}
+
+	// ReallyDangerouslySetPrivateFields allows DangerouslySetPrivateFields option
+	// to modify private fields of unexported types declared in other packages.
+	//
+	// Values of such types may panic later when accessed through reflection,
+	// implementing Unmarshaler is a better choice.
+	// Has no effect without DangerouslySetPrivateFields option.
+	ReallyDangerouslySetPrivateFields UnmarshalOption = func(fn *unmarshalParams) {
+		fn.reallyDangerously = true
+	}
+
+	// AllowComplexNumbers allows unmarshal of complex64 and complex128 values.
+	//
+	// Complex number can be represented as two-element array or as an object:
+	//	[1.5, -2]
+	//	{"re": 1.5, "im": -2}
+	AllowComplexNumbers UnmarshalOption = func(fn *unmarshalParams) {
+		fn.allowComplexNumbers = true
+	}
+
+	// DisableNameGuessing restricts matching of struct fields without tag
+	// to source keys with exact field name.
+	//
+	// By default, field is also matched to keys returned by DefaultKeyCandidates,
+	// e.g. "UserName" field can be bound to "userName" key.
+	DisableNameGuessing UnmarshalOption = func(fn *unmarshalParams) {
+		fn.disableNameGuessing = true
+	}
+
+	// PreserveValuesInInterfaces stores source values as is into interface destinations,
+	// like interface{} fields, map[string]interface{} values or []interface{} elements.
+	//
+	// By default, interface destinations receive result of Value.Interface() call.
+	// With this option, destination receives jsonreflect.Value, so value positions
+	// and nested objects are preserved. Null still resets interface to nil.
+	//
+	// Option changes dynamic type of interface values, so it's opt-in.
+	PreserveValuesInInterfaces UnmarshalOption = func(fn *unmarshalParams) {
+		fn.preserveValues = true
+	}
+
+	// DisallowUnknownFields returns an error when object contains keys
+	// which are not consumed by destination struct fields or orphan collector.
+	//
+	// Returns *UnknownFieldsError, see WithUnknownFieldsLimit.
+	//
+	// Consumed keys are tracked in index over object members which takes
+	// about 4 bytes per key regardless of key length.
+	DisallowUnknownFields UnmarshalOption = func(fn *unmarshalParams) {
+		fn.disallowUnknownFields = true
+	}
+
+	// ObjectsAsArrays allows unmarshal of objects with index keys, like {"0": "a", "1": "b"},
+	// to slices and arrays. Values are ordered by index, see Object.ToArray.
+	ObjectsAsArrays UnmarshalOption = func(fn *unmarshalParams) {
+		fn.objectsAsArrays = true
+	}
+
+	// AllKeysAsSetMembers makes all object keys members of map[string]struct{} destination.
+	//
+	// By default, only keys with true or object values are members,
+	// keys with false or null values are skipped and other values are rejected.
+	AllKeysAsSetMembers UnmarshalOption = func(fn *unmarshalParams) {
+		fn.allKeysAsSetMembers = true
+	}
+
+	// ResetDestination zeroes destination before unmarshal, so reused destination
+	// doesn't keep values of previous unmarshal, see UnmarshalValue.
+	//
+	// Struct fields which are not set by unmarshal, like private fields or
+	// fields with `json:"-"` tag, keep their values. Nested structs and arrays
+	// are reset field by field with the same rules, other values are set to zero value.
+	ResetDestination UnmarshalOption = func(fn *unmarshalParams) {
+		fn.resetDestination = true
+	}
 )
 
-func tryCallUnmarshaler(v Value, dst reflect.Value) (bool, error) {
+// NullElementPolicy defines how null elements of source array are unmarshaled
+// to slice and array elements, see NullElements.
+type NullElementPolicy uint8
+
+const (
+	// NullElementError returns an error if null element can't be unmarshaled to element type.
+	//
+	// Null is passed to element as any other value, it's a default policy.
+	NullElementError NullElementPolicy = iota
+
+	// NullElementZero sets zero value to element.
+	NullElementZero
+
+	// NullElementSkip skips null elements, so destination is compacted.
+	//
+	// Element indexes of destination don't match indexes of source array.
+	// Tail of destination array which is left after skipped elements is zeroed.
+	NullElementSkip
+)
+
+// NullElements sets policy for null elements of source array.
+//
+// Policy is applied only to element types which can't be nil.
+// Null elements are always unmarshaled to nil pointers, maps, slices and interfaces.
+func NullElements(policy NullElementPolicy) UnmarshalOption {
+	return func(p *unmarshalParams) {
+		p.nullElements = policy
+	}
+}
+
+// isPolicyNull reports whether source element is a null handled by null elements policy.
+func (p unmarshalParams) isPolicyNull(src Value, elemType reflect.Type) bool {
+	if p.nullElements == NullElementError || TypeOf(src) != TypeNull {
+		return false
+	}
+
+	switch elemType.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		return false
+	default:
+		return true
+	}
+}
+
+// WithUnknownFieldsLimit sets max count of keys listed in UnknownFieldsError message.
+//
+// Error still contains all unknown keys. All keys are listed if limit is zero.
+func WithUnknownFieldsLimit(limit int) UnmarshalOption {
+	return func(p *unmarshalParams) {
+		p.unknownFieldsLimit = limit
+	}
+}
+
+// MaxSliceLen limits count of elements unmarshaled into a slice or array.
+//
+// Limit is checked before destination allocation, *LimitError is returned
+// if limit is exceeded. Zero value disables the limit.
+func MaxSliceLen(n int) UnmarshalOption {
+	return func(p *unmarshalParams) {
+		p.maxSliceLen = n
+	}
+}
+
+// MaxMapEntries limits count of entries unmarshaled into a map.
+//
+// Limit is checked before destination allocation, *LimitError is returned
+// if limit is exceeded. Zero value disables the limit.
+func MaxMapEntries(n int) UnmarshalOption {
+	return func(p *unmarshalParams) {
+		p.maxMapEntries = n
+	}
+}
+
+// SnapshotSource makes unmarshaler decode a deep copy of source value, see Clone.
+//
+// Values are not safe for concurrent use when one of goroutines modifies them,
+// so source value shouldn't be modified during unmarshal.
+// Use SnapshotSourceLocked to copy a tree which is modified concurrently.
+//
+// Destination never references source values, even with PreserveValuesInInterfaces.
+func SnapshotSource() UnmarshalOption {
+	return SnapshotSourceLocked(nil)
+}
+
+// SnapshotSourceLocked is SnapshotSource which holds lock while source is copied.
+//
+// Lock is held only during copy, so a tree shared between goroutines can be
+// protected by a read lock without holding it during the whole unmarshal:
+//
+//	err := UnmarshalValue(shared, &dst, SnapshotSourceLocked(mu.RLocker()))
+//
+// Nil lock is ignored.
+func SnapshotSourceLocked(lock sync.Locker) UnmarshalOption {
+	return func(p *unmarshalParams) {
+		p.snapshot = true
+		p.snapshotLock = lock
+	}
+}
+
+// rootValue returns source value of unmarshal call.
+//
+// Continues stack of unmarshaler calls if value is passed to unmarshaler.
+func (p *unmarshalParams) rootValue(v Value) Value {
+	if f := valueFrame(v); f.isActive() {
+		p.unmarshalers = f
+	}
+
+	src := p.sourceValue(v)
+	if p.snapshot && p.unmarshalers != nil {
+		// snapshot is unmarshaled instead of passed value
+		src = withUnmarshalerFrame(src, p.unmarshalers)
+	}
+	return src
+}
+
+// sourceValue returns value which should be unmarshaled, see SnapshotSource.
+func (p unmarshalParams) sourceValue(v Value) Value {
+	if !p.snapshot {
+		return v
+	}
+
+	if p.snapshotLock != nil {
+		p.snapshotLock.Lock()
+		defer p.snapshotLock.Unlock()
+	}
+	return Clone(v)
+}
+
+// OnNameGuess sets a callback which is called for each struct field
+// bound to a source key using field name guessing.
+//
+// Useful to audit accidental matches, see DisableNameGuessing.
+func OnNameGuess(fn NameGuessFunc) UnmarshalOption {
+	return func(p *unmarshalParams) {
+		p.onNameGuess = fn
+	}
+}
+
+// WithKeyCandidates sets a function which returns source key candidates
+// for struct fields without tag. Candidates are tried in order, first existing key is used.
+//
+// Wrap DefaultKeyCandidates to keep default candidates:
+//
+//	WithKeyCandidates(func(f reflect.StructField) []string {
+//		return append(DefaultKeyCandidates(f), strcase.ToKebab(f.Name))
+//	})
+//
+// Option has no effect with DisableNameGuessing.
+func WithKeyCandidates(fn KeyCandidatesFunc) UnmarshalOption {
+	return func(p *unmarshalParams) {
+		p.keyCandidates = fn
+	}
+}
+
+func tryCallUnmarshaler(v Value, dst reflect.Value, p unmarshalParams) (bool, error) {
 	if !dst.CanInterface() {
 		return false, nil
 	}
 
-	switch t := v.Interface().(type) {
-	case json.Unmarshaler:
-		str, err := MarshalValue(v, nil)
-		if err != nil {
-			return false, err
+	if isValueDestination(v, dst.Type()) {
+		dst.Set(reflect.ValueOf(v))
+		return true, nil
+	}
+
+	if isValueStruct(dst.Type()) {
+		return true, unmarshalValueStruct(v, dst)
+	}
+
+	if dst.Type() == typeJsonRawMessage {
+		if src, ok := Raw(v); ok {
+			// source bytes are kept as is, including whitespace
+			dst.Set(reflect.ValueOf(json.RawMessage(append([]byte(nil), src...))))
+			return true, nil
 		}
 
-		return true, t.UnmarshalJSON(str)
-	case Unmarshaler:
-		return true, t.UnmarshalJSONValue(v)
-	case json.RawMessage:
 		serialized, err := MarshalValue(v, nil)
 		if err != nil {
 			return false, err
 		}
 
-		dst.Set(reflect.ValueOf(serialized))
+		dst.Set(reflect.ValueOf(json.RawMessage(serialized)))
 		return true, nil
+	}
+
+	if dst.Kind() == reflect.Ptr && TypeOf(v) == TypeNull {
+		// null resets pointer without calling unmarshaler
+		return false, nil
+	}
+
+	target, ok := findUnmarshaler(dst)
+	if !ok {
+		return false, nil
+	}
+
+	switch t := target.Interface().(type) {
+	case Unmarshaler:
+		f, err := p.enterUnmarshaler(v, target.Type())
+		if err != nil {
+			return true, err
+		}
+
+		defer f.leave()
+		return true, t.UnmarshalJSONValue(withUnmarshalerFrame(v, f))
+	case json.Unmarshaler:
+		var data []byte
+		if src, ok := Raw(v); ok {
+			// pass a copy, json.Unmarshaler might retain the data
+			data = append([]byte(nil), src...)
+		} else {
+			str, err := MarshalValue(v, nil)
+			if err != nil {
+				return false, err
+			}
+			data = str
+		}
+
+		f, err := p.enterUnmarshaler(v, target.Type())
+		if err != nil {
+			return true, err
+		}
+
+		defer f.leave()
+		if len(data) == 0 {
+			return true, t.UnmarshalJSON(data)
+		}
+
+		// source is parsed again by nested Unmarshal, which finds the call by data
+		enterJSONUnmarshaler(data, f)
+		defer leaveJSONUnmarshaler(data)
+		return true, t.UnmarshalJSON(data)
 	default:
 		return false, nil
 	}
 }
 
+// findUnmarshaler returns destination value or pointer to it which implements
+// Unmarshaler or json.Unmarshaler.
+//
+// Nil pointer destination is allocated.
+func findUnmarshaler(dst reflect.Value) (reflect.Value, bool) {
+	if dst.Kind() == reflect.Ptr {
+		if !isUnmarshaler(dst.Type()) {
+			return dst, false
+		}
+
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		return dst, true
+	}
+
+	if dst.CanAddr() && isUnmarshaler(reflect.PtrTo(dst.Type())) {
+		return dst.Addr(), true
+	}
+
+	return dst, isUnmarshaler(dst.Type())
+}
+
+func isUnmarshaler(t reflect.Type) bool {
+	return t.Implements(typeUnmarshaler) || t.Implements(typeJsonUnmarshaler)
+}
+
+// isValueDestination checks if source value can be mapped to destination as-is.
+//
+// Destination should be jsonreflect.Value or concrete value type like *jsonreflect.Object.
+func isValueDestination(v Value, dstType reflect.Type) bool {
+	if v == nil {
+		return false
+	}
+
+	if dstType == typeValue {
+		return true
+	}
+
+	return dstType.Kind() != reflect.Interface && reflect.TypeOf(v).AssignableTo(dstType)
+}
+
+// isValueStruct reports whether type is a value type like jsonreflect.Object
+// used by value instead of pointer.
+func isValueStruct(t reflect.Type) bool {
+	_, ok := valueStructTypes[t]
+	return ok
+}
+
+// unmarshalValueStruct copies source value to value type destination like jsonreflect.Object.
+//
+// Null source keeps destination unchanged.
+func unmarshalValueStruct(v Value, dst reflect.Value) error {
+	if TypeOf(v) == TypeNull {
+		return nil
+	}
+
+	src := reflect.ValueOf(v)
+	if src.Type() == dst.Type() {
+		// boolean is used by value
+		dst.Set(src)
+		return nil
+	}
+
+	if src.Type() != reflect.PtrTo(dst.Type()) || src.IsNil() {
+		return newUnmarshalTypeErr(v, dst.Type())
+	}
+
+	dst.Set(src.Elem())
+	return nil
+}
+
 // UnmarshalValue maps JSON value to passed value.
 // Accepts additional options to customise unmarshal process.
 //
@@ -118,13 +654,57 @@ func tryCallUnmarshaler(v Value, dst reflect.Value) (bool, error) {
 // Supported additional tags:
 //
 // - `json:"..."` tag used to collect all orphan values in JSON object to specified field.
+// Use *jsonreflect.Object or []jsonreflect.Member field to keep orphan keys order.
+// If orphan collector is a struct, it's unmarshaled from orphan keys using its own tags
+// and can have own orphan collector. See OrphanStats to find out which keys were collected.
+//
+// - `json:"$key"` and `json:"$value"` tags mark fields of struct which receives object member.
+// Slice of such structs is filled from object members in source order.
+//
+// - Two single quotes as tag name bind field to empty key, as `json:""` tag means that field has no tag:
+//
+//	Field string `json:"''"`
+//
+// - `json:"name,jsonstring"` tag option parses content of string value as JSON document,
+// see DecodeJSONStrings.
+//
+// - `enum:"a|b|c"` tag restricts string field to listed values, see UnmarshalEnum.
 //
 // Supported special unmarshal types:
 //
 // - If destination value is jsonreflect.Value, unmarshaler will map original value.
 //
+// - Value types like jsonreflect.Object are also accepted by value. A struct which embeds
+// jsonreflect.Object receives the whole source object in embedded field, while other fields
+// are unmarshaled as usual. This is the way to get a typed view over raw document.
+// Embedded field with `json:"..."` tag receives only orphan keys.
+//
 // - If destination value is jsonreflect.Unmarshaler, unmarshaler will call Unmarshaler.UnmarshalJSONValue.
 //
+// - map[string]struct{} is a set which is filled from array of strings or from object keys,
+// see AllKeysAsSetMembers. Without strict mode, map[string]bool also accepts array of strings.
+// Maps of named empty structs are unmarshaled as regular maps.
+//
+// Unmarshal into destination which already has a value, like a reused struct:
+//
+// - struct fields are set only for keys present in source, other fields keep their values.
+//
+// - maps and slices are replaced by new ones and don't keep previous items.
+//
+// - arrays are overwritten element by element, elements beyond source array length keep their values.
+//
+// - non-nil pointers are reused, pointed value is unmarshaled with the same rules.
+//
+// - scalars, interfaces and jsonreflect.Value destinations are replaced.
+//
+// - null resets pointers, maps, slices and interfaces to nil, other values keep
+// their values or receive an error in strict mode.
+//
+// Use ResetDestination option to get the same result as unmarshal into a new value.
+//
+// Unmarshal only reads source value, so the same value can be unmarshaled
+// from several goroutines at once. Source value must not be modified
+// during unmarshal, use SnapshotSourceLocked option for values modified concurrently.
 func UnmarshalValue(v Value, dst interface{}, opts ...UnmarshalOption) error {
 	params := newUnmarshalParams(opts)
 	dstVal := reflect.ValueOf(dst)
@@ -137,7 +717,39 @@ func UnmarshalValue(v Value, dst interface{}, opts ...UnmarshalOption) error {
 	}
 
 	dstElem := dstVal.Elem()
-	return unmarshalValue(v, dstElem, params)
+	params.reset(dstElem)
+	return unmarshalValue(params.rootValue(v), dstElem, params)
+}
+
+// UnmarshalReflectValue maps JSON value to passed reflect.Value.
+//
+// Destination should be settable (like a value obtained from addressable struct
+// using FieldByName) or a non-nil pointer which will be dereferenced.
+// Values which can't be set, like map items returned by MapIndex, are rejected.
+//
+// See UnmarshalValue for information about unmarshal behavior.
+func UnmarshalReflectValue(src Value, dst reflect.Value, opts ...UnmarshalOption) error {
+	if !dst.IsValid() {
+		return errors.New("invalid destination value")
+	}
+
+	params := newUnmarshalParams(opts)
+	src = params.rootValue(src)
+	if dst.CanSet() {
+		params.reset(dst)
+		return unmarshalValue(src, dst, params)
+	}
+
+	if dst.Kind() != reflect.Ptr {
+		return fmt.Errorf("destination value of type %s is not settable, pass addressable value or a pointer", dst.Type())
+	}
+
+	if dst.IsNil() {
+		return errors.New("nil pointer passed")
+	}
+
+	params.reset(dst.Elem())
+	return unmarshalValue(src, dst.Elem(), params)
 }
 
 func unmarshalValue(src Value, dst reflect.Value, p unmarshalParams) error {
@@ -145,7 +757,12 @@ func unmarshalValue(src Value, dst reflect.Value, p unmarshalParams) error {
 		return errors.New("destination value must be exported")
 	}
 
-	isUnmarshed, err := tryCallUnmarshaler(src, dst)
+	if p.quotedNulls && isQuotedNull(src, dst.Type()) {
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	}
+
+	isUnmarshed, err := tryCallUnmarshaler(src, dst, p)
 	if err != nil {
 		return err
 	}
@@ -154,6 +771,19 @@ func unmarshalValue(src Value, dst reflect.Value, p unmarshalParams) error {
 		return nil
 	}
 
+	if p.decodeJSONStrings {
+		src, err = decodeJSONString(src, dst.Type(), p.path)
+		if err != nil {
+			return err
+		}
+	}
+
+	if TypeOf(src) == TypeNull && isNillable(dst.Kind()) {
+		// null resets pointers, maps, slices and interfaces to nil, like encoding/json does
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	}
+
 	dstType := dst.Type()
 	if dst.Kind() == reflect.Ptr {
 		dstType = dstType.Elem()
@@ -168,15 +798,20 @@ func unmarshalValue(src Value, dst reflect.Value, p unmarshalParams) error {
 
 	switch k := dstType.Kind(); k {
 	case reflect.String:
-		return unmarshalString(src, dst, p.strict)
+		return p.castScalar(src, dst, unmarshalString)
 	case reflect.Bool:
-		return unmarshalBool(src, dst, p.strict)
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		return unmarshalUint(src, dst, p.strict)
-	case reflect.Int, reflect.Int8, reflect.Int32, reflect.Int64:
-		return unmarshalInt(src, dst, p.strict)
+		return p.castScalar(src, dst, unmarshalBool)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return p.castScalar(src, dst, unmarshalUint)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return p.castScalar(src, dst, unmarshalInt)
 	case reflect.Float32, reflect.Float64:
-		return unmarshalFloat(src, dst, p.strict)
+		return p.castScalar(src, dst, unmarshalFloat)
+	case reflect.Complex64, reflect.Complex128:
+		if !p.allowComplexNumbers {
+			return fmt.Errorf("unsupported destination kind %s (see AllowComplexNumbers option)", k)
+		}
+		return unmarshalComplex(src, dst, p)
 	case reflect.Slice:
 		return unmarshalSlice(src, dst, p)
 	case reflect.Array:
@@ -186,16 +821,27 @@ func unmarshalValue(src Value, dst reflect.Value, p unmarshalParams) error {
 	case reflect.Struct:
 		return unmarshalObject(src, dst, p)
 	case reflect.Interface:
-		return unmarshalInterface(src, dst)
+		return unmarshalInterface(src, dst, p)
+	case reflect.Ptr:
+		// pointer to pointer, allocate next level
+		return unmarshalValue(src, dst, p)
+	default:
+		return fmt.Errorf("unsupported destination kind %s", k)
 	}
-
-	return nil
 }
 
 type tagData struct {
 	skipValue      bool
 	collectOrphans bool
 	srcKey         string
+
+	// emptyKey is set if field is bound to empty key with `json:"''"` tag
+	emptyKey bool
+
+	omitEmpty   bool
+	emptyArray  bool
+	emptyObject bool
+	jsonString  bool
 }
 
 func parseTagData(f reflect.StructField) *tagData {
@@ -209,56 +855,173 @@ func parseTagData(f reflect.StructField) *tagData {
 	}
 
 	parts := strings.Split(val, ",")
-	if parts[0] == tagOptionSkip {
+	if parts[0] == tagOptionSkip && len(parts) == 1 {
 		return &tagData{skipValue: true}
 	}
 
+	td := &tagData{}
+	for _, opt := range parts[1:] {
+		switch strings.TrimSpace(opt) {
+		case tagOptionOmitEmpty:
+			td.omitEmpty = true
+		case tagOptionEmptyArray:
+			td.emptyArray = true
+		case tagOptionEmptyObject:
+			td.emptyObject = true
+		case tagOptionJSONString:
+			td.jsonString = true
+		}
+	}
+
 	srcKey := strings.TrimSpace(parts[0])
 	switch srcKey {
 	case "":
-		return nil
+		if !td.hasOptions() {
+			return nil
+		}
 	case tagOptionCollectOrphan:
-		return &tagData{collectOrphans: true}
+		td.collectOrphans = true
+	case tagKeyEmpty:
+		td.emptyKey = true
 	default:
-		return &tagData{srcKey: srcKey}
+		td.srcKey = srcKey
+	}
+	return td
+}
+
+// key returns source key set by tag.
+func (td *tagData) key() (string, bool) {
+	if td == nil {
+		return "", false
+	}
+	return td.srcKey, td.srcKey != "" || td.emptyKey
+}
+
+func (td *tagData) hasOptions() bool {
+	return td.omitEmpty || td.emptyArray || td.emptyObject || td.jsonString
+}
+
+// findSourceKey attempts to find source object key to unmarshal.
+//
+// First it tries to find `json` tag declaration.
+// If no tag available, method tries key candidates, see DefaultKeyCandidates.
+//
+// Tried candidates are written to trace writer, see TraceFieldResolution.
+func findSourceKey(td *tagData, srcObj *Object, structType reflect.Type, fType reflect.StructField, p unmarshalParams) (key string, ok bool) {
+	var trace *fieldTrace
+	if p.traceFields != nil {
+		trace = &fieldTrace{}
+		defer func() {
+			trace.write(p.traceFields, structType, fType.Name, srcObj, ok)
+		}()
+	}
+
+	if tagKey, ok := td.key(); ok {
+		if trace.try(srcObj, tagKey, "tag") {
+			return tagKey, true
+		}
+
+		return "", false
 	}
-}
 
-// findSourceKey attempts to find source object key to unmarshal.
-//
-// First it tries to find `json` tag declaration.
-// If no tag available, method tries to find source key using property name with different cases.
-func findSourceKey(td *tagData, srcObj *Object, fType reflect.StructField) (string, bool) {
-	if td != nil && td.srcKey != "" {
-		if srcObj.HasKey(td.srcKey) {
-			return td.srcKey, true
+	if p.disableNameGuessing {
+		if trace.try(srcObj, fType.Name, "name") {
+			return fType.Name, true
 		}
 
 		return "", false
 	}
 
-	if srcObj.HasKey(fType.Name) {
-		return fType.Name, true
+	if p.keyCandidates == nil {
+		var tried [len(defaultCandidateSources)]string
+		for i, source := range defaultCandidateSources {
+			key := defaultKeyCandidate(fType.Name, i)
+			tried[i] = key
+			if isDuplicateKey(tried[:i], key) || !trace.try(srcObj, key, source) {
+				continue
+			}
+
+			p.reportNameGuess(structType, fType.Name, key)
+			return key, true
+		}
+
+		return "", false
 	}
 
-	// try to cast to camel case and lookup
-	ccName := strcase.ToLowerCamel(fType.Name)
-	if srcObj.HasKey(ccName) {
-		return ccName, true
+	keys := p.keyCandidates(fType)
+	for i, key := range keys {
+		if isDuplicateKey(keys[:i], key) {
+			continue
+		}
+
+		// trace labels are needed only if trace is enabled
+		source := ""
+		if trace != nil {
+			source = keyCandidateSource(fType.Name, key)
+		}
+
+		if !trace.try(srcObj, key, source) {
+			continue
+		}
+
+		p.reportNameGuess(structType, fType.Name, key)
+		return key, true
 	}
 
 	return "", false
 }
 
+// reportNameGuess reports that field without tag is bound to key which differs from field name.
+func (p unmarshalParams) reportNameGuess(structType reflect.Type, field, key string) {
+	if key == field {
+		return
+	}
+
+	if p.onNameGuess != nil {
+		p.onNameGuess(structType, field, key)
+	}
+	p.report(DiagnosticNameGuessed, structType, field,
+		"field %s bound to key %q", field, key)
+}
+
 func unmarshalObject(src Value, dst reflect.Value, p unmarshalParams) error {
 	srcObj, ok := src.(*Object)
 	if !ok {
-		return newUnmarshalTypeErr(src.Type(), dst.Type())
+		return newUnmarshalTypeErr(src, dst.Type())
+	}
+
+	consumedKeys, err := unmarshalStruct(srcObj, newKeySet(srcObj.Members()), dst, p)
+	if err != nil {
+		return err
+	}
+
+	if !p.disallowUnknownFields {
+		return nil
+	}
+
+	var unknown []Member
+	for i, m := range consumedKeys.members {
+		if !consumedKeys.marked(i) {
+			unknown = append(unknown, m)
+		}
 	}
 
+	if len(unknown) == 0 {
+		return nil
+	}
+
+	return &UnknownFieldsError{Type: dst.Type(), Fields: unknown, Limit: p.unknownFieldsLimit}
+}
+
+// unmarshalStruct maps object to struct and returns keys consumed by struct fields.
+//
+// Keys consumed by embedded structs and orphan collector are also reported.
+// Passed key set of object members is used only to create returned set.
+func unmarshalStruct(srcObj *Object, keys *keySet, dst reflect.Value, p unmarshalParams) (*keySet, error) {
 	// orphan keys registry
-	touchedKeys := make(map[string]struct{})
+	touchedKeys := keys.fork()
 	var orphanDest *reflect.Value
+	var orphanField string
 
 	for i := 0; i < dst.NumField(); i++ {
 		fType := dst.Type().Field(i)
@@ -272,112 +1035,339 @@ func unmarshalObject(src Value, dst reflect.Value, p unmarshalParams) error {
 		if !fVal.CanSet() {
 			// DangerouslySetPrivateFields() option captures private fields with valid `json` tag.
 			if !(p.dangerouslySetPrivateFields && tagData != nil) {
+				reportPrivateField(dst.Type(), fType, tagData, p)
 				continue
 			}
 
+			if err := checkForeignPrivateField(dst.Type(), fType, p); err != nil {
+				return nil, err
+			}
+
 			// Here be dragons 🔥
 			fVal = reflect.NewAt(fVal.Type(), unsafe.Pointer(fVal.UnsafeAddr())).Elem()
 		}
 
+		// mark value as target for all orphan values
+		// if it has `json:"..."` tag.
+		if tagData != nil && tagData.collectOrphans {
+			orphanDest = &fVal
+			orphanField = fType.Name
+			continue
+		}
+
 		if !fType.Anonymous {
-			// mark value as target for all orphan values
-			// if it has `json:"*"` tag.
-			if tagData != nil && tagData.collectOrphans {
-				orphanDest = &fVal
+			srcKey, ok := findSourceKey(tagData, srcObj, dst.Type(), fType, p)
+			if !ok {
 				continue
 			}
 
-			srcKey, ok := findSourceKey(tagData, srcObj, fType)
-			if !ok {
-				continue
+			touchedKeys.add(srcKey)
+			srcVal, _ := srcObj.Get(srcKey)
+			if tagData != nil && tagData.jsonString {
+				path := append(p.path[:len(p.path):len(p.path)], pathSegment{key: srcKey})
+				var err error
+				if srcVal, err = decodeJSONString(srcVal, fVal.Type(), path); err != nil {
+					return nil, fmt.Errorf("can't unmarshal field %q to %s.%s: %w", srcKey, dst.Type(), fType.Type, err)
+				}
+			}
+
+			if err := unmarshalValue(srcVal, fVal, p.withPath(pathSegment{key: srcKey})); err != nil {
+				return nil, fmt.Errorf("can't unmarshal field %q to %s.%s: %w", srcKey, dst.Type(), fType.Type, err)
 			}
 
-			touchedKeys[srcKey] = struct{}{}
-			srcVal := srcObj.Items[srcKey]
-			if err := unmarshalValue(srcVal, fVal, p); err != nil {
-				return fmt.Errorf("can't unmarshal field %q to %s.%s: %w", srcKey, dst.Type(), fType.Type, err)
+			if err := checkEnumField(srcVal, fType, fVal, p); err != nil {
+				return nil, fmt.Errorf("can't unmarshal field %q to %s.%s: %w", srcKey, dst.Type(), fType.Type, err)
+			}
+			continue
+		}
+
+		if embeddedKeys, ok, err := unmarshalStructDestination(srcObj, keys, fVal, p); ok {
+			if err != nil {
+				return nil, fmt.Errorf("can't unmarshal to %s.%s: %w", dst.Type(), fType.Type, err)
 			}
+
+			touchedKeys.union(embeddedKeys)
 			continue
 		}
 
-		if err := unmarshalValue(src, fVal, p); err != nil {
-			return fmt.Errorf("can't unmarshal to %s.%s: %w", dst.Type(), fType.Type, err)
+		if err := unmarshalValue(srcObj, fVal, p); err != nil {
+			return nil, fmt.Errorf("can't unmarshal to %s.%s: %w", dst.Type(), fType.Type, err)
+		}
+
+		if !fVal.Type().Implements(typeValue) && !isValueStruct(fVal.Type()) {
+			continue
 		}
-		continue
+
+		// embedded value like jsonreflect.Object or *jsonreflect.Object keeps all keys
+		touchedKeys.addAll()
 	}
 
 	if orphanDest == nil {
-		return nil
+		return touchedKeys, nil
 	}
 
 	// unmarshal orphan values (if requested)
-	if err := unmarshalOrphanKeys(srcObj, touchedKeys, *orphanDest, p); err != nil {
-		return fmt.Errorf("failed to unmarshal orphan keys to %s: %w", orphanDest.Type(), err)
+	p.reportOrphans(touchedKeys, dst.Type(), orphanField)
+	orphanKeys, err := unmarshalOrphanKeys(srcObj, touchedKeys, *orphanDest, p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal orphan keys to %s: %w", orphanDest.Type(), err)
 	}
-	return nil
+
+	if orphanKeys.empty() {
+		p.report(DiagnosticOrphanCollectorUnused, dst.Type(), orphanField,
+			"orphan collector %s didn't consume any key", orphanField)
+	}
+
+	touchedKeys.union(orphanKeys)
+	return touchedKeys, nil
+}
+
+// reportPrivateField reports private field which was skipped
+// but could be set using DangerouslySetPrivateFields.
+func reportPrivateField(structType reflect.Type, f reflect.StructField, td *tagData, p unmarshalParams) {
+	if f.Anonymous {
+		return
+	}
+
+	switch {
+	case td != nil:
+		p.report(DiagnosticPrivateFieldSkipped, structType, f.Name,
+			"private field %s has json tag, but DangerouslySetPrivateFields option is not set", f.Name)
+	case p.dangerouslySetPrivateFields:
+		p.report(DiagnosticPrivateFieldSkipped, structType, f.Name,
+			"private field %s has no json tag required by DangerouslySetPrivateFields option", f.Name)
+	}
+}
+
+// checkForeignPrivateField returns an error if private field type is unexported
+// type from another package, unless ReallyDangerouslySetPrivateFields option is set.
+func checkForeignPrivateField(structType reflect.Type, f reflect.StructField, p unmarshalParams) error {
+	if p.reallyDangerously {
+		return nil
+	}
+
+	t := foreignUnexportedType(f.Type, f.PkgPath)
+	if t == nil {
+		return nil
+	}
+
+	return fmt.Errorf(
+		"can't set private field %s.%s: type %s is unexported type of package %q, "+
+			"implement Unmarshaler or use ReallyDangerouslySetPrivateFields option",
+		structType, f.Name, t, t.PkgPath())
+}
+
+// foreignUnexportedType returns unexported named type from package other than pkgPath
+// which is used in passed type, or nil if there is no such type.
+func foreignUnexportedType(t reflect.Type, pkgPath string) reflect.Type {
+	for {
+		if t.Name() != "" {
+			if t.PkgPath() != "" && t.PkgPath() != pkgPath && !isExportedName(t.Name()) {
+				return t
+			}
+			return nil
+		}
+
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Chan:
+			t = t.Elem()
+		case reflect.Map:
+			if kt := foreignUnexportedType(t.Key(), pkgPath); kt != nil {
+				return kt
+			}
+			t = t.Elem()
+		default:
+			return nil
+		}
+	}
+}
+
+func isExportedName(name string) bool {
+	r, _ := utf8.DecodeRuneInString(name)
+	return unicode.IsUpper(r)
+}
+
+// unmarshalStructDestination unmarshals object to struct or pointer to struct value
+// and returns consumed keys. Returns false if value is not a struct destination.
+//
+// Nil pointer is initialized only if struct consumed at least one key.
+// Value types like *Object or Object are not struct destinations.
+func unmarshalStructDestination(srcObj *Object, keys *keySet, v reflect.Value, p unmarshalParams) (*keySet, bool, error) {
+	if v.Type().Implements(typeValue) || isValueStruct(v.Type()) {
+		return nil, false, nil
+	}
+
+	if v.Kind() == reflect.Ptr && v.Type().Elem().Kind() == reflect.Struct {
+		if !v.IsNil() {
+			consumed, err := unmarshalStruct(srcObj, keys, v.Elem(), p)
+			return consumed, true, err
+		}
+
+		ptr := reflect.New(v.Type().Elem())
+		consumed, err := unmarshalStruct(srcObj, keys, ptr.Elem(), p)
+		if err == nil && !consumed.empty() {
+			v.Set(ptr)
+		}
+		return consumed, true, err
+	}
+
+	if v.Kind() != reflect.Struct {
+		return nil, false, nil
+	}
+
+	consumed, err := unmarshalStruct(srcObj, keys, v, p)
+	return consumed, true, err
 }
 
-func unmarshalOrphanKeys(srcObj *Object, touchedKeys map[string]struct{}, dst reflect.Value, p unmarshalParams) error {
+// unmarshalOrphanKeys maps keys which are not consumed by struct fields
+// to orphan collector and returns consumed keys.
+//
+// Struct collector is unmarshaled from orphan keys with the same rules as parent struct
+// and reports only keys consumed by its fields. Other collectors consume all orphan keys.
+func unmarshalOrphanKeys(srcObj *Object, touchedKeys *keySet, dst reflect.Value, p unmarshalParams) (*keySet, error) {
 	orphans := make(map[string]Value)
-	for k, v := range srcObj.Items {
-		if _, ok := touchedKeys[k]; ok {
+	consumed := touchedKeys.fork()
+	var members []Member
+	for i, m := range touchedKeys.members {
+		if touchedKeys.marked(i) {
 			continue
 		}
 
-		orphans[k] = v
+		orphans[m.Key] = m.Value
+		consumed.mark(i)
+		members = append(members, m)
+	}
+
+	if dst.Type() == typeMemberSlice {
+		dst.Set(reflect.ValueOf(members))
+		return consumed, nil
 	}
 
 	orphansContainer := &Object{
-		baseValue: srcObj.baseValue,
+		baseValue: baseValue{Position: srcObj.Position},
 		Items:     orphans,
+		members:   members,
+	}
+
+	orphanKeys := newKeySet(members)
+	if keys, ok, err := unmarshalStructDestination(orphansContainer, orphanKeys, dst, p); ok {
+		if err != nil {
+			return nil, err
+		}
+
+		// map keys consumed by struct back to source object members
+		consumed = touchedKeys.fork()
+		for i, m := range keys.members {
+			if keys.marked(i) {
+				consumed.add(m.Key)
+			}
+		}
+		return consumed, nil
 	}
-	return unmarshalValue(orphansContainer, dst, p)
+	return consumed, unmarshalValue(orphansContainer, dst, p)
 }
 
-func unmarshalInterface(src Value, dst reflect.Value) (err error) {
+func unmarshalInterface(src Value, dst reflect.Value, p unmarshalParams) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			err = fmt.Errorf("cannot assign %s to %s: %v", src.Type(), dst.Type(), r)
 		}
 	}()
 
+	if TypeOf(src) == TypeNull {
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	}
+
+	if p.preserveValues && reflect.TypeOf(src).AssignableTo(dst.Type()) {
+		dst.Set(reflect.ValueOf(src))
+		return nil
+	}
+
 	iface := reflect.ValueOf(src.Interface())
 	dst.Set(iface)
 	return nil
 }
 
 func unmarshalMap(src Value, dst reflect.Value, p unmarshalParams) error {
+	if arr, ok := src.(*Array); ok && arr != nil && p.isSetDestination(dst.Type()) {
+		return unmarshalSetArray(arr, dst, p)
+	}
+
 	srcObj, ok := src.(*Object)
 	if !ok {
-		return newUnmarshalTypeErr(src.Type(), dst.Type())
+		return newUnmarshalTypeErr(src, dst.Type())
 	}
 
 	if k := dst.Type().Key().Kind(); k != reflect.String {
 		return fmt.Errorf("destination map key type should be string (got %s)", k)
 	}
 
+	members := srcObj.Members()
+	if err := p.checkLimit(srcObj, limitMaxMapEntries, p.maxMapEntries, len(members)); err != nil {
+		return err
+	}
+
 	elemType := dst.Type().Elem()
-	m := reflect.MakeMap(dst.Type())
-	for key, value := range srcObj.Items {
-		newVal := reflect.New(elemType)
-		if err := unmarshalValue(value, newVal, p); err != nil {
-			return fmt.Errorf("%q: cannot set %s to map value: %w", key, src.Type(), err)
+	m := reflect.MakeMapWithSize(dst.Type(), len(members))
+
+	setElem := isSetElem(elemType)
+
+	// iterate in source order to keep reported errors reproducible
+	for _, member := range members {
+		// object values are unmarshaled into set element as usual
+		if _, isObject := member.Value.(*Object); setElem && !isObject {
+			ok, err := p.isSetMember(member.Value, elemType)
+			if err != nil {
+				return wrapElementError(err, pathSegment{key: member.Key})
+			}
+
+			if ok {
+				m.SetMapIndex(reflect.ValueOf(member.Key), reflect.New(elemType).Elem())
+			}
+			continue
+		}
+
+		newVal := reflect.New(elemType).Elem()
+		if err := unmarshalValue(member.Value, newVal, p.withPath(pathSegment{key: member.Key})); err != nil {
+			return wrapElementError(err, pathSegment{key: member.Key})
 		}
 
-		m.SetMapIndex(reflect.ValueOf(key), newVal.Elem())
+		m.SetMapIndex(reflect.ValueOf(member.Key), newVal)
 	}
 
 	dst.Set(m)
 	return nil
 }
 
+// arraySource returns source array for slice or array destination.
+//
+// Objects with index keys are converted to arrays if ObjectsAsArrays option is set.
+func (p unmarshalParams) arraySource(src Value, dstType reflect.Type) (*Array, error) {
+	switch t := src.(type) {
+	case *Array:
+		if t != nil {
+			return t, nil
+		}
+	case *Object:
+		if t != nil && p.objectsAsArrays {
+			return t.ToArray()
+		}
+	}
+	return nil, newUnmarshalTypeErr(src, dstType)
+}
+
 func unmarshalArray(src Value, dst reflect.Value, p unmarshalParams) error {
-	srcArr, ok := src.(*Array)
-	if !ok {
-		return newUnmarshalTypeErr(src.Type(), dst.Type())
+	srcArr, err := p.arraySource(src, dst.Type())
+	if err != nil {
+		return err
 	}
 
 	items := srcArr.Items
+	if err := p.checkLimit(srcArr, limitMaxSliceLen, p.maxSliceLen, len(items)); err != nil {
+		return err
+	}
+
 	maxLen := dst.Type().Len()
 	if len(items) > maxLen {
 		if p.strict {
@@ -388,26 +1378,125 @@ func unmarshalArray(src Value, dst reflect.Value, p unmarshalParams) error {
 		items = items[:maxLen]
 	}
 
-	for i, val := range srcArr.Items {
-		if err := unmarshalValue(val, dst.Index(i), p); err != nil {
-			return fmt.Errorf("can't assign %s to index #%d: %w", val.Type(), i, err)
+	elemType := dst.Type().Elem()
+	j := 0
+	for i, val := range items {
+		if p.isPolicyNull(val, elemType) {
+			if p.nullElements == NullElementZero {
+				dst.Index(j).Set(reflect.Zero(elemType))
+				j++
+			}
+			continue
+		}
+
+		if err := unmarshalValue(val, dst.Index(j), p.withPath(pathSegment{index: i, isIndex: true})); err != nil {
+			return wrapElementError(err, pathSegment{index: i, isIndex: true})
 		}
+		j++
 	}
 
+	// reset elements left after skipped nulls
+	for ; j < len(items); j++ {
+		dst.Index(j).Set(reflect.Zero(elemType))
+	}
 	return nil
 }
 
 func unmarshalSlice(src Value, dst reflect.Value, p unmarshalParams) error {
-	srcArr, ok := src.(*Array)
-	if !ok {
-		return newUnmarshalTypeErr(src.Type(), dst.Type())
+	if srcObj, ok := src.(*Object); ok {
+		if fields, ok := memberFieldsOf(dst.Type().Elem()); ok {
+			return unmarshalMemberSlice(srcObj, dst, fields, p)
+		}
+	}
+
+	srcArr, err := p.arraySource(src, dst.Type())
+	if err != nil {
+		return err
 	}
 
 	arrLen := len(srcArr.Items)
+	if err := p.checkLimit(srcArr, limitMaxSliceLen, p.maxSliceLen, arrLen); err != nil {
+		return err
+	}
+
+	elemType := dst.Type().Elem()
 	slice := reflect.MakeSlice(dst.Type(), arrLen, arrLen)
+	j := 0
 	for i, val := range srcArr.Items {
-		if err := unmarshalValue(val, slice.Index(i), p); err != nil {
-			return fmt.Errorf("can't set %s to index #%d: %w", val.Type(), i, err)
+		if p.isPolicyNull(val, elemType) {
+			if p.nullElements == NullElementZero {
+				// slice element is already zeroed
+				j++
+			}
+			continue
+		}
+
+		if err := unmarshalValue(val, slice.Index(j), p.withPath(pathSegment{index: i, isIndex: true})); err != nil {
+			return wrapElementError(err, pathSegment{index: i, isIndex: true})
+		}
+		j++
+	}
+
+	dst.Set(slice.Slice(0, j))
+	return nil
+}
+
+// memberFields contains indexes of struct fields marked
+// with `json:"$key"` and `json:"$value"` tags.
+type memberFields struct {
+	key   int
+	value int
+}
+
+// memberFieldsOf returns key and value fields of slice element type
+// which receives object members.
+func memberFieldsOf(elemType reflect.Type) (memberFields, bool) {
+	if elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+
+	if elemType.Kind() != reflect.Struct {
+		return memberFields{}, false
+	}
+
+	fields := memberFields{key: -1, value: -1}
+	for i := 0; i < elemType.NumField(); i++ {
+		f := elemType.Field(i)
+		td := parseTagData(f)
+		if td == nil || f.PkgPath != "" {
+			continue
+		}
+
+		switch td.srcKey {
+		case tagKeyMemberKey:
+			fields.key = i
+		case tagKeyMemberValue:
+			fields.value = i
+		}
+	}
+
+	ok := fields.key != -1 && fields.value != -1 && elemType.Field(fields.key).Type.Kind() == reflect.String
+	return fields, ok
+}
+
+// unmarshalMemberSlice maps object members to slice of key-value structs in source order.
+func unmarshalMemberSlice(srcObj *Object, dst reflect.Value, fields memberFields, p unmarshalParams) error {
+	members := srcObj.Members()
+	if err := p.checkLimit(srcObj, limitMaxSliceLen, p.maxSliceLen, len(members)); err != nil {
+		return err
+	}
+
+	slice := reflect.MakeSlice(dst.Type(), len(members), len(members))
+	for i, m := range members {
+		elem := slice.Index(i)
+		if elem.Kind() == reflect.Ptr {
+			elem.Set(reflect.New(elem.Type().Elem()))
+			elem = elem.Elem()
+		}
+
+		elem.Field(fields.key).SetString(m.Key)
+		if err := unmarshalValue(m.Value, elem.Field(fields.value), p.withPath(pathSegment{key: m.Key})); err != nil {
+			return wrapElementError(fmt.Errorf("member #%d: %w", i, err), pathSegment{key: m.Key})
 		}
 	}
 
@@ -415,6 +1504,62 @@ func unmarshalSlice(src Value, dst reflect.Value, p unmarshalParams) error {
 	return nil
 }
 
+// elementError is an error of map, slice or array element unmarshal.
+//
+// Nested element errors are merged, so error contains full path to the element.
+type elementError struct {
+	path []pathSegment
+	err  error
+}
+
+func wrapElementError(err error, seg pathSegment) error {
+	if elemErr, ok := err.(*elementError); ok {
+		elemErr.path = append([]pathSegment{seg}, elemErr.path...)
+		return elemErr
+	}
+
+	return &elementError{path: []pathSegment{seg}, err: err}
+}
+
+func (err *elementError) Error() string {
+	return fmt.Sprintf("%s: %s", formatPath(err.path), err.err)
+}
+
+func (err *elementError) Unwrap() error {
+	return err.err
+}
+
+func unmarshalComplex(src Value, dst reflect.Value, p unmarshalParams) error {
+	var re, im Value
+	switch t := src.(type) {
+	case *Array:
+		if len(t.Items) != 2 {
+			return fmt.Errorf("complex number array should have 2 elements (got %d)", len(t.Items))
+		}
+		re, im = t.Items[0], t.Items[1]
+	case *Object:
+		re, _ = t.Get("re")
+		im, _ = t.Get("im")
+		if re == nil || im == nil {
+			return errors.New(`complex number object should have "re" and "im" keys`)
+		}
+	default:
+		return newUnmarshalTypeErr(src, dst.Type())
+	}
+
+	parts := [2]float64{}
+	for i, v := range [2]Value{re, im} {
+		part := reflect.New(reflect.TypeOf(parts[i])).Elem()
+		if err := unmarshalFloat(v, part, p.strict); err != nil {
+			return fmt.Errorf("invalid complex number part: %w", err)
+		}
+		parts[i] = part.Float()
+	}
+
+	dst.SetComplex(complex(parts[0], parts[1]))
+	return nil
+}
+
 func unmarshalFloat(src Value, dst reflect.Value, strict bool) error {
 	bitness := 64
 	if dst.Kind() == reflect.Float32 {
@@ -422,7 +1567,7 @@ func unmarshalFloat(src Value, dst reflect.Value, strict bool) error {
 	}
 
 	if strict && src.Type() != TypeNumber {
-		return newUnmarshalTypeErr(src.Type(), dst.Type())
+		return newUnmarshalTypeErr(src, dst.Type())
 	}
 
 	numval, err := ToNumber(src, bitness)
@@ -430,13 +1575,18 @@ func unmarshalFloat(src Value, dst reflect.Value, strict bool) error {
 		return err
 	}
 
-	dst.SetFloat(numval.Float64())
+	f := numval.Float64()
+	if math.IsInf(f, 0) || dst.OverflowFloat(f) {
+		return newNumberRangeError(numval.Ref(), numval.asString(), "value overflows %s", dst.Type())
+	}
+
+	dst.SetFloat(f)
 	return nil
 }
 
 func unmarshalInt(src Value, dst reflect.Value, strict bool) error {
 	if strict && src.Type() != TypeNumber {
-		return newUnmarshalTypeErr(src.Type(), dst.Type())
+		return newUnmarshalTypeErr(src, dst.Type())
 	}
 
 	numval, err := ToNumber(src, 64)
@@ -444,13 +1594,18 @@ func unmarshalInt(src Value, dst reflect.Value, strict bool) error {
 		return err
 	}
 
-	dst.SetInt(numval.Int64())
+	val, ok := numval.int64Value()
+	if !ok || dst.OverflowInt(val) {
+		return newNumberRangeError(numval.Ref(), numval.asString(), "value overflows %s", dst.Type())
+	}
+
+	dst.SetInt(val)
 	return nil
 }
 
 func unmarshalUint(src Value, dst reflect.Value, strict bool) error {
 	if strict && src.Type() != TypeNumber {
-		return newUnmarshalTypeErr(src.Type(), dst.Type())
+		return newUnmarshalTypeErr(src, dst.Type())
 	}
 
 	numval, err := ToNumber(src, 64)
@@ -462,18 +1617,23 @@ func unmarshalUint(src Value, dst reflect.Value, strict bool) error {
 		return fmt.Errorf("assignment of signed value %v to unsigned type %s", numval.Interface(), dst.Type())
 	}
 
-	dst.SetUint(numval.Uint64())
+	val, ok := numval.uint64Value()
+	if !ok || dst.OverflowUint(val) {
+		return newNumberRangeError(numval.Ref(), numval.asString(), "value overflows %s", dst.Type())
+	}
+
+	dst.SetUint(val)
 	return nil
 }
 
 func unmarshalBool(src Value, dst reflect.Value, strict bool) error {
 	switch t := TypeOf(src); t {
 	case TypeBoolean:
-		dst.SetBool(src.(*Boolean).Value)
+		dst.SetBool(src.Interface().(bool))
 		return nil
 	case TypeString:
 		if strict {
-			return newUnmarshalTypeErr(t, dst.Type())
+			return newUnmarshalTypeErr(src, dst.Type())
 		}
 
 		strval, err := src.String()
@@ -481,36 +1641,126 @@ func unmarshalBool(src Value, dst reflect.Value, strict bool) error {
 			return err
 		}
 
-		boolval, err := strconv.ParseBool(strval)
+		boolval, err := parseBool(strval)
 		if err != nil {
-			return newUnmarshalCastErr(t, dst.Type(), err)
+			return newUnmarshalCastErr(src, dst.Type(), err)
 		}
 
 		dst.SetBool(boolval)
 		return nil
 	default:
-		return newUnmarshalTypeErr(t, dst.Type())
+		return newUnmarshalTypeErr(src, dst.Type())
+	}
+}
+
+// parseBool parses boolean string, see NoStrict.
+func parseBool(str string) (bool, error) {
+	switch {
+	case strings.EqualFold(str, "true"):
+		return true, nil
+	case strings.EqualFold(str, "false"):
+		return false, nil
+	default:
+		return strconv.ParseBool(str)
+	}
+}
+
+// decodeJSONString parses content of string source as JSON document
+// if destination accepts composite values, see DecodeJSONStrings.
+//
+// Other sources and destinations which decode strings by themselves
+// (unmarshalers and json.RawMessage) are returned as is.
+func decodeJSONString(src Value, dstType reflect.Type, path []pathSegment) (Value, error) {
+	str, ok := src.(*String)
+	if !ok {
+		return src, nil
+	}
+
+	for dstType.Kind() == reflect.Ptr {
+		dstType = dstType.Elem()
+	}
+
+	if isStringUnmarshaler(dstType) {
+		return src, nil
+	}
+
+	switch dstType.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		if isValueStruct(dstType) {
+			return src, nil
+		}
+	default:
+		return src, nil
+	}
+
+	content, err := str.String()
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := NewParser([]byte(content)).Parse()
+	if err != nil {
+		if len(path) == 0 {
+			return nil, fmt.Errorf("in embedded JSON: %w", err)
+		}
+		return nil, fmt.Errorf("in embedded JSON at %s: %w", formatPath(path), err)
+	}
+	return v, nil
+}
+
+// isStringUnmarshaler checks if type accepts string values by itself
+// and string content shouldn't be decoded, see DecodeJSONStrings.
+func isStringUnmarshaler(t reflect.Type) bool {
+	if t == typeJsonRawMessage {
+		return true
+	}
+
+	ptr := reflect.PtrTo(t)
+	return isUnmarshaler(t) || isUnmarshaler(ptr) ||
+		t.Implements(typeTextUnmarshaler) || ptr.Implements(typeTextUnmarshaler)
+}
+
+// isQuotedNull checks if source is a string which represents null
+// and destination is nillable, see QuotedNulls.
+func isQuotedNull(src Value, dstType reflect.Type) bool {
+	str, ok := src.(*String)
+	if !ok || str == nil || dstType == typeJsonRawMessage || isValueDestination(src, dstType) {
+		return false
+	}
+
+	switch dstType.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+	default:
+		return false
 	}
+
+	val, err := str.String()
+	return err == nil && (val == "" || strings.EqualFold(val, "null"))
 }
 
 func unmarshalString(src Value, dst reflect.Value, strict bool) error {
 	if t := TypeOf(src); strict && t != TypeString {
-		return newUnmarshalTypeErr(t, dst.Type())
+		return newUnmarshalTypeErr(src, dst.Type())
 	}
 
 	strval, err := src.String()
 	if err != nil {
-		return newUnmarshalCastErr(src.Type(), dst.Type(), err)
+		return newUnmarshalCastErr(src, dst.Type(), err)
 	}
 
 	dst.SetString(strval)
 	return nil
 }
 
-func newUnmarshalTypeErr(srcType Type, dstType reflect.Type) error {
-	return fmt.Errorf("cannot unmarshal %s value to %s", srcType, dstType)
+func newUnmarshalTypeErr(src Value, dstType reflect.Type) error {
+	return &UnmarshalTypeError{
+		Position:    positionOf(src),
+		Source:      TypeOf(src),
+		Destination: dstType,
+		Hint:        newShapeHint(src, dstType),
+	}
 }
 
-func newUnmarshalCastErr(srcType Type, dstType reflect.Type, err error) error {
-	return fmt.Errorf("cannot convert %s value to destination value %s: %w", srcType, dstType, err)
+func newUnmarshalCastErr(src Value, dstType reflect.Type, err error) error {
+	return &UnmarshalTypeError{Position: positionOf(src), Source: TypeOf(src), Destination: dstType, Err: err}
 }
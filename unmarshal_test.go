@@ -0,0 +1,1831 @@
+package jsonreflect
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/iancoleman/strcase"
+	"github.com/stretchr/testify/require"
+	"github.com/x1unix/jsonreflect/internal/privtype"
+	. "github.com/x1unix/jsonreflect/internal/testutil"
+)
+
+func TestUnmarshal_OrphanKeysOrder(t *testing.T) {
+	type dst struct {
+		ID      int     `json:"id"`
+		Name    string  `json:"name"`
+		Orphans *Object `json:"..."`
+	}
+
+	src := TestdataFixture("obj_orphans.json").ProvideFixture(t)
+	got := new(dst)
+	require.NoError(t, Unmarshal(src, got))
+	require.Equal(t, 10, got.ID)
+	require.Equal(t, "foo", got.Name)
+	require.NotNil(t, got.Orphans)
+	require.Equal(t, []string{"zeta", "alpha", "Mid", "beta"}, got.Orphans.orderedKeys())
+}
+
+func TestUnmarshal_DeterministicErrors(t *testing.T) {
+	src := []byte(`{"a": 1, "zeta": "bad", "b": 2, "alpha": "worse", "c": 3}`)
+
+	var errs []string
+	for i := 0; i < 2; i++ {
+		var dst map[string]int
+		err := Unmarshal(src, &dst)
+		require.Error(t, err)
+		errs = append(errs, err.Error())
+	}
+
+	require.Equal(t, errs[0], errs[1])
+	require.Equal(t, "zeta: cannot unmarshal string value to int", errs[0])
+}
+
+func TestUnmarshalValue_ValueDestination(t *testing.T) {
+	src, err := ValueOf([]byte(`{"foo": {"bar": 1}, "baz": [true]}`))
+	require.NoError(t, err)
+
+	var dst struct {
+		Foo *Object `json:"foo"`
+		Baz Value   `json:"baz"`
+	}
+	require.NoError(t, UnmarshalValue(src, &dst))
+
+	obj := src.(*Object)
+	require.Same(t, obj.Items["foo"], dst.Foo)
+	require.Equal(t, obj.Items["baz"], dst.Baz)
+}
+
+func TestUnmarshal_OrphanMembers(t *testing.T) {
+	type dst struct {
+		ID      int      `json:"id"`
+		Name    string   `json:"name"`
+		Orphans []Member `json:"..."`
+	}
+
+	src := TestdataFixture("obj_orphans.json").ProvideFixture(t)
+	got := new(dst)
+	require.NoError(t, Unmarshal(src, got))
+
+	keys := make([]string, 0, len(got.Orphans))
+	for _, m := range got.Orphans {
+		keys = append(keys, m.Key)
+	}
+	require.Equal(t, []string{"zeta", "alpha", "Mid", "beta"}, keys)
+	require.Equal(t, TypeNumber, got.Orphans[0].Value.Type())
+}
+
+func TestUnmarshal_ExoticKinds(t *testing.T) {
+	cases := map[string]struct {
+		src  string
+		dst  func() interface{}
+		want interface{}
+		opts []UnmarshalOption
+		err  ExpectedError
+	}{
+		"complex without option": {
+			src: `{"c": [1, 2]}`,
+			dst: func() interface{} {
+				return &struct {
+					C complex128 `json:"c"`
+				}{}
+			},
+			err: `can't unmarshal field "c"`,
+		},
+		"complex from array": {
+			src:  `[1.5, -2]`,
+			dst:  func() interface{} { return new(complex128) },
+			want: complex(1.5, -2),
+			opts: []UnmarshalOption{AllowComplexNumbers},
+		},
+		"complex64 from object": {
+			src:  `{"re": 3, "im": 0.5}`,
+			dst:  func() interface{} { return new(complex64) },
+			want: complex64(complex(3, 0.5)),
+			opts: []UnmarshalOption{AllowComplexNumbers},
+		},
+		"complex from invalid array": {
+			src:  `[1, 2, 3]`,
+			dst:  func() interface{} { return new(complex128) },
+			opts: []UnmarshalOption{AllowComplexNumbers},
+			err:  "complex number array should have 2 elements (got 3)",
+		},
+		"complex from object without parts": {
+			src:  `{"re": 1}`,
+			dst:  func() interface{} { return new(complex128) },
+			opts: []UnmarshalOption{AllowComplexNumbers},
+			err:  `complex number object should have "re" and "im" keys`,
+		},
+		"chan": {
+			src: `{"ch": 1}`,
+			dst: func() interface{} {
+				return &struct {
+					Ch chan int `json:"ch"`
+				}{}
+			},
+			err: "unsupported destination kind chan",
+		},
+		"func": {
+			src: `1`,
+			dst: func() interface{} { return new(func()) },
+			err: "unsupported destination kind func",
+		},
+		"int16": {
+			src:  `-12`,
+			dst:  func() interface{} { return new(int16) },
+			want: int16(-12),
+		},
+		"uintptr": {
+			src:  `12`,
+			dst:  func() interface{} { return new(uintptr) },
+			want: uintptr(12),
+		},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			dst := c.dst()
+			err := Unmarshal([]byte(c.src), dst, c.opts...)
+			if !c.err.AssertError(t, err) {
+				return
+			}
+			require.Equal(t, c.want, reflect.ValueOf(dst).Elem().Interface())
+		})
+	}
+}
+
+func TestUnmarshal_NestedPointer(t *testing.T) {
+	var dst **int
+	require.NoError(t, Unmarshal([]byte(`42`), &dst))
+	require.NotNil(t, dst)
+	require.NotNil(t, *dst)
+	require.Equal(t, 42, **dst)
+
+	var obj struct {
+		Value ***string `json:"value"`
+	}
+	require.NoError(t, Unmarshal([]byte(`{"value": "foo"}`), &obj))
+	require.Equal(t, "foo", ***obj.Value)
+}
+
+func TestUnmarshal_NullResetsNillable(t *testing.T) {
+	x := 1
+	dst := struct {
+		Ptr   *int           `json:"ptr"`
+		Map   map[string]int `json:"map"`
+		Slice []int          `json:"slice"`
+		Any   interface{}    `json:"any"`
+	}{Ptr: &x, Map: map[string]int{"a": 1}, Slice: []int{1}, Any: "foo"}
+
+	require.NoError(t, Unmarshal([]byte(`{"ptr": null, "map": null, "slice": null, "any": null}`), &dst))
+	require.Nil(t, dst.Ptr)
+	require.Nil(t, dst.Map)
+	require.Nil(t, dst.Slice)
+	require.Nil(t, dst.Any)
+}
+
+func TestUnmarshalNext(t *testing.T) {
+	type record struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+
+	cases := map[string]struct {
+		src  string
+		want []record
+		err  ExpectedError
+	}{
+		"records": {
+			src: "{\"id\": 1, \"name\": \"foo\"}\n{\"id\": 2, \"name\": \"bar\"}\n\n{\"id\": 3, \"name\": \"baz\"}\n",
+			want: []record{
+				{ID: 1, Name: "foo"},
+				{ID: 2, Name: "bar"},
+				{ID: 3, Name: "baz"},
+			},
+		},
+		"empty": {
+			src: " \n",
+		},
+		"null record": {
+			src:  "{\"id\": 1}\nnull\n{\"id\": 3}",
+			want: []record{{ID: 1}, {}, {ID: 3}},
+		},
+		"malformed record": {
+			src:  "{\"id\": 1}\n{\"id\": 2,\n{\"id\": 3}",
+			want: []record{{ID: 1}},
+			err:  "cannot parse value #1",
+		},
+		"invalid record": {
+			src:  "{\"id\": 1}\n{\"id\": \"2\"}\n{\"id\": 3}",
+			want: []record{{ID: 1}},
+			err:  "cannot unmarshal value #1",
+		},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			p := NewParser([]byte(c.src))
+			var (
+				got []record
+				err error
+			)
+			for {
+				var r record
+				if err = UnmarshalNext(p, &r); err != nil {
+					break
+				}
+				got = append(got, r)
+			}
+
+			require.Equal(t, c.want, got)
+			if err == io.EOF {
+				err = nil
+				require.Equal(t, len(c.src), p.Offset())
+			}
+			c.err.AssertError(t, err)
+		})
+	}
+}
+
+func TestUnmarshalNext_NullResetsDestination(t *testing.T) {
+	p := NewParser([]byte("{\"id\": 1, \"tags\": [\"a\"]} null"))
+	dst := struct {
+		ID   int      `json:"id"`
+		Tags []string `json:"tags"`
+	}{}
+
+	require.NoError(t, UnmarshalNext(p, &dst))
+	require.Equal(t, 1, dst.ID)
+
+	require.NoError(t, UnmarshalNext(p, &dst))
+	require.Zero(t, dst.ID)
+	require.Nil(t, dst.Tags)
+	require.Equal(t, io.EOF, UnmarshalNext(p, &dst))
+}
+
+func TestUnmarshalReflectValue(t *testing.T) {
+	src, err := ValueOf([]byte(`{"port": 8080}`))
+	require.NoError(t, err)
+
+	type config struct {
+		Server struct {
+			Port int `json:"port"`
+		}
+	}
+
+	cfg := new(config)
+	field := reflect.ValueOf(cfg).Elem().FieldByName("Server")
+	require.NoError(t, UnmarshalReflectValue(src, field))
+	require.Equal(t, 8080, cfg.Server.Port)
+
+	// pointer is dereferenced
+	cfg = new(config)
+	require.NoError(t, UnmarshalReflectValue(src, reflect.ValueOf(&cfg.Server)))
+	require.Equal(t, 8080, cfg.Server.Port)
+
+	// fields of non-addressable struct can't be set
+	err = UnmarshalReflectValue(src, reflect.ValueOf(config{}).FieldByName("Server"))
+	require.EqualError(t, err, "destination value of type struct { Port int \"json:\\\"port\\\"\" } is not settable, pass addressable value or a pointer")
+
+	items := map[string]config{"foo": {}}
+	err = UnmarshalReflectValue(src, reflect.ValueOf(items).MapIndex(reflect.ValueOf("foo")))
+	require.EqualError(t, err, "destination value of type jsonreflect.config is not settable, pass addressable value or a pointer")
+
+	err = UnmarshalReflectValue(src, reflect.Value{})
+	require.EqualError(t, err, "invalid destination value")
+
+	err = UnmarshalReflectValue(src, reflect.ValueOf((*config)(nil)))
+	require.EqualError(t, err, "nil pointer passed")
+}
+
+func TestUnmarshal_NameGuessing(t *testing.T) {
+	type dst struct {
+		UserName string
+		Title    string
+		Tagged   string `json:"user_name"`
+	}
+
+	src := []byte(`{"userName": "guess", "Title": "exact", "user_name": "tag"}`)
+
+	var guesses []string
+	got := new(dst)
+	require.NoError(t, Unmarshal(src, got, OnNameGuess(func(typ reflect.Type, field, key string) {
+		guesses = append(guesses, typ.Name()+"."+field+"="+key)
+	})))
+	require.Equal(t, dst{UserName: "guess", Title: "exact", Tagged: "tag"}, *got)
+	require.Equal(t, []string{"dst.UserName=userName"}, guesses)
+
+	got = new(dst)
+	require.NoError(t, Unmarshal(src, got, DisableNameGuessing))
+	require.Equal(t, dst{Title: "exact", Tagged: "tag"}, *got)
+}
+
+func TestUnmarshal_KeyCandidates(t *testing.T) {
+	type dst struct {
+		UserName  string
+		MaxRetry  int
+		CreatedAt string
+		Rest      map[string]string `json:"..."`
+	}
+
+	require.Equal(t, []string{"UserName", "userName", "user_name", "USER_NAME"},
+		DefaultKeyCandidates(reflect.StructField{Name: "UserName"}))
+
+	src := []byte(`{"user_name": "foo", "MAX_RETRY": 3, "created-at": "today", "other": "bar"}`)
+	got := new(dst)
+	require.NoError(t, Unmarshal(src, got))
+	require.Equal(t, dst{UserName: "foo", MaxRetry: 3, Rest: map[string]string{
+		"created-at": "today",
+		"other":      "bar",
+	}}, *got)
+
+	kebab := WithKeyCandidates(func(f reflect.StructField) []string {
+		return append(DefaultKeyCandidates(f), strcase.ToKebab(f.Name))
+	})
+
+	// matched candidate is consumed, so it's not reported as unknown field
+	got = new(dst)
+	require.NoError(t, Unmarshal(src, got, kebab, DisallowUnknownFields))
+	require.Equal(t, dst{UserName: "foo", MaxRetry: 3, CreatedAt: "today", Rest: map[string]string{
+		"other": "bar",
+	}}, *got)
+
+	type strictDst struct {
+		UserName string
+	}
+
+	err := Unmarshal([]byte(`{"user-name": "foo"}`), new(strictDst), DisallowUnknownFields)
+	require.Error(t, err)
+
+	strict := new(strictDst)
+	require.NoError(t, Unmarshal([]byte(`{"user-name": "foo"}`), strict, kebab, DisallowUnknownFields))
+	require.Equal(t, "foo", strict.UserName)
+
+	// name guessing can still be disabled
+	strict = new(strictDst)
+	require.NoError(t, Unmarshal([]byte(`{"user-name": "foo"}`), strict, kebab, DisableNameGuessing))
+	require.Empty(t, strict.UserName)
+}
+
+func TestUnmarshal_OrphanStruct(t *testing.T) {
+	type meta struct {
+		Author string            `json:"author"`
+		Rest   map[string]string `json:"..."`
+	}
+
+	type extra struct {
+		Version int   `json:"version"`
+		Meta    *meta `json:"..."`
+	}
+
+	type dst struct {
+		ID    int   `json:"id"`
+		Extra extra `json:"..."`
+	}
+
+	src := []byte(`{"id": 1, "version": 2, "author": "foo", "tag": "bar", "label": "baz"}`)
+	got := new(dst)
+	require.NoError(t, Unmarshal(src, got, DisallowUnknownFields))
+	require.Equal(t, dst{
+		ID: 1,
+		Extra: extra{
+			Version: 2,
+			Meta: &meta{
+				Author: "foo",
+				Rest:   map[string]string{"tag": "bar", "label": "baz"},
+			},
+		},
+	}, *got)
+
+	type partial struct {
+		ID    int `json:"id"`
+		Extra struct {
+			Version int `json:"version"`
+		} `json:"..."`
+	}
+
+	err := Unmarshal(src, new(partial), DisallowUnknownFields)
+	require.EqualError(t, err, `unknown fields in jsonreflect.partial: "author" (in range 24:31), "tag" (in range 41:45), "label" (in range 55:61)`)
+	require.NoError(t, Unmarshal(src, new(partial)))
+}
+
+func TestUnmarshal_MemberSlice(t *testing.T) {
+	type script struct {
+		Name    string `json:"$key"`
+		Command string `json:"$value"`
+	}
+
+	type path struct {
+		Path    string         `json:"$key"`
+		Methods map[string]int `json:"$value"`
+	}
+
+	type dst struct {
+		Scripts []script `json:"scripts"`
+		Paths   []*path  `json:"paths"`
+		Tags    []string `json:"tags"`
+	}
+
+	cases := map[string]struct {
+		src  string
+		want dst
+		err  ExpectedError
+	}{
+		"source order": {
+			src: `{"scripts": {"test": "go test", "build": "go build", "lint": "go vet"}}`,
+			want: dst{Scripts: []script{
+				{Name: "test", Command: "go test"},
+				{Name: "build", Command: "go build"},
+				{Name: "lint", Command: "go vet"},
+			}},
+		},
+		"pointer elements": {
+			src: `{"paths": {"/b": {"get": 1}, "/a": {}}}`,
+			want: dst{Paths: []*path{
+				{Path: "/b", Methods: map[string]int{"get": 1}},
+				{Path: "/a", Methods: map[string]int{}},
+			}},
+		},
+		"empty object": {
+			src:  `{"scripts": {}}`,
+			want: dst{Scripts: []script{}},
+		},
+		"array": {
+			src:  `{"scripts": []}`,
+			want: dst{Scripts: []script{}},
+		},
+		"invalid value": {
+			src: `{"paths": {"/a": {}, "/b": {"get": "1"}}}`,
+			err: `["/b"]: member #1: get: cannot unmarshal string value to int`,
+		},
+		"not a member slice": {
+			src: `{"tags": {"a": "b"}}`,
+			err: `cannot unmarshal object value to []string`,
+		},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			got := dst{}
+			err := Unmarshal([]byte(c.src), &got)
+			if !c.err.AssertError(t, err) {
+				return
+			}
+			require.Equal(t, c.want, got)
+		})
+	}
+}
+
+func TestUnmarshal_DisallowUnknownFields(t *testing.T) {
+	type Base struct {
+		Name string `json:"name"`
+	}
+
+	type dst struct {
+		Base
+		*Embedded
+		ID int `json:"id"`
+	}
+
+	src := []byte(`{"id": 1, "name": "foo", "value": 2, "unknown": true}`)
+	err := Unmarshal(src, new(dst), DisallowUnknownFields)
+	require.EqualError(t, err, `unknown field in jsonreflect.dst: "unknown" (in range 37:45)`)
+
+	got := new(dst)
+	require.NoError(t, Unmarshal(src, got))
+	require.Equal(t, 2, got.Value)
+	require.Equal(t, "foo", got.Name)
+
+	got = new(dst)
+	require.NoError(t, Unmarshal([]byte(`{"id": 1, "name": "foo"}`), got, DisallowUnknownFields))
+	require.Nil(t, got.Embedded)
+}
+
+type Embedded struct {
+	Value int `json:"value"`
+}
+
+func TestUnmarshal_NestedComposites(t *testing.T) {
+	type withField struct {
+		Headers map[string][]string `json:"headers"`
+	}
+
+	cases := map[string]struct {
+		src     string
+		dst     func() interface{}
+		want    interface{}
+		opts    []UnmarshalOption
+		wantErr ExpectedError
+	}{
+		"struct field map of slices": {
+			src:  `{"headers": {"Accept": ["a", "b"], "Empty": []}}`,
+			dst:  func() interface{} { return new(withField) },
+			want: &withField{Headers: map[string][]string{"Accept": {"a", "b"}, "Empty": {}}},
+		},
+		"two levels": {
+			src:  `{"x": [{"a": 1}, {"b": 2}], "y": []}`,
+			dst:  func() interface{} { return new(map[string][]map[string]int) },
+			want: &map[string][]map[string]int{"x": {{"a": 1}, {"b": 2}}, "y": {}},
+		},
+		"three levels": {
+			src:  `{"x": [[{"a": [1, 2]}]]}`,
+			dst:  func() interface{} { return new(map[string][][]map[string][]int) },
+			want: &map[string][][]map[string][]int{"x": {{{"a": {1, 2}}}}},
+		},
+		"arrays of maps": {
+			src:  `[{"a": [1, 2, 3]}]`,
+			dst:  func() interface{} { return new([1]map[string][2]int) },
+			opts: []UnmarshalOption{NoStrict},
+			want: &[1]map[string][2]int{{"a": {1, 2}}},
+		},
+		"error path": {
+			src:     `{"x": [[{"a": [1, "z"]}]]}`,
+			dst:     func() interface{} { return new(map[string][][]map[string][]int) },
+			wantErr: `x[0][0].a[1]: cannot unmarshal string value to int`,
+		},
+		"error path in field": {
+			src:     `{"headers": {"Content Type": [1]}}`,
+			dst:     func() interface{} { return new(withField) },
+			wantErr: `["Content Type"][0]: cannot unmarshal number value to string`,
+		},
+		"array overflow": {
+			src:     `[{"a": [1, 2, 3]}]`,
+			dst:     func() interface{} { return new([1]map[string][2]int) },
+			wantErr: `[0].a: value overflows destination array (3 > 2)`,
+		},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			dst := c.dst()
+			err := Unmarshal([]byte(c.src), dst, c.opts...)
+			if !c.wantErr.AssertError(t, err) {
+				return
+			}
+			require.Equal(t, c.want, dst)
+		})
+	}
+}
+
+type testHookValue struct {
+	Raw   string
+	Calls int
+}
+
+func (v *testHookValue) UnmarshalJSONValue(src Value) error {
+	v.Calls++
+	data, err := MarshalValue(src, nil)
+	v.Raw = string(data)
+	return err
+}
+
+type testJSONHookValue struct {
+	Raw   string
+	Calls int
+}
+
+func (v *testJSONHookValue) UnmarshalJSON(data []byte) error {
+	v.Calls++
+	v.Raw = string(data)
+	return nil
+}
+
+func TestUnmarshal_PointerElementsWithHooks(t *testing.T) {
+	src := []byte(`[1, "foo", null]`)
+	objSrc := []byte(`{"a": 1, "b": "foo", "c": null}`)
+
+	var slice []*testHookValue
+	require.NoError(t, Unmarshal(src, &slice))
+	require.Equal(t, []*testHookValue{{Raw: "1", Calls: 1}, {Raw: `"foo"`, Calls: 1}, nil}, slice)
+
+	var jsonSlice []*testJSONHookValue
+	require.NoError(t, Unmarshal(src, &jsonSlice))
+	require.Equal(t, []*testJSONHookValue{{Raw: "1", Calls: 1}, {Raw: `"foo"`, Calls: 1}, nil}, jsonSlice)
+
+	var arr [2]*testHookValue
+	require.NoError(t, Unmarshal([]byte(`[true, [1]]`), &arr))
+	require.Equal(t, [2]*testHookValue{{Raw: "true", Calls: 1}, {Raw: "[1]", Calls: 1}}, arr)
+
+	var m map[string]*testJSONHookValue
+	require.NoError(t, Unmarshal(objSrc, &m))
+	require.Equal(t, map[string]*testJSONHookValue{
+		"a": {Raw: "1", Calls: 1},
+		"b": {Raw: `"foo"`, Calls: 1},
+		"c": nil,
+	}, m)
+
+	// non-pointer elements are called via address
+	var values map[string]testHookValue
+	require.NoError(t, Unmarshal(objSrc, &values))
+	require.Equal(t, testHookValue{Raw: `"foo"`, Calls: 1}, values["b"])
+	require.Equal(t, testHookValue{Raw: "null", Calls: 1}, values["c"])
+
+	var raw struct {
+		Items json.RawMessage `json:"items"`
+	}
+	require.NoError(t, Unmarshal([]byte(`{"items": [1, {"a": true}]}`), &raw))
+	require.Equal(t, `[1, {"a": true}]`, string(raw.Items))
+}
+
+func TestUnmarshal_UnknownFieldsError(t *testing.T) {
+	type dst struct {
+		ID int `json:"id"`
+	}
+
+	src := []byte(`{"a": 1, "id": 1, "b": 2, "c": 3, "d": 4}`)
+	cases := map[string]struct {
+		opts []UnmarshalOption
+		want string
+	}{
+		"below limit": {
+			opts: []UnmarshalOption{WithUnknownFieldsLimit(5)},
+			want: `unknown fields in jsonreflect.dst: "a" (in range 1:3), "b" (in range 18:20), "c" (in range 26:28), "d" (in range 34:36)`,
+		},
+		"at limit": {
+			opts: []UnmarshalOption{WithUnknownFieldsLimit(4)},
+			want: `unknown fields in jsonreflect.dst: "a" (in range 1:3), "b" (in range 18:20), "c" (in range 26:28), "d" (in range 34:36)`,
+		},
+		"above limit": {
+			opts: []UnmarshalOption{WithUnknownFieldsLimit(3)},
+			want: `unknown fields in jsonreflect.dst: "a" (in range 1:3), "b" (in range 18:20), "c" (in range 26:28) and 1 more`,
+		},
+		"single": {
+			opts: []UnmarshalOption{WithUnknownFieldsLimit(1)},
+			want: `unknown fields in jsonreflect.dst: "a" (in range 1:3) and 3 more`,
+		},
+		"no limit": {
+			opts: []UnmarshalOption{WithUnknownFieldsLimit(0)},
+			want: `unknown fields in jsonreflect.dst: "a" (in range 1:3), "b" (in range 18:20), "c" (in range 26:28), "d" (in range 34:36)`,
+		},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			opts := append([]UnmarshalOption{DisallowUnknownFields}, c.opts...)
+			err := Unmarshal(src, new(dst), opts...)
+			require.EqualError(t, err, c.want)
+
+			fieldsErr := new(UnknownFieldsError)
+			require.True(t, errors.As(err, &fieldsErr))
+			require.Len(t, fieldsErr.Fields, 4)
+			require.Equal(t, "d", fieldsErr.Fields[3].Key)
+		})
+	}
+
+	// error is available through nested struct errors
+	var parent struct {
+		Child dst `json:"child"`
+	}
+	err := Unmarshal([]byte(`{"child": {"x": 1}}`), &parent, DisallowUnknownFields)
+	fieldsErr := new(UnknownFieldsError)
+	require.True(t, errors.As(err, &fieldsErr))
+	require.Equal(t, "x", fieldsErr.Fields[0].Key)
+}
+
+func TestUnmarshal_Limits(t *testing.T) {
+	type dst struct {
+		Items  []int          `json:"items"`
+		Fixed  [4]int         `json:"fixed"`
+		Labels map[string]int `json:"labels"`
+		Nested [][]int        `json:"nested"`
+	}
+
+	limits := []UnmarshalOption{MaxSliceLen(2), MaxMapEntries(1)}
+	cases := map[string]struct {
+		src  string
+		want dst
+		err  ExpectedError
+	}{
+		"within limits": {
+			src:  `{"items": [1, 2], "fixed": [1], "labels": {"a": 1}, "nested": [[1], [2, 3]]}`,
+			want: dst{Items: []int{1, 2}, Fixed: [4]int{1}, Labels: map[string]int{"a": 1}, Nested: [][]int{{1}, {2, 3}}},
+		},
+		"slice": {
+			src: `{"items": [1, 2, 3]}`,
+			err: `value at "items" has 3 elements, exceeds MaxSliceLen limit of 2`,
+		},
+		"array": {
+			src: `{"fixed": [1, 2, 3]}`,
+			err: `value at "fixed" has 3 elements, exceeds MaxSliceLen limit of 2`,
+		},
+		"map": {
+			src: `{"labels": {"a": 1, "b": 2}}`,
+			err: `value at "labels" has 2 elements, exceeds MaxMapEntries limit of 1`,
+		},
+		"nested slice": {
+			src: `{"nested": [[1], [1, 2, 3]]}`,
+			err: `value at "nested[1]" has 3 elements, exceeds MaxSliceLen limit of 2`,
+		},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			got := dst{}
+			err := Unmarshal([]byte(c.src), &got, limits...)
+			if !c.err.AssertError(t, err) {
+				limitErr := new(LimitError)
+				require.True(t, errors.As(err, &limitErr))
+				return
+			}
+			require.Equal(t, c.want, got)
+		})
+	}
+}
+
+func TestUnmarshal_LimitBeforeAllocation(t *testing.T) {
+	const size = 100000
+	src := make([]Value, size)
+	for i := range src {
+		src[i] = NewNumberInt(int64(i))
+	}
+	arr := NewArray(src...)
+
+	var dst []int64
+	var err error
+	allocs := testing.AllocsPerRun(10, func() {
+		err = UnmarshalValue(arr, &dst, MaxSliceLen(size-1))
+	})
+	require.Error(t, err)
+	require.Nil(t, dst)
+
+	// only error is allocated, destination slice is not
+	require.Less(t, allocs, float64(10))
+
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	before := stats.TotalAlloc
+	_ = UnmarshalValue(arr, &dst, MaxSliceLen(size-1))
+	runtime.ReadMemStats(&stats)
+	require.Less(t, stats.TotalAlloc-before, uint64(size))
+}
+
+func TestUnmarshal_IntegerRange(t *testing.T) {
+	long := strings.Repeat("9", 30)
+	cases := map[string]struct {
+		src  string
+		dst  interface{}
+		want interface{}
+		err  ExpectedError
+	}{
+		"max uint64":        {src: "18446744073709551615", dst: new(uint64), want: uint64(math.MaxUint64)},
+		"uint64 exponent":   {src: "1.8e19", dst: new(uint64), want: uint64(18000000000000000000)},
+		"min int64":         {src: "-9223372036854775808", dst: new(int64), want: int64(math.MinInt64)},
+		"long fraction":     {src: "1.00000000000000000000000001", dst: new(int64), want: int64(1)},
+		"uint64 overflow":   {src: "18446744073709551616", dst: new(uint64), err: `number "18446744073709551616" is out of range: value overflows uint64`},
+		"int64 overflow":    {src: "9223372036854775808", dst: new(int64), err: `number "9223372036854775808" is out of range: value overflows int64`},
+		"long integer":      {src: long, dst: new(int64), err: ExpectedError(`number "` + long + `" is out of range: value overflows int64`)},
+		"negative overflow": {src: "-" + long, dst: new(int), err: ExpectedError(`number "-` + long + `" is out of range: value overflows int`)},
+		"exponent overflow": {src: "1e30", dst: new(uint64), err: `number "1e30" is out of range: value overflows uint64`},
+		"int8 overflow":     {src: "128", dst: new(int8), err: `number "128" is out of range: value overflows int8`},
+		"uint8 overflow":    {src: "256", dst: new(uint8), err: `number "256" is out of range: value overflows uint8`},
+		"float64 overflow":  {src: "-1e400", dst: new(float64), err: `number "-1e400" is out of range: value overflows float64`},
+		"float32 overflow":  {src: "1e39", dst: new(float32), err: `number "1e39" is out of range: value overflows float32`},
+		"max float64":       {src: "1.7976931348623157e308", dst: new(float64), want: math.MaxFloat64},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			err := Unmarshal([]byte(c.src), c.dst)
+			if !c.err.AssertError(t, err) {
+				require.True(t, errors.Is(err, ErrNumberOutOfRange))
+				return
+			}
+			require.Equal(t, c.want, reflect.ValueOf(c.dst).Elem().Interface())
+		})
+	}
+}
+
+func TestUnmarshal_KeyCandidatesAllocs(t *testing.T) {
+	src, err := NewParser([]byte(`{"Name": "foo", "Age": 1}`)).Parse()
+	require.NoError(t, err)
+
+	type tagged struct {
+		Name string `json:"Name"`
+		Age  int    `json:"Age"`
+	}
+	type untagged struct {
+		Name string
+		Age  int
+	}
+
+	// key candidates after matched field name should not be built
+	want := testing.AllocsPerRun(10, func() {
+		var dst tagged
+		require.NoError(t, UnmarshalValue(src, &dst))
+	})
+	got := testing.AllocsPerRun(10, func() {
+		var dst untagged
+		require.NoError(t, UnmarshalValue(src, &dst))
+	})
+	require.LessOrEqual(t, got, want)
+}
+
+func TestUnmarshal_ForeignPrivateField(t *testing.T) {
+	src := []byte(`{"field": {"Value": "foo"}}`)
+	secret := reflect.TypeOf(privtype.Secret{})
+	errMsg := func(structType reflect.Type) ExpectedError {
+		return ExpectedError(`can't set private field ` + structType.String() + `.field: type privtype.secret is unexported ` +
+			`type of package "github.com/x1unix/jsonreflect/internal/privtype", ` +
+			`implement Unmarshaler or use ReallyDangerouslySetPrivateFields option`)
+	}
+
+	cases := map[string]struct {
+		typ  reflect.Type
+		opts []UnmarshalOption
+		err  bool
+	}{
+		"unexported type": {
+			typ:  secret,
+			opts: []UnmarshalOption{DangerouslySetPrivateFields},
+			err:  true,
+		},
+		"unexported element type": {
+			typ:  reflect.TypeOf(privtype.Secrets{}),
+			opts: []UnmarshalOption{DangerouslySetPrivateFields},
+			err:  true,
+		},
+		"unexported map key type": {
+			typ:  reflect.MapOf(secret, reflect.TypeOf(0)),
+			opts: []UnmarshalOption{DangerouslySetPrivateFields},
+			err:  true,
+		},
+		"exported type": {
+			typ:  reflect.TypeOf(privtype.Public{}),
+			opts: []UnmarshalOption{DangerouslySetPrivateFields},
+		},
+		"local type": {
+			typ:  reflect.TypeOf(struct{ Value string }{}),
+			opts: []UnmarshalOption{DangerouslySetPrivateFields},
+		},
+		"really dangerously": {
+			typ:  secret,
+			opts: []UnmarshalOption{DangerouslySetPrivateFields, ReallyDangerouslySetPrivateFields},
+		},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			// private fields with json tag can't be declared in source since vet rejects them,
+			// so struct type is built at runtime.
+			structType := reflect.StructOf([]reflect.StructField{{
+				Name:    "field",
+				PkgPath: "github.com/x1unix/jsonreflect",
+				Type:    c.typ,
+				Tag:     `json:"field"`,
+			}})
+
+			dst := reflect.New(structType)
+			err := Unmarshal(src, dst.Interface(), c.opts...)
+			if c.err {
+				errMsg(structType).AssertError(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "{{foo}}", fmt.Sprint(dst.Elem().Interface()))
+		})
+	}
+
+	var dst struct {
+		Secret privtype.Secret `json:"field"`
+	}
+	require.NoError(t, Unmarshal(src, &dst))
+	require.Equal(t, "foo", dst.Secret.Value)
+}
+
+func TestUnmarshal_PreserveValuesInInterfaces(t *testing.T) {
+	type dst struct {
+		Any   interface{}            `json:"any"`
+		Map   map[string]interface{} `json:"map"`
+		Slice []interface{}          `json:"slice"`
+		Null  interface{}            `json:"null"`
+	}
+
+	src := `{"any": {"a": 1}, "map": {"b": {"c": true}, "d": "e"}, "slice": [[1], 2.5], "null": null}`
+	cases := map[string]struct {
+		opts  []UnmarshalOption
+		types map[string]interface{}
+	}{
+		"default": {
+			types: map[string]interface{}{
+				"any":     map[string]interface{}{},
+				"map.b":   map[string]interface{}{},
+				"map.d":   "",
+				"slice.0": []interface{}{},
+				"slice.1": float64(0),
+			},
+		},
+		"preserve values": {
+			opts: []UnmarshalOption{PreserveValuesInInterfaces},
+			types: map[string]interface{}{
+				"any":     &Object{},
+				"map.b":   &Object{},
+				"map.d":   &String{},
+				"slice.0": &Array{},
+				"slice.1": &Number{},
+			},
+		},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			got := dst{Null: "x"}
+			require.NoError(t, Unmarshal([]byte(src), &got, c.opts...))
+			require.Nil(t, got.Null)
+
+			values := map[string]interface{}{
+				"any":     got.Any,
+				"map.b":   got.Map["b"],
+				"map.d":   got.Map["d"],
+				"slice.0": got.Slice[0],
+				"slice.1": got.Slice[1],
+			}
+			for k, want := range c.types {
+				require.IsType(t, want, values[k], k)
+			}
+		})
+	}
+
+	t.Run("nested access", func(t *testing.T) {
+		got := map[string]interface{}{}
+		require.NoError(t, Unmarshal([]byte(src), &got, PreserveValuesInInterfaces))
+
+		obj := got["map"].(*Object)
+		require.Equal(t, newPosition(25, 52), obj.Ref())
+
+		c, ok := obj.Items["b"].(*Object).Items["c"].(Boolean)
+		require.True(t, ok)
+		require.True(t, c.Value)
+	})
+}
+
+func TestUnmarshal_NoStrictCasts(t *testing.T) {
+	str := "foo"
+	cases := map[string]struct {
+		src  string
+		opts []UnmarshalOption
+		dst  interface{}
+		want interface{}
+		err  ExpectedError
+	}{
+		"string to int":          {src: `"-12"`, dst: new(int), want: -12},
+		"string to uint":         {src: `"12"`, dst: new(uint8), want: uint8(12)},
+		"string to float":        {src: `"1.5"`, dst: new(float64), want: 1.5},
+		"invalid string to int":  {src: `"foo"`, dst: new(int), err: `cannot cast string value "foo" to number`},
+		"int to string":          {src: `12`, dst: new(string), want: "12"},
+		"float to string":        {src: `-1.50`, dst: new(string), want: "-1.50"},
+		"true to string":         {src: `true`, dst: new(string), want: "true"},
+		"false to string":        {src: `false`, dst: new(string), want: "false"},
+		"null to string":         {src: `null`, dst: new(string), want: ""},
+		"true string to bool":    {src: `"true"`, dst: new(bool), want: true},
+		"false string to bool":   {src: `"false"`, dst: new(bool), want: false},
+		"mixed case true":        {src: `"tRuE"`, dst: new(bool), want: true},
+		"mixed case false":       {src: `"FaLsE"`, dst: new(bool), want: false},
+		"one to bool":            {src: `"1"`, dst: new(bool), want: true},
+		"zero to bool":           {src: `"0"`, dst: new(bool), want: false},
+		"invalid string to bool": {src: `"yes"`, dst: new(bool), err: `invalid syntax`},
+		"quoted null without option": {
+			src: `"null"`, dst: new(*int), err: `cannot cast string value "null" to number`,
+		},
+		"quoted null to pointer": {
+			src: `"NULL"`, opts: []UnmarshalOption{QuotedNulls}, dst: &[]*string{&str}[0], want: (*string)(nil),
+		},
+		"empty string to pointer": {
+			src: `""`, opts: []UnmarshalOption{QuotedNulls}, dst: new(*int), want: (*int)(nil),
+		},
+		"quoted null to map": {
+			src: `"Null"`, opts: []UnmarshalOption{QuotedNulls}, dst: &map[string]int{"a": 1}, want: map[string]int(nil),
+		},
+		"quoted null to slice": {
+			src: `"null"`, opts: []UnmarshalOption{QuotedNulls}, dst: &[]int{1}, want: []int(nil),
+		},
+		"quoted null to interface": {
+			src: `"null"`, opts: []UnmarshalOption{QuotedNulls}, dst: new(interface{}), want: nil,
+		},
+		"quoted null to string": {
+			src: `"null"`, opts: []UnmarshalOption{QuotedNulls}, dst: new(string), want: "null",
+		},
+		"quoted null to value": {
+			src: `"null"`, opts: []UnmarshalOption{QuotedNulls}, dst: new(*String), want: NewString("null"),
+		},
+		"quoted null to raw message": {
+			src: `"null"`, opts: []UnmarshalOption{QuotedNulls}, dst: new(json.RawMessage), want: json.RawMessage(`"null"`),
+		},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			opts := append([]UnmarshalOption{NoStrict}, c.opts...)
+			err := Unmarshal([]byte(c.src), c.dst, opts...)
+			if !c.err.AssertError(t, err) {
+				return
+			}
+
+			got := reflect.ValueOf(c.dst).Elem().Interface()
+			if s, ok := got.(*String); ok {
+				require.Equal(t, c.want.(*String).Interface(), s.Interface())
+				return
+			}
+			require.Equal(t, c.want, got)
+		})
+	}
+}
+
+func TestUnmarshal_SnapshotSource(t *testing.T) {
+	src, err := ValueOf([]byte(`{"count": 1, "tags": ["a", "b"], "meta": {"x": true}}`))
+	require.NoError(t, err)
+	obj := src.(*Object)
+
+	// readers hold lock only while source is copied
+	mu := &sync.RWMutex{}
+	unmarshalModifiedSource(t, obj, mu, SnapshotSourceLocked(mu.RLocker()))
+
+	// destination doesn't reference source values
+	var got struct {
+		Meta interface{} `json:"meta"`
+	}
+	require.NoError(t, UnmarshalValue(src, &got, PreserveValuesInInterfaces, SnapshotSource()))
+	require.NotSame(t, obj.Items["meta"], got.Meta)
+	require.Equal(t, obj.Items["meta"].Interface(), got.Meta.(Value).Interface())
+}
+
+// unsafeSourceEnv is set for test process which unmarshals modified source without snapshot
+const unsafeSourceEnv = "JSONREFLECT_TEST_UNSAFE_SOURCE"
+
+func TestUnmarshal_SnapshotSourceRace(t *testing.T) {
+	if os.Getenv(unsafeSourceEnv) != "" {
+		src, err := ValueOf([]byte(`{"count": 1, "meta": {"x": true}}`))
+		require.NoError(t, err)
+		unmarshalModifiedSource(t, src.(*Object), &sync.RWMutex{})
+		return
+	}
+
+	if !raceEnabled {
+		t.Skip("test requires race detector")
+	}
+
+	// source modified during unmarshal without snapshot is a data race
+	cmd := exec.Command(os.Args[0], "-test.run=^TestUnmarshal_SnapshotSourceRace$")
+	cmd.Env = append(os.Environ(), unsafeSourceEnv+"=1")
+	out, err := cmd.CombinedOutput()
+	require.Error(t, err)
+	require.Contains(t, string(out), "DATA RACE")
+}
+
+// unmarshalModifiedSource unmarshals object from several goroutines
+// while another goroutine modifies it under write lock.
+func unmarshalModifiedSource(t *testing.T, obj *Object, mu *sync.RWMutex, opts ...UnmarshalOption) {
+	type dst struct {
+		Count int                    `json:"count"`
+		Tags  []string               `json:"tags"`
+		Meta  map[string]interface{} `json:"meta"`
+	}
+
+	done := make(chan struct{})
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		for i := int64(0); ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+
+			mu.Lock()
+			obj.Items["count"] = NewNumberInt(i)
+			obj.Items["meta"].(*Object).Items["y"] = NewNumberInt(i)
+			mu.Unlock()
+		}
+	}()
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				var got dst
+				if err := UnmarshalValue(obj, &got, opts...); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(done)
+	<-writerDone
+}
+
+func TestUnmarshal_RawMessageComposites(t *testing.T) {
+	src := TestdataFixture("obj_simple.json").ProvideFixture(t)
+	v, err := ValueOf(src)
+	require.NoError(t, err)
+	obj := v.(*Object)
+
+	// expected bytes are taken from source by value position
+	sourceOf := func(key string) string {
+		item, ok := obj.Get(key)
+		require.True(t, ok, key)
+		pos := item.Ref()
+		return string(src[pos.Start : pos.End+1])
+	}
+
+	var sections map[string]json.RawMessage
+	require.NoError(t, Unmarshal(src, &sections))
+	require.Len(t, sections, obj.Len())
+	for _, key := range obj.Keys() {
+		require.Equal(t, sourceOf(key), string(sections[key]), key)
+	}
+	require.Equal(t, "{\n    \"first_name\": \"John\",\n    \"last_name\": \"Doe\"\n  }", string(sections["meta"]))
+	require.Equal(t, `["root", "owner"]`, string(sections["roles"]))
+
+	var roles []json.RawMessage
+	require.NoError(t, UnmarshalValue(obj.Items["roles"], &roles))
+	require.Equal(t, []json.RawMessage{json.RawMessage(`"root"`), json.RawMessage(`"owner"`)}, roles)
+
+	var dst struct {
+		Meta    *json.RawMessage `json:"meta"`
+		Rating  *json.RawMessage `json:"rating"`
+		Ref     *json.RawMessage `json:"ref"`
+		Missing *json.RawMessage `json:"missing"`
+	}
+	require.NoError(t, Unmarshal(src, &dst))
+	require.Equal(t, sourceOf("meta"), string(*dst.Meta))
+	require.Equal(t, `-3.1415`, string(*dst.Rating))
+	require.Nil(t, dst.Ref)
+	require.Nil(t, dst.Missing)
+
+	// raw message doesn't share memory with source
+	(*dst.Rating)[0] = '+'
+	require.Equal(t, "-3.1415", sourceOf("rating"))
+
+	// modified values are serialized
+	modified := Clone(obj).(*Object)
+	modified.Items["roles"].(*Array).Items[0] = NewString("user")
+	require.NoError(t, UnmarshalValue(modified, &sections))
+	require.Equal(t, `["user","owner"]`, string(sections["roles"]))
+}
+
+func TestUnmarshal_EmbeddedValue(t *testing.T) {
+	type extended struct {
+		Object
+		Known string `json:"known"`
+	}
+
+	type orphans struct {
+		Object `json:"..."`
+		Known  string `json:"known"`
+	}
+
+	type extendedPtr struct {
+		*Object
+		Known string `json:"known"`
+	}
+
+	type withArray struct {
+		Array
+	}
+
+	type withValue struct {
+		Value
+		Known string `json:"known"`
+	}
+
+	src := []byte(`{"known": "foo", "extra": 1, "nested": {"a": true}}`)
+
+	t.Run("whole object", func(t *testing.T) {
+		got := extended{}
+		require.NoError(t, Unmarshal(src, &got, DisallowUnknownFields))
+		require.Equal(t, "foo", got.Known)
+		require.Equal(t, []string{"extra", "known", "nested"}, got.Keys())
+		require.Equal(t, 1, got.Items["extra"].Interface())
+
+		out, err := MarshalValue(&got.Object, nil)
+		require.NoError(t, err)
+		require.JSONEq(t, string(src), string(out))
+	})
+
+	t.Run("object pointer", func(t *testing.T) {
+		got := extendedPtr{}
+		require.NoError(t, Unmarshal(src, &got, DisallowUnknownFields))
+		require.Equal(t, "foo", got.Known)
+		require.Equal(t, []string{"extra", "known", "nested"}, got.Keys())
+	})
+
+	t.Run("orphans", func(t *testing.T) {
+		got := orphans{}
+		require.NoError(t, Unmarshal(src, &got, DisallowUnknownFields))
+		require.Equal(t, "foo", got.Known)
+		require.Equal(t, []string{"extra", "nested"}, got.Keys())
+	})
+
+	t.Run("interface", func(t *testing.T) {
+		got := withValue{}
+		require.NoError(t, Unmarshal(src, &got, DisallowUnknownFields))
+		require.Equal(t, "foo", got.Known)
+		require.Equal(t, TypeObject, got.Type())
+	})
+
+	t.Run("type mismatch", func(t *testing.T) {
+		err := Unmarshal(src, &withArray{})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "cannot unmarshal object value to jsonreflect.Array")
+
+		dst := Object{}
+		require.NoError(t, Unmarshal([]byte(`null`), &dst))
+		require.Error(t, Unmarshal([]byte(`[1]`), &dst))
+	})
+}
+
+func TestUnmarshal_NullElements(t *testing.T) {
+	one, three := 1, 3
+	cases := map[string]struct {
+		src     string
+		dst     func() interface{}
+		policy  NullElementPolicy
+		want    interface{}
+		wantErr ExpectedError
+	}{
+		"slice error": {
+			src:     `[1, null, 3]`,
+			dst:     func() interface{} { return new([]int) },
+			policy:  NullElementError,
+			wantErr: `[1]: cannot unmarshal null value to int`,
+		},
+		"slice zero": {
+			src:    `[1, null, 3]`,
+			dst:    func() interface{} { return new([]int) },
+			policy: NullElementZero,
+			want:   []int{1, 0, 3},
+		},
+		"slice skip": {
+			src:    `[1, null, 3]`,
+			dst:    func() interface{} { return new([]int) },
+			policy: NullElementSkip,
+			want:   []int{1, 3},
+		},
+		"pointers error": {
+			src:    `[1, null, 3]`,
+			dst:    func() interface{} { return new([]*int) },
+			policy: NullElementError,
+			want:   []*int{&one, nil, &three},
+		},
+		"pointers zero": {
+			src:    `[1, null, 3]`,
+			dst:    func() interface{} { return new([]*int) },
+			policy: NullElementZero,
+			want:   []*int{&one, nil, &three},
+		},
+		"pointers skip": {
+			src:    `[1, null, 3]`,
+			dst:    func() interface{} { return new([]*int) },
+			policy: NullElementSkip,
+			want:   []*int{&one, nil, &three},
+		},
+		"array error": {
+			src:     `["a", null, "c"]`,
+			dst:     func() interface{} { return &[3]string{"x", "y", "z"} },
+			policy:  NullElementError,
+			wantErr: `[1]: cannot unmarshal null value to string`,
+		},
+		"array zero": {
+			src:    `["a", null, "c"]`,
+			dst:    func() interface{} { return &[3]string{"x", "y", "z"} },
+			policy: NullElementZero,
+			want:   [3]string{"a", "", "c"},
+		},
+		"array skip": {
+			src:    `["a", null, "c"]`,
+			dst:    func() interface{} { return &[3]string{"x", "y", "z"} },
+			policy: NullElementSkip,
+			want:   [3]string{"a", "c", ""},
+		},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			dst := c.dst()
+			err := Unmarshal([]byte(c.src), dst, NullElements(c.policy))
+			if !c.wantErr.AssertError(t, err) {
+				return
+			}
+			require.Equal(t, c.want, reflect.ValueOf(dst).Elem().Interface())
+		})
+	}
+}
+
+func TestUnmarshal_JSONString(t *testing.T) {
+	type payload struct {
+		ID    int               `json:"id"`
+		Text  string            `json:"text"`
+		Attrs map[string]string `json:"attrs"`
+	}
+
+	type tagged struct {
+		Event   string   `json:"event"`
+		Payload payload  `json:"payload,jsonstring"`
+		Tags    []string `json:"tags,jsonstring"`
+	}
+
+	type plain struct {
+		Event   string   `json:"event"`
+		Payload *payload `json:"payload"`
+	}
+
+	src := `{
+		"event": "created",
+		"payload": "{\"id\": 1, \"text\": \"say \\\"hi\\\"\", \"attrs\": {\"q\": \"{\\\"a\\\":1}\"}}",
+		"tags": "[\"a\", \"b\"]"
+	}`
+	want := tagged{
+		Event: "created",
+		Payload: payload{
+			ID:    1,
+			Text:  `say "hi"`,
+			Attrs: map[string]string{"q": `{"a":1}`},
+		},
+		Tags: []string{"a", "b"},
+	}
+
+	got := tagged{}
+	require.NoError(t, Unmarshal([]byte(src), &got))
+	require.Equal(t, want, got)
+
+	// round trip
+	v, err := ValueFrom(got, nil)
+	require.NoError(t, err)
+	require.Equal(t, TypeString, v.(*Object).Items["payload"].Type())
+
+	out, err := MarshalValue(v, nil)
+	require.NoError(t, err)
+	got = tagged{}
+	require.NoError(t, Unmarshal(out, &got))
+	require.Equal(t, want, got)
+
+	// global option
+	p := plain{}
+	require.NoError(t, Unmarshal([]byte(src), &p, DecodeJSONStrings))
+	require.Equal(t, want.Payload, *p.Payload)
+	require.Error(t, Unmarshal([]byte(src), &plain{}))
+
+	err = Unmarshal([]byte(`{"payload": "{\"id\": 1,, \"text\": \"\"}"}`), &tagged{})
+	require.EqualError(t, err, `can't unmarshal field "payload" to jsonreflect.tagged.jsonreflect.payload: `+
+		`in embedded JSON at payload: unexpected character "," (in range 0:9)`)
+
+	err = Unmarshal([]byte(`{"items": [{"payload": "{"}]}`), &struct {
+		Items []plain `json:"items"`
+	}{}, DecodeJSONStrings)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "in embedded JSON at items[0].payload: ")
+
+	// unmarshalers receive string as is
+	var withUnmarshalers struct {
+		Time  time.Time       `json:"time"`
+		TimeP *time.Time      `json:"timeP"`
+		Raw   json.RawMessage `json:"raw"`
+		Hook  testHookValue   `json:"hook"`
+	}
+	err = Unmarshal([]byte(`{"time": "2021-01-02T03:04:05Z", "timeP": "2021-01-02T03:04:05Z", `+
+		`"raw": "[1]", "hook": "{}"}`), &withUnmarshalers, DecodeJSONStrings)
+	require.NoError(t, err)
+	wantTime := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.True(t, wantTime.Equal(withUnmarshalers.Time))
+	require.True(t, wantTime.Equal(*withUnmarshalers.TimeP))
+	require.Equal(t, json.RawMessage(`"[1]"`), withUnmarshalers.Raw)
+	require.Equal(t, testHookValue{Raw: `"{}"`, Calls: 1}, withUnmarshalers.Hook)
+}
+
+func TestUnmarshal_EmptyKey(t *testing.T) {
+	type nested struct {
+		Empty string         `json:"''"`
+		Rest  map[string]int `json:"..."`
+	}
+
+	type dst struct {
+		Empty  int                    `json:"''"`
+		Nested nested                 `json:"a"`
+		Other  map[string]interface{} `json:"..."`
+		Plain  string                 `json:""`
+	}
+
+	src := []byte(`{"": 1, "a": {"": "x", "b": 2}, "Plain": "p", "rest": {"": true}}`)
+	want := dst{
+		Empty:  1,
+		Nested: nested{Empty: "x", Rest: map[string]int{"b": 2}},
+		Other:  map[string]interface{}{"rest": map[string]interface{}{"": true}},
+		Plain:  "p",
+	}
+
+	got := dst{}
+	require.NoError(t, Unmarshal(src, &got, DisallowUnknownFields))
+	require.Equal(t, want, got)
+
+	m := map[string]map[string]interface{}{}
+	require.NoError(t, Unmarshal([]byte(`{"": {"": 1}, "a": {"": null}}`), &m))
+	require.Equal(t, map[string]map[string]interface{}{"": {"": 1}, "a": {"": nil}}, m)
+
+	// round trip
+	v, err := ValueFrom(got, nil)
+	require.NoError(t, err)
+	out, err := MarshalValue(v, nil)
+	require.NoError(t, err)
+	require.Equal(t, `{"":1,"a":{"":"x","b":2},"rest":{"":true},"Plain":"p"}`, string(out))
+
+	got = dst{}
+	require.NoError(t, Unmarshal(out, &got, DisallowUnknownFields))
+	require.Equal(t, want, got)
+
+	// empty keys are quoted in paths
+	err = Unmarshal([]byte(`{"": {"": [1, 2]}}`), &map[string]map[string][]int{}, MaxSliceLen(1))
+	limitErr := new(LimitError)
+	require.True(t, errors.As(err, &limitErr))
+	require.Equal(t, `[""][""]`, limitErr.Path)
+}
+
+func TestUnmarshal_ResetDestination(t *testing.T) {
+	type inner struct {
+		A int `json:"a"`
+		B int `json:"b"`
+	}
+
+	type dst struct {
+		Name    string                 `json:"name"`
+		Inner   inner                  `json:"inner"`
+		Ptr     *inner                 `json:"ptr"`
+		Map     map[string]int         `json:"map"`
+		Slice   []int                  `json:"slice"`
+		Array   [3]int                 `json:"array"`
+		Structs [2]inner               `json:"structs"`
+		Any     interface{}            `json:"any"`
+		Rest    map[string]interface{} `json:"..."`
+		Skipped string                 `json:"-"`
+		private string
+	}
+
+	first := []byte(`{
+		"name": "first", "inner": {"a": 1, "b": 2}, "ptr": {"a": 3, "b": 4},
+		"map": {"x": 1, "y": 2}, "slice": [1, 2, 3], "array": [1, 2, 3],
+		"structs": [{"a": 1, "b": 2}, {"a": 3}], "any": "foo", "extra": true
+	}`)
+	second := []byte(`{
+		"inner": {"a": 10}, "ptr": {"b": 40}, "map": {"z": 3},
+		"slice": [4], "array": [4], "structs": [{"b": 20}]
+	}`)
+
+	t.Run("merge", func(t *testing.T) {
+		got := dst{Skipped: "keep", private: "keep"}
+		require.NoError(t, Unmarshal(first, &got))
+		require.NoError(t, Unmarshal(second, &got))
+		require.Equal(t, dst{
+			Name:    "first",
+			Inner:   inner{A: 10, B: 2},
+			Ptr:     &inner{A: 3, B: 40},
+			Map:     map[string]int{"z": 3},
+			Slice:   []int{4},
+			Array:   [3]int{4, 2, 3},
+			Structs: [2]inner{{A: 1, B: 20}, {A: 3}},
+			Any:     "foo",
+			Rest:    map[string]interface{}{},
+			Skipped: "keep",
+			private: "keep",
+		}, got)
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		want := dst{Skipped: "keep", private: "keep"}
+		require.NoError(t, Unmarshal(second, &want))
+
+		got := dst{Skipped: "keep", private: "keep"}
+		require.NoError(t, Unmarshal(first, &got, ResetDestination))
+		require.NoError(t, Unmarshal(second, &got, ResetDestination))
+		require.Equal(t, want, got)
+		require.Equal(t, dst{
+			Inner:   inner{A: 10},
+			Ptr:     &inner{B: 40},
+			Map:     map[string]int{"z": 3},
+			Slice:   []int{4},
+			Array:   [3]int{4},
+			Structs: [2]inner{{B: 20}},
+			Rest:    map[string]interface{}{},
+			Skipped: "keep",
+			private: "keep",
+		}, got)
+
+		// decode is idempotent
+		require.NoError(t, Unmarshal(second, &got, ResetDestination))
+		require.Equal(t, want, got)
+	})
+
+	t.Run("reflect value", func(t *testing.T) {
+		got := map[string]int{"a": 1}
+		require.NoError(t, UnmarshalReflectValue(NewNull(), reflect.ValueOf(&got), ResetDestination))
+		require.Nil(t, got)
+	})
+}
+
+// selfUnmarshaler unmarshals source into itself.
+type selfUnmarshaler struct {
+	Name string `json:"name"`
+}
+
+func (s *selfUnmarshaler) UnmarshalJSONValue(v Value) error {
+	return UnmarshalValue(v, s)
+}
+
+// selfJSONUnmarshaler unmarshals source into itself with json.Unmarshaler interface.
+type selfJSONUnmarshaler struct {
+	Name string `json:"name"`
+}
+
+func (s *selfJSONUnmarshaler) UnmarshalJSON(data []byte) error {
+	return Unmarshal(data, s)
+}
+
+// aliasUnmarshaler unmarshals source into itself using type without methods.
+type aliasUnmarshaler struct {
+	Name string `json:"name"`
+}
+
+func (s *aliasUnmarshaler) UnmarshalJSONValue(v Value) error {
+	type plain aliasUnmarshaler
+	return UnmarshalValue(v, (*plain)(s))
+}
+
+// freshUnmarshaler unmarshals source into a new value of the same type.
+type freshUnmarshaler struct {
+	Name string `json:"name"`
+}
+
+func (s *freshUnmarshaler) UnmarshalJSONValue(v Value) error {
+	var tmp freshUnmarshaler
+	return UnmarshalValue(v, &tmp)
+}
+
+// freshJSONUnmarshaler unmarshals source into a new value of the same type
+// with json.Unmarshaler interface.
+type freshJSONUnmarshaler struct {
+	Name string `json:"name"`
+}
+
+func (s *freshJSONUnmarshaler) UnmarshalJSON(data []byte) error {
+	var tmp freshJSONUnmarshaler
+	return Unmarshal(data, &tmp)
+}
+
+// treeUnmarshaler unmarshals children of source into the same type.
+type treeUnmarshaler struct {
+	Name  string
+	Child *treeUnmarshaler
+}
+
+func (s *treeUnmarshaler) UnmarshalJSONValue(v Value) error {
+	obj, err := ToObject(v)
+	if err != nil {
+		return err
+	}
+
+	if err := UnmarshalValue(obj.Items["name"], &s.Name); err != nil {
+		return err
+	}
+
+	child, ok := obj.Items["child"]
+	if !ok {
+		return nil
+	}
+
+	s.Child = new(treeUnmarshaler)
+	return UnmarshalValue(child, s.Child)
+}
+
+func TestUnmarshal_RecursiveUnmarshaler(t *testing.T) {
+	src := []byte(`{"item": {"name": "foo"}}`)
+
+	t.Run("unmarshaler", func(t *testing.T) {
+		dst := struct {
+			Item selfUnmarshaler `json:"item"`
+		}{}
+
+		err := Unmarshal(src, &dst)
+		require.True(t, errors.Is(err, ErrRecursiveUnmarshal))
+
+		recErr := new(RecursiveUnmarshalError)
+		require.True(t, errors.As(err, &recErr))
+		require.Equal(t, reflect.TypeOf(&selfUnmarshaler{}), recErr.Type)
+		require.Equal(t, newPosition(9, 23), recErr.Pos())
+		require.Contains(t, err.Error(), "recursive unmarshal of *jsonreflect.selfUnmarshaler")
+
+		// guard is released after error
+		err = Unmarshal(src, &dst)
+		require.True(t, errors.Is(err, ErrRecursiveUnmarshal))
+	})
+
+	t.Run("fresh destination", func(t *testing.T) {
+		for _, src := range []string{`{"item": {"name": "foo"}}`, `{"item": true}`, `{"item": [1]}`} {
+			dst := struct {
+				Item freshUnmarshaler `json:"item"`
+			}{}
+
+			err := Unmarshal([]byte(src), &dst)
+			require.True(t, errors.Is(err, ErrRecursiveUnmarshal), src)
+			require.Contains(t, err.Error(), "recursive unmarshal of *jsonreflect.freshUnmarshaler", src)
+		}
+
+		err := UnmarshalValue(NewNull(), new(freshUnmarshaler), SnapshotSource())
+		require.True(t, errors.Is(err, ErrRecursiveUnmarshal))
+	})
+
+	t.Run("fresh json destination", func(t *testing.T) {
+		dst := struct {
+			Item freshJSONUnmarshaler `json:"item"`
+		}{}
+
+		err := Unmarshal(src, &dst)
+		require.True(t, errors.Is(err, ErrRecursiveUnmarshal))
+		require.Contains(t, err.Error(), "recursive unmarshal of *jsonreflect.freshJSONUnmarshaler")
+	})
+
+	t.Run("same type children", func(t *testing.T) {
+		var dst treeUnmarshaler
+		require.NoError(t, Unmarshal([]byte(`{"name": "a", "child": {"name": "b", "child": {"name": "c"}}}`), &dst))
+		require.Equal(t, treeUnmarshaler{Name: "a", Child: &treeUnmarshaler{Name: "b", Child: &treeUnmarshaler{Name: "c"}}}, dst)
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		v, err := ValueOf([]byte(`{"name": "a", "child": {"name": "b"}}`))
+		require.NoError(t, err)
+
+		// the same source is unmarshaled into the same type by several goroutines
+		var wg sync.WaitGroup
+		errs := make([]error, 8)
+		for i := range errs {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				errs[i] = UnmarshalValue(v, new(treeUnmarshaler))
+			}(i)
+		}
+		wg.Wait()
+
+		for _, err := range errs {
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("json unmarshaler", func(t *testing.T) {
+		dst := struct {
+			Item selfJSONUnmarshaler `json:"item"`
+		}{}
+
+		err := Unmarshal(src, &dst)
+		require.True(t, errors.Is(err, ErrRecursiveUnmarshal))
+		require.Contains(t, err.Error(), "recursive unmarshal of *jsonreflect.selfJSONUnmarshaler")
+	})
+
+	t.Run("alias", func(t *testing.T) {
+		dst := struct {
+			Items []aliasUnmarshaler `json:"items"`
+		}{}
+
+		require.NoError(t, Unmarshal([]byte(`{"items": [{"name": "foo"}, {"name": "bar"}]}`), &dst))
+		require.Equal(t, []aliasUnmarshaler{{Name: "foo"}, {Name: "bar"}}, dst.Items)
+	})
+}
+
+func TestUnmarshal_ObjectsAsArrays(t *testing.T) {
+	type dst struct {
+		Slice []string  `json:"slice"`
+		Array [2]int    `json:"array"`
+		Plain []float64 `json:"plain"`
+	}
+
+	src := []byte(`{"slice": {"1": "b", "0": "a"}, "array": {"0": 1, "1": 2}, "plain": [1.5]}`)
+	got := dst{}
+	require.NoError(t, Unmarshal(src, &got, ObjectsAsArrays))
+	require.Equal(t, dst{Slice: []string{"a", "b"}, Array: [2]int{1, 2}, Plain: []float64{1.5}}, got)
+
+	err := Unmarshal(src, &dst{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "cannot unmarshal object value to []string")
+
+	err = Unmarshal([]byte(`{"slice": {"0": "a", "01": "b"}}`), &dst{}, ObjectsAsArrays)
+	require.EqualError(t, err, `can't unmarshal field "slice" to jsonreflect.dst.[]string: `+
+		`object is not an array: missing indexes 1; invalid keys "01"`)
+}
+
+// setMarker is an empty struct with own unmarshaler.
+type setMarker struct{}
+
+func (*setMarker) UnmarshalJSONValue(v Value) error {
+	if TypeOf(v) != TypeNumber {
+		return fmt.Errorf("unexpected marker %s", TypeOf(v))
+	}
+	return nil
+}
+
+func TestUnmarshal_Sets(t *testing.T) {
+	type set = map[string]struct{}
+	type flags = map[string]bool
+
+	cases := map[string]struct {
+		src     string
+		dst     interface{}
+		opts    []UnmarshalOption
+		want    interface{}
+		wantErr string
+	}{
+		"array to set": {
+			src:  `["a", "b", "a"]`,
+			dst:  &set{},
+			want: &set{"a": {}, "b": {}},
+		},
+		"object to set": {
+			src:  `{"a": true, "b": {}, "c": false, "d": null}`,
+			dst:  &set{},
+			want: &set{"a": {}, "b": {}},
+		},
+		"object values": {
+			src:  `{"a": {"x": 1}, "b": true}`,
+			dst:  &set{},
+			want: &set{"a": {}, "b": {}},
+		},
+		"named element": {
+			src:  `{"a": 1, "b": 2}`,
+			dst:  &map[string]setMarker{},
+			want: &map[string]setMarker{"a": {}, "b": {}},
+		},
+		"named element error": {
+			src:     `{"a": true}`,
+			dst:     &map[string]setMarker{},
+			wantErr: "a: unexpected marker boolean",
+		},
+		"all keys to set": {
+			src:  `{"a": true, "b": 0, "c": false}`,
+			dst:  &set{},
+			opts: []UnmarshalOption{AllKeysAsSetMembers},
+			want: &set{"a": {}, "b": {}, "c": {}},
+		},
+		"invalid member": {
+			src:     `{"a": true, "b": 1}`,
+			dst:     &set{},
+			wantErr: "b: cannot unmarshal number value to struct {}",
+		},
+		"invalid element": {
+			src:     `["a", 1]`,
+			dst:     &set{},
+			wantErr: "[1]: cannot unmarshal number value to string",
+		},
+		"array to flags": {
+			src:  `["a", "b", "a"]`,
+			dst:  &flags{},
+			opts: []UnmarshalOption{NoStrict},
+			want: &flags{"a": true, "b": true},
+		},
+		"object to flags": {
+			src:  `{"a": true, "b": false}`,
+			dst:  &flags{},
+			want: &flags{"a": true, "b": false},
+		},
+		"strict flags": {
+			src:     `["a"]`,
+			dst:     &flags{},
+			wantErr: "cannot unmarshal array value to map[string]bool",
+		},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			err := Unmarshal([]byte(c.src), c.dst, c.opts...)
+			if c.wantErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), c.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, c.want, c.dst)
+		})
+	}
+
+	// array to set is accepted in struct fields and by limits
+	dst := struct {
+		Features set `json:"features"`
+	}{}
+	require.NoError(t, Unmarshal([]byte(`{"features": ["x"]}`), &dst))
+	require.Equal(t, set{"x": {}}, dst.Features)
+
+	err := Unmarshal([]byte(`["a", "b"]`), &set{}, MaxMapEntries(1))
+	require.Error(t, err)
+}
@@ -0,0 +1,184 @@
+package jsonreflect
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FromURLValues builds an object from form values, like webhook payloads.
+//
+// Keys are split into nested keys like in OverlayFromPairs, "." is used if separator is empty.
+// Bracket segments are also supported, so "a.c[0]" and "a[c][0]" keys are equal.
+// Values are converted into scalar values using InferScalar.
+//
+// Key with multiple values or with "[]" suffix produces an array:
+//
+//	tags[]=a&tags[]=b&id=1&id=2
+//
+// Result:
+//
+//	{"id": [1, 2], "tags": ["a", "b"]}
+//
+// Returns an error with both keys if key is used both for a value and nested keys.
+func FromURLValues(v url.Values, sep string) (Value, error) {
+	if sep == "" {
+		sep = defaultOverlaySeparator
+	}
+
+	keys := make([]string, 0, len(v))
+	for k := range v {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	root := &overlayNode{children: make(map[string]*overlayNode)}
+	for _, key := range keys {
+		values := v[key]
+		segments, err := splitFormKey(key, sep)
+		if err != nil {
+			return nil, err
+		}
+
+		isArray := len(values) > 1
+		if last := len(segments) - 1; segments[last] == "" {
+			// "[]" suffix
+			segments, isArray = segments[:last], true
+		}
+
+		for i, value := range values {
+			path := segments
+			if isArray {
+				path = append(segments[:len(segments):len(segments)], strconv.Itoa(i))
+			}
+
+			if err := root.insert(key, path, InferScalar(value)); err != nil {
+				return nil, fmt.Errorf("%s=%q: %w", key, value, err)
+			}
+		}
+	}
+
+	return root.toValue(nil)
+}
+
+// splitFormKey splits form key into segments by separator and brackets.
+//
+// Last segment is empty if key ends with "[]".
+func splitFormKey(key, sep string) ([]string, error) {
+	var segments []string
+	for _, part := range strings.Split(key, sep) {
+		i := strings.IndexByte(part, '[')
+		if i == -1 {
+			segments = append(segments, part)
+			continue
+		}
+
+		segments = append(segments, part[:i])
+		for rest := part[i:]; rest != ""; {
+			end := strings.IndexByte(rest, ']')
+			if rest[0] != '[' || end == -1 {
+				return nil, fmt.Errorf("%s: malformed bracket segment %q", key, rest)
+			}
+
+			segments = append(segments, rest[1:end])
+			rest = rest[end+1:]
+		}
+	}
+
+	for i, seg := range segments[:len(segments)-1] {
+		if seg == "" {
+			return nil, fmt.Errorf("%s: empty key segment at position %d", key, i)
+		}
+	}
+	return segments, nil
+}
+
+// ToURLValues converts object into form values, reverse of FromURLValues.
+//
+// Nested object keys are joined with separator, "." is used if separator is empty.
+// Array items are written with bracket index, like "a.c[0]".
+// Strings are unquoted, other scalars are written in JSON form,
+// so strings like "42" or "true" become numbers and booleans after FromURLValues.
+//
+// Empty objects and arrays and keys which contain separator or brackets
+// can't be represented and cause an error.
+func ToURLValues(v Value, sep string) (url.Values, error) {
+	if sep == "" {
+		sep = defaultOverlaySeparator
+	}
+
+	obj, ok := v.(*Object)
+	if !ok || obj == nil {
+		return nil, fmt.Errorf("cannot convert %s value to url values, value should be an object", TypeOf(v))
+	}
+
+	out := make(url.Values, obj.Len())
+	if err := appendURLValues(out, "", obj, sep, &cycleGuard{}); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func appendURLValues(out url.Values, key string, v Value, sep string, g *cycleGuard) error {
+	switch t := v.(type) {
+	case *Object:
+		if t.Len() == 0 && key != "" {
+			return fmt.Errorf("%s: empty object can't be represented in url values", key)
+		}
+
+		if !g.enter(t) {
+			return g.newError(t)
+		}
+		defer g.leave()
+
+		for _, m := range t.Members() {
+			if m.Key == "" || strings.Contains(m.Key, sep) || strings.ContainsAny(m.Key, "[]") {
+				return fmt.Errorf("%s: key %q can't be represented in url values", key, m.Key)
+			}
+
+			childKey := m.Key
+			if key != "" {
+				childKey = key + sep + m.Key
+			}
+
+			if err := appendURLValues(out, childKey, m.Value, sep, g); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *Array:
+		if t == nil || len(t.Items) == 0 {
+			return fmt.Errorf("%s: empty array can't be represented in url values", key)
+		}
+
+		if !g.enter(t) {
+			return g.newError(t)
+		}
+		defer g.leave()
+
+		for i, item := range t.Items {
+			if err := appendURLValues(out, key+"["+strconv.Itoa(i)+"]", item, sep, g); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *String:
+		str, err := t.String()
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+
+		out.Add(key, str)
+		return nil
+	default:
+		str, err := MarshalScalar(v)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+
+		out.Add(key, str)
+		return nil
+	}
+}
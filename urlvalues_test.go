@@ -0,0 +1,136 @@
+package jsonreflect
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	. "github.com/x1unix/jsonreflect/internal/testutil"
+)
+
+func TestFromURLValues(t *testing.T) {
+	cases := map[string]struct {
+		src     string
+		sep     string
+		want    string
+		wantErr ExpectedError
+	}{
+		"nested keys": {
+			src:  `a.b=1&a.c[0]=x&a.c[1]=true&d=null&e=foo+bar`,
+			want: `{"a":{"b":1,"c":["x",true]},"d":null,"e":"foo bar"}`,
+		},
+		"brackets": {
+			src:  `user[name]=bob&user[roles][0]=admin&user[roles][1]=dev`,
+			want: `{"user":{"name":"bob","roles":["admin","dev"]}}`,
+		},
+		"repeated keys": {
+			src:  `tags[]=a&tags[]=b&id=1&id=2&one[]=3`,
+			want: `{"id":[1,2],"one":[3],"tags":["a","b"]}`,
+		},
+		"custom separator": {
+			src:  `a__b=-1.5&a__c=`,
+			sep:  "__",
+			want: `{"a":{"b":-1.5,"c":""}}`,
+		},
+		"scalar and object": {
+			src:     `a.b=1&a.b.c=2`,
+			wantErr: `a.b.c="2": key conflicts with value of "a.b"`,
+		},
+		"object and scalar": {
+			src:     `a.b.c=1&a[b]=2`,
+			wantErr: `a[b]="2": value conflicts with nested key "a.b.c"`,
+		},
+		"same key": {
+			src:     `a.b=1&a[b]=2`,
+			wantErr: `a[b]="2": value conflicts with value of "a.b"`,
+		},
+		"sparse array": {
+			src:     `a[0]=1&a[2]=2`,
+			wantErr: `array indexes should be contiguous`,
+		},
+		"malformed bracket": {
+			src:     `a[b=1`,
+			wantErr: `a[b: malformed bracket segment "[b"`,
+		},
+		"empty segment": {
+			src:     `a[][b]=1`,
+			wantErr: `a[][b]: empty key segment at position 1`,
+		},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			values, err := url.ParseQuery(c.src)
+			require.NoError(t, err)
+
+			got, err := FromURLValues(values, c.sep)
+			if !c.wantErr.AssertError(t, err) {
+				return
+			}
+
+			out, err := MarshalValue(got, nil)
+			require.NoError(t, err)
+			require.Equal(t, c.want, string(out))
+		})
+	}
+}
+
+func TestToURLValues(t *testing.T) {
+	cases := map[string]struct {
+		src     string
+		sep     string
+		want    url.Values
+		wantErr ExpectedError
+	}{
+		"nested": {
+			src: `{"a": {"b": 1, "c": ["x", true, [null]]}, "d": "foo bar", "e": -0.5}`,
+			want: url.Values{
+				"a.b":       {"1"},
+				"a.c[0]":    {"x"},
+				"a.c[1]":    {"true"},
+				"a.c[2][0]": {"null"},
+				"d":         {"foo bar"},
+				"e":         {"-0.5"},
+			},
+		},
+		"custom separator": {
+			src:  `{"a": {"b": "c"}}`,
+			sep:  "/",
+			want: url.Values{"a/b": {"c"}},
+		},
+		"not an object": {
+			src:     `[1]`,
+			wantErr: "cannot convert array value to url values",
+		},
+		"empty array": {
+			src:     `{"a": {"b": []}}`,
+			wantErr: "a.b: empty array can't be represented in url values",
+		},
+		"empty object": {
+			src:     `{"a": [{}]}`,
+			wantErr: "a[0]: empty object can't be represented in url values",
+		},
+		"key with separator": {
+			src:     `{"a": {"b.c": 1}}`,
+			wantErr: `a: key "b.c" can't be represented in url values`,
+		},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			v, err := ValueOf([]byte(c.src))
+			require.NoError(t, err)
+
+			got, err := ToURLValues(v, c.sep)
+			if !c.wantErr.AssertError(t, err) {
+				return
+			}
+			require.Equal(t, c.want, got)
+
+			// round trip
+			back, err := FromURLValues(got, c.sep)
+			require.NoError(t, err)
+			require.Equal(t, v.Interface(), back.Interface())
+		})
+	}
+}
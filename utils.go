@@ -1,47 +1,414 @@
 package jsonreflect
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"math"
+	"math/big"
+	"reflect"
 	"strconv"
 	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+	"unsafe"
 )
 
+const hexChars = "0123456789abcdef"
+
 // numberValueFromString parses string into jsonreflect.Number
 func numberValueFromString(pos Position, str string, bitSize int) (*Number, error) {
+	n := &Number{}
+	if err := parseNumberInto(n, pos, str, bitSize); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// parseNumberInto parses string into passed number.
+//
+// Numbers which don't fit into fixed size fields or whose float value
+// can't be computed exactly from them keep exact decimal text.
+// Numbers out of float64 range are kept as text too, see Number.Float64.
+func parseNumberInto(n *Number, pos Position, str string, bitSize int) error {
+	err := parseFixedNumberInto(n, pos, str, bitSize)
+	switch {
+	case err == nil && n.isExactFloat():
+		return nil
+	case err == nil:
+		// digits fit into fields, only float value is parsed from text
+		// error is ignored as out of range values are rounded to infinity
+		n.float, _ = strconv.ParseFloat(str, 64)
+		n.parsedFloat = true
+		if math.IsInf(n.float, 0) {
+			parseDecimalNumberInto(n, pos, str)
+		}
+		return nil
+	case errors.Is(err, strconv.ErrRange):
+		parseDecimalNumberInto(n, pos, str)
+		return nil
+	default:
+		return err
+	}
+}
+
+// parseDecimalNumberInto stores exact copy of number text and its float value.
+//
+// Source text should be a valid number.
+func parseDecimalNumberInto(n *Number, pos Position, str string) {
+	// error is ignored as out of range values are rounded to infinity
+	f, _ := strconv.ParseFloat(str, 64)
+	*n = Number{
+		baseValue: baseValue{Position: pos},
+		decimal:   string(append([]byte(nil), str...)),
+		float:     f,
+		hasFloat:  true,
+		IsSigned:  str[0] == '-',
+	}
+
+	mantissa, ok := decimalIntPart(str)
+	n.mantissa = mantissa
+
+	// integer values in exponent notation are integers, like in parseExponentNumberInto
+	n.IsFloat = !ok || strings.IndexByte(str, '.') != -1 || strings.Contains(str, "e-") || strings.Contains(str, "E-")
+
+	if i := strings.IndexAny(str, "eE"); i != -1 {
+		n.notation = NotationExponent
+		if str[i] == 'E' {
+			n.notation = NotationUpperExponent
+		}
+		if i+1 < len(str) && str[i+1] == '+' {
+			n.notation |= NotationExplicitPlus
+		}
+	}
+}
+
+// decimalIntPart returns integer part of number text clamped to int64 range.
+//
+// Returns false if integer part is out of int64 range.
+func decimalIntPart(str string) (int64, bool) {
+	i, ok := decimalInteger(str)
+	switch {
+	case !ok:
+		return clampInt64(str[0] == '-'), false
+	case !i.IsInt64():
+		return clampInt64(i.Sign() < 0), false
+	default:
+		return i.Int64(), true
+	}
+}
+
+// decimalInteger returns integer part of number text.
+//
+// Returns false if text is invalid or integer part has more digits than any uint64 value.
+func decimalInteger(str string) (*big.Int, bool) {
+	coefficient, scale, err := parseDecimal(str)
+	if err != nil {
+		return nil, false
+	}
+
+	digits := len(coefficient.String())
+	if coefficient.Sign() < 0 {
+		digits--
+	}
+
+	switch {
+	case coefficient.Sign() == 0, scale > 0 && int(scale) >= digits:
+		return new(big.Int), true
+	case scale < 0 && -int64(scale)+int64(digits) > maxUint64Digits:
+		return nil, false
+	}
+
+	pow := new(big.Int).Exp(big.NewInt(10), big.NewInt(abs32(scale)), nil)
+	if scale < 0 {
+		return coefficient.Mul(coefficient, pow), true
+	}
+	return coefficient.Quo(coefficient, pow), true
+}
+
+// maxUint64Digits is count of digits of the largest uint64 value
+const maxUint64Digits = 20
+
+func clampInt64(negative bool) int64 {
+	if negative {
+		return math.MinInt64
+	}
+	return math.MaxInt64
+}
+
+func abs32(v int32) int64 {
+	if v < 0 {
+		return -int64(v)
+	}
+	return int64(v)
+}
+
+// parseFixedNumberInto parses number which fits into int64 integer part and uint64 fraction.
+func parseFixedNumberInto(n *Number, pos Position, str string, bitSize int) error {
+	if i := strings.IndexAny(str, "eE"); i != -1 {
+		return parseExponentNumberInto(n, pos, str, i, bitSize)
+	}
+
+	*n = Number{baseValue: baseValue{Position: pos}}
 	if str == "" || str == "0" {
-		return &Number{baseValue: baseValue{pos}}, nil
+		return nil
 	}
 
 	// strconv.ParseFloat is not precise enough
-	chunks := strings.SplitN(str, ".", 2)
-	mantissaPart := chunks[0]
+	mantissaPart, fraction := str, ""
+	dot := strings.IndexByte(str, '.')
+	if dot != -1 {
+		mantissaPart, fraction = str[:dot], str[dot+1:]
+	}
+
 	isNegative := mantissaPart[0] == '-'
 	mantissa, err := strconv.ParseInt(mantissaPart, 10, bitSize)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse mantissa part of number (%w)", err)
+		return fmt.Errorf("failed to parse mantissa part of number (%w)", err)
 	}
 
-	if len(chunks) < 2 {
-		return &Number{
-			baseValue: baseValue{pos},
-			mantissa:  mantissa,
-			IsSigned:  isNegative,
-		}, nil
+	n.mantissa = mantissa
+	n.IsSigned = isNegative
+	if dot == -1 {
+		return nil
 	}
 
-	expoLen := len(chunks[1])
-	exponent, err := strconv.ParseUint(chunks[1], 10, bitSize)
+	exponent, err := strconv.ParseUint(fraction, 10, bitSize)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse exponent part of number (%w)", err)
+		return fmt.Errorf("failed to parse exponent part of number (%w)", err)
+	}
+
+	n.IsFloat = true
+	n.exponent = exponent
+	n.expoLen = len(fraction)
+	return nil
+}
+
+// parseExponentNumberInto parses number in exponent notation like "1.5e-3".
+//
+// Part before exponent mark is stored as a regular number, value is scaled on read.
+func parseExponentNumberInto(n *Number, pos Position, str string, mark, bitSize int) error {
+	base, exp := str[:mark], str[mark+1:]
+	if base == "" || base == "-" {
+		return fmt.Errorf("number %q has no digits before exponent", str)
+	}
+
+	if err := parseFixedNumberInto(n, pos, base, bitSize); err != nil {
+		return err
+	}
+
+	exp10, err := strconv.Atoi(exp)
+	if errors.Is(err, strconv.ErrRange) {
+		return newNumberRangeError(pos, str, "exponent overflows int")
+	}
+	if err != nil || exp == "" || exp[len(exp)-1] < '0' || exp[len(exp)-1] > '9' {
+		return fmt.Errorf("failed to parse exponent of number %q", str)
+	}
+
+	n.exp10 = exp10
+	n.notation = NotationExponent
+	if str[mark] == 'E' {
+		n.notation = NotationUpperExponent
+	}
+	if exp[0] == '+' {
+		n.notation |= NotationExplicitPlus
+	}
+
+	// value of numbers which can't be computed exactly is checked by caller
+	f := n.Float64()
+
+	// integer values in exponent notation are integers, like 1e9
+	n.IsFloat = n.IsFloat || exp10 < 0 || f >= maxInt64Float || f < -maxInt64Float
+	return nil
+}
+
+// decodeHexRune decodes 4 hex digits of "\uXXXX" escape sequence.
+func decodeHexRune(src []byte) (rune, bool) {
+	if len(src) < 4 {
+		return 0, false
+	}
+
+	var r rune
+	for _, c := range src[:4] {
+		switch {
+		case '0' <= c && c <= '9':
+			c = c - '0'
+		case 'a' <= c && c <= 'f':
+			c = c - 'a' + 10
+		case 'A' <= c && c <= 'F':
+			c = c - 'A' + 10
+		default:
+			return 0, false
+		}
+		r = r*16 + rune(c)
+	}
+	return r, true
+}
+
+// decodeEscape decodes escape sequence at the start of src and returns
+// decoded rune and length of the sequence.
+//
+// Unpaired surrogates are decoded as utf8.RuneError, like encoding/json does.
+func decodeEscape(src []byte) (rune, int, error) {
+	if len(src) < 2 {
+		return 0, 0, errors.New("unterminated escape sequence")
 	}
 
-	return &Number{
-		baseValue: baseValue{pos},
-		IsFloat:   true,
-		IsSigned:  isNegative,
+	switch src[1] {
+	case tokenString, '\\', '/':
+		return rune(src[1]), 2, nil
+	case 'b':
+		return '\b', 2, nil
+	case 'f':
+		return '\f', 2, nil
+	case 'n':
+		return '\n', 2, nil
+	case 'r':
+		return '\r', 2, nil
+	case 't':
+		return '\t', 2, nil
+	case 'u':
+		r, ok := decodeHexRune(src[2:])
+		if !ok {
+			return 0, 0, errors.New("invalid unicode escape sequence")
+		}
+
+		if !utf16.IsSurrogate(r) {
+			return r, 6, nil
+		}
+
+		// surrogate pair takes two escape sequences
+		if len(src) > 7 && src[6] == '\\' && src[7] == 'u' {
+			r2, ok := decodeHexRune(src[8:])
+			if ok && utf16.DecodeRune(r, r2) != utf8.RuneError {
+				return utf16.DecodeRune(r, r2), 12, nil
+			}
+		}
+		return utf8.RuneError, 6, nil
+	default:
+		return 0, 0, fmt.Errorf("invalid escape sequence '\\%c'", src[1])
+	}
+}
+
+// unquoteString decodes JSON-quoted string.
+//
+// Escape sequences are decoded by decodeEscape, so unpaired surrogates
+// are decoded as utf8.RuneError.
+func unquoteString(raw []byte) (string, error) {
+	if len(raw) < 2 || raw[0] != tokenString || raw[len(raw)-1] != tokenString {
+		return "", errors.New("invalid quoted string")
+	}
+
+	raw = raw[1 : len(raw)-1]
+	i := bytes.IndexByte(raw, '\\')
+	if i == -1 {
+		return string(raw), nil
+	}
+
+	var r [utf8.UTFMax]byte
+	buf := make([]byte, 0, len(raw))
+	for i != -1 {
+		buf = append(buf, raw[:i]...)
+		c, n, err := decodeEscape(raw[i:])
+		if err != nil {
+			return "", err
+		}
+
+		buf = append(buf, r[:utf8.EncodeRune(r[:], c)]...)
+		raw = raw[i+n:]
+		i = bytes.IndexByte(raw, '\\')
+	}
+
+	buf = append(buf, raw...)
+	return string(buf), nil
+}
+
+// quoteString returns JSON-quoted string.
+//
+// Control characters, line and paragraph separators are escaped
+// and invalid UTF-8 sequences are replaced with utf8.RuneError.
+func quoteString(str string) []byte {
+	buf := make([]byte, 0, len(str)+2)
+	buf = append(buf, tokenString)
+	for i := 0; i < len(str); {
+		c := str[i]
+		if c < utf8.RuneSelf {
+			switch c {
+			case tokenString, '\\':
+				buf = append(buf, '\\', c)
+			case '\n':
+				buf = append(buf, '\\', 'n')
+			case '\r':
+				buf = append(buf, '\\', 'r')
+			case '\t':
+				buf = append(buf, '\\', 't')
+			case '\b':
+				buf = append(buf, '\\', 'b')
+			case '\f':
+				buf = append(buf, '\\', 'f')
+			default:
+				if c < 0x20 {
+					buf = append(buf, '\\', 'u', '0', '0', hexChars[c>>4], hexChars[c&0xF])
+					break
+				}
+				buf = append(buf, c)
+			}
+			i++
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(str[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			buf = append(buf, `\ufffd`...)
+		case r == '\u2028', r == '\u2029':
+			buf = append(buf, '\\', 'u', '2', '0', '2', hexChars[r&0xF])
+		default:
+			buf = append(buf, str[i:i+size]...)
+		}
+		i += size
+	}
+
+	return append(buf, tokenString)
+}
+
+// escapeHTML escapes HTML characters in JSON-quoted string.
+func escapeHTML(quoted []byte) []byte {
+	buf := make([]byte, 0, len(quoted))
+	for _, c := range quoted {
+		switch c {
+		case '<', '>', '&':
+			buf = append(buf, '\\', 'u', '0', '0', hexChars[c>>4], hexChars[c&0xF])
+		default:
+			buf = append(buf, c)
+		}
+	}
+	return buf
+}
+
+// bytesString returns string which shares memory with passed byte slice.
+//
+// Slice must not be modified while string is in use.
+func bytesString(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	return *(*string)(unsafe.Pointer(&b))
+}
+
+// stringBytes returns byte slice which shares memory with passed string.
+//
+// Returned slice must not be modified.
+func stringBytes(s string) []byte {
+	if s == "" {
+		return nil
+	}
 
-		mantissa: mantissa,
-		exponent: exponent,
-		expoLen:  expoLen,
-	}, nil
+	var b []byte
+	sh := (*reflect.StringHeader)(unsafe.Pointer(&s))
+	bh := (*reflect.SliceHeader)(unsafe.Pointer(&b))
+	bh.Data = sh.Data
+	bh.Len = len(s)
+	bh.Cap = len(s)
+	return b
 }
@@ -1,9 +1,11 @@
 package jsonreflect
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"strconv"
+	"unicode/utf8"
 )
 
 // Type represents value type
@@ -52,6 +54,40 @@ func (t Type) String() string {
 	}
 }
 
+// AllTypes returns list of valid value types.
+func AllTypes() []Type {
+	return []Type{TypeNull, TypeBoolean, TypeNumber, TypeString, TypeObject, TypeArray}
+}
+
+// ParseType returns value type by its name, see Type.String.
+func ParseType(s string) (Type, error) {
+	for _, t := range AllTypes() {
+		if t.String() == s {
+			return t, nil
+		}
+	}
+	return TypeUnknown, fmt.Errorf("unknown value type %q", s)
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (t Type) MarshalText() ([]byte, error) {
+	if t == TypeUnknown || t > TypeArray {
+		return nil, fmt.Errorf("cannot marshal invalid value type %d", t)
+	}
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (t *Type) UnmarshalText(text []byte) error {
+	v, err := ParseType(string(text))
+	if err != nil {
+		return err
+	}
+
+	*t = v
+	return nil
+}
+
 type Position struct {
 	Start int
 	End   int
@@ -62,12 +98,21 @@ func newPosition(start, end int) Position {
 }
 
 type baseValue struct {
+	// gen is generation of value allocated by ParseInto, see valueGeneration
+	gen valueGeneration
+
 	// Position is value declaration position
 	Position Position
+
+	// raw is value source, set only for parsed values
+	raw []byte
+
+	// unmarshaler is set for copy of value passed to unmarshaler, see unmarshalerFrame
+	unmarshaler *unmarshalerFrame
 }
 
 func newBaseValue(start, end int) baseValue {
-	return baseValue{newPosition(start, end)}
+	return baseValue{Position: newPosition(start, end)}
 }
 
 // Type implements jsonreflect.Value
@@ -85,7 +130,11 @@ func (_ baseValue) String() (string, error) {
 	return "", ErrNotStringable
 }
 
-// Value is abstract JSON document value
+// Value is abstract JSON document value.
+//
+// Pointer value types are safe to use with nil pointers: read-only methods
+// return zero results and serialization returns ErrNilValue.
+// Boolean and Null are used by value.
 type Value interface {
 	// Ref returns reference to value in source
 	Ref() Position
@@ -111,30 +160,63 @@ type String struct {
 
 func newString(pos Position, val []byte) *String {
 	return &String{
-		baseValue: baseValue{pos},
+		baseValue: baseValue{Position: pos},
 		rawValue:  val,
 	}
 }
 
-func (s String) marshal(w io.Writer, _ *marshalFormatter) error {
+func (s *String) marshal(w io.Writer, mf *marshalFormatter) error {
+	if s == nil {
+		return ErrNilValue
+	}
+
+	s.gen.check()
+
+	if mf != nil && mf.escapeHTML {
+		_, err := w.Write(escapeHTML(s.rawValue))
+		return err
+	}
+
 	_, err := w.Write(s.rawValue)
 	return err
 }
 
+// Ref implements jsonreflect.Value
+func (s *String) Ref() Position {
+	if s == nil {
+		return Position{}
+	}
+
+	s.gen.check()
+
+	return s.Position
+}
+
 // Type implements jsonreflect.Value
-func (_ String) Type() Type {
+func (s *String) Type() Type {
 	return TypeString
 }
 
 // RawString returns quoted raw string
-func (s String) RawString() string {
+func (s *String) RawString() string {
+	if s == nil {
+		return ""
+	}
 	return string(s.rawValue)
 }
 
 // String implements jsonreflect.Value
-func (s String) String() (string, error) {
-	str := s.RawString()
-	v, err := strconv.Unquote(str)
+//
+// Escape sequences are decoded like in DecodedLen,
+// so unpaired surrogates are replaced with utf8.RuneError.
+func (s *String) String() (string, error) {
+	if s == nil {
+		return "", nil
+	}
+
+	s.gen.check()
+
+	v, err := unquoteString(s.rawValue)
 	if err != nil {
 		return "", fmt.Errorf("jsonreflect.String: failed to unquote raw string value '%s': %w", s.rawValue, err)
 	}
@@ -142,8 +224,54 @@ func (s String) String() (string, error) {
 	return v, nil
 }
 
+// HasEscapes reports whether raw string contains escape sequences.
+func (s *String) HasEscapes() bool {
+	if s == nil {
+		return false
+	}
+	return bytes.IndexByte(s.rawValue, '\\') != -1
+}
+
+// DecodedLen returns length of unquoted string in bytes.
+//
+// Length is computed in a single pass over raw value without decoding a string.
+// Unpaired surrogates are counted as utf8.RuneError, like encoding/json does.
+func (s *String) DecodedLen() (int, error) {
+	if s == nil {
+		return 0, nil
+	}
+
+	raw := s.rawValue
+	if len(raw) < 2 || raw[0] != tokenString || raw[len(raw)-1] != tokenString {
+		return 0, fmt.Errorf("jsonreflect.String: invalid raw string value '%s'", raw)
+	}
+
+	raw = raw[1 : len(raw)-1]
+	size := 0
+	for i := 0; i < len(raw); {
+		if raw[i] != '\\' {
+			size++
+			i++
+			continue
+		}
+
+		r, n, err := decodeEscape(raw[i:])
+		if err != nil {
+			return 0, fmt.Errorf("jsonreflect.String: %s in '%s'", err, s.rawValue)
+		}
+		size += utf8.RuneLen(r)
+		i += n
+	}
+
+	return size, nil
+}
+
 // Number returns number quoted in string
-func (s String) Number() (*Number, error) {
+func (s *String) Number() (*Number, error) {
+	if s == nil {
+		return nil, ErrNilValue
+	}
+
 	v, err := s.String()
 	if err != nil {
 		return nil, err
@@ -152,7 +280,13 @@ func (s String) Number() (*Number, error) {
 }
 
 // Interface() implements json.Value
-func (s String) Interface() interface{} {
+func (s *String) Interface() interface{} {
+	if s == nil {
+		return nil
+	}
+
+	s.gen.check()
+
 	v, err := s.String()
 	if err != nil {
 		return s.RawString()
@@ -160,16 +294,34 @@ func (s String) Interface() interface{} {
 	return v
 }
 
+// literalValue is base of boolean and null values.
+//
+// Unlike baseValue it doesn't keep source bytes, so literals don't
+// refer to parser source and can be compared with == operator.
+type literalValue struct {
+	// gen is generation of value allocated by ParseInto, see valueGeneration
+	gen valueGeneration
+
+	// Position is value declaration position
+	Position Position
+
+	// parsed is set for values produced by parser, see Raw
+	parsed bool
+
+	// unmarshaler is set for copy of value passed to unmarshaler, see unmarshalerFrame
+	unmarshaler *unmarshalerFrame
+}
+
 // Boolean is boolean value
 type Boolean struct {
-	baseValue
+	literalValue
 	Value bool
 }
 
 func newBoolean(pos Position, val bool) Boolean {
 	return Boolean{
-		baseValue: baseValue{
-			pos,
+		literalValue: literalValue{
+			Position: pos,
 		},
 		Value: val,
 	}
@@ -177,19 +329,32 @@ func newBoolean(pos Position, val bool) Boolean {
 
 // String implements jsonreflect.Value
 func (b Boolean) String() (string, error) {
+	b.gen.check()
+
 	return strconv.FormatBool(b.Value), nil
 }
 
 func (b Boolean) marshal(w io.Writer, _ *marshalFormatter) error {
+	b.gen.check()
+
 	_, err := w.Write([]byte(strconv.FormatBool(b.Value)))
 	return err
 }
 
 // Interface() implements json.Value
 func (b Boolean) Interface() interface{} {
+	b.gen.check()
+
 	return b.Value
 }
 
+// Ref implements jsonreflect.Value
+func (b Boolean) Ref() Position {
+	b.gen.check()
+
+	return b.Position
+}
+
 // Type implements jsonreflect.Value
 func (_ Boolean) Type() Type {
 	return TypeBoolean
@@ -197,7 +362,7 @@ func (_ Boolean) Type() Type {
 
 // Null is JSON null value
 type Null struct {
-	baseValue
+	literalValue
 }
 
 // Type implements jsonreflect.Value
@@ -206,20 +371,84 @@ func (_ Null) Type() Type {
 }
 
 // String implements jsonreflect.Value
-func (_ Null) String() (string, error) {
+func (n Null) String() (string, error) {
+	n.gen.check()
+
 	return "", nil
 }
 
-func (_ Null) marshal(w io.Writer, _ *marshalFormatter) error {
+func (n Null) marshal(w io.Writer, _ *marshalFormatter) error {
+	n.gen.check()
+
 	_, err := w.Write([]byte("null"))
 	return err
 }
 
 func newNull(pos Position) Null {
-	return Null{baseValue{pos}}
+	return Null{literalValue{Position: pos}}
 }
 
 // Interface() implements json.Value
 func (n Null) Interface() interface{} {
+	n.gen.check()
+
 	return nil
 }
+
+// Ref implements jsonreflect.Value
+func (n Null) Ref() Position {
+	n.gen.check()
+
+	return n.Position
+}
+
+// CustomValue is the interface for Value implementations outside of the package.
+//
+// Value interface can't be implemented outside of the package directly,
+// use AdaptValue to convert CustomValue into Value.
+//
+// Compatibility contract:
+//
+// - Methods of CustomValue have the same semantics as Value methods
+// and won't change in minor releases.
+//
+// - Type should match the result of Interface, e.g. TypeNumber for numeric values.
+//
+// - WriteJSON should write a single valid JSON value to the writer.
+// Options are nil for compact output. Indented output should respect
+// options level, see MarshalOptions.Level.
+//
+// - Helpers which require concrete value types (like ToObject or ToArray) return
+// an error for custom values.
+type CustomValue interface {
+	// Ref returns reference to value in source
+	Ref() Position
+
+	// Type returns value type
+	Type() Type
+
+	// Interface returns interface{} value
+	Interface() interface{}
+
+	// String returns string representation of a value
+	String() (string, error)
+
+	// WriteJSON writes JSON representation of a value
+	WriteJSON(w io.Writer, opts *MarshalOptions) error
+}
+
+type adaptedValue struct {
+	CustomValue
+}
+
+func (v adaptedValue) marshal(w io.Writer, mf *marshalFormatter) error {
+	return v.WriteJSON(w, mf.options())
+}
+
+// AdaptValue converts CustomValue into Value.
+func AdaptValue(cv CustomValue) Value {
+	if v, ok := cv.(Value); ok {
+		return v
+	}
+	return adaptedValue{cv}
+}
@@ -1,7 +1,14 @@
 package jsonreflect
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -30,9 +37,21 @@ func TestString_String(t *testing.T) {
 			in:   `"foo\nbar"`,
 			want: "foo\nbar",
 		},
+		"escaped solidus": {
+			in:   `"a\/b"`,
+			want: "a/b",
+		},
+		"lone surrogate": {
+			in:   `"\ud800"`,
+			want: "\ufffd",
+		},
+		"invalid escape": {
+			in:  `"\c"`,
+			err: `failed to unquote raw string value '"\c"': invalid escape sequence '\c'`,
+		},
 		"invalid string": {
 			in:  "foo",
-			err: "failed to unquote raw string value 'foo': invalid syntax",
+			err: "failed to unquote raw string value 'foo': invalid quoted string",
 		},
 	}
 
@@ -100,6 +119,18 @@ func TestString_Interface(t *testing.T) {
 			in:   `"foo\nbar"`,
 			want: "foo\nbar",
 		},
+		"escaped solidus": {
+			in:   `"a\/b"`,
+			want: "a/b",
+		},
+		"lone surrogate": {
+			in:   `"\ud800"`,
+			want: "\ufffd",
+		},
+		"invalid escape": {
+			in:   `"\c"`,
+			want: `"\c"`,
+		},
 		"invalid string": {
 			in:   "foo",
 			want: "foo",
@@ -121,6 +152,20 @@ func TestBoolean_Interface(t *testing.T) {
 	require.Equal(t, true, b.Interface())
 }
 
+func TestValueOf_Literals(t *testing.T) {
+	v, err := ValueOf([]byte(`[true, null]`))
+	require.NoError(t, err)
+
+	items := v.(*Array).Items
+	b, ok := items[0].(Boolean)
+	require.True(t, ok)
+	require.True(t, b.Value)
+	require.Equal(t, newPosition(1, 4), b.Ref())
+
+	_, ok = items[1].(Null)
+	require.True(t, ok)
+}
+
 func TestNumber_Float32_and_64(t *testing.T) {
 	cases := map[float64]*Number{
 		32: {
@@ -163,6 +208,13 @@ func TestNumber_Uint(t *testing.T) {
 	require.Equal(t, in, n.Uint())
 	require.Equal(t, uint32(in), n.Uint32())
 	require.Equal(t, uint64(in), n.Uint64())
+
+	// values above int64 range are not clamped to it
+	v, err := ValueOf([]byte("18446744073709551615"))
+	require.NoError(t, err)
+	require.Equal(t, uint64(math.MaxUint64), v.(*Number).Uint64())
+	require.Equal(t, ^uint(0), v.(*Number).Uint())
+	require.Equal(t, uint32(math.MaxUint32), v.(*Number).Uint32())
 }
 
 func TestNumber_Interface(t *testing.T) {
@@ -172,12 +224,121 @@ func TestNumber_Interface(t *testing.T) {
 	require.Equal(t, 2, n2.Interface())
 }
 
+func TestNumber_FloatWithZeroFraction(t *testing.T) {
+	cases := map[string]struct {
+		src  string
+		want string
+	}{
+		"one":           {src: `1.0`, want: `1.0`},
+		"trailing zero": {src: `-2.000`, want: `-2.000`},
+		"negative zero": {src: `-0.0`, want: `-0.0`},
+		"in array":      {src: `[1, 1.0]`, want: `[1,1.0]`},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			v, err := NewParser([]byte(c.src)).Parse()
+			require.NoError(t, err)
+
+			got, err := MarshalValue(v, nil)
+			require.NoError(t, err)
+			require.Equal(t, c.want, string(got))
+		})
+	}
+
+	v, err := NewParser([]byte(`1.0`)).Parse()
+	require.NoError(t, err)
+	require.Equal(t, float64(1), v.Interface())
+
+	// number marked as float keeps fraction part
+	n := NewNumberInt(1)
+	n.IsFloat = true
+	require.Equal(t, float64(1), n.Interface())
+	str, err := n.String()
+	require.NoError(t, err)
+	require.Equal(t, "1.0", str)
+}
+
+func TestNumber_SetFormat(t *testing.T) {
+	values := map[string]float64{
+		"one":           1,
+		"large":         1e21,
+		"below large":   999999999999999868928,
+		"huge":          1e300,
+		"negative huge": -1.5e300,
+		"max":           math.MaxFloat64,
+		"smallest":      5e-324,
+		"negative zero": math.Copysign(0, -1),
+		"inexact sum":   0.1 + 0.2,
+		"long fraction": -123456789.123456789,
+	}
+
+	formats := []struct {
+		format byte
+		prec   int
+	}{
+		{format: 'g', prec: -1},
+		{format: 'f', prec: -1},
+		{format: 'f', prec: 3},
+		{format: 'e', prec: -1},
+		{format: 'E', prec: 2},
+	}
+
+	for n, val := range values {
+		t.Run(n, func(t *testing.T) {
+			num, err := NewNumberFloat(val)
+			require.NoError(t, err)
+			require.Equal(t, math.Float64bits(val), math.Float64bits(num.Float64()))
+
+			// large values are written in exponent notation
+			want := strconv.FormatFloat(val, 'f', -1, 64)
+			if math.Abs(val) >= 1e21 {
+				want = strconv.FormatFloat(val, 'e', -1, 64)
+			}
+
+			str, err := num.String()
+			require.NoError(t, err)
+			require.Equal(t, want, str)
+
+			v, err := ValueOf([]byte(str))
+			require.NoError(t, err)
+			require.Equal(t, val, v.(*Number).Float64())
+
+			for _, f := range formats {
+				num.SetFormat(f.format, f.prec)
+				got, err := MarshalValue(num, nil)
+				require.NoError(t, err)
+				require.Equal(t, strconv.FormatFloat(val, f.format, f.prec, 64), string(got))
+			}
+		})
+	}
+}
+
+func TestNumber_SetFormat_Parsed(t *testing.T) {
+	v, err := NewParser([]byte(`1.50`)).Parse()
+	require.NoError(t, err)
+
+	num := v.(*Number)
+	num.SetFormat('e', -1)
+	got, err := MarshalValue(num, nil)
+	require.NoError(t, err)
+	require.Equal(t, "1.5e+00", string(got))
+	_, ok := Raw(num)
+	require.False(t, ok)
+
+	// unsupported format resets number format
+	num.SetFormat('x', -1)
+	got, err = MarshalValue(num, nil)
+	require.NoError(t, err)
+	require.Equal(t, "1.50", string(got))
+}
+
 func TestArray_Interface(t *testing.T) {
 	want := []interface{}{true, 3}
 	arr := Array{
 		Items: []Value{
 			Boolean{Value: true},
-			Number{mantissa: 3},
+			&Number{mantissa: 3},
 		},
 	}
 	require.Equal(t, want, arr.Interface())
@@ -192,7 +353,7 @@ func TestObject_Interface(t *testing.T) {
 	o := Object{
 		Items: map[string]Value{
 			"foo": Boolean{Value: true},
-			"bar": String{rawValue: []byte(`"baz"`)},
+			"bar": &String{rawValue: []byte(`"baz"`)},
 		},
 	}
 	require.Equal(t, want, o.ToMap())
@@ -203,3 +364,509 @@ func TestNull_Interface(t *testing.T) {
 	n := Null{}
 	require.Nil(t, n.Interface())
 }
+
+func TestString_DecodedLen(t *testing.T) {
+	cases := map[string]struct {
+		in   string
+		want int
+		err  ExpectedError
+	}{
+		"plain ascii": {
+			in: `"foo bar"`,
+		},
+		"empty string": {
+			in: `""`,
+		},
+		"simple escapes": {
+			in: `"foo\nbar\t\"baz\"\\"`,
+		},
+		"unicode escapes": {
+			in: `"\u0041\u00e9\u4e16"`,
+		},
+		"raw multibyte chars": {
+			in: `"привет, 世界"`,
+		},
+		"surrogate pair": {
+			in:   `"\ud83d\ude00!"`,
+			want: len("😀!"),
+		},
+		"unpaired surrogate": {
+			in:   `"\ud83dx"`,
+			want: len("�x"),
+		},
+		"escaped solidus": {
+			in: `"a\/b"`,
+		},
+		"lone surrogate": {
+			in: `"\ud800"`,
+		},
+		"lone low surrogate": {
+			in: `"x\udc00y"`,
+		},
+		"unquoted": {
+			in:  `foo`,
+			err: "invalid raw string value 'foo'",
+		},
+		"invalid unicode escape": {
+			in:  `"\u00zz"`,
+			err: `invalid unicode escape sequence`,
+		},
+		"invalid escape": {
+			in:  `"\c"`,
+			err: `invalid escape sequence '\c'`,
+		},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			str := String{rawValue: []byte(c.in)}
+			got, err := str.DecodedLen()
+			if !c.err.AssertError(t, err) {
+				return
+			}
+
+			if c.want == 0 {
+				decoded, err := str.String()
+				require.NoError(t, err)
+				c.want = len(decoded)
+			}
+			require.Equal(t, c.want, got)
+		})
+	}
+}
+
+func TestString_HasEscapes(t *testing.T) {
+	require.False(t, (&String{rawValue: []byte(`"foo"`)}).HasEscapes())
+	require.True(t, (&String{rawValue: []byte(`"foo\nbar"`)}).HasEscapes())
+}
+
+func TestObject_Members(t *testing.T) {
+	src := []byte(`{"c": 1, "a": 2, "b": 3}`)
+	v, err := ValueOf(src)
+	require.NoError(t, err)
+	obj := v.(*Object)
+
+	keysOf := func(members []Member) []string {
+		keys := make([]string, 0, len(members))
+		for _, m := range members {
+			keys = append(keys, m.Key)
+		}
+		return keys
+	}
+
+	members := obj.Members()
+	require.Equal(t, []string{"c", "a", "b"}, keysOf(members))
+	require.Equal(t, newPosition(1, 3), members[0].KeyPos)
+	require.Equal(t, obj.Items["c"], members[0].Value)
+
+	// modified values and keys are reflected in members
+	delete(obj.Items, "a")
+	obj.Items["b"] = Boolean{Value: true}
+	obj.Items["z"] = Null{}
+	obj.Items["d"] = Null{}
+	members = obj.Members()
+	require.Equal(t, []string{"c", "b", "d", "z"}, keysOf(members))
+	require.Equal(t, Boolean{Value: true}, members[1].Value)
+	require.Equal(t, Position{}, members[2].KeyPos)
+
+	// objects without source order use sorted keys
+	o := Object{Items: map[string]Value{"b": Null{}, "a": Null{}}}
+	require.Equal(t, []string{"a", "b"}, keysOf(o.Members()))
+	require.Nil(t, (&Object{}).Members())
+}
+
+func TestObject_EmptyKey(t *testing.T) {
+	src := `{"": {"": 1, "a": [{"": null}]}, "b": ""}`
+	v, err := NewParser([]byte(src)).Parse()
+	require.NoError(t, err)
+
+	obj := v.(*Object)
+	require.True(t, obj.HasKey(""))
+	require.Equal(t, []string{"", "b"}, obj.Keys())
+
+	inner, ok := obj.Get("")
+	require.True(t, ok)
+	deep, ok := inner.(*Object).Get("")
+	require.True(t, ok)
+	require.Equal(t, 1, deep.Interface())
+
+	got, err := MarshalValue(Clone(v), nil)
+	require.NoError(t, err)
+	require.Equal(t, `{"":{"":1,"a":[{"":null}]},"b":""}`, string(got))
+	require.True(t, Equal(v, Clone(v), EqualOptions{}))
+}
+
+func TestObject_ToArray(t *testing.T) {
+	cases := map[string]struct {
+		src     string
+		want    string
+		wantErr string
+	}{
+		"ordered":       {src: `{"0": "a", "1": "b", "2": "c"}`, want: `["a","b","c"]`},
+		"shuffled":      {src: `{"2": 3, "0": 1, "1": {"x": 2}}`, want: `[1,{"x":2},3]`},
+		"empty":         {src: `{}`, want: `[]`},
+		"gap":           {src: `{"0": 1, "2": 3}`, wantErr: `object is not an array: missing indexes 1; indexes out of range 2`},
+		"huge index":    {src: `{"0": 1, "99999999999999999999": 2}`, wantErr: `object is not an array: missing indexes 1; indexes out of range 99999999999999999999`},
+		"leading zeros": {src: `{"0": 1, "01": 2}`, wantErr: `object is not an array: missing indexes 1; invalid keys "01"`},
+		"mixed":         {src: `{"a": 1, "1": 2, "-0": 3, "5": 4}`, wantErr: `object is not an array: missing indexes 0, 2, 3; indexes out of range 5; invalid keys "a", "-0"`},
+		"sign":          {src: `{"+0": 1}`, wantErr: `object is not an array: missing indexes 0; invalid keys "+0"`},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			v, err := NewParser([]byte(c.src)).Parse()
+			require.NoError(t, err)
+
+			arr, err := v.(*Object).ToArray()
+			if c.wantErr != "" {
+				require.EqualError(t, err, c.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, v.Ref(), arr.Ref())
+			got, err := MarshalValue(arr, nil)
+			require.NoError(t, err)
+			require.Equal(t, c.want, string(got))
+		})
+	}
+}
+
+func TestObject_marshal_KeepsSourceOrder(t *testing.T) {
+	src := `{"c":1,"a":{"z":true,"y":null},"b":"foo"}`
+	v, err := ValueOf([]byte(src))
+	require.NoError(t, err)
+
+	got, err := MarshalValue(v, nil)
+	require.NoError(t, err)
+	require.Equal(t, src, string(got))
+}
+
+func TestNumber_String(t *testing.T) {
+	for _, want := range []string{"0", "10", "-10", "1.05", "-0.5", "3.001", "-12.5"} {
+		t.Run(want, func(t *testing.T) {
+			v, err := ValueOf([]byte(want))
+			require.NoError(t, err)
+
+			got, err := v.String()
+			require.NoError(t, err)
+			require.Equal(t, want, got)
+		})
+	}
+}
+
+func TestNumber_Notation(t *testing.T) {
+	cases := map[string]struct {
+		src      string
+		notation Notation
+		want     interface{}
+	}{
+		"plain":         {src: "1000000000", notation: NotationPlain, want: int64(1000000000)},
+		"exponent":      {src: "1e9", notation: NotationExponent, want: int64(1000000000)},
+		"upper plus":    {src: "1E+9", notation: NotationUpperExponent | NotationExplicitPlus, want: int64(1000000000)},
+		"negative":      {src: "1.5e-3", notation: NotationExponent, want: 0.0015},
+		"zero fraction": {src: "-1.0e2", notation: NotationExponent, want: float64(-100)},
+		"zero exponent": {src: "2e0", notation: NotationExponent, want: int64(2)},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			v, err := NewParser([]byte(c.src)).Parse()
+			require.NoError(t, err)
+
+			num := v.(*Number)
+			require.Equal(t, c.notation, num.Notation())
+			if want, ok := c.want.(int64); ok {
+				require.False(t, num.IsFloat)
+				require.Equal(t, want, num.Int64())
+			} else {
+				require.True(t, num.IsFloat)
+				require.Equal(t, c.want, num.Float64())
+			}
+
+			got, err := MarshalValue(v, nil)
+			require.NoError(t, err)
+			require.Equal(t, c.src, string(got))
+
+			// notation is kept without source
+			got, err = MarshalValue(Clone(v), nil)
+			require.NoError(t, err)
+			require.Equal(t, c.src, string(got))
+		})
+	}
+
+	plain, err := ValueOf([]byte("1000000000"))
+	require.NoError(t, err)
+	for _, src := range []string{"1e9", "1E+9", "10e8", "0.1e10"} {
+		v, err := ValueOf([]byte(src))
+		require.NoError(t, err)
+		require.True(t, Equal(plain, v, EqualOptions{}), src)
+	}
+
+	for _, src := range []string{"1e", "1e+", "1E-", "--1", "1.2.3", "1e5e5", "1e1.5", "-e5", "1-2"} {
+		_, err := NewParser([]byte(src)).Parse()
+		require.Error(t, err, src)
+	}
+}
+
+func TestNumber_Decimal(t *testing.T) {
+	cases := map[string]struct {
+		src         string
+		coefficient string
+		scale       int32
+	}{
+		"integer":       {src: "42", coefficient: "42", scale: 0},
+		"fraction":      {src: "-0.05", coefficient: "-5", scale: 2},
+		"exponent":      {src: "1.5e-3", coefficient: "15", scale: 4},
+		"positive exp":  {src: "12E+3", coefficient: "12", scale: -3},
+		"long integer":  {src: "123456789012345678901234567890", coefficient: "123456789012345678901234567890", scale: 0},
+		"long fraction": {src: "0.123456789012345678901234567890", coefficient: "123456789012345678901234567890", scale: 30},
+		"long number":   {src: "-98765432109876543210.0123456789012345", coefficient: "-987654321098765432100123456789012345", scale: 16},
+		"long with exp": {src: "123456789012345678901234567890e-40", coefficient: "123456789012345678901234567890", scale: 40},
+		"long zeros":    {src: "1.0000000000000000000000000000001", coefficient: "10000000000000000000000000000001", scale: 31},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			v, err := NewParser([]byte(c.src)).Parse()
+			require.NoError(t, err)
+
+			coefficient, scale, err := v.(*Number).Decimal()
+			require.NoError(t, err)
+			require.Equal(t, c.coefficient, coefficient.String())
+			require.Equal(t, c.scale, scale)
+
+			// source text is kept
+			got, err := MarshalValue(Clone(v), nil)
+			require.NoError(t, err)
+			require.Equal(t, c.src, string(got))
+
+			// value survives round trip
+			num, err := NewNumberDecimal(coefficient, scale)
+			require.NoError(t, err)
+			gotCoefficient, gotScale, err := num.Decimal()
+			require.NoError(t, err)
+			require.Equal(t, c.coefficient, gotCoefficient.String())
+			require.Equal(t, c.scale, gotScale)
+
+			want, _ := new(big.Float).SetPrec(200).SetString(c.src)
+			got, err = MarshalValue(num, nil)
+			require.NoError(t, err)
+			parsed, _ := new(big.Float).SetPrec(200).SetString(string(got))
+			require.Zero(t, want.Cmp(parsed), string(got))
+		})
+	}
+
+	v, err := ValueOf([]byte("123456789012345678901234567890.5"))
+	require.NoError(t, err)
+	require.True(t, v.(*Number).IsFloat)
+	require.InDelta(t, 1.2345678901234568e29, v.(*Number).Float64(), 1e14)
+	require.Equal(t, int64(math.MaxInt64), v.(*Number).Int64())
+
+	f, err := NewNumberFloat(0.1)
+	require.NoError(t, err)
+	coefficient, scale, err := f.Decimal()
+	require.NoError(t, err)
+	require.Equal(t, "1", coefficient.String())
+	require.Equal(t, int32(1), scale)
+}
+
+func TestNewNumberDecimal(t *testing.T) {
+	cases := map[string]struct {
+		coefficient *big.Int
+		scale       int32
+		want        string
+		err         ExpectedError
+	}{
+		"nil":           {coefficient: nil, scale: 0, want: "0"},
+		"integer":       {coefficient: big.NewInt(-15), scale: 0, want: "-15"},
+		"fraction":      {coefficient: big.NewInt(12345), scale: 2, want: "123.45"},
+		"leading zeros": {coefficient: big.NewInt(-5), scale: 3, want: "-0.005"},
+		"exponent":      {coefficient: big.NewInt(25), scale: -3, want: "25e3"},
+		"tiny exponent": {coefficient: big.NewInt(1), scale: 400, want: "0." + strings.Repeat("0", 399) + "1"},
+		"huge exponent": {coefficient: big.NewInt(1), scale: -400, want: "1e400"},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			num, err := NewNumberDecimal(c.coefficient, c.scale)
+			if !c.err.AssertError(t, err) {
+				require.True(t, errors.Is(err, ErrNumberOutOfRange))
+				return
+			}
+
+			got, err := MarshalValue(num, nil)
+			require.NoError(t, err)
+			require.Equal(t, c.want, string(got))
+
+			// number is readable back
+			_, err = ValueOf(got)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestValue_NilReceivers(t *testing.T) {
+	values := []Value{
+		(*String)(nil),
+		(*Number)(nil),
+		(*Object)(nil),
+		(*Array)(nil),
+	}
+
+	for _, v := range values {
+		rv := reflect.ValueOf(v)
+		for i := 0; i < rv.NumMethod(); i++ {
+			method := rv.Type().Method(i)
+			t.Run(fmt.Sprintf("%T.%s", v, method.Name), func(t *testing.T) {
+				fn := rv.Method(i)
+				args := make([]reflect.Value, 0, fn.Type().NumIn())
+				for j := 0; j < fn.Type().NumIn(); j++ {
+					args = append(args, reflect.Zero(fn.Type().In(j)))
+				}
+
+				require.NotPanics(t, func() {
+					fn.Call(args)
+				})
+			})
+		}
+
+		t.Run(fmt.Sprintf("%T.marshal", v), func(t *testing.T) {
+			require.Equal(t, Position{}, v.Ref())
+			_, err := MarshalValue(v, nil)
+			require.True(t, errors.Is(err, ErrNilValue))
+		})
+	}
+}
+
+func TestInferScalar(t *testing.T) {
+	cases := map[string]struct {
+		src  string
+		want interface{}
+	}{
+		"integer":        {src: "42", want: 42},
+		"negative float": {src: "-3.14", want: -3.14},
+		"exponent":       {src: "1e3", want: 1000},
+		"true":           {src: "true", want: true},
+		"false":          {src: "false", want: false},
+		"null":           {src: "null", want: nil},
+		"empty":          {src: "", want: ""},
+		"text":           {src: "hello", want: "hello"},
+		"prefix":         {src: "nullable", want: "nullable"},
+		"padded":         {src: " 1", want: " 1"},
+		"trailing data":  {src: "1 2", want: "1 2"},
+		"version":        {src: "1.2.3", want: "1.2.3"},
+		"quoted":         {src: `"1"`, want: `"1"`},
+		"object":         {src: `{"a":1}`, want: `{"a":1}`},
+		"array":          {src: `[1]`, want: `[1]`},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			got := InferScalar(c.src)
+			require.Equal(t, c.want, got.Interface())
+			if _, ok := c.want.(string); ok {
+				require.Equal(t, TypeString, got.Type())
+			}
+		})
+	}
+}
+
+func TestSetPosition(t *testing.T) {
+	pos := Position{Start: 2, End: 6}
+	cases := map[string]Value{
+		"string":  NewString("x"),
+		"number":  NewNumberInt(1),
+		"boolean": NewBoolean(true),
+		"null":    NewNull(),
+		"array":   NewArray(),
+		"object":  NewObject(nil),
+	}
+
+	for n, v := range cases {
+		t.Run(n, func(t *testing.T) {
+			got := SetPosition(v, pos)
+			require.Equal(t, pos, got.Ref())
+			require.Equal(t, v.Interface(), got.Interface())
+		})
+	}
+
+	// literals are passed by value and original value is not changed
+	b := NewBoolean(true)
+	SetPosition(b, pos)
+	require.Equal(t, Position{}, b.Ref())
+
+	var nilStr *String
+	require.Nil(t, SetPosition(nilStr, pos).(*String))
+}
+
+func TestParseType(t *testing.T) {
+	types := AllTypes()
+	require.Len(t, types, 6)
+	for _, typ := range types {
+		got, err := ParseType(typ.String())
+		require.NoError(t, err)
+		require.Equal(t, typ, got)
+
+		text, err := typ.MarshalText()
+		require.NoError(t, err)
+		require.Equal(t, typ.String(), string(text))
+
+		var dst Type
+		require.NoError(t, dst.UnmarshalText(text))
+		require.Equal(t, typ, dst)
+	}
+
+	for _, s := range []string{"", "undefined", "Object", "integer"} {
+		_, err := ParseType(s)
+		require.EqualError(t, err, fmt.Sprintf("unknown value type %q", s))
+	}
+
+	_, err := TypeUnknown.MarshalText()
+	require.EqualError(t, err, "cannot marshal invalid value type 0")
+	_, err = Type(100).MarshalText()
+	require.Error(t, err)
+
+	dst := TypeNumber
+	require.Error(t, dst.UnmarshalText([]byte("foo")))
+	require.Equal(t, TypeNumber, dst)
+}
+
+func TestType_TextEncoding(t *testing.T) {
+	type spec struct {
+		Types map[string]Type `json:"types"`
+		Root  Type            `json:"root"`
+	}
+
+	src := `{"types":{"id":"number","tags":"array"},"root":"object"}`
+	var got spec
+	require.NoError(t, json.Unmarshal([]byte(src), &got))
+	require.Equal(t, spec{
+		Types: map[string]Type{"id": TypeNumber, "tags": TypeArray},
+		Root:  TypeObject,
+	}, got)
+
+	out, err := json.Marshal(got)
+	require.NoError(t, err)
+	require.Equal(t, src, string(out))
+
+	err = json.Unmarshal([]byte(`{"root":"integer"}`), &got)
+	require.EqualError(t, err, `unknown value type "integer"`)
+}
+
+func newBenchmarkObject(size int) *Object {
+	members := make([]Member, 0, size)
+	for i := 0; i < size; i++ {
+		members = append(members, Member{Key: "key_" + strconv.Itoa(i), Value: NewNull()})
+	}
+	return NewObjectFromMembers(members...)
+}
+
+func BenchmarkObject_Members(b *testing.B) {
+	obj := newBenchmarkObject(1000000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = obj.Members()
+	}
+}
@@ -0,0 +1,360 @@
+package jsonreflect
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+var (
+	typeJsonMarshaler = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	typeTextMarshaler = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	typeStringer      = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+)
+
+// ValueFrom converts Go value to a jsonreflect.Value.
+//
+// Method supports the same tags and behavior as standard json.Marshal method.
+// Options are optional and control conversion of nil slices and maps.
+//
+// Supported additional tag options:
+//
+// - `json:"name,emptyarray"` converts nil slice to empty array instead of null.
+//
+// - `json:"name,emptyobject"` converts nil map to empty object instead of null.
+//
+// - `json:"..."` field with orphan values is merged into parent object.
+//
+// - Field with two single quotes as tag name is written with empty key:
+//
+//	Field string `json:"''"`
+//
+// - `json:"name,jsonstring"` encodes object or array into a string with JSON document.
+//
+// Values implementing encoding.TextMarshaler are converted to strings.
+// Map keys can be strings or implement encoding.TextMarshaler.
+func ValueFrom(v interface{}, opts *MarshalOptions) (Value, error) {
+	if v == nil {
+		return NewNull(), nil
+	}
+
+	return valueFrom(reflect.ValueOf(v), newMarshalParams([]MarshalOption{WithMarshalOptions(opts)}), nil)
+}
+
+func valueFrom(v reflect.Value, p *marshalParams, td *tagData) (Value, error) {
+	if !v.IsValid() {
+		return NewNull(), nil
+	}
+
+	if v.CanInterface() {
+		if val, ok := v.Interface().(Value); ok {
+			if isNilValue(v) {
+				return NewNull(), nil
+			}
+			return val, nil
+		}
+
+		if m, ok := findMarshaler(v, typeJsonMarshaler); ok {
+			return valueFromJSONMarshaler(m)
+		}
+
+		if m, ok := findMarshaler(v, typeTextMarshaler); ok {
+			return valueFromTextMarshaler(m)
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return NewNull(), nil
+		}
+		return valueFrom(v.Elem(), p, td)
+	case reflect.Bool:
+		return NewBoolean(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return NewNumberInt(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return newNumberUint(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return NewNumberFloat(v.Float())
+	case reflect.String:
+		return NewString(v.String()), nil
+	case reflect.Slice:
+		if v.IsNil() {
+			if td.emptyArrayEnabled(p) {
+				return NewArray(), nil
+			}
+			return NewNull(), nil
+		}
+
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			// keep compatibility with encoding/json
+			return NewString(base64.StdEncoding.EncodeToString(v.Bytes())), nil
+		}
+		return arrayValueFrom(v, p)
+	case reflect.Array:
+		return arrayValueFrom(v, p)
+	case reflect.Map:
+		if v.IsNil() {
+			if td.emptyObjectEnabled(p) {
+				return NewObject(nil), nil
+			}
+			return NewNull(), nil
+		}
+		return objectValueFromMap(v, p)
+	case reflect.Struct:
+		return objectValueFromStruct(v, p)
+	default:
+		if p.stringerFallback {
+			if m, ok := findMarshaler(v, typeStringer); ok && !isNilValue(m) {
+				return NewString(m.Interface().(fmt.Stringer).String()), nil
+			}
+		}
+		return nil, fmt.Errorf("unsupported value type %s", v.Type())
+	}
+}
+
+// findMarshaler returns value or pointer to value that implements passed interface.
+func findMarshaler(v reflect.Value, iface reflect.Type) (reflect.Value, bool) {
+	if v.Type().Implements(iface) {
+		return v, true
+	}
+
+	if v.Kind() != reflect.Ptr && v.CanAddr() && reflect.PtrTo(v.Type()).Implements(iface) {
+		return v.Addr(), true
+	}
+
+	return v, false
+}
+
+func valueFromTextMarshaler(v reflect.Value) (Value, error) {
+	if isNilValue(v) {
+		return NewNull(), nil
+	}
+
+	text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+	if err != nil {
+		return nil, fmt.Errorf("failed to call MarshalText of %s: %w", v.Type(), err)
+	}
+	return NewString(string(text)), nil
+}
+
+func valueFromJSONMarshaler(v reflect.Value) (Value, error) {
+	if isNilValue(v) {
+		return NewNull(), nil
+	}
+
+	data, err := v.Interface().(json.Marshaler).MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("failed to call MarshalJSON of %s: %w", v.Type(), err)
+	}
+
+	val, err := ValueOf(data)
+	if err != nil {
+		return nil, fmt.Errorf("MarshalJSON of %s returned invalid JSON: %w", v.Type(), err)
+	}
+
+	if val == nil {
+		return NewNull(), nil
+	}
+	return val, nil
+}
+
+func arrayValueFrom(v reflect.Value, p *marshalParams) (*Array, error) {
+	items := make([]Value, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		item, err := valueFrom(v.Index(i), p, nil)
+		if err != nil {
+			return nil, fmt.Errorf("can't convert index #%d: %w", i, err)
+		}
+
+		items = append(items, item)
+	}
+	return NewArray(items...), nil
+}
+
+func objectValueFromMap(v reflect.Value, p *marshalParams) (*Object, error) {
+	keys := make([]string, 0, v.Len())
+	mapKeys := make(map[string]reflect.Value, v.Len())
+	for _, k := range v.MapKeys() {
+		key, err := mapKeyString(k)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, key)
+		mapKeys[key] = k
+	}
+	sort.Strings(keys)
+
+	obj := NewObject(make(map[string]Value, len(keys)))
+	for _, key := range keys {
+		item, err := valueFrom(v.MapIndex(mapKeys[key]), p, nil)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", key, err)
+		}
+
+		obj.set(key, item)
+	}
+	return obj, nil
+}
+
+func mapKeyString(k reflect.Value) (string, error) {
+	if k.Kind() == reflect.String {
+		return k.String(), nil
+	}
+
+	if k.Type().Implements(typeTextMarshaler) {
+		if isNilValue(k) {
+			return "", fmt.Errorf("nil map key of type %s", k.Type())
+		}
+
+		text, err := k.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return "", fmt.Errorf("failed to call MarshalText of map key %s: %w", k.Type(), err)
+		}
+		return string(text), nil
+	}
+
+	return "", fmt.Errorf("map key type should be string or encoding.TextMarshaler (got %s)", k.Type())
+}
+
+func objectValueFromStruct(v reflect.Value, p *marshalParams) (*Object, error) {
+	obj := NewObject(nil)
+	if err := appendStructFields(obj, v, p); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+func appendStructFields(obj *Object, v reflect.Value, p *marshalParams) error {
+	for i := 0; i < v.NumField(); i++ {
+		fType := v.Type().Field(i)
+		fVal := v.Field(i)
+
+		td := parseTagData(fType)
+		if td != nil && td.skipValue {
+			continue
+		}
+
+		if _, hasKey := td.key(); fType.Anonymous && !hasKey {
+			if fVal.Kind() == reflect.Ptr {
+				if fVal.IsNil() {
+					continue
+				}
+				fVal = fVal.Elem()
+			}
+
+			if fVal.Kind() == reflect.Struct {
+				if err := appendStructFields(obj, fVal, p); err != nil {
+					return fmt.Errorf("can't convert %s.%s: %w", v.Type(), fType.Name, err)
+				}
+				continue
+			}
+		}
+
+		if fType.PkgPath != "" {
+			// skip private fields
+			continue
+		}
+
+		if td != nil && td.omitEmpty && isEmptyValue(fVal) {
+			continue
+		}
+
+		val, err := valueFrom(fVal, p, td)
+		if err != nil {
+			return fmt.Errorf("can't convert field %s.%s: %w", v.Type(), fType.Name, err)
+		}
+
+		if td != nil && td.jsonString {
+			if val, err = encodeJSONString(val); err != nil {
+				return fmt.Errorf("can't convert field %s.%s: %w", v.Type(), fType.Name, err)
+			}
+		}
+
+		if td != nil && td.collectOrphans {
+			// merge orphan values container into parent
+			orphans, ok := val.(*Object)
+			if !ok {
+				continue
+			}
+
+			for _, m := range orphans.Members() {
+				obj.set(m.Key, m.Value)
+			}
+			continue
+		}
+
+		key := fType.Name
+		if tagKey, ok := td.key(); ok {
+			key = tagKey
+		}
+		obj.set(key, val)
+	}
+	return nil
+}
+
+// encodeJSONString encodes object or array value into a string, see `jsonstring` tag option.
+func encodeJSONString(v Value) (Value, error) {
+	switch v.(type) {
+	case *Object, *Array:
+	default:
+		return v, nil
+	}
+
+	data, err := MarshalValue(v, nil)
+	if err != nil {
+		return nil, err
+	}
+	return NewString(string(data)), nil
+}
+
+func (td *tagData) emptyArrayEnabled(p *marshalParams) bool {
+	if td != nil && td.emptyArray {
+		return true
+	}
+	return p.nilSliceAsEmptyArray
+}
+
+func (td *tagData) emptyObjectEnabled(p *marshalParams) bool {
+	if td != nil && td.emptyObject {
+		return true
+	}
+	return p.nilMapAsEmptyObject
+}
+
+func isNilValue(v reflect.Value) bool {
+	return isNillable(v.Kind()) && v.IsNil()
+}
+
+func isNillable(k reflect.Kind) bool {
+	switch k {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		return true
+	default:
+		return false
+	}
+}
+
+// isEmptyValue reports if value is empty in terms of "omitempty" tag option.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,207 @@
+package jsonreflect
+
+import (
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	. "github.com/x1unix/jsonreflect/internal/testutil"
+)
+
+type ValueFromNested struct {
+	Flag bool `json:"flag"`
+}
+
+type valueFromSample struct {
+	ValueFromNested
+
+	ID      int               `json:"id"`
+	Name    string            `json:"name,omitempty"`
+	Rate    float64           `json:"rate"`
+	Tags    []string          `json:"tags"`
+	Labels  map[string]string `json:"labels"`
+	Skip    string            `json:"-"`
+	private string
+}
+
+type testUUID [4]byte
+
+func (u testUUID) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("%x-%x", u[:2], u[2:])), nil
+}
+
+type testAddr struct {
+	ip []byte
+}
+
+func (a *testAddr) MarshalText() ([]byte, error) {
+	return []byte(net.IP(a.ip).String()), nil
+}
+
+type testStringer func()
+
+func (testStringer) String() string {
+	return "stringer"
+}
+
+func TestMarshal(t *testing.T) {
+	cases := map[string]struct {
+		src  interface{}
+		opts *MarshalOptions
+		want string
+		err  ExpectedError
+	}{
+		"nil": {
+			want: "null",
+		},
+		"scalars": {
+			src:  []interface{}{1, -2, uint8(3), 0.05, -0.5, "foo\n", true, nil},
+			want: `[1,-2,3,0.05,-0.5,"foo\n",true,null]`,
+		},
+		"bytes": {
+			src:  []byte("foo"),
+			want: `"Zm9v"`,
+		},
+		"struct with nil values": {
+			src:  valueFromSample{ID: 1, Rate: 1.5, Skip: "skip", private: "private"},
+			want: `{"flag":false,"id":1,"rate":1.5,"tags":null,"labels":null}`,
+		},
+		"struct with empty values": {
+			src: valueFromSample{
+				Name:   "foo",
+				Tags:   []string{},
+				Labels: map[string]string{},
+			},
+			want: `{"flag":false,"id":0,"name":"foo","rate":0,"tags":[],"labels":{}}`,
+		},
+		"struct with populated values": {
+			src: valueFromSample{
+				ValueFromNested: ValueFromNested{Flag: true},
+				Tags:            []string{"a", "b"},
+				Labels:          map[string]string{"b": "2", "a": "1"},
+			},
+			want: `{"flag":true,"id":0,"rate":0,"tags":["a","b"],"labels":{"a":"1","b":"2"}}`,
+		},
+		"nil as empty options": {
+			src:  valueFromSample{},
+			opts: &MarshalOptions{NilSliceAsEmptyArray: true, NilMapAsEmptyObject: true},
+			want: `{"flag":false,"id":0,"rate":0,"tags":[],"labels":{}}`,
+		},
+		"nil as empty tag options": {
+			src: struct {
+				Tags     []int          `json:"tags,emptyarray"`
+				Labels   map[string]int `json:"labels,emptyobject"`
+				NilTags  []int          `json:"nilTags"`
+				NilPtr   *int           `json:"nilPtr"`
+				Untagged []int          `json:",emptyarray"`
+			}{},
+			want: `{"tags":[],"labels":{},"nilTags":null,"nilPtr":null,"Untagged":[]}`,
+		},
+		"orphans": {
+			src: struct {
+				ID      int                    `json:"id"`
+				Orphans map[string]interface{} `json:"..."`
+			}{ID: 1, Orphans: map[string]interface{}{"foo": "bar"}},
+			want: `{"id":1,"foo":"bar"}`,
+		},
+		"value fields": {
+			src: struct {
+				Obj Value   `json:"obj"`
+				Nil *Object `json:"nil"`
+			}{Obj: NewObject(map[string]Value{"a": NewBoolean(true)})},
+			want: `{"obj":{"a":true},"nil":null}`,
+		},
+		"unsupported type": {
+			src: struct {
+				Ch chan int
+			}{},
+			err: "unsupported value type chan int",
+		},
+		"non-string map keys": {
+			src: map[int]string{1: "foo"},
+			err: "map key type should be string",
+		},
+		"text marshaler": {
+			src: &struct {
+				ID   testUUID  `json:"id"`
+				Addr testAddr  `json:"addr"`
+				Ptr  *testAddr `json:"ptr"`
+			}{
+				ID:   testUUID{0xde, 0xad, 0xbe, 0xef},
+				Addr: testAddr{ip: net.IPv4(127, 0, 0, 1)},
+			},
+			want: `{"id":"dead-beef","addr":"127.0.0.1","ptr":null}`,
+		},
+		"text marshaler map keys": {
+			src:  map[testUUID]int{{0, 0, 0, 2}: 2, {0, 0, 0, 1}: 1},
+			want: `{"0000-0001":1,"0000-0002":2}`,
+		},
+		"stringer without fallback": {
+			src: testStringer(func() {}),
+			err: "unsupported value type jsonreflect.testStringer",
+		},
+		"stringer fallback": {
+			src:  []testStringer{func() {}},
+			opts: &MarshalOptions{StringerFallback: true},
+			want: `["stringer"]`,
+		},
+		"uint overflow": {
+			src: uint64(1 << 63),
+			err: "value out of range",
+		},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			got, err := Marshal(c.src, c.opts)
+			if !c.err.AssertError(t, err) {
+				return
+			}
+			require.Equal(t, c.want, string(got))
+		})
+	}
+}
+
+func TestMarshal_UnmarshalRoundTrip(t *testing.T) {
+	cases := map[string]struct {
+		src  valueFromSample
+		opts *MarshalOptions
+		want valueFromSample
+	}{
+		"nil values": {
+			src: valueFromSample{ID: 1},
+		},
+		"nil as empty values": {
+			src:  valueFromSample{ID: 1},
+			opts: &MarshalOptions{NilSliceAsEmptyArray: true, NilMapAsEmptyObject: true},
+			want: valueFromSample{ID: 1, Tags: []string{}, Labels: map[string]string{}},
+		},
+		"populated values": {
+			src: valueFromSample{
+				ValueFromNested: ValueFromNested{Flag: true},
+				ID:              2,
+				Name:            "foo",
+				Rate:            -0.25,
+				Tags:            []string{"a"},
+				Labels:          map[string]string{"a": "b"},
+			},
+		},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			data, err := Marshal(c.src, c.opts)
+			require.NoError(t, err)
+
+			got := valueFromSample{}
+			require.NoError(t, Unmarshal(data, &got))
+
+			want := c.want
+			if c.opts == nil {
+				want = c.src
+			}
+			require.Equal(t, want, got)
+		})
+	}
+}